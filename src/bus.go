@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/gateway"
+	"mqtt-modbus-bridge/pkg/health"
+	"mqtt-modbus-bridge/pkg/logger"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/recovery"
+	"sort"
+	"strings"
+	"time"
+)
+
+// gatewayBus groups everything that talks to one gateway (one RS-485 bus)
+// Each bus has its own circuit breaker, executor (and scheduler lock) and health monitor,
+// so timeouts on one bus never delay or take offline the devices on another
+type gatewayBus struct {
+	name          string
+	gateway       gateway.Gateway // Circuit breaker wrapper around the transport
+	executor      *modbus.StrategyExecutor
+	healthMonitor *health.GatewayHealthMonitor
+	devices       map[string]config.Device // Devices wired to this bus
+	writers       []*modbus.RegisterWriter // Writable registers of the devices on this bus
+	writePolicy   *modbus.WritePolicy      // Allowlist, confirmation and rate limit of writes on this bus
+}
+
+// newGatewayBus creates the transport, circuit breaker and executor for a named gateway
+func newGatewayBus(name string, gwCfg config.GatewayConfig, cfg *config.Config, devices map[string]config.Device) (*gatewayBus, error) {
+	baseGateway, err := gateway.NewNamedGateway(name, &gwCfg, &cfg.MQTT)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wrap gateway with circuit breaker for resilience (one breaker per bus)
+	cbConfig := recovery.CircuitBreakerConfig{
+		MaxFailures:      5,
+		Timeout:          30 * time.Second,
+		HalfOpenMaxTries: 3,
+	}
+	gatewayInstance := gateway.NewCircuitBreakerGateway(baseGateway, cbConfig)
+
+	bus := &gatewayBus{
+		name:          name,
+		gateway:       gatewayInstance,
+		executor:      modbus.NewStrategyExecutor(gatewayInstance, cfg.HomeAssistant.DiscoveryPrefix),
+		healthMonitor: health.NewGatewayHealthMonitor(time.Duration(cfg.Application.ErrorGracePeriod) * time.Second),
+		devices:       devices,
+	}
+
+	bus.executor.SetMaxRegistersPerRequest(gwCfg.GetMaxRegistersPerRequest())
+	if err := bus.executor.RegisterFromDevices(devices); err != nil {
+		return nil, fmt.Errorf("gateway '%s': %w", name, err)
+	}
+
+	bus.writers = newRegisterWriters(devices, gatewayInstance)
+	bus.writePolicy = modbus.NewWritePolicy(cfg.WritePolicy)
+	for _, writer := range bus.writers {
+		bus.writePolicy.Allow(writer)
+	}
+
+	logger.LogInfo("🚌 Gateway '%s' (%s) serves %d device(s)", name, gwCfg.GetType(), len(devices))
+	return bus, nil
+}
+
+// newGatewayBuses creates one bus per configured gateway that has at least one device
+// Buses are returned sorted by name for deterministic startup and diagnostics
+func newGatewayBuses(cfg *config.Config) ([]*gatewayBus, error) {
+	gateways := cfg.GetGateways()
+	names := make([]string, 0, len(gateways))
+	for name := range gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buses := make([]*gatewayBus, 0, len(names))
+	for _, name := range names {
+		devices := config.FilterDevicesByGateway(cfg.Devices, name)
+		if len(devices) == 0 {
+			logger.LogWarn("⚠️ Gateway '%s' has no devices - skipping", name)
+			continue
+		}
+
+		bus, err := newGatewayBus(name, gateways[name], cfg, devices)
+		if err != nil {
+			return nil, err
+		}
+		buses = append(buses, bus)
+	}
+
+	if len(buses) == 0 {
+		return nil, fmt.Errorf("no gateway has any device assigned")
+	}
+	return buses, nil
+}
+
+// deviceForKey returns the device owning a strategy key (format: deviceKey_groupOrRegisterKey)
+// The longest matching device key wins, so "meter" and "meter_2" are told apart
+func (b *gatewayBus) deviceForKey(key string) (string, bool) {
+	deviceID := ""
+	for candidate := range b.devices {
+		if strings.HasPrefix(key, candidate+"_") && len(candidate) > len(deviceID) {
+			deviceID = candidate
+		}
+	}
+	return deviceID, deviceID != ""
+}
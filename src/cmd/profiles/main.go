@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"os"
+	"sort"
+)
+
+const usage = `Usage:
+  go run ./cmd/profiles list           List the built-in device profiles
+  go run ./cmd/profiles show <name>    Print a built-in profile (name or model, e.g. DTSU666-H)`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "list":
+		if err := listProfiles(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	case "show":
+		if len(os.Args) < 3 {
+			fmt.Println(usage)
+			os.Exit(1)
+		}
+		data, err := config.CatalogProfileYAML(os.Args[2])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(data))
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+}
+
+// listProfiles prints one line per built-in profile with its register counts
+func listProfiles() error {
+	entries, err := config.Catalog()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📚 Built-in device profiles: %d\n", len(entries))
+	for _, entry := range entries {
+		profile := entry.Profile
+		registers := len(profile.Modbus.Registers)
+		groups := make([]string, 0, len(profile.Modbus.RegisterGroups))
+		for key, group := range profile.Modbus.RegisterGroups {
+			groups = append(groups, key)
+			registers += len(group.Registers)
+		}
+		sort.Strings(groups)
+
+		fmt.Printf("   %-12s %s %s\n", entry.Name, profile.Manufacturer, profile.Model)
+		if profile.Description != "" {
+			fmt.Printf("                %s\n", profile.Description)
+		}
+		fmt.Printf("                %d registers in groups %v, %d calculated values\n",
+			registers, groups, len(profile.CalculatedValues))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/state"
+	"os"
+	"time"
+)
+
+const usage = `Usage:
+  go run ./cmd/state show <state-file> [key]    Print the saved state (all keys or one key)
+  go run ./cmd/state clear <state-file> [key]   Remove one key or the whole state
+
+Stop the bridge before clearing: it saves its in-memory state on exit.`
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+
+	store, err := state.Open(os.Args[2])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	key := ""
+	if len(os.Args) > 3 {
+		key = os.Args[3]
+	}
+
+	switch os.Args[1] {
+	case "show":
+		if err := showState(store, key); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	case "clear":
+		if err := clearState(store, key); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+}
+
+// showState prints the keys of the store with their JSON values
+func showState(store *state.Store, key string) error {
+	keys := store.Keys()
+	if key != "" {
+		keys = []string{key}
+	}
+
+	if savedAt := store.SavedAt(); !savedAt.IsZero() {
+		fmt.Printf("💾 %s (saved %s)\n", store.Path(), savedAt.Format(time.RFC3339))
+	} else {
+		fmt.Printf("💾 %s (empty)\n", store.Path())
+	}
+
+	for _, name := range keys {
+		raw, exists := store.GetRaw(name)
+		if !exists {
+			return fmt.Errorf("key '%s' not found in %s", name, store.Path())
+		}
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("error decoding '%s': %w", name, err)
+		}
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n%s:\n%s\n", name, data)
+	}
+	return nil
+}
+
+// clearState removes one key (or all keys) and saves the store
+func clearState(store *state.Store, key string) error {
+	if key == "" {
+		store.Clear()
+		fmt.Printf("🧹 Cleared all state in %s\n", store.Path())
+	} else {
+		if !store.Delete(key) {
+			return fmt.Errorf("key '%s' not found in %s", key, store.Path())
+		}
+		fmt.Printf("🧹 Removed '%s' from %s\n", key, store.Path())
+	}
+	return store.Save()
+}
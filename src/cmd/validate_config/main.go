@@ -1,14 +1,14 @@
 package main
 
 import (
-	"chint-mqtt-modbus-bridge/pkg/config"
 	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
 	"os"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run validate_config.go <config-file>")
+		fmt.Println("Usage: go run ./cmd/validate_config <config-file>")
 		os.Exit(1)
 	}
 
@@ -51,11 +51,12 @@ func main() {
 			} else {
 				fmt.Printf("         HA Device ID: %s\n", haDeviceID)
 			}
+			if device.Profile != "" {
+				fmt.Printf("         Profile: %s\n", device.Profile)
+			}
 			fmt.Printf("         Enabled: %v\n", device.IsEnabled())
 			fmt.Printf("         Register Groups: %d\n", len(device.Modbus.RegisterGroups))
-			if device.GetPollInterval() > 0 {
-				fmt.Printf("         Poll Interval: %d ms\n", device.GetPollInterval())
-			}
+			printReadPlan(device)
 		}
 	} else if len(cfg.RegisterGroups) > 0 {
 		fmt.Printf("   Register Groups (V2.0): %d\n", len(cfg.RegisterGroups))
@@ -65,3 +66,22 @@ func main() {
 
 	fmt.Println("\n✅ Configuration is valid!")
 }
+
+// printReadPlan prints the block reads computed for the flat register list of a device
+func printReadPlan(device config.Device) {
+	if len(device.Modbus.PlannedGroups) == 0 {
+		return
+	}
+
+	fmt.Printf("         Read Plan: %d registers in %d reads\n", len(device.Modbus.Registers), len(device.Modbus.PlannedGroups))
+	for _, key := range device.Modbus.PlannedGroups {
+		group := device.Modbus.RegisterGroups[key]
+		fmt.Printf("           - %s: FC 0x%02X, 0x%04X-0x%04X (%d registers), every %d ms\n",
+			key, group.FunctionCode, group.StartAddress, int(group.StartAddress)+int(group.RegisterCount)-1,
+			group.RegisterCount, group.PollInterval)
+		for _, reg := range group.Registers {
+			fmt.Printf("               0x%04X %-20s %s (offset %d)\n",
+				int(group.StartAddress)+reg.Offset/2, reg.Key, reg.GetDataType(), reg.Offset)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/errors"
+	"mqtt-modbus-bridge/pkg/gateway"
+	"mqtt-modbus-bridge/pkg/logger"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/mqtt"
+	"strings"
+	"sync"
+)
+
+// identifyTimeoutSeconds bounds each device identification request
+const identifyTimeoutSeconds = 5
+
+// deviceInfoField identifies the device registry field fed by a register
+type deviceInfoField struct {
+	deviceKey string
+	field     string // serial_number, sw_version or hw_version
+}
+
+// deviceRegistry holds the device registry fields read from the devices (serial numbers, versions, identification)
+// The values are added to the "device" block of the discovery messages of the device entities.
+type deviceRegistry struct {
+	mu         sync.Mutex
+	fields     map[string]deviceInfoField               // result key (deviceKey_registerKey) -> field
+	values     map[string]map[string]string             // device key -> field -> value
+	identities map[string]*gateway.DeviceIdentification // device key -> FC 0x2B/0x0E identification
+}
+
+// newDeviceRegistry collects the device_info registers of all enabled devices
+func newDeviceRegistry(devices map[string]config.Device) *deviceRegistry {
+	registry := &deviceRegistry{
+		fields:     make(map[string]deviceInfoField),
+		values:     make(map[string]map[string]string),
+		identities: make(map[string]*gateway.DeviceIdentification),
+	}
+	for deviceKey, device := range devices {
+		if !device.IsEnabled() {
+			continue
+		}
+		for _, group := range device.Modbus.RegisterGroups {
+			for _, reg := range group.Registers {
+				if reg.DeviceInfo != "" {
+					key := fmt.Sprintf("%s_%s", deviceKey, reg.Key)
+					registry.fields[key] = deviceInfoField{deviceKey: deviceKey, field: reg.DeviceInfo}
+				}
+			}
+		}
+	}
+	return registry
+}
+
+// update stores the value of a device_info register result
+// Returns the device key and whether the value changed; ok is false for other results.
+func (r *deviceRegistry) update(key string, result *modbus.CommandResult) (deviceKey string, changed bool, ok bool) {
+	target, exists := r.fields[key]
+	if !exists {
+		return "", false, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values, exists := r.values[target.deviceKey]
+	if !exists {
+		values = make(map[string]string)
+		r.values[target.deviceKey] = values
+	}
+	if values[target.field] == result.State {
+		return target.deviceKey, false, true
+	}
+	values[target.field] = result.State
+	return target.deviceKey, true, true
+}
+
+// identify stores the identification reported by a device
+func (r *deviceRegistry) identify(deviceKey string, id *gateway.DeviceIdentification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.identities[deviceKey] = id
+}
+
+// apply copies the fields read from a device into its Home Assistant device info
+// Identified vendor and product code replace the metadata values; homeassistant overrides still win.
+// The identified revision is used when no sw_version register is configured.
+func (r *deviceRegistry) apply(deviceKey string, device config.Device, info *mqtt.DeviceInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := r.values[deviceKey]
+	info.SerialNumber = values[config.DeviceInfoSerialNumber]
+	info.SwVersion = values[config.DeviceInfoSwVersion]
+	info.HwVersion = values[config.DeviceInfoHwVersion]
+
+	id := r.identities[deviceKey]
+	if id == nil {
+		return
+	}
+	if id.VendorName != "" && (device.HomeAssistant == nil || device.HomeAssistant.Manufacturer == "") {
+		info.Manufacturer = id.VendorName
+	}
+	if id.ProductCode != "" && (device.HomeAssistant == nil || device.HomeAssistant.Model == "") {
+		info.Model = id.ProductCode
+	}
+	if info.SwVersion == "" {
+		info.SwVersion = id.Revision
+	}
+}
+
+// updateDeviceRegistry stores device_info results and republishes the discovery of devices whose fields changed
+// Returns true if the result fed the registry and must not be published as a sensor.
+func (app *Application) updateDeviceRegistry(ctx context.Context, key string, result *modbus.CommandResult) bool {
+	deviceKey, changed, ok := app.deviceRegistry.update(key, result)
+	if !ok {
+		return false
+	}
+	if changed {
+		logger.LogInfo("🏷️ Device '%s' %s: %s", deviceKey, app.deviceRegistry.fields[key].field, result.State)
+		if err := app.publishDeviceDiscovery(ctx, deviceKey, app.config.Devices[deviceKey]); err != nil {
+			logger.LogWarn("⚠️ Error republishing discovery for device %s: %v", deviceKey, err)
+		}
+	}
+	return true
+}
+
+// identifyDevices reads the device identification of the bus devices with metadata.identify set
+// Devices that answer with an exception or do not answer keep their configured manufacturer and model.
+func (app *Application) identifyDevices(ctx context.Context, bus *gatewayBus) {
+	for deviceKey, device := range bus.devices {
+		if !device.IsEnabled() || !device.Metadata.Identify {
+			continue
+		}
+
+		id, err := bus.gateway.ReadDeviceIdentification(ctx, device.GetSlaveID(), identifyTimeoutSeconds)
+		if err != nil {
+			if exception, ok := errors.AsModbusException(err); ok {
+				logger.LogWarn("⚠️ Device '%s' does not support device identification (%s), using configured metadata",
+					deviceKey, exception.Name())
+			} else {
+				logger.LogWarn("⚠️ Cannot read device identification of '%s', using configured metadata: %v", deviceKey, err)
+			}
+			continue
+		}
+
+		logger.LogInfo("🏷️ Device '%s' identified: %s %s (revision %s)", deviceKey, id.VendorName, id.ProductCode, id.Revision)
+		if device.Metadata.Model != "" && id.ProductCode != "" && !strings.EqualFold(id.ProductCode, device.Metadata.Model) {
+			logger.LogWarn("⚠️ Device '%s' reports model '%s' but is configured as '%s'", deviceKey, id.ProductCode, device.Metadata.Model)
+		}
+
+		app.deviceRegistry.identify(deviceKey, id)
+		if err := app.publishDeviceDiscovery(ctx, deviceKey, device); err != nil {
+			logger.LogWarn("⚠️ Error republishing discovery for device %s: %v", deviceKey, err)
+		}
+	}
+}
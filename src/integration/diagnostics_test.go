@@ -153,6 +153,52 @@ func TestRecordError(t *testing.T) {
 	}
 }
 
+// TestRecordException tests that Modbus exceptions are counted separately from other errors
+func TestRecordException(t *testing.T) {
+	publisher := NewMockPublisher()
+	diagnosticConfig := getTestConfig()
+
+	devices := map[string]config.Device{
+		"test_device": {
+			Metadata: config.DeviceMetadata{
+				Name:    "Test Device",
+				Enabled: true,
+			},
+		},
+	}
+
+	manager := diagnostics.NewDeviceManager(publisher, diagnosticConfig, devices)
+
+	manager.RecordError("test_device", "timeout")
+	manager.RecordException("test_device", "illegal data address")
+
+	metrics, err := manager.GetMetrics("test_device")
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+
+	if metrics.TotalReads != 2 {
+		t.Errorf("Expected TotalReads=2, got %d", metrics.TotalReads)
+	}
+
+	if metrics.FailedReads != 2 {
+		t.Errorf("Expected FailedReads=2, got %d", metrics.FailedReads)
+	}
+
+	if metrics.ExceptionReads != 1 {
+		t.Errorf("Expected ExceptionReads=1, got %d", metrics.ExceptionReads)
+	}
+
+	// The device answered, so the exception does not add a consecutive error
+	if metrics.ConsecutiveErrors != 1 {
+		t.Errorf("Expected ConsecutiveErrors=1, got %d", metrics.ConsecutiveErrors)
+	}
+
+	if metrics.LastError != "illegal data address" {
+		t.Errorf("Expected LastError='illegal data address', got '%s'", metrics.LastError)
+	}
+}
+
 // TestPublishDiscovery tests discovery publishing for all devices
 func TestPublishDiscovery(t *testing.T) {
 	publisher := NewMockPublisher()
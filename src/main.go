@@ -6,15 +6,14 @@ import (
 	"mqtt-modbus-bridge/pkg/config"
 	"mqtt-modbus-bridge/pkg/diagnostics"
 	"mqtt-modbus-bridge/pkg/errors"
-	"mqtt-modbus-bridge/pkg/gateway"
 	"mqtt-modbus-bridge/pkg/health"
 	httpHealth "mqtt-modbus-bridge/pkg/http"
 	"mqtt-modbus-bridge/pkg/logger"
 	"mqtt-modbus-bridge/pkg/metrics"
 	"mqtt-modbus-bridge/pkg/modbus"
 	"mqtt-modbus-bridge/pkg/mqtt"
-	"mqtt-modbus-bridge/pkg/recovery"
 	"mqtt-modbus-bridge/pkg/scheduler"
+	"mqtt-modbus-bridge/pkg/state"
 	"mqtt-modbus-bridge/pkg/topics"
 	"os"
 	"os/signal"
@@ -39,14 +38,13 @@ const (
 // Refactored to use extracted health monitoring and performance tracking components
 type Application struct {
 	config    *config.Config
-	gateway   gateway.Gateway // Interface allows circuit breaker wrapper
-	executor  *modbus.StrategyExecutor
+	buses     []*gatewayBus // One gateway, circuit breaker, executor and health monitor per RS-485 bus
 	publisher *mqtt.Publisher
 
-	mu sync.Mutex // Mutex for synchronizing access to the gateway
+	mu sync.Mutex // Mutex for synchronizing access to lastPublishTime
 
-	// Health monitoring (extracted from Application)
-	healthMonitor *health.GatewayHealthMonitor
+	// Health monitoring (extracted from Application) - aggregated over all buses
+	healthMonitor *health.BridgeHealthMonitor
 
 	// Performance tracking (using PerformanceTracker)
 	performanceTracker *metrics.PerformanceTracker
@@ -57,8 +55,17 @@ type Application struct {
 	// Last publish tracking for forced republish
 	lastPublishTime map[string]time.Time // Track last publish time per sensor
 
+	// Deadband and publish intervals of the registers (report by exception)
+	publishFilter *mqtt.PublishFilter
+
 	// Device diagnostics manager (moved to diagnostics package for better separation)
 	diagnosticManager *diagnostics.DeviceManager
+
+	// Serial numbers and versions read from the devices for the Home Assistant device registry
+	deviceRegistry *deviceRegistry
+
+	// Energy readings, device metrics and publish times kept across restarts (nil if disabled)
+	stateStore *state.Store
 }
 
 // NewApplication creates a new application instance
@@ -78,20 +85,6 @@ func NewApplication(configPath string) (*Application, error) {
 	topics.Initialize(cfg.HomeAssistant.DiscoveryPrefix)
 	logger.LogDebug("📍 Topics package initialized with discovery prefix: %s", cfg.HomeAssistant.DiscoveryPrefix)
 
-	// Create gateway
-	baseGateway := gateway.NewUSRGateway(&cfg.MQTT)
-
-	// Wrap gateway with circuit breaker for resilience
-	cbConfig := recovery.CircuitBreakerConfig{
-		MaxFailures:      5,
-		Timeout:          30 * time.Second,
-		HalfOpenMaxTries: 3,
-	}
-	gatewayInstance := gateway.NewCircuitBreakerGateway(baseGateway, cbConfig)
-
-	// Create strategy executor with discovery prefix
-	executor := modbus.NewStrategyExecutor(gatewayInstance, cfg.HomeAssistant.DiscoveryPrefix)
-
 	// Create publisher for Home Assistant
 	publisher := mqtt.NewPublisher(&cfg.MQTT, &cfg.HomeAssistant)
 
@@ -112,11 +105,9 @@ func NewApplication(configPath string) (*Application, error) {
 
 	app := &Application{
 		config:    cfg,
-		gateway:   gatewayInstance,
-		executor:  executor,
 		publisher: publisher,
-		// Initialize health monitoring with configured grace period
-		healthMonitor: health.NewGatewayHealthMonitor(time.Duration(cfg.Application.ErrorGracePeriod) * time.Second),
+		// Per-bus health monitors are added when the buses are created
+		healthMonitor: health.NewBridgeHealthMonitor(),
 		// Initialize performance tracking
 		performanceTracker: performanceTracker,
 		// Initialize metrics collector (interface - PrometheusMetrics or NullMetrics)
@@ -124,6 +115,9 @@ func NewApplication(configPath string) (*Application, error) {
 
 		// Initialize last publish tracking
 		lastPublishTime: make(map[string]time.Time),
+		publishFilter:   mqtt.NewPublishFilter(),
+
+		deviceRegistry: newDeviceRegistry(cfg.Devices),
 	}
 
 	// Initialize device diagnostics manager (if enabled)
@@ -136,21 +130,33 @@ func NewApplication(configPath string) (*Application, error) {
 		logger.LogDebug("📊 Device diagnostics manager initialized")
 	}
 
-	// Register all strategies from devices
+	// Create one bus per gateway and register the strategies of its devices
 	if err := app.registerStrategies(); err != nil {
 		return nil, fmt.Errorf("error registering strategies: %w", err)
 	}
 
+	// Restore the state of the previous run (if a state file is configured)
+	app.openStateStore()
+
 	return app, nil
 }
 
-// registerStrategies registers all strategies from device configuration
+// registerStrategies creates the gateway buses and registers all strategies from device configuration
 func (app *Application) registerStrategies() error {
 	logger.LogInfo("🔧 Registering strategies from devices...")
 
 	// Register from V2.1 device configuration
 	if len(app.config.Devices) > 0 {
-		return app.executor.RegisterFromDevices(app.config.Devices)
+		buses, err := newGatewayBuses(app.config)
+		if err != nil {
+			return err
+		}
+		app.buses = buses
+		for _, bus := range buses {
+			app.healthMonitor.Add(bus.name, bus.healthMonitor)
+			bus.executor.SetRejectHandler(app.rejectHandler(bus))
+		}
+		return nil
 	}
 
 	// V2.0 compatibility: convert old format to devices
@@ -168,11 +174,6 @@ func (app *Application) registerStrategies() error {
 func (app *Application) Start(ctx context.Context) error {
 	logger.LogInfo("🚀 Starting MQTT-Modbus Bridge...")
 
-	// Connect gateway
-	if err := app.gateway.Connect(ctx); err != nil {
-		return fmt.Errorf("error connecting gateway: %w", err)
-	}
-
 	// Connect publisher
 	if err := app.publisher.Connect(ctx); err != nil {
 		return fmt.Errorf("error connecting publisher: %w", err)
@@ -195,11 +196,20 @@ func (app *Application) Start(ctx context.Context) error {
 		}
 	}
 
+	// Accept Home Assistant commands for writable registers
+	app.subscribeWriteCommands(ctx)
+
+	// Accept acknowledgements of meter resets
+	app.subscribeCounterResets()
+
 	// Set initial gateway status in metrics collector
 	app.metricsCollector.SetGatewayStatus(true) // Start as online
 
-	// Start polling loop (unified for all register types)
-	go app.mainLoopNormalRegisters(ctx)
+	// Start one polling loop per bus (each connects its own gateway first,
+	// so an unreachable bus does not delay the others)
+	for _, bus := range app.buses {
+		go app.mainLoopNormalRegisters(ctx, bus)
+	}
 
 	// Start heartbeat to maintain online status
 	go app.heartbeatLoop(ctx)
@@ -213,6 +223,11 @@ func (app *Application) Start(ctx context.Context) error {
 	// Start forced republish loop for energy sensors
 	go app.forcedRepublishLoop(ctx)
 
+	// Start state save loop (if a state file is configured)
+	if app.stateStore != nil {
+		go app.stateSaveLoop(ctx)
+	}
+
 	// Start metrics server (if enabled)
 	if app.config.Application.MetricsPort > 0 {
 		go func() {
@@ -255,39 +270,84 @@ func (app *Application) Stop() {
 		}
 	}
 
-	app.gateway.Disconnect()
+	for _, bus := range app.buses {
+		bus.gateway.Disconnect()
+	}
 	app.publisher.Disconnect()
 
+	// Keep the latest counters for the next start
+	app.saveState()
+
 	logger.LogInfo("✅ MQTT-Modbus Bridge stopped")
 }
 
-// mainLoopNormalRegisters polling loop using per-group scheduling
-func (app *Application) mainLoopNormalRegisters(ctx context.Context) {
-	// Get poll intervals for all groups
-	groupIntervals := app.executor.GetGroupIntervals()
+// mainLoopNormalRegisters connects the bus gateway and polls it using per-group scheduling
+func (app *Application) mainLoopNormalRegisters(ctx context.Context, bus *gatewayBus) {
+	// Connect gateway (retries until connected or the context is cancelled)
+	if err := bus.gateway.Connect(ctx); err != nil {
+		logger.LogError("❌ Error connecting gateway '%s': %v", bus.name, err)
+		return
+	}
+
+	// Read vendor, model and revision of the devices that opted in
+	app.identifyDevices(ctx, bus)
 
-	// Create group scheduler
-	groupScheduler := scheduler.NewGroupScheduler(app.executor, groupIntervals)
+	// Publish the current value of writable registers before polling starts
+	app.publishWritableStates(ctx, bus)
+
+	// Get poll intervals for all groups on this bus
+	groupIntervals := bus.executor.GetGroupIntervals()
+
+	// Create group scheduler (own execution lock per bus)
+	groupScheduler := scheduler.NewGroupScheduler(bus.executor, groupIntervals)
+	groupScheduler.OnError(func(ctx context.Context, groupKey string, err error) {
+		if exception, ok := errors.AsModbusException(err); ok {
+			// The slave answered: the bus is healthy, only this request was rejected
+			app.recordDeviceException(bus, groupKey, exception)
+			return
+		}
+		app.handleGatewayError(ctx, bus)
+	})
 
 	// Start scheduler with callback for publishing results
 	groupScheduler.Start(ctx, func(ctx context.Context, results map[string]*modbus.CommandResult) {
-		app.publishGroupResults(ctx, results)
+		app.handleGatewaySuccess(ctx, bus)
+		app.publishGroupResults(ctx, bus, results)
 	})
 }
 
+// recordDeviceException records a Modbus exception in the diagnostics of the device owning a group
+func (app *Application) recordDeviceException(bus *gatewayBus, groupKey string, exception *errors.ModbusExceptionError) {
+	logger.LogWarn("⚠️ Group '%s' rejected by slave %d: %s (function 0x%02X, address 0x%04X)",
+		groupKey, exception.SlaveID, exception.Name(), exception.FunctionCode, exception.Address)
+
+	if app.diagnosticManager == nil {
+		return
+	}
+	if deviceID, ok := bus.deviceForKey(groupKey); ok {
+		app.diagnosticManager.RecordException(deviceID, exception.Error())
+	}
+}
+
+// rejectHandler records values rejected by the plausibility check in the diagnostics of their device
+func (app *Application) rejectHandler(bus *gatewayBus) modbus.RejectHandler {
+	return func(key string, result *modbus.CommandResult, reason error) {
+		if app.diagnosticManager == nil {
+			return
+		}
+		if deviceID, ok := bus.deviceForKey(key); ok {
+			app.diagnosticManager.RecordRejection(deviceID, result.SensorKey, result.RawValue, result.Value, reason.Error())
+		}
+	}
+}
+
 // publishGroupResults publishes results from a single group execution
-func (app *Application) publishGroupResults(ctx context.Context, results map[string]*modbus.CommandResult) {
-	// Extract device ID from first result key (format: deviceID_groupName_registerName)
-	// This is safe because all results in a group belong to the same device
+func (app *Application) publishGroupResults(ctx context.Context, bus *gatewayBus, results map[string]*modbus.CommandResult) {
+	// All results of a group belong to the same device, so the first key identifies it
 	var deviceID string
 	for key := range results {
-		// Extract device ID from key (e.g., "energy_meter_mains_instant_voltage" -> "energy_meter_mains")
-		parts := strings.Split(key, "_")
-		if len(parts) >= 3 {
-			// Take first 3 parts as device ID (energy_meter_mains or energy_meter_lights)
-			deviceID = strings.Join(parts[:3], "_")
-			break
-		}
+		deviceID, _ = bus.deviceForKey(key)
+		break
 	}
 
 	// Record success for device diagnostics
@@ -300,22 +360,50 @@ func (app *Application) publishGroupResults(ctx context.Context, results map[str
 	for key, result := range results {
 		logger.LogTrace("� %s: %.3f %s", result.Name, result.Value, result.Unit)
 
+		// Serial numbers and versions go to the device registry
+		if app.updateDeviceRegistry(ctx, key, result) {
+			continue
+		}
+
+		// Skip values within the deadband or publish interval of the register
+		if !app.filterPublish(bus, key, result) {
+			continue
+		}
+
 		// Publish to Home Assistant
 		if pubErr := app.publisher.PublishSensorState(ctx, result); pubErr != nil {
 			logger.LogError("⚠️ Error publishing sensor state for %s: %v", key, pubErr)
+		} else {
+			app.markPublished(key, result)
 		}
 	}
 }
 
+// filterPublish reports whether a result passes the deadband and publish intervals of its register
+// Suppressed values are counted in the metrics.
+func (app *Application) filterPublish(bus *gatewayBus, key string, result *modbus.CommandResult) bool {
+	register, exists := bus.executor.RegisterConfig(key)
+	if !exists {
+		return true // Status bits follow their word
+	}
+	if app.publishFilter.ShouldPublish(key, register.Publish, result.Value, result.State, time.Now()) {
+		return true
+	}
+
+	logger.LogTrace("🔇 %s: %.3f %s suppressed", result.Name, result.Value, result.Unit)
+	app.metricsCollector.IncrementMQTTSuppressed()
+	return false
+}
+
 // mainLoopEnergyRegisters - removed (now using unified polling)
 
-// executeAllStrategies executes all registered strategies and publishes results
-func (app *Application) executeAllStrategies(ctx context.Context) {
+// executeAllStrategies executes all registered strategies of a bus and publishes results
+func (app *Application) executeAllStrategies(ctx context.Context, bus *gatewayBus) {
 	// Track start time for response time measurement
 	startTime := time.Now()
 
 	// Execute all strategies (groups first, then calculated)
-	results, err := app.executor.ExecuteAll(ctx)
+	results, err := bus.executor.ExecuteAll(ctx)
 
 	responseTime := time.Since(startTime)
 
@@ -325,7 +413,7 @@ func (app *Application) executeAllStrategies(ctx context.Context) {
 		// Record metrics
 		app.metricsCollector.IncrementModbusErrors()
 
-		app.handleGatewayError(ctx)
+		app.handleGatewayError(ctx, bus)
 
 		// Handle typed errors with specific logging and diagnostics
 		var diagCode int
@@ -354,10 +442,10 @@ func (app *Application) executeAllStrategies(ctx context.Context) {
 			errorMsg = fmt.Sprintf("Strategy execution error: %v", err)
 		}
 
-		// Update metrics for all devices (error) - if diagnostic manager is enabled
+		// Update metrics for all devices on this bus (error) - if diagnostic manager is enabled
 		if app.diagnosticManager != nil {
-			for deviceID := range app.config.Devices {
-				if app.config.Devices[deviceID].Metadata.Enabled {
+			for deviceID, device := range bus.devices {
+				if device.Metadata.Enabled {
 					app.diagnosticManager.RecordError(deviceID, errorMsg)
 				}
 			}
@@ -370,9 +458,9 @@ func (app *Application) executeAllStrategies(ctx context.Context) {
 		return
 	}
 
-	// Success - update metrics for all enabled devices (if diagnostic manager is enabled)
+	// Success - update metrics for all enabled devices on this bus (if diagnostic manager is enabled)
 	if app.diagnosticManager != nil {
-		for deviceID, device := range app.config.Devices {
+		for deviceID, device := range bus.devices {
 			if device.Metadata.Enabled {
 				app.diagnosticManager.RecordSuccess(deviceID, responseTime)
 			}
@@ -386,7 +474,7 @@ func (app *Application) executeAllStrategies(ctx context.Context) {
 	app.metricsCollector.IncrementModbusReads()
 	app.metricsCollector.ObserveModbusReadDuration(responseTime)
 
-	app.handleGatewaySuccess(ctx)
+	app.handleGatewaySuccess(ctx, bus)
 
 	// Print summary if interval has passed
 	app.performanceTracker.PrintSummaryIfNeeded()
@@ -398,14 +486,23 @@ func (app *Application) executeAllStrategies(ctx context.Context) {
 	for key, result := range results {
 		logger.LogTrace("� %s: %.3f %s", result.Name, result.Value, result.Unit)
 
+		// Serial numbers and versions go to the device registry
+		if app.updateDeviceRegistry(ctx, key, result) {
+			continue
+		}
+
+		// Skip values within the deadband or publish interval of the register
+		if !app.filterPublish(bus, key, result) {
+			continue
+		}
+
 		// Publish to Home Assistant
 		if pubErr := app.publisher.PublishSensorState(ctx, result); pubErr != nil {
 			logger.LogError("⚠️ Error publishing sensor state for %s: %v", key, pubErr)
 			// Record MQTT error
 			app.metricsCollector.IncrementMQTTErrors()
 		} else {
-			// Update last publish time for successful publications
-			app.updateLastPublishTime(key)
+			app.markPublished(key, result)
 			// Record MQTT success
 			app.metricsCollector.IncrementMQTTPublishes()
 		}
@@ -417,30 +514,43 @@ func (app *Application) executeAllStrategies(ctx context.Context) {
 }
 
 // handleGatewayError manages error counting and offline status with grace period
-func (app *Application) handleGatewayError(ctx context.Context) {
+// Errors only affect the bus they occurred on; the bridge goes offline once every bus is offline
+func (app *Application) handleGatewayError(ctx context.Context, bus *gatewayBus) {
+	monitor := bus.healthMonitor
+
 	// Record error and check if should mark offline
-	shouldMarkOffline := app.healthMonitor.RecordError()
+	shouldMarkOffline := monitor.RecordError()
 
 	// If this is first error, log grace period start
-	if app.healthMonitor.GetConsecutiveErrors() == 1 {
-		logger.LogWarn("⚠️ First error detected, starting grace period")
+	if monitor.GetConsecutiveErrors() == 1 {
+		logger.LogWarn("⚠️ First error detected on gateway '%s', starting grace period", bus.name)
 	}
 
 	// Check if we're still in grace period
-	if app.healthMonitor.IsInGracePeriod() {
+	if monitor.IsInGracePeriod() {
 		// Still in grace period - don't change status to offline yet
-		logger.LogDebug("🕐 Error %d in grace period (%.1fs elapsed) - keeping status online",
-			app.healthMonitor.GetConsecutiveErrors(),
-			app.healthMonitor.GetTimeSinceFirstError().Seconds())
+		logger.LogDebug("🕐 Gateway '%s' error %d in grace period (%.1fs elapsed) - keeping status online",
+			bus.name, monitor.GetConsecutiveErrors(), monitor.GetTimeSinceFirstError().Seconds())
 		return
 	}
 
 	// Grace period expired - set status to offline if needed
-	if shouldMarkOffline && app.healthMonitor.IsOnline() {
-		app.healthMonitor.MarkOffline()
-		logger.LogError("🔴 Grace period expired - App marked as OFFLINE after %d errors over %.1f seconds",
-			app.healthMonitor.GetConsecutiveErrors(),
-			app.healthMonitor.GetTimeSinceFirstError().Seconds())
+	if shouldMarkOffline && monitor.IsOnline() {
+		monitor.MarkOffline()
+		logger.LogError("🔴 Grace period expired - Gateway '%s' marked as OFFLINE after %d errors over %.1f seconds",
+			bus.name, monitor.GetConsecutiveErrors(), monitor.GetTimeSinceFirstError().Seconds())
+
+		if app.healthMonitor.IsOnline() {
+			// Other buses are still working - report the dead bus but keep the bridge online
+			message := fmt.Sprintf("Gateway '%s' offline (offline gateways: %s)",
+				bus.name, strings.Join(app.healthMonitor.GetOfflineGateways(), ", "))
+			if err := app.publisher.PublishDiagnostic(ctx, DiagnosticGatewayError, message); err != nil {
+				logger.LogError("⚠️ Error publishing diagnostic: %v", err)
+			}
+			return
+		}
+
+		logger.LogError("🔴 All gateways offline - App marked as OFFLINE")
 
 		// Update metrics
 		app.metricsCollector.SetGatewayStatus(false)
@@ -453,25 +563,33 @@ func (app *Application) handleGatewayError(ctx context.Context) {
 }
 
 // handleGatewaySuccess resets error counter and changes status to online when functionality resumes
-func (app *Application) handleGatewaySuccess(ctx context.Context) {
+func (app *Application) handleGatewaySuccess(ctx context.Context, bus *gatewayBus) {
+	wasBusOnline := bus.healthMonitor.IsOnline()
+	wasAppOnline := app.healthMonitor.IsOnline()
+
 	// Reset error counter and grace period tracking
-	app.healthMonitor.RecordSuccess()
+	bus.healthMonitor.RecordSuccess()
 
-	// If gateway was offline, mark it back online
-	if !app.healthMonitor.IsOnline() {
-		app.healthMonitor.MarkOnline()
-		logger.LogInfo("🟢 App marked as ONLINE - functionality restored")
+	// If the bus was offline, mark it back online
+	if !wasBusOnline {
+		bus.healthMonitor.MarkOnline()
+		logger.LogInfo("🟢 Gateway '%s' marked as ONLINE - functionality restored", bus.name)
 
-		// Update metrics
-		app.metricsCollector.SetGatewayStatus(true)
+		if !wasAppOnline {
+			logger.LogInfo("🟢 App marked as ONLINE - functionality restored")
 
-		// Publish online status
-		if err := app.publisher.PublishStatusOnline(ctx); err != nil {
-			logger.LogError("⚠️ Error publishing online status: %v", err)
+			// Update metrics
+			app.metricsCollector.SetGatewayStatus(true)
+
+			// Publish online status
+			if err := app.publisher.PublishStatusOnline(ctx); err != nil {
+				logger.LogError("⚠️ Error publishing online status: %v", err)
+			}
 		}
 
 		// Publish recovery diagnostic
-		if err := app.publisher.PublishDiagnostic(ctx, DiagnosticOK, "Functionality restored - app back online"); err != nil {
+		message := fmt.Sprintf("Functionality restored - gateway '%s' back online", bus.name)
+		if err := app.publisher.PublishDiagnostic(ctx, DiagnosticOK, message); err != nil {
 			logger.LogError("⚠️ Error publishing recovery diagnostic: %v", err)
 		}
 	}
@@ -499,79 +617,129 @@ func (app *Application) publishDiscoveryConfigsV21(ctx context.Context) error {
 			continue
 		}
 
-		// Build DeviceInfo for this Modbus device
-		// Use device_id from homeassistant config, or deviceKey as fallback
-		haDeviceID := device.GetHADeviceID(deviceKey)
+		if err := app.publishDeviceDiscovery(ctx, deviceKey, device); err != nil {
+			logger.LogWarn("⚠️ Error publishing discoveries for device %s: %v", deviceKey, err)
+			// Continue with other devices
+		}
+
+		// Small pause between devices
+		time.Sleep(200 * time.Millisecond)
+	}
 
-		deviceInfo := &mqtt.DeviceInfo{
-			Name:         device.GetHADeviceName(),
-			Identifiers:  []string{haDeviceID},
-			Manufacturer: device.GetHAManufacturer(),
-			Model:        device.GetHAModel(),
+	// Publish bridge-level diagnostic sensor discovery
+	if err := app.publisher.PublishDiagnosticDiscovery(ctx); err != nil {
+		logger.LogError("⚠️ Error publishing diagnostic discovery: %v", err)
+	}
+
+	// Publish per-device diagnostic sensor discovery (if enabled and manager exists)
+	if app.config.HomeAssistant.DeviceDiagnostics.Enabled && app.diagnosticManager != nil {
+		if err := app.diagnosticManager.PublishDiscoveryForAllDevices(ctx); err != nil {
+			logger.LogWarn("⚠️ Error publishing device diagnostic discoveries: %v", err)
 		}
+	}
 
-		logger.LogDebug("📡 Publishing discovery for device: %s (slave_id=%d)", device.GetName(), device.GetSlaveID())
+	return nil
+}
 
-		// Create mock results for this device's sensors
-		var deviceResults []*modbus.CommandResult
+// publishDeviceDiscovery publishes the discovery of every entity of a V2.1 device
+// It is also called when the identification, serial number or a version read from the device changes.
+func (app *Application) publishDeviceDiscovery(ctx context.Context, deviceKey string, device config.Device) error {
+	// Build DeviceInfo for this Modbus device
+	// Use device_id from homeassistant config, or deviceKey as fallback
+	haDeviceID := device.GetHADeviceID(deviceKey)
+
+	deviceInfo := &mqtt.DeviceInfo{
+		Name:         device.GetHADeviceName(),
+		Identifiers:  []string{haDeviceID},
+		Manufacturer: device.GetHAManufacturer(),
+		Model:        device.GetHAModel(),
+	}
+	app.deviceRegistry.apply(deviceKey, device, deviceInfo)
 
-		// Add register_groups sensors
-		for _, group := range device.Modbus.RegisterGroups {
-			for _, register := range group.Registers {
-				// Construct the full HA topic path automatically
-				topic := topics.ConstructHATopic(haDeviceID, register.Key, register.DeviceClass)
+	logger.LogDebug("📡 Publishing discovery for device: %s (slave_id=%d)", device.GetName(), device.GetSlaveID())
 
-				result := &modbus.CommandResult{
-					Strategy:    register.Key,
-					Name:        register.Name,
-					Value:       0, // Mock value
-					Unit:        register.Unit,
-					Topic:       topic,
-					SensorKey:   register.Key, // Just the sensor key, not device_id_sensor_key
-					DeviceClass: register.DeviceClass,
-					StateClass:  register.StateClass,
+	// Create mock results for this device's sensors
+	var deviceResults []*modbus.CommandResult
+
+	// Add register_groups sensors (coil/discrete input groups are binary sensors)
+	for _, group := range device.Modbus.RegisterGroups {
+		component := topics.ComponentSensor
+		if group.IsBitGroup() {
+			component = topics.ComponentBinarySensor
+		}
+
+		for _, register := range group.Registers {
+			// Serial numbers and versions are part of the device info, not sensors
+			if register.DeviceInfo != "" {
+				continue
+			}
+
+			// Status words publish one binary sensor per bit instead of the register itself
+			if len(register.Bits) > 0 {
+				for _, bit := range register.Bits {
+					deviceResults = append(deviceResults, &modbus.CommandResult{
+						Strategy:    bit.Key,
+						Name:        bit.Name,
+						Value:       0, // Mock value
+						Topic:       topics.ConstructComponentTopic(topics.ComponentBinarySensor, haDeviceID, bit.Key),
+						SensorKey:   bit.Key,
+						DeviceClass: bit.DeviceClass,
+						Component:   topics.ComponentBinarySensor,
+					})
 				}
-				deviceResults = append(deviceResults, result)
+				continue
 			}
-		}
 
-		// Add calculated_values sensors
-		for _, calc := range device.CalculatedValues {
 			// Construct the full HA topic path automatically
-			topic := topics.ConstructHATopic(haDeviceID, calc.Key, calc.DeviceClass)
+			topic := topics.ConstructComponentTopic(component, haDeviceID, register.Key)
 
 			result := &modbus.CommandResult{
-				Strategy:    calc.Key,
-				Name:        calc.Name,
+				Strategy:    register.Key,
+				Name:        register.Name,
 				Value:       0, // Mock value
-				Unit:        calc.Unit,
+				Unit:        register.Unit,
 				Topic:       topic,
-				SensorKey:   calc.Key, // Just the sensor key, not device_id_sensor_key
-				DeviceClass: calc.DeviceClass,
-				StateClass:  calc.StateClass,
+				SensorKey:   register.Key, // Just the sensor key, not device_id_sensor_key
+				DeviceClass: register.DeviceClass,
+				StateClass:  register.StateClass,
+				Component:   component,
+				DataType:    register.DataType,
+			}
+			if len(register.Enum) > 0 {
+				result.DeviceClass = config.DeviceClassEnum
+				result.Options = register.EnumOptions()
 			}
 			deviceResults = append(deviceResults, result)
 		}
+	}
 
-		// Publish sensor discoveries for this device
-		if err := app.publisher.PublishAllDiscoveries(ctx, deviceResults, deviceInfo); err != nil {
-			logger.LogWarn("⚠️ Error publishing discoveries for device %s: %v", deviceKey, err)
-			// Continue with other devices
+	// Add calculated_values sensors
+	for _, calc := range device.CalculatedValues {
+		// Construct the full HA topic path automatically
+		topic := topics.ConstructHATopic(haDeviceID, calc.Key, calc.DeviceClass)
+
+		result := &modbus.CommandResult{
+			Strategy:    calc.Key,
+			Name:        calc.Name,
+			Value:       0, // Mock value
+			Unit:        calc.Unit,
+			Topic:       topic,
+			SensorKey:   calc.Key, // Just the sensor key, not device_id_sensor_key
+			DeviceClass: calc.DeviceClass,
+			StateClass:  calc.StateClass,
 		}
-
-		// Small pause between devices
-		time.Sleep(200 * time.Millisecond)
+		deviceResults = append(deviceResults, result)
 	}
 
-	// Publish bridge-level diagnostic sensor discovery
-	if err := app.publisher.PublishDiagnosticDiscovery(ctx); err != nil {
-		logger.LogError("⚠️ Error publishing diagnostic discovery: %v", err)
+	// Publish sensor discoveries for this device
+	if err := app.publisher.PublishAllDiscoveries(ctx, deviceResults, deviceInfo); err != nil {
+		return err
 	}
 
-	// Publish per-device diagnostic sensor discovery (if enabled and manager exists)
-	if app.config.HomeAssistant.DeviceDiagnostics.Enabled && app.diagnosticManager != nil {
-		if err := app.diagnosticManager.PublishDiscoveryForAllDevices(ctx); err != nil {
-			logger.LogWarn("⚠️ Error publishing device diagnostic discoveries: %v", err)
+	// Publish number/select/switch/button discoveries for writable registers
+	for i := range device.Writable {
+		if err := app.publisher.PublishWritableDiscovery(ctx, haDeviceID, &device.Writable[i], deviceInfo); err != nil {
+			logger.LogWarn("⚠️ Error publishing discovery for %s: %v", device.Writable[i].Name, err)
 		}
 	}
 
@@ -580,8 +748,13 @@ func (app *Application) publishDiscoveryConfigsV21(ctx context.Context) error {
 
 // publishDiscoveryConfigsLegacy publishes discoveries for V2.0/V1 configs (backward compatibility)
 func (app *Application) publishDiscoveryConfigsLegacy(ctx context.Context) error {
-	// Get all strategies from executor to create mock results
-	allStrategies := app.executor.GetAllStrategies()
+	// Get all strategies from every bus executor to create mock results
+	allStrategies := make(map[string]interface{})
+	for _, bus := range app.buses {
+		for key, strategy := range bus.executor.GetAllStrategies() {
+			allStrategies[key] = strategy
+		}
+	}
 
 	var results []*modbus.CommandResult
 	for key, strategy := range allStrategies {
@@ -618,6 +791,21 @@ func (app *Application) publishDiscoveryConfigsLegacy(ctx context.Context) error
 		case *modbus.GroupRegisterStrategy:
 			// Groups contain multiple registers, extract them
 			for _, regWithKey := range s.GetRegisters() {
+				for _, bit := range regWithKey.Register.Bits {
+					results = append(results, &modbus.CommandResult{
+						Strategy:    bit.Key,
+						Name:        bit.Name,
+						Value:       0,
+						Topic:       topics.ConstructComponentTopic(topics.ComponentBinarySensor, regWithKey.DeviceKey, bit.Key),
+						SensorKey:   bit.Key,
+						DeviceClass: bit.DeviceClass,
+						Component:   topics.ComponentBinarySensor,
+					})
+				}
+				if len(regWithKey.Register.Bits) > 0 {
+					continue
+				}
+
 				result := &modbus.CommandResult{
 					Strategy:    regWithKey.Key,
 					Name:        regWithKey.Register.Name,
@@ -627,6 +815,11 @@ func (app *Application) publishDiscoveryConfigsLegacy(ctx context.Context) error
 					SensorKey:   regWithKey.Key,
 					DeviceClass: regWithKey.Register.DeviceClass,
 					StateClass:  regWithKey.Register.StateClass,
+					Component:   s.GetComponent(),
+				}
+				if len(regWithKey.Register.Enum) > 0 {
+					result.DeviceClass = config.DeviceClassEnum
+					result.Options = regWithKey.Register.EnumOptions()
 				}
 				results = append(results, result)
 			}
@@ -684,10 +877,12 @@ func main() {
 	if diagnosticMode {
 		logger.LogInfo("🔍 Running diagnostic mode...")
 
-		// Connect gateway for diagnostic
-		if err := app.gateway.Connect(ctx); err != nil {
-			logger.LogError("Gateway connection error: %v", err)
-			os.Exit(1)
+		// Connect gateways for diagnostic
+		for _, bus := range app.buses {
+			if err := bus.gateway.Connect(ctx); err != nil {
+				logger.LogError("Gateway '%s' connection error: %v", bus.name, err)
+				os.Exit(1)
+			}
 		}
 
 		// Connect publisher for diagnostic
@@ -757,6 +952,13 @@ func (app *Application) heartbeatLoop(ctx context.Context) {
 	}
 }
 
+// markPublished records a successful publication for the publish intervals and the forced republish
+// The publish times are saved with the state, so a restart keeps the forced republish schedule.
+func (app *Application) markPublished(key string, result *modbus.CommandResult) {
+	app.updateLastPublishTime(key)
+	app.publishFilter.MarkPublished(key, result.Value, result.State, time.Now())
+}
+
 // updateLastPublishTime updates the last publish time for a sensor
 func (app *Application) updateLastPublishTime(sensorName string) {
 	app.mu.Lock()
@@ -815,7 +1017,7 @@ func (app *Application) forceRepublishEnergySensors(ctx context.Context) {
 			logger.LogInfo("🔄 Force republishing %s (last published: %v)", sensorName, lastPublish.Format("15:04:05"))
 
 			// Execute strategy to get current value
-			result, err := app.executor.GetResult(ctx, sensorName)
+			result, err := app.getResult(ctx, sensorName)
 			if err != nil {
 				logger.LogError("❌ Failed to force republish %s: %v", sensorName, err)
 				continue
@@ -831,43 +1033,76 @@ func (app *Application) forceRepublishEnergySensors(ctx context.Context) {
 	}
 }
 
+// getResult executes a strategy on whichever bus it is registered on
+func (app *Application) getResult(ctx context.Context, key string) (*modbus.CommandResult, error) {
+	var lastErr error
+	for _, bus := range app.buses {
+		if _, exists := bus.executor.GetAllStrategies()[key]; !exists {
+			continue
+		}
+		result, err := bus.executor.GetResult(ctx, key)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("strategy %s not found on any gateway", key)
+	}
+	return nil, lastErr
+}
+
 // DiagnosticMode runs diagnostic tests to help troubleshoot connectivity issues
 func (app *Application) DiagnosticMode(ctx context.Context) error {
 	logger.LogInfo("🔍 Starting diagnostic mode...")
 
-	// Test 1: MQTT Connectivity
-	logger.LogInfo("🔍 Test 1: MQTT Broker Connectivity")
-	if !app.gateway.IsConnected() {
-		logger.LogError("❌ Gateway is not connected to MQTT broker")
-		return fmt.Errorf("gateway not connected to MQTT broker")
+	for _, bus := range app.buses {
+		if err := app.diagnoseBus(ctx, bus); err != nil {
+			return err
+		}
+	}
+
+	logger.LogInfo("🎉 All diagnostic tests passed!")
+	return nil
+}
+
+// diagnoseBus runs the connectivity tests for a single gateway (bus)
+func (app *Application) diagnoseBus(ctx context.Context, bus *gatewayBus) error {
+	gwCfg := app.config.GetGateways()[bus.name]
+
+	// Test 1: Gateway Connectivity
+	logger.LogInfo("🔍 Test 1: Gateway '%s' Connectivity", bus.name)
+	if !bus.gateway.IsConnected() {
+		logger.LogError("❌ Gateway '%s' is not connected", bus.name)
+		return fmt.Errorf("gateway '%s' not connected", bus.name)
 	}
-	logger.LogInfo("✅ Gateway is connected to MQTT broker")
+	logger.LogInfo("✅ Gateway '%s' is connected", bus.name)
 	// Skip publisher connection check for now - focus on gateway
 	logger.LogInfo("✅ Publisher setup complete")
 
 	// Test 2: Gateway Communication
-	logger.LogInfo("🔍 Test 2: USR-DR164 Gateway Communication")
-	if err := app.gateway.SendDiagnosticCommand(ctx); err != nil {
+	logger.LogInfo("🔍 Test 2: Gateway Communication (%s)", gwCfg.GetType())
+	if err := bus.gateway.SendDiagnosticCommand(ctx); err != nil {
 		logger.LogError("❌ Gateway communication failed: %v", err)
 		logger.LogInfo("💡 Possible issues:")
-		logger.LogInfo("   - USR-DR164 gateway is not connected to MQTT broker")
-		logger.LogInfo("   - USR-DR164 gateway is not configured correctly")
-		logger.LogInfo("   - Wrong MAC address in configuration (%s)", app.config.MQTT.Gateway.MAC)
-		logger.LogInfo("   - Network connectivity issues")
-		return fmt.Errorf("gateway communication failed: %w", err)
+		for _, hint := range gatewayHints(&gwCfg) {
+			logger.LogInfo("   - %s", hint)
+		}
+		return fmt.Errorf("gateway '%s' communication failed: %w", bus.name, err)
 	}
 	logger.LogInfo("✅ Gateway communication successful")
 
 	// Test 3: Modbus Device Communication
-	logger.LogInfo("🔍 Test 3: Modbus Device Communication")
+	logger.LogInfo("🔍 Test 3: Modbus Device Communication (gateway '%s')", bus.name)
 
 	// Try to execute all strategies to read registers
-	results, err := app.executor.ExecuteAll(ctx)
+	results, err := bus.executor.ExecuteAll(ctx)
 	if err != nil || len(results) == 0 {
 		logger.LogError("❌ Modbus device communication failed: %v", err)
 		logger.LogInfo("💡 Possible issues:")
-		logger.LogInfo("   - Modbus device is not connected to USR-DR164 gateway")
+		logger.LogInfo("   - Modbus device is not connected to gateway '%s'", bus.name)
 		logger.LogInfo("   - Wrong slave ID in device configuration")
+		logger.LogInfo("   - Wrong rtu.gateway in device configuration")
 		logger.LogInfo("   - Modbus device is not powered on")
 		logger.LogInfo("   - Physical connection issues (RS485 wiring)")
 		logger.LogInfo("   - Wrong baud rate or communication parameters")
@@ -883,6 +1118,36 @@ func (app *Application) DiagnosticMode(ctx context.Context) error {
 		break // Just show one example
 	}
 
-	logger.LogInfo("🎉 All diagnostic tests passed!")
 	return nil
 }
+
+// gatewayHints returns the likely causes of a failed gateway diagnostic for the transport type
+func gatewayHints(gwCfg *config.GatewayConfig) []string {
+	switch gwCfg.GetType() {
+	case config.GatewayTypeModbusTCP:
+		return []string{
+			fmt.Sprintf("Modbus TCP gateway not reachable at %s:%d", gwCfg.ModbusTCP.Host, gwCfg.ModbusTCP.Port),
+			"Firewall blocking the Modbus TCP port",
+			"Network connectivity issues",
+		}
+	case config.GatewayTypeSerialRTU:
+		return []string{
+			fmt.Sprintf("Serial device %s missing or in use by another process", gwCfg.Serial.Device),
+			"No permission to open the serial device (e.g. user not in the dialout group)",
+			fmt.Sprintf("Baud rate %d or parity %s does not match the meters", gwCfg.Serial.BaudRate, gwCfg.Serial.Parity),
+		}
+	case config.GatewayTypeRTUTCP, config.GatewayTypeRTUUDP:
+		return []string{
+			fmt.Sprintf("Converter not reachable at %s:%d", gwCfg.Socket.Host, gwCfg.Socket.Port),
+			"Converter not in transparent (raw RTU) mode",
+			"Network connectivity issues",
+		}
+	default:
+		return []string{
+			"USR-DR164 gateway is not connected to MQTT broker",
+			"USR-DR164 gateway is not configured correctly",
+			fmt.Sprintf("Wrong MAC address in configuration (%s)", gwCfg.MAC),
+			"Network connectivity issues",
+		}
+	}
+}
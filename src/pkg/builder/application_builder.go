@@ -112,7 +112,11 @@ func (b *ApplicationBuilder) Build() (*Application, error) {
 
 	// Create default implementations if not provided
 	if b.gateway == nil {
-		b.gateway = gateway.NewUSRGateway(&b.config.MQTT)
+		gw, err := gateway.NewGateway(&b.config.MQTT)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gateway: %w", err)
+		}
+		b.gateway = gw
 	}
 
 	if b.executor == nil {
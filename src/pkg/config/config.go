@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"mqtt-modbus-bridge/pkg/logger"
 	"os"
+	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 )
@@ -25,11 +26,17 @@ type Config struct {
 	HomeAssistant  HAConfig                      `yaml:"homeassistant"`
 	Modbus         ModbusConfig                  `yaml:"modbus"`
 	Application    ApplicationConfig             `yaml:"application"`                    // Application-level settings (timings, intervals)
+	Gateways       map[string]GatewayConfig      `yaml:"gateways,omitempty"`             // Named gateways, one per RS-485 bus (optional)
+	WritePolicy    WritePolicyConfig             `yaml:"write_policy,omitempty"`         // Rate limit and confirmation of writes
 	Registers      map[string]Register           `yaml:"registers,omitempty"`            // V1 format
 	RegisterGroups map[string]RegisterGroup      `yaml:"register_groups,omitempty"`      // V2.0 format
 	Devices        map[string]Device             `yaml:"devices,omitempty"`              // V2.1 format (recommended)
 	CalculatedRegs map[string]CalculatedRegister `yaml:"calculated_registers,omitempty"` // V2.0+ format
+	Profiles       map[string]DeviceProfile      `yaml:"profiles,omitempty"`             // Device profiles referenced by devices.<key>.profile
+	ProfileFiles   []string                      `yaml:"profile_files,omitempty"`        // YAML files with more profiles (relative to the configuration file)
 	Logging        logger.LoggingConfig          `yaml:"logging"`
+
+	configDir string // Directory of the configuration file (base of relative profile_files)
 }
 
 // MQTTConfig contains MQTT broker and gateway settings
@@ -45,13 +52,6 @@ type MQTTConfig struct {
 	Gateway           GatewayConfig `yaml:"gateway"`
 }
 
-// GatewayConfig contains USR-DR164 gateway specific settings
-type GatewayConfig struct {
-	MAC       string `yaml:"mac"`
-	CmdTopic  string `yaml:"cmd_topic"`
-	DataTopic string `yaml:"data_topic"`
-}
-
 // HAConfig contains Home Assistant MQTT Discovery settings
 // Global settings for the bridge (discovery prefix and device diagnostics config)
 // Per-device Home Assistant information is configured in Device struct
@@ -97,11 +97,13 @@ type DiagnosticThresholdsConfig struct {
 
 // ApplicationConfig contains application-level settings
 type ApplicationConfig struct {
-	PerformanceSummaryInterval int `yaml:"performance_summary_interval"` // Seconds between performance summaries (default: 30)
-	ErrorGracePeriod           int `yaml:"error_grace_period"`           // Seconds to wait before marking offline (default: 15)
-	MaxPublishInterval         int `yaml:"max_publish_interval"`         // Maximum seconds between publishes (default: 300)
-	HealthCheckPort            int `yaml:"health_check_port"`            // Port for health check endpoint (default: 8080, 0 = disabled)
-	MetricsPort                int `yaml:"metrics_port"`                 // Port for Prometheus metrics endpoint (default: 0 = disabled)
+	PerformanceSummaryInterval int    `yaml:"performance_summary_interval"`  // Seconds between performance summaries (default: 30)
+	ErrorGracePeriod           int    `yaml:"error_grace_period"`            // Seconds to wait before marking offline (default: 15)
+	MaxPublishInterval         int    `yaml:"max_publish_interval"`          // Maximum seconds between publishes (default: 300)
+	HealthCheckPort            int    `yaml:"health_check_port"`             // Port for health check endpoint (default: 8080, 0 = disabled)
+	MetricsPort                int    `yaml:"metrics_port"`                  // Port for Prometheus metrics endpoint (default: 0 = disabled)
+	StateFile                  string `yaml:"state_file,omitempty"`          // File keeping counters and last values across restarts (default: "" = disabled)
+	StateSaveInterval          int    `yaml:"state_save_interval,omitempty"` // Seconds between state saves (default: 60)
 }
 
 // ModbusConfig contains Modbus device settings
@@ -117,19 +119,29 @@ type ModbusConfig struct {
 // Register represents a Modbus register configuration
 // Used by Strategy Pattern implementations
 type Register struct {
-	Name          string   `yaml:"name"`
-	Address       uint16   `yaml:"address"`
-	Unit          string   `yaml:"unit"`
-	ScaleFactor   float64  `yaml:"scale_factor,omitempty"` // Multiplier to convert raw value to desired unit (default: 1.0)
-	ApplyAbs      bool     `yaml:"apply_abs,omitempty"`    // Apply absolute value to result (e.g., for power factor)
-	Formula       string   `yaml:"formula,omitempty"`      // Mathematical formula for calculated values
-	DependsOn     []string `yaml:"depends_on,omitempty"`   // Register keys this calculation depends on
-	DeviceClass   string   `yaml:"device_class"`
-	StateClass    string   `yaml:"state_class"`
-	HATopic       string   `yaml:"ha_topic"`
-	Min           *float64 `yaml:"min,omitempty"`              // Minimum valid value (optional)
-	Max           *float64 `yaml:"max,omitempty"`              // Maximum valid value (optional)
-	MaxKwhPerHour *float64 `yaml:"max_kwh_per_hour,omitempty"` // Maximum kWh change per hour for energy registers (optional)
+	Name          string           `yaml:"name"`
+	Address       uint16           `yaml:"address"`
+	Unit          string           `yaml:"unit"`
+	ScaleFactor   float64          `yaml:"scale_factor,omitempty"` // Multiplier to convert raw value to desired unit (default: 1.0)
+	ApplyAbs      bool             `yaml:"apply_abs,omitempty"`    // Apply absolute value to result (e.g., for power factor)
+	DataType      string           `yaml:"data_type,omitempty"`    // Register data type (default: float32)
+	ByteOrder     string           `yaml:"byte_order,omitempty"`   // Register byte order (default: ABCD)
+	Formula       string           `yaml:"formula,omitempty"`      // Mathematical formula for calculated values
+	DependsOn     []string         `yaml:"depends_on,omitempty"`   // Register keys this calculation depends on
+	DeviceClass   string           `yaml:"device_class"`
+	StateClass    string           `yaml:"state_class"`
+	HATopic       string           `yaml:"ha_topic"`
+	Min           *float64         `yaml:"min,omitempty"`              // Minimum valid value (optional)
+	Max           *float64         `yaml:"max,omitempty"`              // Maximum valid value (optional)
+	MaxKwhPerHour *float64         `yaml:"max_kwh_per_hour,omitempty"` // Maximum kWh change per hour for energy registers (optional)
+	Length        int              `yaml:"-"`                          // Length in bytes of string and bcd registers (register groups only)
+	Bits          []BitField       `yaml:"-"`                          // Status word bits (register groups only)
+	Enum          map[int64]string `yaml:"-"`                          // Value → label map (register groups only)
+	Transforms    Transforms       `yaml:"-"`                          // Value pipeline after scale_factor and apply_abs
+	Filter        *ValueFilter     `yaml:"-"`                          // Noise filter after the transforms (register groups only)
+	Counter       *CounterConfig   `yaml:"-"`                          // Rollover and reset handling of total_increasing counters (register groups only)
+	RawInputs     bool             `yaml:"-"`                          // Formula reads unfiltered values (calculated values only)
+	Publish       PublishPolicy    `yaml:"-"`                          // Deadband and publish intervals
 }
 
 // LoadConfig loads configuration from specified file with version detection
@@ -197,10 +209,13 @@ func LoadConfig(configPath string) (*Config, error) {
 	if config.Version == "" {
 		config.Version = "1.0"
 	}
+	config.configDir = filepath.Dir(usedPath)
 
 	// Apply defaults before validation
 	config.ApplyApplicationDefaults()
 	config.ApplyDeviceDiagnosticsDefaults()
+	config.ApplyGatewayDefaults()
+	config.ApplyWritePolicyDefaults()
 
 	// Configuration validation
 	if err := config.Validate(); err != nil {
@@ -240,6 +255,8 @@ func LoadConfigFromString(yamlContent string) (*Config, error) {
 	// Apply defaults before validation
 	config.ApplyApplicationDefaults()
 	config.ApplyDeviceDiagnosticsDefaults()
+	config.ApplyGatewayDefaults()
+	config.ApplyWritePolicyDefaults()
 
 	// Configuration validation
 	if err := config.Validate(); err != nil {
@@ -259,8 +276,8 @@ func (c *Config) Validate() error {
 	if c.MQTT.Port <= 0 || c.MQTT.Port > 65535 {
 		return fmt.Errorf("mqtt.port must be between 1 and 65535 (got %d)", c.MQTT.Port)
 	}
-	if c.MQTT.Gateway.MAC == "" {
-		return fmt.Errorf("mqtt.gateway.mac is not specified")
+	if err := c.validateGateways(); err != nil {
+		return err
 	}
 	if c.Modbus.PollInterval <= 0 {
 		return fmt.Errorf("modbus.poll_interval must be positive")
@@ -276,6 +293,9 @@ func (c *Config) Validate() error {
 	if err := c.validateApplicationConfig(); err != nil {
 		return err
 	}
+	if err := c.validateWritePolicy(); err != nil {
+		return err
+	}
 
 	// Note: StatusTopic and DiagnosticTopic are now auto-generated from BridgeDeviceID
 	// No validation needed - they are constructed via GetStatusTopic() and GetDiagnosticTopic()
@@ -289,9 +309,18 @@ func (c *Config) Validate() error {
 
 		// Validate devices (V2.1 format - preferred)
 		if len(c.Devices) > 0 {
+			if err := c.expandProfiles(); err != nil {
+				return err
+			}
+			if err := c.expandReadPlans(); err != nil {
+				return err
+			}
 			if err := ValidateDevices(c.Devices); err != nil {
 				return err
 			}
+			if err := c.validateDeviceGateways(); err != nil {
+				return err
+			}
 
 			// Convert devices to flat groups for backward compatibility
 			if len(c.RegisterGroups) == 0 {
@@ -357,6 +386,9 @@ func (c *Config) Validate() error {
 			if reg.HATopic == "" {
 				return fmt.Errorf("register %s has no Home Assistant topic", name)
 			}
+			if err := ValidateDataType(reg.GetDataType(), reg.GetByteOrder()); err != nil {
+				return fmt.Errorf("register %s: %w", name, err)
+			}
 		}
 	}
 
@@ -388,6 +420,11 @@ func (c *Config) ApplyApplicationDefaults() {
 	}
 
 	// Metrics port defaults to 0 (disabled) - no change needed
+
+	// Apply state save interval default (the state store is disabled without state_file)
+	if app.StateFile != "" && app.StateSaveInterval == 0 {
+		app.StateSaveInterval = 60 // 1 minute
+	}
 }
 
 // ApplyDeviceDiagnosticsDefaults applies default values for device diagnostics configuration
@@ -484,6 +521,9 @@ func (c *Config) validateApplicationConfig() error {
 	if app.MaxPublishInterval < 0 {
 		return fmt.Errorf("application.max_publish_interval must be non-negative (got %d)", app.MaxPublishInterval)
 	}
+	if app.StateSaveInterval < 0 {
+		return fmt.Errorf("application.state_save_interval must be non-negative (got %d)", app.StateSaveInterval)
+	}
 
 	// Validate relationships between timing values
 	if app.ErrorGracePeriod > 0 && app.MaxPublishInterval > 0 {
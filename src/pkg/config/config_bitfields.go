@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DeviceClassEnum is the Home Assistant device class of sensors with a fixed set of text states
+const DeviceClassEnum = "enum"
+
+// BitField maps one bit of a status register to a Home Assistant binary sensor
+type BitField struct {
+	Bit         int    `yaml:"bit"`                    // Bit index, 0 = least significant bit
+	Key         string `yaml:"key"`                    // Unique identifier within the device (e.g., "alarm_overvoltage")
+	Name        string `yaml:"name"`                   // Display name
+	DeviceClass string `yaml:"device_class,omitempty"` // Binary sensor device class (e.g., "problem", "running")
+}
+
+// IsStatusRegister reports whether the register is published as bits or enum instead of a number
+func (r *GroupRegister) IsStatusRegister() bool {
+	return len(r.Bits) > 0 || len(r.Enum) > 0
+}
+
+// EnumOptions returns the enum labels ordered by value
+func (r *GroupRegister) EnumOptions() []string {
+	return enumOptions(r.Enum)
+}
+
+// EnumOptions returns the enum labels ordered by value
+func (r *Register) EnumOptions() []string {
+	return enumOptions(r.Enum)
+}
+
+// EnumLabel returns the label of a raw register value
+func (r *Register) EnumLabel(raw float64) (string, bool) {
+	label, exists := r.Enum[int64(raw)]
+	return label, exists
+}
+
+// enumOptions sorts the labels of a value→label map by value
+func enumOptions(enum map[int64]string) []string {
+	values := make([]int64, 0, len(enum))
+	for value := range enum {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	options := make([]string, len(values))
+	for i, value := range values {
+		options[i] = enum[value]
+	}
+	return options
+}
+
+// validateStatusFields checks the bits and enum declarations of a register
+func (r *GroupRegister) validateStatusFields() error {
+	if !r.IsStatusRegister() {
+		return nil
+	}
+	if len(r.Bits) > 0 && len(r.Enum) > 0 {
+		return fmt.Errorf("bits and enum cannot be combined")
+	}
+	dataType := r.GetDataType()
+	if dataType == DataTypeFloat32 || dataType == DataTypeFloat64 || IsTextDataType(dataType) {
+		return fmt.Errorf("bits and enum require an integer data_type (got %s)", dataType)
+	}
+
+	size, _ := DataTypeSize(dataType)
+	bitKeys := make(map[string]bool)
+	for i, bit := range r.Bits {
+		if bit.Key == "" {
+			return fmt.Errorf("bits[%d]: key cannot be empty", i)
+		}
+		if bitKeys[bit.Key] {
+			return fmt.Errorf("bits[%d]: duplicate key '%s'", i, bit.Key)
+		}
+		bitKeys[bit.Key] = true
+		if bit.Bit < 0 || bit.Bit >= size*8 {
+			return fmt.Errorf("bits[%d]: bit %d out of range for %s (0-%d)", i, bit.Bit, dataType, size*8-1)
+		}
+	}
+
+	if len(r.Enum) > 0 {
+		if r.DeviceClass != "" && r.DeviceClass != DeviceClassEnum {
+			return fmt.Errorf("enum registers must use device_class '%s' (got '%s')", DeviceClassEnum, r.DeviceClass)
+		}
+		if r.StateClass != "" || r.Unit != "" {
+			return fmt.Errorf("enum registers cannot have a unit or state_class")
+		}
+		labels := make(map[string]bool)
+		for value, label := range r.Enum {
+			if label == "" {
+				return fmt.Errorf("enum value %d has an empty label", value)
+			}
+			if labels[label] {
+				return fmt.Errorf("duplicate enum label '%s'", label)
+			}
+			labels[label] = true
+		}
+	}
+
+	return nil
+}
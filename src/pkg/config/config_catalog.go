@@ -0,0 +1,96 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// catalogFS holds the built-in device profiles, one profile file per model
+//
+//go:embed catalog/*.yaml
+var catalogFS embed.FS
+
+// CatalogEntry is a built-in profile together with the file it is defined in
+type CatalogEntry struct {
+	Name    string        // Profile name (e.g. "ddsu666-h")
+	File    string        // File in the embedded catalog (e.g. "catalog/ddsu666-h.yaml")
+	Profile DeviceProfile // Parsed profile
+}
+
+var (
+	catalogOnce    sync.Once
+	catalogEntries []CatalogEntry
+	catalogErr     error
+)
+
+// Catalog returns the built-in device profiles sorted by name
+func Catalog() ([]CatalogEntry, error) {
+	catalogOnce.Do(func() {
+		catalogEntries, catalogErr = loadCatalog()
+	})
+	return catalogEntries, catalogErr
+}
+
+// loadCatalog parses every profile file of the embedded catalog
+func loadCatalog() ([]CatalogEntry, error) {
+	files, err := fs.Glob(catalogFS, "catalog/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []CatalogEntry
+	seen := make(map[string]string)
+	for _, path := range files {
+		data, err := catalogFS.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var file profileFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("profile catalog: error parsing %s: %w", path, err)
+		}
+		for name, profile := range file.Profiles {
+			if other, exists := seen[strings.ToLower(name)]; exists {
+				return nil, fmt.Errorf("profile catalog: profile '%s' in %s is already defined in %s", name, path, other)
+			}
+			seen[strings.ToLower(name)] = path
+			entries = append(entries, CatalogEntry{Name: name, File: path, Profile: profile})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// FindCatalogProfile returns the built-in profile matching a profile or model name
+// Matching ignores case, so "DTSU666-H" selects the dtsu666-h profile.
+func FindCatalogProfile(name string) (CatalogEntry, bool, error) {
+	entries, err := Catalog()
+	if err != nil {
+		return CatalogEntry{}, false, err
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name, name) || strings.EqualFold(entry.Profile.Model, name) {
+			return entry, true, nil
+		}
+	}
+	return CatalogEntry{}, false, nil
+}
+
+// CatalogProfileYAML returns the profile file of a built-in profile as shipped
+func CatalogProfileYAML(name string) ([]byte, error) {
+	entry, exists, err := FindCatalogProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("profile '%s' is not in the catalog", name)
+	}
+	return catalogFS.ReadFile(entry.File)
+}
@@ -0,0 +1,55 @@
+package config
+
+import "fmt"
+
+// StateClassTotalIncreasing is the Home Assistant state class of cumulative meter counters
+// Registers with this state class are published as a continuous series: rollovers and
+// acknowledged meter resets are added to an offset instead of letting the value drop.
+const StateClassTotalIncreasing = "total_increasing"
+
+// rolloverWindow is the part of the rollover a counter may advance across a wrap;
+// larger drops are treated as a meter reset
+const rolloverWindow = 0.1
+
+// CounterConfig describes how a cumulative meter counter wraps and resets
+//
+//	counter:
+//	  rollover: 99999.99      # The meter counts 0 → 99999.99 and starts over
+//	  auto_acknowledge: false # Wait for an operator before continuing after a reset
+type CounterConfig struct {
+	Rollover        float64 `yaml:"rollover,omitempty"`         // Value after which the meter counter wraps to 0 (0 = never wraps)
+	AutoAcknowledge bool    `yaml:"auto_acknowledge,omitempty"` // Continue after a meter reset or replacement without acknowledgement
+}
+
+// IsRollover reports whether a drop from last to current is the counter wrapping at the rollover
+// The counter must have advanced less than 10% of the rollover across the wrap.
+func (c *CounterConfig) IsRollover(last, current float64) bool {
+	if c == nil || c.Rollover <= 0 || current >= last {
+		return false
+	}
+	advance := c.Rollover - last + current
+	return advance >= 0 && advance <= c.Rollover*rolloverWindow
+}
+
+// Validate checks the rollover limit
+func (c *CounterConfig) Validate() error {
+	if c.Rollover < 0 {
+		return fmt.Errorf("counter rollover must not be negative (got %.3f)", c.Rollover)
+	}
+	return nil
+}
+
+// validateCounter checks the counter of a group register
+// Only total_increasing registers are tracked as counters.
+func (r *GroupRegister) validateCounter() error {
+	if r.Counter == nil {
+		return nil
+	}
+	if r.StateClass != StateClassTotalIncreasing {
+		return fmt.Errorf("counter requires state_class %s (got '%s')", StateClassTotalIncreasing, r.StateClass)
+	}
+	if r.IsStatusRegister() || IsTextDataType(r.GetDataType()) {
+		return fmt.Errorf("counter is not supported for status, enum and %s registers", r.GetDataType())
+	}
+	return r.Counter.Validate()
+}
@@ -0,0 +1,164 @@
+package config
+
+import "fmt"
+
+// Register data types (data_type)
+const (
+	DataTypeInt16   = "int16"
+	DataTypeUint16  = "uint16"
+	DataTypeInt32   = "int32"
+	DataTypeUint32  = "uint32"
+	DataTypeInt64   = "int64"
+	DataTypeFloat32 = "float32" // Default: the Chint meters report every value as IEEE 754 float32
+	DataTypeFloat64 = "float64"
+	DataTypeString  = "string" // ASCII text of length bytes (register groups only)
+	DataTypeBCD     = "bcd"    // Packed BCD digits of length bytes (register groups only)
+)
+
+// Device registry fields that can be read from string and bcd registers (device_info)
+const (
+	DeviceInfoSerialNumber = "serial_number"
+	DeviceInfoSwVersion    = "sw_version"
+	DeviceInfoHwVersion    = "hw_version"
+)
+
+// maxDeviceInfoPollInterval is the poll_interval limit of groups holding only device_info registers (24 hours)
+const maxDeviceInfoPollInterval = 24 * 60 * 60 * 1000
+
+// dataTypeSizes maps each data type to its width in bytes
+var dataTypeSizes = map[string]int{
+	DataTypeInt16:   2,
+	DataTypeUint16:  2,
+	DataTypeInt32:   4,
+	DataTypeUint32:  4,
+	DataTypeInt64:   8,
+	DataTypeFloat32: 4,
+	DataTypeFloat64: 8,
+}
+
+// Byte orders (byte_order), named after the position of the bytes of a 32-bit value ABCD
+// (A = most significant byte) in the order they are received.
+// Values wider than 32 bits follow the same rules: CDAB reverses the order of all
+// 16-bit words and BADC swaps the two bytes inside each word.
+const (
+	ByteOrderABCD = "ABCD" // Big-endian, high word first (default)
+	ByteOrderCDAB = "CDAB" // Low word first, big-endian words
+	ByteOrderBADC = "BADC" // High word first, bytes swapped in each word
+	ByteOrderDCBA = "DCBA" // Little-endian
+)
+
+// DataTypeSize returns the width in bytes of a data type
+func DataTypeSize(dataType string) (int, bool) {
+	size, exists := dataTypeSizes[dataType]
+	return size, exists
+}
+
+// IsTextDataType reports whether a data type is decoded to a string
+func IsTextDataType(dataType string) bool {
+	return dataType == DataTypeString || dataType == DataTypeBCD
+}
+
+// ValidateDataType checks a numeric data type and byte order pair
+func ValidateDataType(dataType, byteOrder string) error {
+	if _, exists := dataTypeSizes[dataType]; !exists {
+		return fmt.Errorf("unsupported data_type '%s' (use int16, uint16, int32, uint32, int64, float32 or float64)", dataType)
+	}
+	switch byteOrder {
+	case ByteOrderABCD, ByteOrderCDAB, ByteOrderBADC, ByteOrderDCBA:
+		return nil
+	}
+	return fmt.Errorf("unsupported byte_order '%s' (use ABCD, CDAB, BADC or DCBA)", byteOrder)
+}
+
+// GetDataType returns the register data type (default: float32)
+func (r *GroupRegister) GetDataType() string {
+	if r.DataType == "" {
+		return DataTypeFloat32
+	}
+	return r.DataType
+}
+
+// GetByteOrder returns the register byte order (default: ABCD)
+func (r *GroupRegister) GetByteOrder() string {
+	if r.ByteOrder == "" {
+		return ByteOrderABCD
+	}
+	return r.ByteOrder
+}
+
+// GetDataType returns the register data type (default: float32)
+func (r *Register) GetDataType() string {
+	if r.DataType == "" {
+		return DataTypeFloat32
+	}
+	return r.DataType
+}
+
+// ByteSize returns the number of bytes the register occupies in the group data
+func (r *GroupRegister) ByteSize() int {
+	if IsTextDataType(r.GetDataType()) {
+		return r.Length
+	}
+	size, _ := DataTypeSize(r.GetDataType())
+	return size
+}
+
+// validateDataType checks the data type, byte order and length of a group register
+func (r *GroupRegister) validateDataType() error {
+	if !IsTextDataType(r.GetDataType()) {
+		if r.Length != 0 {
+			return fmt.Errorf("length is only used by string and bcd data types")
+		}
+		return ValidateDataType(r.GetDataType(), r.GetByteOrder())
+	}
+	if r.Length <= 0 || r.Length%2 != 0 {
+		return fmt.Errorf("%s requires a positive, even length in bytes (got %d)", r.GetDataType(), r.Length)
+	}
+	return ValidateDataType(DataTypeUint16, r.GetByteOrder())
+}
+
+// validateDeviceInfo checks the device registry field of a register
+func (r *GroupRegister) validateDeviceInfo() error {
+	switch r.DeviceInfo {
+	case "":
+		return nil
+	case DeviceInfoSerialNumber, DeviceInfoSwVersion, DeviceInfoHwVersion:
+	default:
+		return fmt.Errorf("unsupported device_info '%s' (use serial_number, sw_version or hw_version)", r.DeviceInfo)
+	}
+	if !IsTextDataType(r.GetDataType()) {
+		return fmt.Errorf("device_info requires a string or bcd data_type")
+	}
+	return nil
+}
+
+// IsDeviceInfoGroup reports whether every register of the group feeds the device registry
+// Such groups rarely change and may be polled as slowly as once a day.
+func (g *RegisterGroup) IsDeviceInfoGroup() bool {
+	for _, reg := range g.Registers {
+		if reg.DeviceInfo == "" {
+			return false
+		}
+	}
+	return len(g.Registers) > 0
+}
+
+// GetByteOrder returns the register byte order (default: ABCD)
+func (r *Register) GetByteOrder() string {
+	if r.ByteOrder == "" {
+		return ByteOrderABCD
+	}
+	return r.ByteOrder
+}
+
+// RegisterCount returns the number of 16-bit registers the value occupies
+func (r *Register) RegisterCount() uint16 {
+	if IsTextDataType(r.GetDataType()) {
+		return uint16(r.Length / 2) // #nosec G115 -- length is validated against the group range
+	}
+	size, exists := dataTypeSizes[r.GetDataType()]
+	if !exists {
+		return 2
+	}
+	return uint16(size / 2) // #nosec G115 -- sizes are at most 8 bytes
+}
@@ -9,11 +9,16 @@ import (
 // Device represents a Modbus device on the RTU bus (Version 2.1+)
 // Organized into 4 sections: metadata, rtu, modbus, homeassistant
 type Device struct {
-	Metadata         DeviceMetadata     `yaml:"metadata"`                    // Device metadata (name, manufacturer, model)
-	RTU              RTUConfig          `yaml:"rtu"`                         // RTU/Physical layer configuration
-	Modbus           ModbusDeviceConfig `yaml:"modbus"`                      // Modbus protocol layer
-	HomeAssistant    *HADeviceConfig    `yaml:"homeassistant,omitempty"`     // Home Assistant integration (optional)
-	CalculatedValues []CalculatedValue  `yaml:"calculated_values,omitempty"` // Calculated/derived values
+	Metadata         DeviceMetadata     `yaml:"metadata"`                     // Device metadata (name, manufacturer, model)
+	RTU              RTUConfig          `yaml:"rtu"`                          // RTU/Physical layer configuration
+	Modbus           ModbusDeviceConfig `yaml:"modbus"`                       // Modbus protocol layer
+	HomeAssistant    *HADeviceConfig    `yaml:"homeassistant,omitempty"`      // Home Assistant integration (optional)
+	CalculatedValues []CalculatedValue  `yaml:"calculated_values,omitempty"`  // Calculated/derived values
+	Writable         []WritableRegister `yaml:"writable_registers,omitempty"` // Registers exposed as HA number/select/switch/button
+	Profile          string             `yaml:"profile,omitempty"`            // Profile providing the register groups and calculated values
+	Overrides        *ProfileOverrides  `yaml:"overrides,omitempty"`          // Changes to the profile for this device
+
+	profileApplied bool // Profile registers already merged (validation may run more than once)
 }
 
 // DeviceMetadata contains device identification and metadata
@@ -22,16 +27,21 @@ type DeviceMetadata struct {
 	Manufacturer string `yaml:"manufacturer,omitempty"` // Device manufacturer
 	Model        string `yaml:"model,omitempty"`        // Device model
 	Enabled      bool   `yaml:"enabled"`                // Enable/disable this device
+	Identify     bool   `yaml:"identify,omitempty"`     // Read manufacturer, model and revision from the device (FC 0x2B/0x0E) at startup
 }
 
 // RTUConfig contains RTU/Physical layer configuration
 type RTUConfig struct {
-	SlaveID uint8 `yaml:"slave_id"` // Modbus device ID (1-247)
+	SlaveID uint8  `yaml:"slave_id"`          // Modbus device ID (1-247)
+	Gateway string `yaml:"gateway,omitempty"` // Name of the gateway (bus) the device is wired to (default: "default")
 }
 
 // ModbusDeviceConfig contains Modbus protocol layer configuration
 type ModbusDeviceConfig struct {
-	RegisterGroups map[string]RegisterGroup `yaml:"register_groups"` // Register groups (instant, energy, status, etc.)
+	RegisterGroups map[string]RegisterGroup `yaml:"register_groups"`     // Register groups (instant, energy, status, etc.)
+	Registers      []PlannedRegister        `yaml:"registers,omitempty"` // Registers by absolute address, merged into block reads by the read planner
+	ReadPlan       ReadPlanConfig           `yaml:"read_plan,omitempty"` // Limits of the planned block reads
+	PlannedGroups  []string                 `yaml:"-"`                   // Keys of the register groups computed from Registers
 }
 
 // HADeviceConfig contains Home Assistant specific configuration
@@ -45,15 +55,18 @@ type HADeviceConfig struct {
 // CalculatedValue represents a value computed from other registers
 // Calculated values are executed AFTER all Modbus reads complete
 type CalculatedValue struct {
-	Key         string   `yaml:"key"`                    // Unique key for this calculated value
-	Name        string   `yaml:"name"`                   // Display name
-	Unit        string   `yaml:"unit"`                   // Unit of measurement
-	Formula     string   `yaml:"formula"`                // Mathematical expression
-	ScaleFactor float64  `yaml:"scale_factor,omitempty"` // Multiplier applied to result (default: 1.0)
-	DeviceClass string   `yaml:"device_class"`           // Home Assistant device class
-	StateClass  string   `yaml:"state_class"`            // Home Assistant state class
-	Min         *float64 `yaml:"min,omitempty"`          // Minimum valid value
-	Max         *float64 `yaml:"max,omitempty"`          // Maximum valid value
+	Key           string           `yaml:"key"`                    // Unique key for this calculated value
+	Name          string           `yaml:"name"`                   // Display name
+	Unit          string           `yaml:"unit"`                   // Unit of measurement
+	Formula       string           `yaml:"formula"`                // Mathematical expression
+	ScaleFactor   float64          `yaml:"scale_factor,omitempty"` // Multiplier applied to result (default: 1.0)
+	DeviceClass   string           `yaml:"device_class"`           // Home Assistant device class
+	StateClass    string           `yaml:"state_class"`            // Home Assistant state class
+	Min           *float64         `yaml:"min,omitempty"`          // Minimum valid value
+	Max           *float64         `yaml:"max,omitempty"`          // Maximum valid value
+	Transforms    Transforms       `yaml:"transforms,omitempty"`   // Steps applied after scale_factor (offset, invert, clamp, round, convert)
+	RawInputs     bool             `yaml:"raw_inputs,omitempty"`   // Use the unfiltered values of filtered registers in the formula
+	PublishPolicy `yaml:",inline"` // deadband, deadband_percent, min/max_publish_interval
 }
 
 // GetName returns the device name from metadata
@@ -66,6 +79,14 @@ func (d *Device) GetSlaveID() uint8 {
 	return d.RTU.SlaveID
 }
 
+// GetGateway returns the name of the gateway the device is polled through
+func (d *Device) GetGateway() string {
+	if d.RTU.Gateway == "" {
+		return DefaultGatewayName
+	}
+	return d.RTU.Gateway
+}
+
 // IsEnabled returns whether the device is enabled
 func (d *Device) IsEnabled() bool {
 	return d.Metadata.Enabled
@@ -131,11 +152,12 @@ func (d *Device) Validate() error {
 
 	// Validate Modbus configuration
 	if len(d.Modbus.RegisterGroups) == 0 {
-		return fmt.Errorf("device '%s' has no modbus.register_groups", d.Metadata.Name)
+		return fmt.Errorf("device '%s' has no modbus.register_groups or modbus.registers", d.Metadata.Name)
 	}
 
 	// Track register keys across all groups to ensure uniqueness
 	usedRegisterKeys := make(map[string]string) // register key -> group name
+	usedDeviceInfo := make(map[string]string)   // device_info field -> register key
 
 	// Validate each register group
 	for groupName, group := range d.Modbus.RegisterGroups {
@@ -166,6 +188,23 @@ func (d *Device) Validate() error {
 					d.Metadata.Name, reg.Key, existingGroup, groupName)
 			}
 			usedRegisterKeys[reg.Key] = groupName
+
+			if reg.DeviceInfo != "" {
+				if existing, exists := usedDeviceInfo[reg.DeviceInfo]; exists {
+					return fmt.Errorf("device '%s': device_info '%s' is read by registers '%s' and '%s'",
+						d.Metadata.Name, reg.DeviceInfo, existing, reg.Key)
+				}
+				usedDeviceInfo[reg.DeviceInfo] = reg.Key
+			}
+
+			// Bits are published as entities of their own and share the register key namespace
+			for _, bit := range reg.Bits {
+				if existingGroup, exists := usedRegisterKeys[bit.Key]; exists {
+					return fmt.Errorf("device '%s': duplicate register key '%s' found in groups '%s' and '%s'",
+						d.Metadata.Name, bit.Key, existingGroup, groupName)
+				}
+				usedRegisterKeys[bit.Key] = groupName
+			}
 		}
 	}
 
@@ -187,6 +226,16 @@ func (d *Device) Validate() error {
 			return fmt.Errorf("device '%s': calculated value '%s' has no formula", d.Metadata.Name, calc.Key)
 		}
 
+		if err := calc.Transforms.validateFor(calc.Unit); err != nil {
+			return fmt.Errorf("device '%s': calculated value '%s': %w", d.Metadata.Name, calc.Key, err)
+		}
+		if err := calc.validateRange(); err != nil {
+			return fmt.Errorf("device '%s': calculated value '%s': %w", d.Metadata.Name, calc.Key, err)
+		}
+		if err := calc.PublishPolicy.Validate(); err != nil {
+			return fmt.Errorf("device '%s': calculated value '%s': %w", d.Metadata.Name, calc.Key, err)
+		}
+
 		// Validate formula syntax and extract variables
 		variables, err := ValidateFormula(calc.Formula)
 		if err != nil {
@@ -205,6 +254,18 @@ func (d *Device) Validate() error {
 		usedRegisterKeys[calc.Key] = "calculated_values"
 	}
 
+	// Validate writable registers
+	for i, writable := range d.Writable {
+		if err := writable.Validate(); err != nil {
+			return fmt.Errorf("device '%s': writable_registers[%d]: %w", d.Metadata.Name, i, err)
+		}
+		if existingGroup, exists := usedRegisterKeys[writable.Key]; exists {
+			return fmt.Errorf("device '%s': writable register key '%s' conflicts with register in group '%s'",
+				d.Metadata.Name, writable.Key, existingGroup)
+		}
+		usedRegisterKeys[writable.Key] = "writable_registers"
+	}
+
 	return nil
 }
 
@@ -214,9 +275,10 @@ func ValidateDevices(devices map[string]Device) error {
 		return fmt.Errorf("at least one device is required")
 	}
 
-	// Track used slave IDs, device keys, and HA device IDs to detect conflicts
-	usedSlaveIDs := make(map[uint8]string)
-	usedHADeviceIDs := make(map[string]string) // HA device ID -> device key
+	// Track used slave IDs (per gateway), device keys, and HA device IDs to detect conflicts
+	// Slave IDs only have to be unique on their own bus
+	usedSlaveIDs := make(map[string]map[uint8]string) // gateway name -> slave ID -> device name
+	usedHADeviceIDs := make(map[string]string)        // HA device ID -> device key
 	deviceKeys := make([]string, 0, len(devices))
 
 	for deviceKey, device := range devices {
@@ -230,12 +292,17 @@ func ValidateDevices(devices map[string]Device) error {
 			return fmt.Errorf("device '%s': %w", deviceKey, err)
 		}
 
-		// Check for duplicate slave IDs
-		if existingDevice, exists := usedSlaveIDs[device.RTU.SlaveID]; exists {
-			return fmt.Errorf("duplicate rtu.slave_id %d: used by both '%s' and '%s'",
-				device.RTU.SlaveID, existingDevice, device.Metadata.Name)
+		// Check for duplicate slave IDs on the same gateway
+		busSlaveIDs, exists := usedSlaveIDs[device.GetGateway()]
+		if !exists {
+			busSlaveIDs = make(map[uint8]string)
+			usedSlaveIDs[device.GetGateway()] = busSlaveIDs
+		}
+		if existingDevice, exists := busSlaveIDs[device.RTU.SlaveID]; exists {
+			return fmt.Errorf("duplicate rtu.slave_id %d on gateway '%s': used by both '%s' and '%s'",
+				device.RTU.SlaveID, device.GetGateway(), existingDevice, device.Metadata.Name)
 		}
-		usedSlaveIDs[device.RTU.SlaveID] = device.Metadata.Name
+		busSlaveIDs[device.RTU.SlaveID] = device.Metadata.Name
 
 		// Get the effective HA device ID (explicit or fallback to device key)
 		haDeviceID := device.GetHADeviceID(deviceKey)
@@ -253,6 +320,17 @@ func ValidateDevices(devices map[string]Device) error {
 	return nil
 }
 
+// FilterDevicesByGateway returns the devices polled through the named gateway
+func FilterDevicesByGateway(devices map[string]Device, gatewayName string) map[string]Device {
+	filtered := make(map[string]Device)
+	for deviceKey, device := range devices {
+		if device.GetGateway() == gatewayName {
+			filtered[deviceKey] = device
+		}
+	}
+	return filtered
+}
+
 // ConvertDevicesToGroups converts device-based config (V2.1) to flat groups (V2.0) for backward compatibility
 func ConvertDevicesToGroups(devices map[string]Device) map[string]RegisterGroup {
 	groups := make(map[string]RegisterGroup)
@@ -344,14 +422,10 @@ func GetAllRegistersFromDevices(devices map[string]Device) map[string]Register {
 				haTopic := reg.HATopic
 				if haTopic == "" {
 					haTopic = topics.ConstructHATopic(haDeviceID, reg.Key, reg.DeviceClass)
-				} // Create pointers for optional fields (only if non-zero)
-				var minPtr, maxPtr, maxKwhPtr *float64
-				if reg.Min != 0 {
-					minPtr = &reg.Min
-				}
-				if reg.Max != 0 {
-					maxPtr = &reg.Max
 				}
+
+				// Create pointer for the optional rate bound (only if non-zero)
+				var maxKwhPtr *float64
 				if reg.MaxKwhPerHour != 0 {
 					maxKwhPtr = &reg.MaxKwhPerHour
 				}
@@ -371,13 +445,22 @@ func GetAllRegistersFromDevices(devices map[string]Device) map[string]Register {
 					Unit:          reg.Unit,
 					ScaleFactor:   scaleFactor,
 					ApplyAbs:      reg.ApplyAbs, // Copy apply_abs flag
+					DataType:      reg.DataType,
+					ByteOrder:     reg.ByteOrder,
+					Length:        reg.Length,
 					Formula:       reg.Formula,
 					DependsOn:     reg.DependsOn,
 					DeviceClass:   reg.DeviceClass,
 					StateClass:    reg.StateClass,
 					HATopic:       haTopic,
-					Min:           minPtr,
-					Max:           maxPtr,
+					Bits:          reg.Bits,
+					Enum:          reg.Enum,
+					Transforms:    reg.Transforms,
+					Filter:        reg.Filter,
+					Counter:       reg.Counter,
+					Publish:       reg.PublishPolicy,
+					Min:           reg.Min,
+					Max:           reg.Max,
 					MaxKwhPerHour: maxKwhPtr,
 				}
 
@@ -419,6 +502,9 @@ func GetAllRegistersFromDevices(devices map[string]Device) map[string]Register {
 				HATopic:     haTopic,
 				Min:         minPtr,
 				Max:         maxPtr,
+				Transforms:  calc.Transforms,
+				RawInputs:   calc.RawInputs,
+				Publish:     calc.PublishPolicy,
 			}
 
 			logger.LogDebug("Converted device '%s' calculated value '%s' -> '%s' (formula: %s, topic: %s)",
@@ -0,0 +1,78 @@
+package config
+
+import "fmt"
+
+// Noise filter types
+const (
+	FilterMovingAverage = "moving_average" // Mean of the last N samples
+	FilterEMA           = "ema"            // Exponential moving average
+	FilterMedian        = "median"         // Median of the last N samples
+)
+
+// DefaultMaxSpikes is the number of consecutive spikes after which the new level is accepted
+const DefaultMaxSpikes = 3
+
+// maxFilterSamples bounds the window of moving_average and median filters
+const maxFilterSamples = 100
+
+// ValueFilter smooths the value of a register and rejects spikes
+// Spike rejection (max_rate) runs first and can be used alone or with one smoothing type:
+//
+//	filter:
+//	  type: median          # moving_average, ema or median
+//	  samples: 5            # Window of moving_average and median
+//	  max_rate: 50          # Reject changes faster than 50 units per second
+type ValueFilter struct {
+	Type      string  `yaml:"type,omitempty"`       // moving_average, ema or median (empty = spike rejection only)
+	Samples   int     `yaml:"samples,omitempty"`    // Window of moving_average and median (2-100)
+	Alpha     float64 `yaml:"alpha,omitempty"`      // Weight of the new sample for ema (0-1]
+	MaxRate   float64 `yaml:"max_rate,omitempty"`   // Largest plausible change per second (0 = no spike rejection)
+	MaxSpikes int     `yaml:"max_spikes,omitempty"` // Consecutive spikes accepted as a real step change (default: 3)
+}
+
+// GetMaxSpikes returns the number of consecutive spikes after which the new level is accepted
+func (f *ValueFilter) GetMaxSpikes() int {
+	if f.MaxSpikes <= 0 {
+		return DefaultMaxSpikes
+	}
+	return f.MaxSpikes
+}
+
+// Validate checks that the filter has the parameters of its type
+func (f *ValueFilter) Validate() error {
+	switch f.Type {
+	case FilterMovingAverage, FilterMedian:
+		if f.Samples < 2 || f.Samples > maxFilterSamples {
+			return fmt.Errorf("filter %s needs samples between 2 and %d (got %d)", f.Type, maxFilterSamples, f.Samples)
+		}
+	case FilterEMA:
+		if f.Alpha <= 0 || f.Alpha > 1 {
+			return fmt.Errorf("filter ema needs alpha in (0, 1] (got %.3f)", f.Alpha)
+		}
+	case "":
+		if f.MaxRate == 0 {
+			return fmt.Errorf("filter needs a type (%s, %s or %s) or max_rate", FilterMovingAverage, FilterEMA, FilterMedian)
+		}
+	default:
+		return fmt.Errorf("unknown filter type '%s' (use %s, %s or %s)", f.Type, FilterMovingAverage, FilterEMA, FilterMedian)
+	}
+	if f.MaxRate < 0 {
+		return fmt.Errorf("filter max_rate must not be negative (got %.3f)", f.MaxRate)
+	}
+	if f.MaxSpikes < 0 {
+		return fmt.Errorf("filter max_spikes must not be negative (got %d)", f.MaxSpikes)
+	}
+	return nil
+}
+
+// validateFilter checks the filter of a group register
+// Status words, enums and text registers publish states, not measurements to smooth.
+func (r *GroupRegister) validateFilter() error {
+	if r.Filter == nil {
+		return nil
+	}
+	if r.IsStatusRegister() || IsTextDataType(r.GetDataType()) {
+		return fmt.Errorf("filter is not supported for status, enum and %s registers", r.GetDataType())
+	}
+	return r.Filter.Validate()
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+)
+
+// Gateway transport types
+const (
+	GatewayTypeUSRMQTT   = "usr_mqtt"   // USR-DR164 tunnelling Modbus RTU frames over MQTT (default)
+	GatewayTypeModbusTCP = "modbus_tcp" // Native Modbus TCP (MBAP) gateway or device
+	GatewayTypeSerialRTU = "serial_rtu" // Local serial port (e.g. USB RS-485 dongle) as Modbus RTU master
+	GatewayTypeRTUTCP    = "rtu_tcp"    // Raw RTU frames over a transparent TCP socket (e.g. USR-DR164 TCP server mode)
+	GatewayTypeRTUUDP    = "rtu_udp"    // Raw RTU frames over UDP datagrams (e.g. USR-DR164 UDP mode)
+)
+
+// DefaultGatewayName is the name of the gateway configured under mqtt.gateway
+// Devices without rtu.gateway are polled through this gateway
+const DefaultGatewayName = "default"
+
+// GatewayConfig contains Modbus gateway transport settings
+// The transport is selected with Type; only the section matching the type is used
+type GatewayConfig struct {
+	Type      string          `yaml:"type,omitempty"`       // Transport type: usr_mqtt, modbus_tcp, serial_rtu, rtu_tcp or rtu_udp (default: usr_mqtt)
+	MAC       string          `yaml:"mac"`                  // USR-DR164 MAC address (usr_mqtt only)
+	CmdTopic  string          `yaml:"cmd_topic"`            // MQTT topic for Modbus commands (usr_mqtt only)
+	DataTopic string          `yaml:"data_topic"`           // MQTT topic for Modbus responses (usr_mqtt only)
+	ModbusTCP ModbusTCPConfig `yaml:"modbus_tcp,omitempty"` // Modbus TCP settings (modbus_tcp only)
+	Serial    SerialConfig    `yaml:"serial,omitempty"`     // Serial line settings (serial_rtu only)
+	Socket    RTUSocketConfig `yaml:"socket,omitempty"`     // Transparent socket settings (rtu_tcp and rtu_udp only)
+
+	MaxRegistersPerRequest int `yaml:"max_registers_per_request,omitempty"` // Registers per 0x03/0x04 read; larger groups are split (default and maximum: 125)
+}
+
+// ModbusTCPConfig contains native Modbus TCP transport settings
+type ModbusTCPConfig struct {
+	Host           string `yaml:"host"`            // Gateway hostname or IP address
+	Port           int    `yaml:"port"`            // TCP port (default: 502)
+	ConnectTimeout int    `yaml:"connect_timeout"` // Dial timeout in milliseconds (default: 3000)
+	RetryDelay     int    `yaml:"retry_delay"`     // Delay between connection retries in milliseconds (default: 5000)
+}
+
+// SerialConfig contains local serial line settings for the RTU master
+type SerialConfig struct {
+	Device          string `yaml:"device"`               // Serial device path (e.g. /dev/ttyUSB0)
+	BaudRate        int    `yaml:"baud_rate"`            // Baud rate (default: 9600)
+	DataBits        int    `yaml:"data_bits"`            // Data bits: 7 or 8 (default: 8)
+	Parity          string `yaml:"parity"`               // Parity: N, E or O (default: N)
+	StopBits        int    `yaml:"stop_bits"`            // Stop bits: 1 or 2 (default: 1)
+	InterFrameDelay int    `yaml:"inter_frame_delay_us"` // t3.5 bus silence in microseconds (default: derived from baud rate)
+	RetryDelay      int    `yaml:"retry_delay"`          // Delay between open retries in milliseconds (default: 5000)
+}
+
+// RTUSocketConfig contains settings for raw RTU frames over a transparent TCP or UDP socket
+type RTUSocketConfig struct {
+	Host            string `yaml:"host"`                 // Converter hostname or IP address
+	Port            int    `yaml:"port"`                 // Converter TCP/UDP port
+	ConnectTimeout  int    `yaml:"connect_timeout"`      // Dial timeout in milliseconds (default: 3000)
+	InterFrameDelay int    `yaml:"inter_frame_delay_us"` // Minimum pause between frames in microseconds (default: 0)
+	RetryDelay      int    `yaml:"retry_delay"`          // Delay between connection retries in milliseconds (default: 5000)
+}
+
+// supportedBaudRates lists the baud rates accepted for serial_rtu
+var supportedBaudRates = map[int]bool{
+	1200: true, 2400: true, 4800: true, 9600: true, 19200: true,
+	38400: true, 57600: true, 115200: true,
+}
+
+// GetType returns the configured transport type, defaulting to usr_mqtt
+func (g *GatewayConfig) GetType() string {
+	if g.Type == "" {
+		return GatewayTypeUSRMQTT
+	}
+	return g.Type
+}
+
+// GetMaxRegistersPerRequest returns the register limit of one 0x03/0x04 read on this gateway
+func (g *GatewayConfig) GetMaxRegistersPerRequest() uint16 {
+	if g.MaxRegistersPerRequest == 0 {
+		return MaxReadRegisters
+	}
+	return uint16(g.MaxRegistersPerRequest) // #nosec G115 -- validated against MaxReadRegisters
+}
+
+// isConfigured reports whether any transport setting was provided
+func (g *GatewayConfig) isConfigured() bool {
+	return g.Type != "" || g.MAC != "" || g.CmdTopic != "" || g.DataTopic != ""
+}
+
+// GetGateways returns all gateways (buses) by name
+// The legacy mqtt.gateway section is exposed as DefaultGatewayName when no gateways map
+// is configured, or when it is configured alongside the map without a "default" entry
+func (c *Config) GetGateways() map[string]GatewayConfig {
+	gateways := make(map[string]GatewayConfig, len(c.Gateways)+1)
+	for name, gw := range c.Gateways {
+		gateways[name] = gw
+	}
+	if _, exists := gateways[DefaultGatewayName]; !exists && (len(c.Gateways) == 0 || c.MQTT.Gateway.isConfigured()) {
+		gateways[DefaultGatewayName] = c.MQTT.Gateway
+	}
+	return gateways
+}
+
+// ApplyGatewayDefaults applies default values for every gateway transport
+func (c *Config) ApplyGatewayDefaults() {
+	c.MQTT.Gateway.applyDefaults()
+	for name, gw := range c.Gateways {
+		gw.applyDefaults()
+		c.Gateways[name] = gw
+	}
+}
+
+// validateGateways validates every gateway and the device references to them
+func (c *Config) validateGateways() error {
+	if len(c.Gateways) == 0 {
+		return c.MQTT.Gateway.Validate()
+	}
+
+	if _, exists := c.Gateways[DefaultGatewayName]; exists && c.MQTT.Gateway.isConfigured() {
+		return fmt.Errorf("gateways.%s conflicts with mqtt.gateway (configure the default gateway in one place)", DefaultGatewayName)
+	}
+	if c.MQTT.Gateway.isConfigured() {
+		if err := c.MQTT.Gateway.Validate(); err != nil {
+			return err
+		}
+	}
+	for name, gw := range c.Gateways {
+		if name == "" {
+			return fmt.Errorf("gateways: gateway name cannot be empty")
+		}
+		if err := gw.validate("gateways." + name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDeviceGateways checks that every device refers to a configured gateway
+func (c *Config) validateDeviceGateways() error {
+	gateways := c.GetGateways()
+	for deviceKey, device := range c.Devices {
+		if _, exists := gateways[device.GetGateway()]; !exists {
+			return fmt.Errorf("device '%s': rtu.gateway '%s' is not defined in gateways", deviceKey, device.GetGateway())
+		}
+	}
+	return nil
+}
+
+// applyDefaults fills in transport defaults for the selected gateway type
+func (g *GatewayConfig) applyDefaults() {
+	switch g.GetType() {
+	case GatewayTypeModbusTCP:
+		if g.ModbusTCP.Port == 0 {
+			g.ModbusTCP.Port = 502 // Standard Modbus TCP port
+		}
+		if g.ModbusTCP.ConnectTimeout == 0 {
+			g.ModbusTCP.ConnectTimeout = 3000 // 3 seconds
+		}
+		if g.ModbusTCP.RetryDelay == 0 {
+			g.ModbusTCP.RetryDelay = 5000 // 5 seconds
+		}
+	case GatewayTypeRTUTCP, GatewayTypeRTUUDP:
+		if g.Socket.ConnectTimeout == 0 {
+			g.Socket.ConnectTimeout = 3000 // 3 seconds
+		}
+		if g.Socket.RetryDelay == 0 {
+			g.Socket.RetryDelay = 5000 // 5 seconds
+		}
+	case GatewayTypeSerialRTU:
+		if g.Serial.BaudRate == 0 {
+			g.Serial.BaudRate = 9600 // Most common meter setting
+		}
+		if g.Serial.DataBits == 0 {
+			g.Serial.DataBits = 8
+		}
+		if g.Serial.Parity == "" {
+			g.Serial.Parity = "N"
+		}
+		if g.Serial.StopBits == 0 {
+			g.Serial.StopBits = 1
+		}
+		if g.Serial.RetryDelay == 0 {
+			g.Serial.RetryDelay = 5000 // 5 seconds
+		}
+	}
+}
+
+// Validate validates the gateway transport configuration under mqtt.gateway
+func (g *GatewayConfig) Validate() error {
+	return g.validate("mqtt.gateway")
+}
+
+// validate validates the transport settings, reporting errors under the given config path
+func (g *GatewayConfig) validate(path string) error {
+	if g.MaxRegistersPerRequest < 0 || g.MaxRegistersPerRequest > MaxReadRegisters {
+		return fmt.Errorf("%s.max_registers_per_request must be 0 (default) or between 1 and %d (got %d)", path, MaxReadRegisters, g.MaxRegistersPerRequest)
+	}
+	switch g.GetType() {
+	case GatewayTypeUSRMQTT:
+		if g.MAC == "" {
+			return fmt.Errorf("%s.mac is not specified", path)
+		}
+	case GatewayTypeModbusTCP:
+		if g.ModbusTCP.Host == "" {
+			return fmt.Errorf("%s.modbus_tcp.host is not specified", path)
+		}
+		if g.ModbusTCP.Port <= 0 || g.ModbusTCP.Port > 65535 {
+			return fmt.Errorf("%s.modbus_tcp.port must be between 1 and 65535 (got %d)", path, g.ModbusTCP.Port)
+		}
+		if g.ModbusTCP.ConnectTimeout < 0 {
+			return fmt.Errorf("%s.modbus_tcp.connect_timeout must be non-negative", path)
+		}
+	case GatewayTypeSerialRTU:
+		if g.Serial.Device == "" {
+			return fmt.Errorf("%s.serial.device is not specified", path)
+		}
+		if !supportedBaudRates[g.Serial.BaudRate] {
+			return fmt.Errorf("%s.serial.baud_rate %d is not supported", path, g.Serial.BaudRate)
+		}
+		if g.Serial.DataBits != 7 && g.Serial.DataBits != 8 {
+			return fmt.Errorf("%s.serial.data_bits must be 7 or 8 (got %d)", path, g.Serial.DataBits)
+		}
+		if g.Serial.Parity != "N" && g.Serial.Parity != "E" && g.Serial.Parity != "O" {
+			return fmt.Errorf("%s.serial.parity must be N, E or O (got '%s')", path, g.Serial.Parity)
+		}
+		if g.Serial.StopBits != 1 && g.Serial.StopBits != 2 {
+			return fmt.Errorf("%s.serial.stop_bits must be 1 or 2 (got %d)", path, g.Serial.StopBits)
+		}
+		if g.Serial.InterFrameDelay < 0 {
+			return fmt.Errorf("%s.serial.inter_frame_delay_us must be non-negative", path)
+		}
+	case GatewayTypeRTUTCP, GatewayTypeRTUUDP:
+		if g.Socket.Host == "" {
+			return fmt.Errorf("%s.socket.host is not specified", path)
+		}
+		if g.Socket.Port <= 0 || g.Socket.Port > 65535 {
+			return fmt.Errorf("%s.socket.port must be between 1 and 65535 (got %d)", path, g.Socket.Port)
+		}
+		if g.Socket.ConnectTimeout < 0 || g.Socket.InterFrameDelay < 0 {
+			return fmt.Errorf("%s.socket timings must be non-negative", path)
+		}
+	default:
+		return fmt.Errorf("%s.type '%s' is not supported (use %s, %s, %s, %s or %s)", path,
+			g.Type, GatewayTypeUSRMQTT, GatewayTypeModbusTCP, GatewayTypeSerialRTU, GatewayTypeRTUTCP, GatewayTypeRTUUDP)
+	}
+	return nil
+}
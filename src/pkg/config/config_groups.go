@@ -10,9 +10,9 @@ import (
 type RegisterGroup struct {
 	Name          string          `yaml:"name"`
 	SlaveID       uint8           `yaml:"slave_id"`       // Modbus device ID
-	FunctionCode  uint8           `yaml:"function_code"`  // Modbus function (0x03, 0x04, etc.)
+	FunctionCode  uint8           `yaml:"function_code"`  // Modbus read function (0x01, 0x02, 0x03 or 0x04)
 	StartAddress  uint16          `yaml:"start_address"`  // First register address
-	RegisterCount uint16          `yaml:"register_count"` // Number of 16-bit registers
+	RegisterCount uint16          `yaml:"register_count"` // Number of 16-bit registers (coils/inputs for 0x01/0x02)
 	Enabled       bool            `yaml:"enabled"`        // Enable/disable this group
 	PollInterval  int             `yaml:"poll_interval"`  // Polling interval in milliseconds (per group)
 	Registers     []GroupRegister `yaml:"registers"`      // Registers in this group
@@ -20,20 +20,30 @@ type RegisterGroup struct {
 
 // GroupRegister defines a register within a group
 type GroupRegister struct {
-	Key           string   `yaml:"key"`                    // Unique identifier (e.g., "voltage")
-	Name          string   `yaml:"name"`                   // Display name
-	Offset        int      `yaml:"offset"`                 // Byte offset from group start (-1 for calculated registers)
-	Unit          string   `yaml:"unit"`                   // Unit of measurement (V, A, W, kWh, etc.)
-	ScaleFactor   float64  `yaml:"scale_factor,omitempty"` // Multiplier to convert raw value to desired unit (default: 1.0)
-	ApplyAbs      bool     `yaml:"apply_abs,omitempty"`    // Apply absolute value to result (e.g., for power factor)
-	Formula       string   `yaml:"formula,omitempty"`      // Mathematical formula for calculated values (e.g., "sqrt(power_active^2 + power_reactive^2)")
-	DependsOn     []string `yaml:"depends_on,omitempty"`   // Register keys this calculation depends on
-	DeviceClass   string   `yaml:"device_class"`
-	StateClass    string   `yaml:"state_class"`
-	HATopic       string   `yaml:"ha_topic,omitempty"` // Optional: Auto-constructed if not provided in v2.1
-	Min           float64  `yaml:"min,omitempty"`
-	Max           float64  `yaml:"max,omitempty"`
-	MaxKwhPerHour float64  `yaml:"max_kwh_per_hour,omitempty"`
+	Key           string           `yaml:"key"`                    // Unique identifier (e.g., "voltage")
+	Name          string           `yaml:"name"`                   // Display name
+	Offset        int              `yaml:"offset"`                 // Byte offset from group start, bit index for 0x01/0x02 groups (-1 for calculated registers)
+	DataType      string           `yaml:"data_type,omitempty"`    // int16, uint16, int32, uint32, int64, float32 (default), float64, string or bcd
+	ByteOrder     string           `yaml:"byte_order,omitempty"`   // ABCD (default), CDAB, BADC or DCBA
+	Unit          string           `yaml:"unit"`                   // Unit of measurement (V, A, W, kWh, etc.)
+	ScaleFactor   float64          `yaml:"scale_factor,omitempty"` // Multiplier to convert raw value to desired unit (default: 1.0)
+	ApplyAbs      bool             `yaml:"apply_abs,omitempty"`    // Apply absolute value to result (e.g., for power factor)
+	Formula       string           `yaml:"formula,omitempty"`      // Mathematical formula for calculated values (e.g., "sqrt(power_active^2 + power_reactive^2)")
+	DependsOn     []string         `yaml:"depends_on,omitempty"`   // Register keys this calculation depends on
+	DeviceClass   string           `yaml:"device_class"`
+	StateClass    string           `yaml:"state_class"`
+	HATopic       string           `yaml:"ha_topic,omitempty"` // Optional: Auto-constructed if not provided in v2.1
+	Min           *float64         `yaml:"min,omitempty"`      // Minimum plausible value (optional, 0 is a bound)
+	Max           *float64         `yaml:"max,omitempty"`      // Maximum plausible value (optional, 0 is a bound)
+	MaxKwhPerHour float64          `yaml:"max_kwh_per_hour,omitempty"`
+	Length        int              `yaml:"length,omitempty"`      // Length in bytes of string and bcd registers
+	DeviceInfo    string           `yaml:"device_info,omitempty"` // Publish in the HA device registry instead of as a sensor (serial_number, sw_version, hw_version)
+	Bits          []BitField       `yaml:"bits,omitempty"`        // Status word: publish each bit as a binary sensor
+	Enum          map[int64]string `yaml:"enum,omitempty"`        // Value → label map published as an enum sensor
+	Transforms    Transforms       `yaml:"transforms,omitempty"`  // Steps applied after scale_factor and apply_abs (offset, invert, clamp, round, convert)
+	Filter        *ValueFilter     `yaml:"filter,omitempty"`      // Noise filter of the published value (moving_average, ema, median, spike rejection)
+	Counter       *CounterConfig   `yaml:"counter,omitempty"`     // Rollover and reset handling of total_increasing counters
+	PublishPolicy `yaml:",inline"` // deadband, deadband_percent, min/max_publish_interval
 }
 
 // CalculatedRegister defines a virtual register calculated from other registers
@@ -47,13 +57,35 @@ type CalculatedRegister struct {
 	DependsOn   []string `yaml:"depends_on"` // Register keys this depends on
 }
 
+// Modbus read function codes supported by register groups
+const (
+	FunctionReadCoils            uint8 = 0x01
+	FunctionReadDiscreteInputs   uint8 = 0x02
+	FunctionReadHoldingRegisters uint8 = 0x03
+	FunctionReadInputRegisters   uint8 = 0x04
+)
+
+// maxBitCount is the Modbus limit of coils/discrete inputs per read request
+const maxBitCount = 2000
+
+// IsBitGroup returns true for coil and discrete input groups (0x01/0x02)
+// Their registers are single bits published as binary sensors
+func (g *RegisterGroup) IsBitGroup() bool {
+	return g.FunctionCode == FunctionReadCoils || g.FunctionCode == FunctionReadDiscreteInputs
+}
+
 // Validate validates the register group configuration
 func (g *RegisterGroup) Validate() error {
 	if g.SlaveID == 0 {
 		return fmt.Errorf("slave_id is required for register group '%s'", g.Name)
 	}
-	if g.FunctionCode == 0 {
+	switch g.FunctionCode {
+	case 0:
 		return fmt.Errorf("function_code is required for register group '%s'", g.Name)
+	case FunctionReadCoils, FunctionReadDiscreteInputs, FunctionReadHoldingRegisters, FunctionReadInputRegisters:
+	default:
+		return fmt.Errorf("function_code 0x%02X is not supported for register group '%s' (use 0x01, 0x02, 0x03 or 0x04)",
+			g.FunctionCode, g.Name)
 	}
 	if g.RegisterCount == 0 {
 		return fmt.Errorf("register_count is required for register group '%s'", g.Name)
@@ -64,25 +96,87 @@ func (g *RegisterGroup) Validate() error {
 	if g.PollInterval <= 0 {
 		return fmt.Errorf("poll_interval must be positive for register group '%s' (got %d ms)", g.Name, g.PollInterval)
 	}
-	if g.PollInterval > 300000 { // Max 5 minutes
-		return fmt.Errorf("poll_interval too large for register group '%s' (got %d ms, max 300000 ms)", g.Name, g.PollInterval)
+	maxPollInterval := 300000 // Max 5 minutes
+	if g.IsDeviceInfoGroup() {
+		maxPollInterval = maxDeviceInfoPollInterval
+	}
+	if g.PollInterval > maxPollInterval {
+		return fmt.Errorf("poll_interval too large for register group '%s' (got %d ms, max %d ms)", g.Name, g.PollInterval, maxPollInterval)
+	}
+
+	if g.IsBitGroup() {
+		return g.validateBitOffsets()
 	}
 
 	// Validate that offsets are within the read range
 	maxBytes := int(g.RegisterCount) * 2 // Each register is 2 bytes
 	for _, reg := range g.Registers {
+		if err := reg.validateDataType(); err != nil {
+			return fmt.Errorf("register '%s': %w", reg.Key, err)
+		}
+		if err := reg.validateDeviceInfo(); err != nil {
+			return fmt.Errorf("register '%s': %w", reg.Key, err)
+		}
+		if err := reg.validateStatusFields(); err != nil {
+			return fmt.Errorf("register '%s': %w", reg.Key, err)
+		}
+		if err := reg.validateTransforms(); err != nil {
+			return fmt.Errorf("register '%s': %w", reg.Key, err)
+		}
+		if err := reg.validateRange(); err != nil {
+			return fmt.Errorf("register '%s': %w", reg.Key, err)
+		}
+		if err := reg.validateFilter(); err != nil {
+			return fmt.Errorf("register '%s': %w", reg.Key, err)
+		}
+		if err := reg.validateCounter(); err != nil {
+			return fmt.Errorf("register '%s': %w", reg.Key, err)
+		}
+		if err := reg.PublishPolicy.Validate(); err != nil {
+			return fmt.Errorf("register '%s': %w", reg.Key, err)
+		}
 		if reg.Offset < 0 {
 			return fmt.Errorf("register '%s' has negative offset", reg.Key)
 		}
-		if reg.Offset+4 > maxBytes { // +4 because float32 is 4 bytes
-			return fmt.Errorf("register '%s' offset %d exceeds group range (max %d bytes)",
-				reg.Key, reg.Offset, maxBytes)
+		size := reg.ByteSize()
+		if reg.Offset+size > maxBytes {
+			return fmt.Errorf("register '%s' offset %d exceeds group range (%s needs %d bytes, max %d bytes)",
+				reg.Key, reg.Offset, reg.GetDataType(), size, maxBytes)
 		}
 	}
 
 	return nil
 }
 
+// validateBitOffsets checks that every bit index of a coil/discrete input group is read
+func (g *RegisterGroup) validateBitOffsets() error {
+	if g.RegisterCount > maxBitCount {
+		return fmt.Errorf("register_count %d exceeds %d bits for register group '%s'", g.RegisterCount, maxBitCount, g.Name)
+	}
+	for _, reg := range g.Registers {
+		if reg.IsStatusRegister() {
+			return fmt.Errorf("register '%s': bits and enum are only supported in 0x03/0x04 groups", reg.Key)
+		}
+		if len(reg.Transforms) > 0 {
+			return fmt.Errorf("register '%s': transforms are only supported in 0x03/0x04 groups", reg.Key)
+		}
+		if reg.Filter != nil {
+			return fmt.Errorf("register '%s': filter is only supported in 0x03/0x04 groups", reg.Key)
+		}
+		if reg.Counter != nil {
+			return fmt.Errorf("register '%s': counter is only supported in 0x03/0x04 groups", reg.Key)
+		}
+		if reg.Offset < 0 {
+			return fmt.Errorf("register '%s' has negative offset", reg.Key)
+		}
+		if reg.Offset >= int(g.RegisterCount) {
+			return fmt.Errorf("register '%s' bit offset %d exceeds group range (max %d bits)",
+				reg.Key, reg.Offset, g.RegisterCount)
+		}
+	}
+	return nil
+}
+
 // ValidateGroups validates all register groups and their dependencies
 func ValidateGroups(groups map[string]RegisterGroup, calculated map[string]CalculatedRegister) error {
 	if len(groups) == 0 {
@@ -146,14 +240,8 @@ func ConvertGroupsToRegisters(groups map[string]RegisterGroup) map[string]Regist
 			// #nosec G115 -- Validated above that offsetInRegisters fits in uint16
 			address := group.StartAddress + uint16(offsetInRegisters)
 
-			// Create pointers for optional fields (only if non-zero)
-			var minPtr, maxPtr, maxKwhPtr *float64
-			if reg.Min != 0 {
-				minPtr = &reg.Min
-			}
-			if reg.Max != 0 {
-				maxPtr = &reg.Max
-			}
+			// Create pointer for the optional rate bound (only if non-zero)
+			var maxKwhPtr *float64
 			if reg.MaxKwhPerHour != 0 {
 				maxKwhPtr = &reg.MaxKwhPerHour
 			}
@@ -169,13 +257,22 @@ func ConvertGroupsToRegisters(groups map[string]RegisterGroup) map[string]Regist
 				Address:       address,
 				Unit:          reg.Unit,
 				ScaleFactor:   scaleFactor,
+				DataType:      reg.DataType,
+				ByteOrder:     reg.ByteOrder,
+				Length:        reg.Length,
 				Formula:       reg.Formula,
 				DependsOn:     reg.DependsOn,
 				DeviceClass:   reg.DeviceClass,
 				StateClass:    reg.StateClass,
 				HATopic:       reg.HATopic,
-				Min:           minPtr,
-				Max:           maxPtr,
+				Bits:          reg.Bits,
+				Enum:          reg.Enum,
+				Transforms:    reg.Transforms,
+				Filter:        reg.Filter,
+				Counter:       reg.Counter,
+				Publish:       reg.PublishPolicy,
+				Min:           reg.Min,
+				Max:           reg.Max,
 				MaxKwhPerHour: maxKwhPtr,
 			}
 
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"math"
+	"strings"
+)
+
+// Phase conductors recognised as register key suffixes
+// Keys end in one phase for phase-to-neutral values (voltage_l1, power_active_l2)
+// or two phases for line-to-line values (voltage_l1_l2).
+const (
+	PhaseL1      = "l1"
+	PhaseL2      = "l2"
+	PhaseL3      = "l3"
+	PhaseNeutral = "n"
+)
+
+// NominalPhaseVoltage is the phase-to-neutral voltage the plausibility ranges are based on (V)
+const NominalPhaseVoltage = 230.0
+
+// MaxVoltage bounds voltages without a phase suffix, which include DC and PV string voltages (V)
+const MaxVoltage = 1000.0
+
+// PhaseKey is a register key split into the measured quantity and its phases
+type PhaseKey struct {
+	Quantity string   // Key without the phase suffix (e.g. "voltage", "power_active")
+	Phases   []string // No phase (single-phase or total), one phase, or two phases (line-to-line)
+}
+
+// ParsePhaseKey splits the phase suffix off a register key
+// Examples: "voltage" -> {voltage, []}, "current_l2" -> {current, [l2]}, "voltage_l1_l2" -> {voltage, [l1 l2]}
+func ParsePhaseKey(key string) PhaseKey {
+	parts := strings.Split(key, "_")
+	end := len(parts)
+	for end > 1 && end > len(parts)-2 && isPhase(parts[end-1]) {
+		end--
+	}
+	phases := make([]string, 0, len(parts)-end)
+	for _, part := range parts[end:] {
+		phases = append(phases, strings.ToLower(part))
+	}
+
+	if len(phases) == 2 {
+		switch {
+		case phases[1] == PhaseNeutral && phases[0] != PhaseNeutral:
+			// voltage_l1_n is the phase-to-neutral voltage of L1
+			phases = phases[:1]
+		case phases[0] == PhaseNeutral || phases[0] == phases[1]:
+			// Not a pair of phases: only the last part is the phase
+			end++
+			phases = phases[1:]
+		}
+	}
+	return PhaseKey{Quantity: strings.Join(parts[:end], "_"), Phases: phases}
+}
+
+// isPhase reports whether a key part names a phase conductor (case-insensitive, as in voltage_L1)
+func isPhase(part string) bool {
+	switch strings.ToLower(part) {
+	case PhaseL1, PhaseL2, PhaseL3, PhaseNeutral:
+		return true
+	}
+	return false
+}
+
+// IsLineToLine reports whether the key is a value between two phases (e.g. voltage_l1_l2)
+func (k PhaseKey) IsLineToLine() bool {
+	return len(k.Phases) == 2
+}
+
+// Phase returns the phase suffix of the key ("" for single-phase and total values)
+func (k PhaseKey) Phase() string {
+	return strings.Join(k.Phases, "_")
+}
+
+// PhaseRange returns the default plausible range of a measurement, based on its device class and phases
+// Line-to-line voltages are √3 times the phase-to-neutral voltage; voltages without a phase
+// suffix keep the wide MaxVoltage bound. Values outside the range come from a wrong register
+// map or scale factor rather than from the grid. Registers with their own min/max do not use it.
+// ok is false when no range applies to the device class.
+func PhaseRange(key string, deviceClass string) (minValue float64, maxValue float64, ok bool) {
+	phaseKey := ParsePhaseKey(key)
+	switch deviceClass {
+	case "voltage":
+		switch {
+		case phaseKey.IsLineToLine():
+			maxValue = 2 * NominalPhaseVoltage * math.Sqrt(3)
+		case len(phaseKey.Phases) == 1:
+			maxValue = 2 * NominalPhaseVoltage
+		default:
+			maxValue = MaxVoltage
+		}
+		return 0, maxValue, true
+	case "power_factor":
+		// Phases and totals are signed on meters that measure export
+		return -1, 1, true
+	case "frequency":
+		return 40, 70, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// CheckValue reports why a value is not plausible for the register
+// NaN and ±Inf are never valid; min and max bound the published value (after scale_factor
+// and transforms) when they are set. Text registers carry no numeric value and always pass.
+func (r *Register) CheckValue(value float64) error {
+	if IsTextDataType(r.GetDataType()) {
+		return nil
+	}
+	if math.IsNaN(value) {
+		return fmt.Errorf("value is NaN")
+	}
+	if math.IsInf(value, 0) {
+		return fmt.Errorf("value is infinite")
+	}
+	if r.Min != nil && value < *r.Min {
+		return fmt.Errorf("value %.3f is below min %.3f", value, *r.Min)
+	}
+	if r.Max != nil && value > *r.Max {
+		return fmt.Errorf("value %.3f is above max %.3f", value, *r.Max)
+	}
+	return nil
+}
+
+// HasLimits reports whether the register has its own min or max
+// The built-in plausibility ranges of the device classes only apply to registers without limits.
+func (r *Register) HasLimits() bool {
+	return r.Min != nil || r.Max != nil
+}
+
+// validateRange checks that the plausibility range of a group register is not empty
+func (r *GroupRegister) validateRange() error {
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return fmt.Errorf("min %.3f is greater than max %.3f", *r.Min, *r.Max)
+	}
+	return nil
+}
+
+// validateRange checks that the plausibility range of a calculated value is not empty
+func (c *CalculatedValue) validateRange() error {
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		return fmt.Errorf("min %.3f is greater than max %.3f", *c.Min, *c.Max)
+	}
+	return nil
+}
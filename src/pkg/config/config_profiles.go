@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DeviceProfile describes the registers of a device model once, for every device that references it
+type DeviceProfile struct {
+	Description      string             `yaml:"description,omitempty"`       // Short description shown when listing profiles
+	Manufacturer     string             `yaml:"manufacturer,omitempty"`      // Default metadata.manufacturer of the devices using the profile
+	Model            string             `yaml:"model,omitempty"`             // Default metadata.model of the devices using the profile
+	Modbus           ModbusDeviceConfig `yaml:"modbus"`                      // Register groups, planned registers and read plan
+	CalculatedValues []CalculatedValue  `yaml:"calculated_values,omitempty"` // Calculated/derived values
+}
+
+// ProfileOverrides adapts a profile to one device
+// Group and register overrides only replace the fields they set; everything else comes from the profile.
+type ProfileOverrides struct {
+	DisableGroups    []string             `yaml:"disable_groups,omitempty"`    // Profile groups this device does not read
+	DisableRegisters []string             `yaml:"disable_registers,omitempty"` // Profile registers and calculated values this device does not publish
+	Groups           map[string]yaml.Node `yaml:"groups,omitempty"`            // Group key -> fields replaced for this device (e.g. poll_interval)
+	Registers        map[string]yaml.Node `yaml:"registers,omitempty"`         // Register or calculated value key -> fields replaced for this device
+}
+
+// profileFile is the layout of the files listed in profile_files
+type profileFile struct {
+	Profiles map[string]DeviceProfile `yaml:"profiles"`
+}
+
+// loadProfiles returns the inline profiles together with the profiles of profile_files
+// Relative file paths are resolved against the directory of the configuration file.
+func (c *Config) loadProfiles() (map[string]DeviceProfile, error) {
+	profiles := make(map[string]DeviceProfile, len(c.Profiles))
+	for name, profile := range c.Profiles {
+		profiles[name] = profile
+	}
+
+	for _, path := range c.ProfileFiles {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.configDir, path)
+		}
+		// #nosec G304 - Profile files are listed by the operator in the configuration
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("profile_files: %w", err)
+		}
+		var file profileFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("profile_files: error parsing %s: %w", path, err)
+		}
+		for name, profile := range file.Profiles {
+			if _, exists := profiles[name]; exists {
+				return nil, fmt.Errorf("profile_files: profile '%s' in %s is already defined", name, path)
+			}
+			profiles[name] = profile
+		}
+	}
+	return profiles, nil
+}
+
+// expandProfiles replaces the profile reference of each device with the profile registers and its overrides
+func (c *Config) expandProfiles() error {
+	var profiles map[string]DeviceProfile
+	for deviceKey, device := range c.Devices {
+		if device.profileApplied {
+			continue
+		}
+		if device.Profile == "" {
+			if device.Overrides != nil {
+				return fmt.Errorf("device '%s': overrides require a profile", deviceKey)
+			}
+			continue
+		}
+
+		if profiles == nil {
+			var err error
+			if profiles, err = c.loadProfiles(); err != nil {
+				return err
+			}
+		}
+		// Profiles of the configuration shadow the built-in catalog
+		profile, exists := profiles[device.Profile]
+		if !exists {
+			entry, found, err := FindCatalogProfile(device.Profile)
+			if err != nil {
+				return err
+			}
+			if !found {
+				return fmt.Errorf("device '%s': profile '%s' is not defined (not in profiles, profile_files or the built-in catalog)",
+					deviceKey, device.Profile)
+			}
+			profile = entry.Profile
+		}
+
+		expanded, err := profile.expand(device)
+		if err != nil {
+			return fmt.Errorf("device '%s': profile '%s': %w", deviceKey, device.Profile, err)
+		}
+		c.Devices[deviceKey] = expanded
+	}
+	return nil
+}
+
+// expand returns the device with the profile registers, its overrides applied and its own groups added
+func (p *DeviceProfile) expand(device Device) (Device, error) {
+	groups := make(map[string]RegisterGroup, len(p.Modbus.RegisterGroups))
+	for key, group := range p.Modbus.RegisterGroups {
+		group.Registers = cloneGroupRegisters(group.Registers)
+		groups[key] = group
+	}
+	planned := slices.Clone(p.Modbus.Registers)
+	for i := range planned {
+		planned[i].GroupRegister = cloneGroupRegister(planned[i].GroupRegister)
+	}
+	calculated := slices.Clone(p.CalculatedValues)
+
+	overrides := device.Overrides
+	if overrides == nil {
+		overrides = &ProfileOverrides{}
+	}
+
+	for _, key := range overrides.DisableGroups {
+		if _, exists := groups[key]; !exists {
+			return device, fmt.Errorf("overrides.disable_groups: no group '%s'", key)
+		}
+		delete(groups, key)
+	}
+	for key, node := range overrides.Groups {
+		group, exists := groups[key]
+		if !exists {
+			return device, fmt.Errorf("overrides.groups: no group '%s'", key)
+		}
+		if err := node.Decode(&group); err != nil {
+			return device, fmt.Errorf("overrides.groups.%s: %w", key, err)
+		}
+		groups[key] = group
+	}
+
+	for key, node := range overrides.Registers {
+		target := findProfileRegister(groups, planned, calculated, key)
+		if target == nil {
+			return device, fmt.Errorf("overrides.registers: no register '%s'", key)
+		}
+		if err := node.Decode(target); err != nil {
+			return device, fmt.Errorf("overrides.registers.%s: %w", key, err)
+		}
+	}
+
+	for _, key := range overrides.DisableRegisters {
+		if !disableProfileRegister(groups, &planned, &calculated, key) {
+			return device, fmt.Errorf("overrides.disable_registers: no register '%s'", key)
+		}
+	}
+
+	// Device groups are added to the profile groups
+	for key, group := range device.Modbus.RegisterGroups {
+		if _, exists := groups[key]; exists {
+			return device, fmt.Errorf("modbus.register_groups.%s is defined by the profile (use overrides.groups)", key)
+		}
+		groups[key] = group
+	}
+	device.Modbus.RegisterGroups = groups
+	device.Modbus.Registers = append(planned, device.Modbus.Registers...)
+	if device.Modbus.ReadPlan == (ReadPlanConfig{}) {
+		device.Modbus.ReadPlan = p.Modbus.ReadPlan
+	}
+	device.CalculatedValues = append(calculated, device.CalculatedValues...)
+
+	if device.Metadata.Manufacturer == "" {
+		device.Metadata.Manufacturer = p.Manufacturer
+	}
+	if device.Metadata.Model == "" {
+		device.Metadata.Model = p.Model
+	}
+	device.profileApplied = true
+	return device, nil
+}
+
+// findProfileRegister returns the register or calculated value with the given key, for decoding an override into it
+func findProfileRegister(groups map[string]RegisterGroup, planned []PlannedRegister, calculated []CalculatedValue, key string) any {
+	for _, group := range groups {
+		for i := range group.Registers {
+			if group.Registers[i].Key == key {
+				return &group.Registers[i]
+			}
+		}
+	}
+	for i := range planned {
+		if planned[i].Key == key {
+			return &planned[i]
+		}
+	}
+	for i := range calculated {
+		if calculated[i].Key == key {
+			return &calculated[i]
+		}
+	}
+	return nil
+}
+
+// disableProfileRegister removes the register or calculated value with the given key
+// Groups left without registers are removed as well. Returns false if the key does not exist.
+func disableProfileRegister(groups map[string]RegisterGroup, planned *[]PlannedRegister, calculated *[]CalculatedValue, key string) bool {
+	for groupKey, group := range groups {
+		index := slices.IndexFunc(group.Registers, func(reg GroupRegister) bool { return reg.Key == key })
+		if index < 0 {
+			continue
+		}
+		group.Registers = slices.Delete(group.Registers, index, index+1)
+		if len(group.Registers) == 0 {
+			delete(groups, groupKey)
+		} else {
+			groups[groupKey] = group
+		}
+		return true
+	}
+	if index := slices.IndexFunc(*planned, func(reg PlannedRegister) bool { return reg.Key == key }); index >= 0 {
+		*planned = slices.Delete(*planned, index, index+1)
+		return true
+	}
+	if index := slices.IndexFunc(*calculated, func(calc CalculatedValue) bool { return calc.Key == key }); index >= 0 {
+		*calculated = slices.Delete(*calculated, index, index+1)
+		return true
+	}
+	return false
+}
+
+// cloneGroupRegisters copies registers so overrides of one device do not leak into the profile
+func cloneGroupRegisters(registers []GroupRegister) []GroupRegister {
+	cloned := make([]GroupRegister, len(registers))
+	for i, reg := range registers {
+		cloned[i] = cloneGroupRegister(reg)
+	}
+	return cloned
+}
+
+// cloneGroupRegister copies the slices and maps of a register (decoding merges into existing maps)
+func cloneGroupRegister(reg GroupRegister) GroupRegister {
+	reg.DependsOn = slices.Clone(reg.DependsOn)
+	reg.Bits = slices.Clone(reg.Bits)
+	reg.Enum = maps.Clone(reg.Enum)
+	reg.Transforms = slices.Clone(reg.Transforms)
+	if reg.Filter != nil {
+		filter := *reg.Filter
+		reg.Filter = &filter
+	}
+	if reg.Min != nil {
+		minValue := *reg.Min
+		reg.Min = &minValue
+	}
+	if reg.Max != nil {
+		maxValue := *reg.Max
+		reg.Max = &maxValue
+	}
+	if reg.Counter != nil {
+		counter := *reg.Counter
+		reg.Counter = &counter
+	}
+	return reg
+}
@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+// PublishPolicy decides which new values of a register are published (report by exception)
+// Without a policy every read is published. Intervals are in seconds:
+//
+//	deadband: 0.5                # Publish when the value moves by 0.5 (register unit)
+//	deadband_percent: 2          # ... or by 2% of the last published value
+//	min_publish_interval: 5      # Never publish more often than every 5 s
+//	max_publish_interval: 300    # Always refresh at least every 5 minutes
+type PublishPolicy struct {
+	Deadband           float64 `yaml:"deadband,omitempty"`             // Minimum absolute change to publish
+	DeadbandPercent    float64 `yaml:"deadband_percent,omitempty"`     // Minimum change to publish, in percent of the last published value
+	MinPublishInterval int     `yaml:"min_publish_interval,omitempty"` // Minimum seconds between two publishes
+	MaxPublishInterval int     `yaml:"max_publish_interval,omitempty"` // Maximum seconds without a publish (0 = only on change)
+}
+
+// HasDeadband reports whether small changes are suppressed
+func (p PublishPolicy) HasDeadband() bool {
+	return p.Deadband > 0 || p.DeadbandPercent > 0
+}
+
+// Validate checks the deadband and intervals of the policy
+func (p PublishPolicy) Validate() error {
+	if p.Deadband < 0 {
+		return fmt.Errorf("deadband must not be negative (got %.3f)", p.Deadband)
+	}
+	if p.DeadbandPercent < 0 || p.DeadbandPercent > 100 {
+		return fmt.Errorf("deadband_percent must be between 0 and 100 (got %.3f)", p.DeadbandPercent)
+	}
+	if p.MinPublishInterval < 0 || p.MaxPublishInterval < 0 {
+		return fmt.Errorf("publish intervals must not be negative")
+	}
+	if p.MinPublishInterval > 0 && p.MaxPublishInterval > 0 && p.MinPublishInterval > p.MaxPublishInterval {
+		return fmt.Errorf("min_publish_interval %ds is greater than max_publish_interval %ds",
+			p.MinPublishInterval, p.MaxPublishInterval)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MaxReadRegisters is the Modbus limit on the registers returned by one 0x03/0x04 request
+const MaxReadRegisters = 125
+
+// plannedGroupPrefix names the register groups computed by the read planner (read_plan_1, read_plan_2, ...)
+const plannedGroupPrefix = "read_plan_"
+
+// PlannedRegister is a register listed by absolute address
+// The read planner merges planned registers into register groups, so no start_address,
+// register_count or offset has to be worked out by hand.
+type PlannedRegister struct {
+	Address       uint16 `yaml:"address"`                 // Absolute register address
+	FunctionCode  uint8  `yaml:"function_code,omitempty"` // 0x03 (default) or 0x04
+	PollInterval  int    `yaml:"poll_interval,omitempty"` // Polling interval in milliseconds (default: modbus.poll_interval)
+	GroupRegister `yaml:",inline"`
+}
+
+// ReadPlanConfig limits the block reads computed for the planned registers of a device
+type ReadPlanConfig struct {
+	MaxGap       int `yaml:"max_gap,omitempty"`        // Unused registers a block may read between two registers (default: 0, contiguous only)
+	MaxBlockSize int `yaml:"max_block_size,omitempty"` // Registers per read (default and maximum: 125)
+}
+
+// GetMaxBlockSize returns the register limit of one block read
+func (p ReadPlanConfig) GetMaxBlockSize() int {
+	if p.MaxBlockSize == 0 {
+		return MaxReadRegisters
+	}
+	return p.MaxBlockSize
+}
+
+// Validate checks the read plan limits
+func (p ReadPlanConfig) Validate() error {
+	if p.MaxGap < 0 {
+		return fmt.Errorf("read_plan.max_gap must be non-negative (got %d)", p.MaxGap)
+	}
+	if p.MaxBlockSize < 0 || p.MaxBlockSize > MaxReadRegisters {
+		return fmt.Errorf("read_plan.max_block_size must be between 1 and %d (got %d)", MaxReadRegisters, p.MaxBlockSize)
+	}
+	return nil
+}
+
+// plannedRead is a planned register with its defaults applied
+type plannedRead struct {
+	PlannedRegister
+	words int // Registers occupied by the value
+}
+
+// end returns the address after the last register of the value
+func (r plannedRead) end() int {
+	return int(r.Address) + r.words
+}
+
+// PlanReads merges planned registers into as few block reads as possible
+// Registers are split by function code and poll interval; within each split a block
+// grows while the gap to the next register is at most max_gap and the block stays
+// within max_block_size. Scanning registers by address this way yields the minimum
+// number of blocks. The groups are returned ordered by function code, poll interval
+// and start address, with register offsets relative to the block start.
+func PlanReads(registers []PlannedRegister, defaultPollInterval int, plan ReadPlanConfig) ([]RegisterGroup, error) {
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	maxBlock := plan.GetMaxBlockSize()
+
+	reads := make([]plannedRead, 0, len(registers))
+	for i, reg := range registers {
+		if reg.FunctionCode == 0 {
+			reg.FunctionCode = FunctionReadHoldingRegisters
+		}
+		if reg.FunctionCode != FunctionReadHoldingRegisters && reg.FunctionCode != FunctionReadInputRegisters {
+			return nil, fmt.Errorf("registers[%d] '%s': function_code 0x%02X cannot be planned (use 0x03 or 0x04)",
+				i, reg.Key, reg.FunctionCode)
+		}
+		if reg.PollInterval == 0 {
+			reg.PollInterval = defaultPollInterval
+		}
+		if err := reg.validateDataType(); err != nil {
+			return nil, fmt.Errorf("registers[%d] '%s': %w", i, reg.Key, err)
+		}
+
+		read := plannedRead{PlannedRegister: reg, words: (reg.ByteSize() + 1) / 2}
+		if read.words > maxBlock {
+			return nil, fmt.Errorf("registers[%d] '%s': %d registers do not fit read_plan.max_block_size %d",
+				i, reg.Key, read.words, maxBlock)
+		}
+		if read.end() > 0x10000 {
+			return nil, fmt.Errorf("registers[%d] '%s': address 0x%04X + %d registers exceeds the address space",
+				i, reg.Key, reg.Address, read.words)
+		}
+		reads = append(reads, read)
+	}
+
+	sort.SliceStable(reads, func(i, j int) bool {
+		a, b := reads[i], reads[j]
+		if a.FunctionCode != b.FunctionCode {
+			return a.FunctionCode < b.FunctionCode
+		}
+		if a.PollInterval != b.PollInterval {
+			return a.PollInterval < b.PollInterval
+		}
+		return a.Address < b.Address
+	})
+
+	var groups []RegisterGroup
+	var block []plannedRead
+	blockStart, blockEnd := 0, 0
+	flush := func() {
+		if len(block) > 0 {
+			groups = append(groups, buildPlannedGroup(block, blockStart, blockEnd))
+		}
+	}
+	for _, read := range reads {
+		if len(block) > 0 {
+			first := block[0]
+			sameSplit := read.FunctionCode == first.FunctionCode && read.PollInterval == first.PollInterval
+			end := max(blockEnd, read.end())
+			if sameSplit && int(read.Address)-blockEnd <= plan.MaxGap && end-blockStart <= maxBlock {
+				block = append(block, read)
+				blockEnd = end
+				continue
+			}
+			flush()
+		}
+		block = []plannedRead{read}
+		blockStart, blockEnd = int(read.Address), read.end()
+	}
+	flush()
+
+	return groups, nil
+}
+
+// buildPlannedGroup builds the register group reading one planned block
+func buildPlannedGroup(block []plannedRead, start int, end int) RegisterGroup {
+	first := block[0]
+	group := RegisterGroup{
+		Name:          fmt.Sprintf("Read plan 0x%02X 0x%04X-0x%04X", first.FunctionCode, start, end-1),
+		FunctionCode:  first.FunctionCode,
+		StartAddress:  uint16(start),       // #nosec G115 -- addresses are uint16
+		RegisterCount: uint16(end - start), // #nosec G115 -- bounded by MaxReadRegisters
+		Enabled:       true,
+		PollInterval:  first.PollInterval,
+		Registers:     make([]GroupRegister, 0, len(block)),
+	}
+	for _, read := range block {
+		reg := read.GroupRegister
+		reg.Offset = (int(read.Address) - start) * 2
+		group.Registers = append(group.Registers, reg)
+	}
+	return group
+}
+
+// expandReadPlans adds the register groups planned from the flat register lists of the devices
+// Planned groups are named read_plan_1, read_plan_2, ... and their keys recorded in Modbus.PlannedGroups.
+func (c *Config) expandReadPlans() error {
+	for deviceKey, device := range c.Devices {
+		if len(device.Modbus.Registers) == 0 {
+			continue
+		}
+
+		groups, err := PlanReads(device.Modbus.Registers, c.Modbus.PollInterval, device.Modbus.ReadPlan)
+		if err != nil {
+			return fmt.Errorf("device '%s': modbus.%w", deviceKey, err)
+		}
+
+		if device.Modbus.RegisterGroups == nil {
+			device.Modbus.RegisterGroups = make(map[string]RegisterGroup)
+		}
+		// Drop the groups of an earlier expansion so validation can run again
+		for _, key := range device.Modbus.PlannedGroups {
+			delete(device.Modbus.RegisterGroups, key)
+		}
+		device.Modbus.PlannedGroups = nil
+
+		for i, group := range groups {
+			key := fmt.Sprintf("%s%d", plannedGroupPrefix, i+1)
+			if _, exists := device.Modbus.RegisterGroups[key]; exists {
+				return fmt.Errorf("device '%s': register group name '%s' is reserved for planned reads", deviceKey, key)
+			}
+			device.Modbus.RegisterGroups[key] = group
+			device.Modbus.PlannedGroups = append(device.Modbus.PlannedGroups, key)
+		}
+		c.Devices[deviceKey] = device
+	}
+	return nil
+}
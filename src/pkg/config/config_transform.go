@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// maxRoundDecimals bounds the precision of a round step (float64 holds ~15 significant digits)
+const maxRoundDecimals = 10
+
+// Transform is one step of a register's value pipeline
+// Each step sets exactly one field; steps run in the order they are listed, after
+// scale_factor and apply_abs:
+//
+//	transforms:
+//	  - invert: true                  # CT installed backwards
+//	  - offset: -0.4                  # Calibration offset
+//	  - clamp: {min: 0}               # No negative readings
+//	  - convert: {from: W, to: kW}    # Publish in kW
+//	  - round: 2                      # Two decimals
+type Transform struct {
+	Offset  *float64        `yaml:"offset,omitempty"`  // Added to the value
+	Invert  bool            `yaml:"invert,omitempty"`  // Negates the value
+	Clamp   *ClampTransform `yaml:"clamp,omitempty"`   // Limits the value to a range
+	Round   *int            `yaml:"round,omitempty"`   // Rounds to this many decimals (0-10)
+	Convert *UnitConversion `yaml:"convert,omitempty"` // Converts between unit prefixes (W→kW, Wh→kWh)
+}
+
+// ClampTransform limits a value to [min, max]; either bound may be omitted
+type ClampTransform struct {
+	Min *float64 `yaml:"min,omitempty"`
+	Max *float64 `yaml:"max,omitempty"`
+}
+
+// UnitConversion converts a value between two prefixes of the same unit
+type UnitConversion struct {
+	From string `yaml:"from"` // Unit of the value before the step (e.g. "W")
+	To   string `yaml:"to"`   // Unit of the value after the step (e.g. "kW")
+}
+
+// Transforms is the ordered value pipeline of a register or calculated value
+type Transforms []Transform
+
+// unitPrefixes are the SI prefixes a unit conversion may add or remove
+var unitPrefixes = map[string]int{"m": -3, "k": 3, "M": 6, "G": 9}
+
+// convertibleUnits are the base units a unit conversion accepts
+var convertibleUnits = []string{"Wh", "varh", "VAh", "W", "var", "VA", "V", "A", "Hz"}
+
+// Apply runs every step of the pipeline on the value
+func (t Transforms) Apply(value float64) float64 {
+	for _, step := range t {
+		value = step.Apply(value)
+	}
+	return value
+}
+
+// Apply runs the step on the value
+func (t Transform) Apply(value float64) float64 {
+	switch {
+	case t.Offset != nil:
+		return value + *t.Offset
+	case t.Invert:
+		return -value
+	case t.Clamp != nil:
+		if t.Clamp.Min != nil && value < *t.Clamp.Min {
+			return *t.Clamp.Min
+		}
+		if t.Clamp.Max != nil && value > *t.Clamp.Max {
+			return *t.Clamp.Max
+		}
+		return value
+	case t.Round != nil:
+		factor := math.Pow(10, float64(*t.Round))
+		return math.Round(value*factor) / factor
+	case t.Convert != nil:
+		factor, _ := t.Convert.Factor()
+		return value * factor
+	}
+	return value
+}
+
+// Validate checks that every step sets exactly one valid operation
+func (t Transforms) Validate() error {
+	for i, step := range t {
+		if err := step.Validate(); err != nil {
+			return fmt.Errorf("transforms[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the step sets exactly one valid operation
+func (t Transform) Validate() error {
+	count := 0
+	for _, set := range []bool{t.Offset != nil, t.Invert, t.Clamp != nil, t.Round != nil, t.Convert != nil} {
+		if set {
+			count++
+		}
+	}
+	if count != 1 {
+		return fmt.Errorf("each step needs exactly one of offset, invert, clamp, round or convert (got %d)", count)
+	}
+
+	switch {
+	case t.Clamp != nil:
+		if t.Clamp.Min == nil && t.Clamp.Max == nil {
+			return fmt.Errorf("clamp needs min, max or both")
+		}
+		if t.Clamp.Min != nil && t.Clamp.Max != nil && *t.Clamp.Min > *t.Clamp.Max {
+			return fmt.Errorf("clamp min %.3f is greater than max %.3f", *t.Clamp.Min, *t.Clamp.Max)
+		}
+	case t.Round != nil:
+		if *t.Round < 0 || *t.Round > maxRoundDecimals {
+			return fmt.Errorf("round must be between 0 and %d decimals (got %d)", maxRoundDecimals, *t.Round)
+		}
+	case t.Convert != nil:
+		if _, err := t.Convert.Factor(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Factor returns the multiplier converting a value from one unit to the other
+// Example: W → kW is 0.001, kWh → Wh is 1000.
+func (c UnitConversion) Factor() (float64, error) {
+	fromBase, fromExp, err := splitUnitPrefix(c.From)
+	if err != nil {
+		return 0, err
+	}
+	toBase, toExp, err := splitUnitPrefix(c.To)
+	if err != nil {
+		return 0, err
+	}
+	if fromBase != toBase {
+		return 0, fmt.Errorf("cannot convert %s to %s", c.From, c.To)
+	}
+	return math.Pow(10, float64(fromExp-toExp)), nil
+}
+
+// splitUnitPrefix splits a unit such as "kWh" into its base unit and prefix exponent
+func splitUnitPrefix(unit string) (string, int, error) {
+	for _, base := range convertibleUnits {
+		if unit == base {
+			return base, 0, nil
+		}
+		if prefix, found := strings.CutSuffix(unit, base); found {
+			if exp, known := unitPrefixes[prefix]; known {
+				return base, exp, nil
+			}
+		}
+	}
+	return "", 0, fmt.Errorf("unsupported unit '%s' for convert (use W, var, VA, Wh, varh, VAh, V, A or Hz with m, k, M or G)", unit)
+}
+
+// validateTransforms checks the transforms of a group register
+// Text registers have no numeric value to transform.
+func (r *GroupRegister) validateTransforms() error {
+	if len(r.Transforms) == 0 {
+		return nil
+	}
+	if IsTextDataType(r.GetDataType()) {
+		return fmt.Errorf("transforms are not supported for %s registers", r.GetDataType())
+	}
+	return r.Transforms.validateFor(r.Unit)
+}
+
+// validateFor validates the steps and checks that the last unit conversion produces the published unit
+func (t Transforms) validateFor(unit string) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	for i := len(t) - 1; i >= 0; i-- {
+		if t[i].Convert != nil {
+			if t[i].Convert.To != unit {
+				return fmt.Errorf("transforms[%d]: convert produces %s but unit is '%s'", i, t[i].Convert.To, unit)
+			}
+			break
+		}
+	}
+	return nil
+}
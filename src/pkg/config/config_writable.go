@@ -0,0 +1,251 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"mqtt-modbus-bridge/pkg/topics"
+)
+
+// Register value types that can be written (a subset of the register data types)
+const (
+	ValueTypeUint16  = DataTypeUint16
+	ValueTypeInt16   = DataTypeInt16
+	ValueTypeUint32  = DataTypeUint32
+	ValueTypeInt32   = DataTypeInt32
+	ValueTypeFloat32 = DataTypeFloat32
+)
+
+// valueTypeRegisters maps each value type to its width in 16-bit registers
+var valueTypeRegisters = map[string]uint16{
+	ValueTypeUint16:  1,
+	ValueTypeInt16:   1,
+	ValueTypeUint32:  2,
+	ValueTypeInt32:   2,
+	ValueTypeFloat32: 2,
+}
+
+// Register types that can be written
+const (
+	RegisterTypeHolding = "holding" // Holding register: written with 0x06/0x10, read back with 0x03
+	RegisterTypeCoil    = "coil"    // Coil: written with 0x05, read back with 0x01
+)
+
+// Home Assistant components available for writable registers
+const (
+	WritableNumber = topics.ComponentNumber
+	WritableSelect = topics.ComponentSelect
+	WritableSwitch = topics.ComponentSwitch
+	WritableButton = topics.ComponentButton
+)
+
+// WritableRegister exposes a holding register or coil as a Home Assistant control
+// Values in HA units are converted to raw register values with scale_factor (raw = value / scale_factor).
+// Option values, on/off values and press values are raw register values.
+type WritableRegister struct {
+	Key            string         `yaml:"key"`                       // Unique identifier within the device (e.g., "baud_rate")
+	Name           string         `yaml:"name"`                      // Display name
+	Component      string         `yaml:"component"`                 // Home Assistant entity: number, select, switch or button
+	RegisterType   string         `yaml:"register_type,omitempty"`   // holding (default) or coil
+	Address        uint16         `yaml:"address"`                   // Register or coil address
+	ValueType      string         `yaml:"value_type,omitempty"`      // uint16 (default), int16, uint32, int32 or float32
+	ScaleFactor    float64        `yaml:"scale_factor,omitempty"`    // Multiplier from raw value to HA value (default: 1.0)
+	Unit           string         `yaml:"unit,omitempty"`            // Unit of measurement (number only)
+	DeviceClass    string         `yaml:"device_class,omitempty"`    // Home Assistant device class
+	EntityCategory string         `yaml:"entity_category,omitempty"` // Home Assistant entity category (config or diagnostic)
+	Min            *float64       `yaml:"min,omitempty"`             // number: minimum accepted value (required)
+	Max            *float64       `yaml:"max,omitempty"`             // number: maximum accepted value (required)
+	Step           float64        `yaml:"step,omitempty"`            // number: value increment (default: 1)
+	Options        []SelectOption `yaml:"options,omitempty"`         // select: labels shown in HA and the raw values written for them
+	OnValue        *float64       `yaml:"on_value,omitempty"`        // switch on a holding register: raw value for ON (default: 1)
+	OffValue       *float64       `yaml:"off_value,omitempty"`       // switch on a holding register: raw value for OFF (default: 0)
+	PressValue     *float64       `yaml:"press_value,omitempty"`     // button on a holding register: raw value written on press (default: 1)
+	Confirm        bool           `yaml:"confirm,omitempty"`         // Require the same command twice within write_policy.confirm_timeout
+}
+
+// WritePolicyConfig limits how often and how writable registers may be written
+type WritePolicyConfig struct {
+	MaxWritesPerMinute int `yaml:"max_writes_per_minute"` // Accepted writes per slave per minute (default: 10)
+	ConfirmTimeout     int `yaml:"confirm_timeout"`       // Seconds to repeat a command on a confirm register (default: 30)
+}
+
+// ApplyWritePolicyDefaults applies default values for the write policy
+func (c *Config) ApplyWritePolicyDefaults() {
+	policy := &c.WritePolicy
+	if policy.MaxWritesPerMinute == 0 {
+		policy.MaxWritesPerMinute = 10
+	}
+	if policy.ConfirmTimeout == 0 {
+		policy.ConfirmTimeout = 30
+	}
+}
+
+// validateWritePolicy validates the write policy configuration
+func (c *Config) validateWritePolicy() error {
+	if c.WritePolicy.MaxWritesPerMinute < 0 {
+		return fmt.Errorf("write_policy.max_writes_per_minute must be positive (got %d)", c.WritePolicy.MaxWritesPerMinute)
+	}
+	if c.WritePolicy.ConfirmTimeout < 0 {
+		return fmt.Errorf("write_policy.confirm_timeout must be positive (got %d)", c.WritePolicy.ConfirmTimeout)
+	}
+	return nil
+}
+
+// SelectOption maps a label shown in Home Assistant to a raw register value
+type SelectOption struct {
+	Label string  `yaml:"label"`
+	Value float64 `yaml:"value"`
+}
+
+// GetRegisterType returns the register type (default: holding)
+func (w *WritableRegister) GetRegisterType() string {
+	if w.RegisterType == "" {
+		return RegisterTypeHolding
+	}
+	return w.RegisterType
+}
+
+// IsCoil returns true when the control writes a coil
+func (w *WritableRegister) IsCoil() bool {
+	return w.GetRegisterType() == RegisterTypeCoil
+}
+
+// GetValueType returns the register value type (default: uint16)
+func (w *WritableRegister) GetValueType() string {
+	if w.ValueType == "" {
+		return ValueTypeUint16
+	}
+	return w.ValueType
+}
+
+// GetScaleFactor returns the scale factor (default: 1.0)
+func (w *WritableRegister) GetScaleFactor() float64 {
+	if w.ScaleFactor == 0 {
+		return 1.0
+	}
+	return w.ScaleFactor
+}
+
+// GetStep returns the number step (default: 1)
+func (w *WritableRegister) GetStep() float64 {
+	if w.Step == 0 {
+		return 1
+	}
+	return w.Step
+}
+
+// GetOnValue returns the raw value written for ON (default: 1)
+func (w *WritableRegister) GetOnValue() float64 {
+	if w.OnValue == nil {
+		return 1
+	}
+	return *w.OnValue
+}
+
+// GetOffValue returns the raw value written for OFF (default: 0)
+func (w *WritableRegister) GetOffValue() float64 {
+	if w.OffValue == nil {
+		return 0
+	}
+	return *w.OffValue
+}
+
+// GetPressValue returns the raw value written when the button is pressed (default: 1)
+func (w *WritableRegister) GetPressValue() float64 {
+	if w.PressValue == nil {
+		return 1
+	}
+	return *w.PressValue
+}
+
+// RegisterCount returns the number of registers (or coils) the control occupies
+func (w *WritableRegister) RegisterCount() uint16 {
+	if w.IsCoil() {
+		return 1
+	}
+	return valueTypeRegisters[w.GetValueType()]
+}
+
+// OptionValue returns the raw value of a select option label
+func (w *WritableRegister) OptionValue(label string) (float64, bool) {
+	for _, option := range w.Options {
+		if option.Label == label {
+			return option.Value, true
+		}
+	}
+	return 0, false
+}
+
+// OptionLabel returns the label of the select option with the given raw value
+func (w *WritableRegister) OptionLabel(value float64) (string, bool) {
+	for _, option := range w.Options {
+		if option.Value == value {
+			return option.Label, true
+		}
+	}
+	return "", false
+}
+
+// Validate validates the writable register configuration
+func (w *WritableRegister) Validate() error {
+	if w.Key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+	if w.Name == "" {
+		return fmt.Errorf("writable register '%s' has no name", w.Key)
+	}
+
+	switch w.GetRegisterType() {
+	case RegisterTypeHolding:
+		if _, exists := valueTypeRegisters[w.GetValueType()]; !exists {
+			return fmt.Errorf("writable register '%s' has unsupported value_type '%s' (use uint16, int16, uint32, int32 or float32)",
+				w.Key, w.ValueType)
+		}
+	case RegisterTypeCoil:
+		if w.Component != WritableSwitch && w.Component != WritableButton {
+			return fmt.Errorf("writable register '%s': register_type coil is only supported for switch and button", w.Key)
+		}
+	default:
+		return fmt.Errorf("writable register '%s' has unsupported register_type '%s' (use holding or coil)", w.Key, w.RegisterType)
+	}
+
+	if w.ScaleFactor < 0 || math.IsNaN(w.ScaleFactor) || math.IsInf(w.ScaleFactor, 0) {
+		return fmt.Errorf("writable register '%s' has invalid scale_factor %g", w.Key, w.ScaleFactor)
+	}
+
+	switch w.Component {
+	case WritableNumber:
+		if w.Min == nil || w.Max == nil {
+			return fmt.Errorf("writable register '%s': number requires min and max", w.Key)
+		}
+		if *w.Min >= *w.Max {
+			return fmt.Errorf("writable register '%s': min (%g) must be less than max (%g)", w.Key, *w.Min, *w.Max)
+		}
+		if w.Step < 0 {
+			return fmt.Errorf("writable register '%s': step must be positive (got %g)", w.Key, w.Step)
+		}
+	case WritableSelect:
+		if len(w.Options) == 0 {
+			return fmt.Errorf("writable register '%s': select requires at least one option", w.Key)
+		}
+		labels := make(map[string]bool)
+		for _, option := range w.Options {
+			if option.Label == "" {
+				return fmt.Errorf("writable register '%s': option label cannot be empty", w.Key)
+			}
+			if labels[option.Label] {
+				return fmt.Errorf("writable register '%s': duplicate option label '%s'", w.Key, option.Label)
+			}
+			labels[option.Label] = true
+		}
+	case WritableSwitch:
+		if !w.IsCoil() && w.GetOnValue() == w.GetOffValue() {
+			return fmt.Errorf("writable register '%s': on_value and off_value must differ", w.Key)
+		}
+	case WritableButton:
+	default:
+		return fmt.Errorf("writable register '%s' has unsupported component '%s' (use number, select, switch or button)",
+			w.Key, w.Component)
+	}
+
+	return nil
+}
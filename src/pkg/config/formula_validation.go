@@ -17,7 +17,7 @@ func ValidateFormula(formula string) ([]string, error) {
 	variablesMap := make(map[string]bool)
 
 	// Remove function calls to simplify variable extraction
-	// Functions: sqrt(...), abs(...)
+	// Functions: sqrt(...), abs(...), min(...), max(...)
 	cleanFormula := formula
 
 	// Extract content from functions and validate parentheses balance
@@ -37,7 +37,7 @@ func ValidateFormula(formula string) ([]string, error) {
 	}
 
 	// Validate function syntax
-	functionPattern := regexp.MustCompile(`(sqrt|abs)\s*\(`)
+	functionPattern := regexp.MustCompile(`\b(sqrt|abs|min|max)\s*\(`)
 	invalidFunctions := regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*\s*\(`)
 
 	// Find all function calls
@@ -46,18 +46,18 @@ func ValidateFormula(formula string) ([]string, error) {
 
 	// Check if there are any invalid function calls
 	if len(allFunctions) != len(validFunctions) {
-		// There's a function that's not sqrt or abs
+		// There's a function that's not sqrt, abs, min or max
 		for _, fn := range allFunctions {
 			fnName := strings.TrimSpace(strings.TrimSuffix(fn, "("))
-			if fnName != "sqrt" && fnName != "abs" {
-				return nil, fmt.Errorf("unsupported function '%s' (only sqrt and abs are supported)", fnName)
+			if !isOperatorOrKeyword(fnName) {
+				return nil, fmt.Errorf("unsupported function '%s' (only sqrt, abs, min and max are supported)", fnName)
 			}
 		}
 	}
 
 	// Remove function calls for variable extraction
-	// This is a simplified approach - we just remove "sqrt(" and "abs(" and their matching ")"
-	cleanFormula = regexp.MustCompile(`(sqrt|abs)\s*\(`).ReplaceAllString(cleanFormula, "")
+	// This is a simplified approach - we just remove "sqrt(", "abs(", "min(", "max(" and their matching ")"
+	cleanFormula = functionPattern.ReplaceAllString(cleanFormula, "")
 
 	// Extract variable names (alphanumeric + underscore, not starting with a digit)
 	// Variable pattern: starts with letter or underscore, followed by letters, digits, or underscores
@@ -73,7 +73,7 @@ func ValidateFormula(formula string) ([]string, error) {
 	}
 
 	// Validate operators
-	invalidOperators := regexp.MustCompile(`[^a-zA-Z0-9_\s\+\-\*/\^\(\)\.]`)
+	invalidOperators := regexp.MustCompile(`[^a-zA-Z0-9_\s\+\-\*/\^\(\)\.,]`)
 	if invalidOp := invalidOperators.FindString(formula); invalidOp != "" {
 		return nil, fmt.Errorf("invalid operator or character '%s'", invalidOp)
 	}
@@ -103,6 +103,8 @@ func isOperatorOrKeyword(s string) bool {
 	reserved := map[string]bool{
 		"sqrt": true,
 		"abs":  true,
+		"min":  true,
+		"max":  true,
 		// Future: "sin", "cos", "tan", "log", "exp", "if", "then", "else"
 	}
 	return reserved[s]
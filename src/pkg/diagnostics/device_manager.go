@@ -3,6 +3,7 @@ package diagnostics
 import (
 	"context"
 	"fmt"
+	"math"
 	"mqtt-modbus-bridge/pkg/config"
 	"mqtt-modbus-bridge/pkg/logger"
 	"mqtt-modbus-bridge/pkg/mqtt"
@@ -85,6 +86,63 @@ func (m *DeviceManager) RecordError(deviceID string, errorMsg string) {
 	metrics.LastErrorTime = now
 }
 
+// RecordException records a read rejected by the device with a Modbus exception
+// The device answered, so consecutive errors (used to detect unreachable devices) are not increased
+func (m *DeviceManager) RecordException(deviceID string, errorMsg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metrics, exists := m.metrics[deviceID]
+	if !exists {
+		metrics = &mqtt.DeviceMetrics{}
+		m.metrics[deviceID] = metrics
+	}
+
+	now := time.Now()
+	metrics.LastReadTime = now
+	metrics.TotalReads++
+	metrics.FailedReads++
+	metrics.ExceptionReads++
+	metrics.LastError = errorMsg
+	metrics.LastErrorTime = now
+}
+
+// RecordRejection records a value that failed the plausibility check of its register
+// The read itself succeeded, so read counters and the device state are not affected.
+func (m *DeviceManager) RecordRejection(deviceID string, registerKey string, rawValue float64, value float64, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metrics, exists := m.metrics[deviceID]
+	if !exists {
+		metrics = &mqtt.DeviceMetrics{}
+		m.metrics[deviceID] = metrics
+	}
+	if metrics.Rejections == nil {
+		metrics.Rejections = make(map[string]*mqtt.ValueRejection)
+	}
+	rejection, exists := metrics.Rejections[registerKey]
+	if !exists {
+		rejection = &mqtt.ValueRejection{}
+		metrics.Rejections[registerKey] = rejection
+	}
+
+	metrics.RejectedValues++
+	rejection.Count++
+	rejection.LastRawValue = finiteOrZero(rawValue)
+	rejection.LastValue = finiteOrZero(value)
+	rejection.LastReason = reason
+	rejection.LastTime = time.Now()
+}
+
+// finiteOrZero replaces NaN and ±Inf, which JSON cannot encode, with 0 (the reason names them)
+func finiteOrZero(value float64) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return value
+}
+
 // StartDiagnosticsLoop starts the periodic device diagnostics publishing loop
 func (m *DeviceManager) StartDiagnosticsLoop(ctx context.Context) {
 	// Start with a small delay to let devices initialize
@@ -227,6 +285,33 @@ func (m *DeviceManager) getDiagnosticIntervalForState(state string) time.Duratio
 	}
 }
 
+// Snapshot returns a copy of the metrics of every device, for the state store
+func (m *DeviceManager) Snapshot() map[string]mqtt.DeviceMetrics {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := make(map[string]mqtt.DeviceMetrics, len(m.metrics))
+	for deviceID, metrics := range m.metrics {
+		snapshot[deviceID] = copyMetrics(metrics)
+	}
+	return snapshot
+}
+
+// Restore continues the counters of devices that are still configured
+// The state is recalculated from the restored metrics at the next publish.
+func (m *DeviceManager) Restore(snapshot map[string]mqtt.DeviceMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for deviceID, metrics := range snapshot {
+		if _, exists := m.metrics[deviceID]; !exists {
+			continue // Device removed or disabled since the state was saved
+		}
+		restored := copyMetrics(&metrics)
+		m.metrics[deviceID] = &restored
+	}
+}
+
 // GetMetrics returns a copy of metrics for a specific device (for testing/debugging)
 func (m *DeviceManager) GetMetrics(deviceID string) (*mqtt.DeviceMetrics, error) {
 	m.mu.RLock()
@@ -238,6 +323,19 @@ func (m *DeviceManager) GetMetrics(deviceID string) (*mqtt.DeviceMetrics, error)
 	}
 
 	// Return a copy to prevent external modification
-	metricsCopy := *metrics
+	metricsCopy := copyMetrics(metrics)
 	return &metricsCopy, nil
 }
+
+// copyMetrics copies the metrics together with their rejections
+func copyMetrics(metrics *mqtt.DeviceMetrics) mqtt.DeviceMetrics {
+	metricsCopy := *metrics
+	if metrics.Rejections != nil {
+		metricsCopy.Rejections = make(map[string]*mqtt.ValueRejection, len(metrics.Rejections))
+		for key, rejection := range metrics.Rejections {
+			rejectionCopy := *rejection
+			metricsCopy.Rejections[key] = &rejectionCopy
+		}
+	}
+	return metricsCopy
+}
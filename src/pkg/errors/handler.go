@@ -35,6 +35,8 @@ func (h *ErrorHandler) Handle(ctx context.Context, err error) {
 		h.handleGatewayError(ctx, e)
 	case *ModbusError:
 		h.handleModbusError(ctx, e)
+	case *ModbusExceptionError:
+		h.handleModbusExceptionError(ctx, e)
 	case *MQTTError:
 		h.handleMQTTError(ctx, e)
 	case *ConfigError:
@@ -92,6 +94,20 @@ func (h *ErrorHandler) handleModbusError(ctx context.Context, err *ModbusError)
 	}
 }
 
+// handleModbusExceptionError handles exception responses from Modbus slaves
+func (h *ErrorHandler) handleModbusExceptionError(ctx context.Context, err *ModbusExceptionError) {
+	logger.LogWarn("⚠️ Modbus Exception: %s", err.Error())
+
+	// Publish diagnostic if publisher is available
+	if h.diagnosticPublisher != nil {
+		message := fmt.Sprintf("Slave %d rejected function 0x%02X at 0x%04X: %s",
+			err.SlaveID, err.FunctionCode, err.Address, err.Name())
+		if publishErr := h.diagnosticPublisher.PublishDiagnostic(ctx, err.Code, message); publishErr != nil {
+			logger.LogDebug("Failed to publish Modbus exception diagnostic: %v", publishErr)
+		}
+	}
+}
+
 // handleMQTTError handles MQTT-specific errors
 func (h *ErrorHandler) handleMQTTError(ctx context.Context, err *MQTTError) {
 	switch err.Severity {
@@ -207,6 +223,8 @@ func GetDiagnosticCode(err error) int {
 		return e.Code
 	case *ModbusError:
 		return e.Code
+	case *ModbusExceptionError:
+		return e.Code
 	case *MQTTError:
 		return e.Code
 	case *ConfigError:
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 )
 
@@ -203,3 +204,97 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("[%s] Field '%s': expected %v, got %v",
 		e.Severity, e.Field, e.Expected, e.Actual)
 }
+
+// Modbus exception codes (Modbus Application Protocol Specification V1.1b3, section 7)
+const (
+	ExceptionIllegalFunction                    uint8 = 0x01
+	ExceptionIllegalDataAddress                 uint8 = 0x02
+	ExceptionIllegalDataValue                   uint8 = 0x03
+	ExceptionServerDeviceFailure                uint8 = 0x04
+	ExceptionAcknowledge                        uint8 = 0x05
+	ExceptionServerDeviceBusy                   uint8 = 0x06
+	ExceptionMemoryParityError                  uint8 = 0x08
+	ExceptionGatewayPathUnavailable             uint8 = 0x0A
+	ExceptionGatewayTargetDeviceFailedToRespond uint8 = 0x0B
+)
+
+// ExceptionName returns the specification name of a Modbus exception code
+func ExceptionName(code uint8) string {
+	switch code {
+	case ExceptionIllegalFunction:
+		return "Illegal Function"
+	case ExceptionIllegalDataAddress:
+		return "Illegal Data Address"
+	case ExceptionIllegalDataValue:
+		return "Illegal Data Value"
+	case ExceptionServerDeviceFailure:
+		return "Server Device Failure"
+	case ExceptionAcknowledge:
+		return "Acknowledge"
+	case ExceptionServerDeviceBusy:
+		return "Server Device Busy"
+	case ExceptionMemoryParityError:
+		return "Memory Parity Error"
+	case ExceptionGatewayPathUnavailable:
+		return "Gateway Path Unavailable"
+	case ExceptionGatewayTargetDeviceFailedToRespond:
+		return "Gateway Target Device Failed To Respond"
+	default:
+		return "Unknown Exception"
+	}
+}
+
+// ModbusExceptionError represents an exception response sent by a Modbus slave
+// The slave received and rejected the request, so the bus and gateway are working
+type ModbusExceptionError struct {
+	BridgeError
+	SlaveID       uint8
+	FunctionCode  uint8 // Request function code (without the 0x80 exception flag)
+	Address       uint16
+	ExceptionCode uint8
+}
+
+// NewModbusExceptionError creates a new Modbus exception error
+func NewModbusExceptionError(slaveID uint8, functionCode uint8, address uint16, exceptionCode uint8) *ModbusExceptionError {
+	return &ModbusExceptionError{
+		BridgeError: BridgeError{
+			Op:       "modbus_exception",
+			Severity: SeverityWarning,
+			Code:     3, // Modbus error diagnostic code
+		},
+		SlaveID:       slaveID,
+		FunctionCode:  functionCode,
+		Address:       address,
+		ExceptionCode: exceptionCode,
+	}
+}
+
+// Name returns the specification name of the exception code
+func (e *ModbusExceptionError) Name() string {
+	return ExceptionName(e.ExceptionCode)
+}
+
+// Error implements the error interface
+func (e *ModbusExceptionError) Error() string {
+	return fmt.Sprintf("[%s] Modbus exception 0x%02X (%s) from slave %d: function 0x%02X, address 0x%04X",
+		e.Severity, e.ExceptionCode, e.Name(), e.SlaveID, e.FunctionCode, e.Address)
+}
+
+// AsModbusException returns the Modbus exception wrapped in err, if any
+func AsModbusException(err error) (*ModbusExceptionError, bool) {
+	var exception *ModbusExceptionError
+	if stderrors.As(err, &exception) {
+		return exception, true
+	}
+	return nil, false
+}
+
+// IsTransient reports whether an error may go away by itself (timeouts, lost connections)
+// Modbus exceptions are answers from a working slave: retrying the same request gives the same result
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, isException := AsModbusException(err)
+	return !isException
+}
@@ -131,3 +131,35 @@ func TestErrorCodes(t *testing.T) {
 		t.Errorf("Expected Code 4, got %d", mqttErr.Code)
 	}
 }
+
+// TestModbusExceptionError tests exception decoding and classification
+func TestModbusExceptionError(t *testing.T) {
+	exception := NewModbusExceptionError(11, 0x03, 0x2000, ExceptionIllegalDataAddress)
+
+	if exception.Name() != "Illegal Data Address" {
+		t.Errorf("Expected name 'Illegal Data Address', got '%s'", exception.Name())
+	}
+	if ExceptionName(0x7F) != "Unknown Exception" {
+		t.Errorf("Expected unknown exception name, got '%s'", ExceptionName(0x7F))
+	}
+
+	// Exceptions wrapped by strategy errors must still be recognized
+	wrapped := fmt.Errorf("failed to execute group strategy: %w",
+		NewModbusError("read_register_group", exception, 11, "meter_instant"))
+
+	found, ok := AsModbusException(wrapped)
+	if !ok || found.ExceptionCode != ExceptionIllegalDataAddress || found.Address != 0x2000 {
+		t.Errorf("Expected wrapped exception to be found, got %v", found)
+	}
+
+	if IsTransient(wrapped) {
+		t.Error("Expected Modbus exception to be non-transient")
+	}
+	if !IsTransient(fmt.Errorf("timeout waiting for response")) {
+		t.Error("Expected timeout to be transient")
+	}
+	if GetDiagnosticCode(exception) != 3 {
+		t.Errorf("Expected Code 3, got %d", GetDiagnosticCode(exception))
+	}
+	t.Logf("ModbusExceptionError message: %s", exception.Error())
+}
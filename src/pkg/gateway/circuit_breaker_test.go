@@ -3,6 +3,7 @@ package gateway
 import (
 	"context"
 	"errors"
+	bridgeErrors "mqtt-modbus-bridge/pkg/errors"
 	"mqtt-modbus-bridge/pkg/recovery"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 type MockGateway struct {
 	failCount    int
 	shouldFail   bool
+	exception    uint8 // Answer with this Modbus exception code (0 = no exception)
 	callCount    int
 	connected    bool
 	lastSlaveID  uint8
@@ -57,6 +59,10 @@ func (m *MockGateway) SendCommandAndWaitForResponse(
 	m.lastAddress = address
 	m.lastCount = count
 
+	if m.exception != 0 {
+		return nil, bridgeErrors.NewModbusExceptionError(slaveID, functionCode, address, m.exception)
+	}
+
 	if m.shouldFail {
 		m.failCount++
 		return nil, errors.New("mock gateway error")
@@ -65,6 +71,37 @@ func (m *MockGateway) SendCommandAndWaitForResponse(
 	return []byte{0x01, 0x02, 0x03, 0x04}, nil
 }
 
+func (m *MockGateway) Write(ctx context.Context, slaveID uint8, req *WriteRequest, timeoutSeconds int) error {
+	m.callCount++
+	m.lastSlaveID = slaveID
+	m.lastFuncCode = req.FunctionCode
+	m.lastAddress = req.Address
+	m.lastCount = req.Quantity
+
+	if m.exception != 0 {
+		return bridgeErrors.NewModbusExceptionError(slaveID, req.FunctionCode, req.Address, m.exception)
+	}
+
+	if m.shouldFail {
+		m.failCount++
+		return errors.New("mock gateway error")
+	}
+
+	return nil
+}
+
+func (m *MockGateway) ReadDeviceIdentification(ctx context.Context, slaveID uint8, timeoutSeconds int) (*DeviceIdentification, error) {
+	m.callCount++
+	m.lastSlaveID = slaveID
+	m.lastFuncCode = FunctionEncapsulatedInterface
+
+	if m.exception != 0 {
+		return nil, bridgeErrors.NewModbusExceptionError(slaveID, FunctionEncapsulatedInterface, 0, m.exception)
+	}
+
+	return &DeviceIdentification{VendorName: "Mock", ProductCode: "MOCK-1", Revision: "1.0"}, nil
+}
+
 func (m *MockGateway) SendDiagnosticCommand(ctx context.Context) error {
 	return nil
 }
@@ -236,3 +273,76 @@ func TestCircuitBreakerGetState(t *testing.T) {
 		t.Error("Expected state to be OPEN after failures")
 	}
 }
+
+// TestCircuitBreakerIgnoresModbusExceptions tests that exception responses do not open the circuit
+func TestCircuitBreakerIgnoresModbusExceptions(t *testing.T) {
+	mock := NewMockGateway()
+	mock.exception = bridgeErrors.ExceptionIllegalDataAddress
+	config := recovery.CircuitBreakerConfig{
+		MaxFailures:      2,
+		Timeout:          1 * time.Second,
+		HalfOpenMaxTries: 2,
+	}
+	cbGateway := NewCircuitBreakerGateway(mock, config)
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_, err := cbGateway.SendCommandAndWaitForResponse(ctx, 1, 0x03, 0x9999, 2, 5)
+		if _, ok := bridgeErrors.AsModbusException(err); !ok {
+			t.Fatalf("Expected Modbus exception to be passed through, got %v", err)
+		}
+	}
+
+	if !cbGateway.circuitBreaker.IsClosed() {
+		t.Errorf("Expected circuit to stay closed on Modbus exceptions, got %s", cbGateway.GetState())
+	}
+	if mock.callCount != 5 {
+		t.Errorf("Expected 5 calls, got %d", mock.callCount)
+	}
+
+	// Transient failures still open the circuit
+	mock.exception = 0
+	mock.shouldFail = true
+	for i := 0; i < 2; i++ {
+		_, _ = cbGateway.SendCommandAndWaitForResponse(ctx, 1, 0x03, 0x2000, 2, 5)
+	}
+	if !cbGateway.circuitBreaker.IsOpen() {
+		t.Error("Expected circuit to open after transient failures")
+	}
+}
+
+// TestCircuitBreakerWrites tests that writes go through the circuit breaker
+func TestCircuitBreakerWrites(t *testing.T) {
+	mock := NewMockGateway()
+	config := recovery.CircuitBreakerConfig{
+		MaxFailures:      2,
+		Timeout:          1 * time.Second,
+		HalfOpenMaxTries: 2,
+	}
+	cbGateway := NewCircuitBreakerGateway(mock, config)
+
+	ctx := context.Background()
+	if err := cbGateway.Write(ctx, 1, NewWriteRegister(0x0006, 3), 5); err != nil {
+		t.Fatalf("Expected write to succeed, got %v", err)
+	}
+	if mock.lastFuncCode != FunctionWriteSingleRegister || mock.lastAddress != 0x0006 {
+		t.Errorf("Expected FC 0x06 at 0x0006, got FC 0x%02X at 0x%04X", mock.lastFuncCode, mock.lastAddress)
+	}
+
+	// Failed writes open the circuit, after which reads and writes fail fast
+	mock.shouldFail = true
+	for i := 0; i < 2; i++ {
+		_ = cbGateway.Write(ctx, 1, NewWriteRegister(0x0006, 3), 5)
+	}
+	if !cbGateway.circuitBreaker.IsOpen() {
+		t.Fatal("Expected circuit to open after failed writes")
+	}
+
+	beforeCount := mock.callCount
+	if err := cbGateway.Write(ctx, 1, NewWriteCoil(0x0001, true), 5); err == nil {
+		t.Error("Expected write to be rejected when circuit is open")
+	}
+	if mock.callCount != beforeCount {
+		t.Error("Expected no calls to mock when circuit is open")
+	}
+}
@@ -79,6 +79,30 @@ func (cbg *CircuitBreakerGateway) SendCommandAndWaitForResponse(
 	return result, nil
 }
 
+// Write wraps the gateway write with circuit breaker
+// Writes count towards the same failure threshold as reads: both use the same bus.
+func (cbg *CircuitBreakerGateway) Write(ctx context.Context, slaveID uint8, req *WriteRequest, timeoutSeconds int) error {
+	err := cbg.circuitBreaker.Call(func() error {
+		return cbg.gateway.Write(ctx, slaveID, req, timeoutSeconds)
+	})
+
+	cbg.logStateIfChanged()
+	return err
+}
+
+// ReadDeviceIdentification wraps the device identification read with circuit breaker
+func (cbg *CircuitBreakerGateway) ReadDeviceIdentification(ctx context.Context, slaveID uint8, timeoutSeconds int) (*DeviceIdentification, error) {
+	var id *DeviceIdentification
+	err := cbg.circuitBreaker.Call(func() error {
+		var err error
+		id, err = cbg.gateway.ReadDeviceIdentification(ctx, slaveID, timeoutSeconds)
+		return err
+	})
+
+	cbg.logStateIfChanged()
+	return id, err
+}
+
 // SendDiagnosticCommand delegates to the underlying gateway
 func (cbg *CircuitBreakerGateway) SendDiagnosticCommand(ctx context.Context) error {
 	return cbg.gateway.SendDiagnosticCommand(ctx)
@@ -0,0 +1,38 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/logger"
+	"time"
+)
+
+// connectWithRetry calls dial until it succeeds or the context is cancelled
+// Shared by every transport that opens its own link (Modbus TCP, RTU socket and serial)
+func connectWithRetry(ctx context.Context, name string, retryDelayMs int, dial func(ctx context.Context) error) error {
+	retryDelay := time.Duration(retryDelayMs) * time.Millisecond
+	if retryDelay == 0 {
+		retryDelay = 5000 * time.Millisecond // Default 5 seconds
+	}
+
+	attempt := 1
+	for {
+		logger.LogDebug("Attempting to connect to %s (attempt %d)...", name, attempt)
+
+		err := dial(ctx)
+		if err == nil {
+			logger.LogInfo("Gateway successfully connected to %s after %d attempts", name, attempt)
+			return nil
+		}
+
+		logger.LogError("Connection to %s failed (attempt %d): %v", name, attempt, err)
+		logger.LogInfo("Retrying in %.0f seconds...", retryDelay.Seconds())
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("connection cancelled: %w", ctx.Err())
+		case <-time.After(retryDelay):
+			attempt++
+		}
+	}
+}
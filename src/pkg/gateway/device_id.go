@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Read Device Identification (Modbus Encapsulated Interface, FC 0x2B / MEI 0x0E)
+const (
+	FunctionEncapsulatedInterface uint8 = 0x2B
+	meiReadDeviceIdentification   uint8 = 0x0E
+	readDeviceIDBasic             uint8 = 0x01 // Basic category: vendor name, product code, revision
+	maxDeviceIDRequests                 = 3    // Follow-up requests allowed when objects do not fit one response
+)
+
+// Basic device identification object IDs
+const (
+	objectVendorName    uint8 = 0x00
+	objectProductCode   uint8 = 0x01
+	objectMajorMinorRev uint8 = 0x02
+)
+
+// DeviceIdentification holds the basic identification objects reported by a slave
+type DeviceIdentification struct {
+	VendorName  string
+	ProductCode string
+	Revision    string
+}
+
+// buildDeviceIDPDU builds a Read Device Identification request starting at objectID
+func buildDeviceIDPDU(objectID uint8) []byte {
+	return []byte{FunctionEncapsulatedInterface, meiReadDeviceIdentification, readDeviceIDBasic, objectID}
+}
+
+// readDeviceIdentification reads the basic objects with as many requests as the slave needs
+// transact sends one request PDU and returns the response payload (after the function code).
+func readDeviceIdentification(transact func(pdu []byte) ([]byte, error)) (*DeviceIdentification, error) {
+	id := &DeviceIdentification{}
+	objectID := objectVendorName
+	for i := 0; i < maxDeviceIDRequests; i++ {
+		data, err := transact(buildDeviceIDPDU(objectID))
+		if err != nil {
+			return nil, err
+		}
+		more, next, err := parseDeviceIDResponse(data, id)
+		if err != nil {
+			return nil, err
+		}
+		if !more || next <= objectID {
+			return id, nil
+		}
+		objectID = next
+	}
+	return id, nil
+}
+
+// parseDeviceIDResponse stores the objects of one response payload in id
+// Payload layout: MEI type, read code, conformity level, more follows, next object ID,
+// number of objects, then (object ID, length, value) for each object.
+func parseDeviceIDResponse(data []byte, id *DeviceIdentification) (more bool, next uint8, err error) {
+	if len(data) < 6 {
+		return false, 0, fmt.Errorf("device identification response too short (len=%d)", len(data))
+	}
+	if data[0] != meiReadDeviceIdentification {
+		return false, 0, fmt.Errorf("unexpected MEI type 0x%02X in device identification response", data[0])
+	}
+
+	count := int(data[5])
+	pos := 6
+	for i := 0; i < count; i++ {
+		if pos+2 > len(data) || pos+2+int(data[pos+1]) > len(data) {
+			return false, 0, fmt.Errorf("device identification object %d truncated", i)
+		}
+		objectID, value := data[pos], strings.TrimRight(string(data[pos+2:pos+2+int(data[pos+1])]), "\x00 ")
+		switch objectID {
+		case objectVendorName:
+			id.VendorName = value
+		case objectProductCode:
+			id.ProductCode = value
+		case objectMajorMinorRev:
+			id.Revision = value
+		}
+		pos += 2 + int(data[pos+1])
+	}
+
+	return data[3] == 0xFF, data[4], nil
+}
+
+// deviceIDResponseLength returns the PDU length of a device identification response at the start of pdu
+// Returns 0 when more bytes are needed to know it.
+func deviceIDResponseLength(pdu []byte) int {
+	if len(pdu) < 7 {
+		return 0
+	}
+	pos := 7 // FC + MEI type + read code + conformity + more follows + next object + object count
+	for i := 0; i < int(pdu[6]); i++ {
+		if len(pdu) < pos+2 {
+			return 0
+		}
+		pos += 2 + int(pdu[pos+1])
+	}
+	return pos
+}
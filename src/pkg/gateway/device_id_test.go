@@ -0,0 +1,94 @@
+package gateway
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/crc"
+	"mqtt-modbus-bridge/pkg/errors"
+	"testing"
+)
+
+// deviceIDResponsePDU builds a Read Device Identification response PDU
+// objects holds (object ID, value) pairs; next > 0 sets the "more follows" flag.
+func deviceIDResponsePDU(next uint8, objects ...any) []byte {
+	more := uint8(0x00)
+	if next > 0 {
+		more = 0xFF
+	}
+	pdu := []byte{FunctionEncapsulatedInterface, meiReadDeviceIdentification, readDeviceIDBasic, 0x01, more, next, byte(len(objects) / 2)}
+	for i := 0; i < len(objects); i += 2 {
+		value := objects[i+1].(string)
+		pdu = append(pdu, objects[i].(uint8), byte(len(value)))
+		pdu = append(pdu, value...)
+	}
+	return pdu
+}
+
+// TestReadDeviceIdentification verifies parsing across "more follows" responses
+func TestReadDeviceIdentification(t *testing.T) {
+	responses := map[uint8][]byte{
+		objectVendorName:    deviceIDResponsePDU(objectMajorMinorRev, objectVendorName, "CHINT", objectProductCode, "DDSU666"),
+		objectMajorMinorRev: deviceIDResponsePDU(0, objectMajorMinorRev, "V1.02"),
+	}
+
+	var requested []uint8
+	id, err := readDeviceIdentification(func(pdu []byte) ([]byte, error) {
+		if fmt.Sprintf("%02X", pdu[:3]) != "2B0E01" {
+			t.Fatalf("❌ Unexpected request PDU %02X", pdu)
+		}
+		requested = append(requested, pdu[3])
+		return responses[pdu[3]][1:], nil
+	})
+	if err != nil {
+		t.Fatalf("❌ Unexpected error: %v", err)
+	}
+	if id.VendorName != "CHINT" || id.ProductCode != "DDSU666" || id.Revision != "V1.02" {
+		t.Errorf("❌ Unexpected identification: %+v", id)
+	}
+	if len(requested) != 2 || requested[1] != objectMajorMinorRev {
+		t.Errorf("❌ Expected follow-up request from object 0x02, got %v", requested)
+	}
+
+	// Truncated objects are rejected
+	truncated := deviceIDResponsePDU(0, objectVendorName, "CHINT")
+	if _, err := readDeviceIdentification(func(pdu []byte) ([]byte, error) {
+		return truncated[1 : len(truncated)-1], nil
+	}); err == nil {
+		t.Error("❌ Expected error for truncated object")
+	}
+
+	// Exception responses are passed through for the caller's fallback
+	exception := errors.NewModbusExceptionError(1, FunctionEncapsulatedInterface, 0, errors.ExceptionIllegalFunction)
+	if _, err := readDeviceIdentification(func(pdu []byte) ([]byte, error) {
+		return nil, exception
+	}); err == nil {
+		t.Error("❌ Expected exception error")
+	} else if _, ok := errors.AsModbusException(err); !ok {
+		t.Errorf("❌ Expected Modbus exception, got %v", err)
+	}
+	t.Logf("✅ Device identification: %+v", id)
+}
+
+// TestExtractRTUDeviceIDFrame verifies RTU reassembly of variable-length identification responses
+func TestExtractRTUDeviceIDFrame(t *testing.T) {
+	frame := crc.AppendCRC(append([]byte{0x01}, deviceIDResponsePDU(0, objectVendorName, "CHINT", objectProductCode, "DDSU666")...))
+
+	for split := 1; split < len(frame); split++ {
+		if _, _, ok := extractRTUFrame(frame[:split]); ok {
+			t.Fatalf("❌ Frame extracted from %d of %d bytes", split, len(frame))
+		}
+	}
+	got, rest, ok := extractRTUFrame(frame)
+	if !ok || len(rest) != 0 || len(got) != len(frame) {
+		t.Fatalf("❌ Expected complete frame, got ok=%v frame=%02X rest=%02X", ok, got, rest)
+	}
+
+	data, err := parseResponsePDU(1, FunctionEncapsulatedInterface, 0, got[1:len(got)-2])
+	if err != nil {
+		t.Fatalf("❌ Unexpected error: %v", err)
+	}
+	id := &DeviceIdentification{}
+	if _, _, err := parseDeviceIDResponse(data, id); err != nil || id.ProductCode != "DDSU666" {
+		t.Errorf("❌ Unexpected identification %+v (err=%v)", id, err)
+	}
+	t.Logf("✅ Device identification frame: %02X", frame)
+}
@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+)
+
+// NewGateway creates the gateway transport selected by mqtt.gateway.type
+// Factory Pattern - callers depend only on the Gateway interface
+func NewGateway(cfg *config.MQTTConfig) (Gateway, error) {
+	return NewNamedGateway(config.DefaultGatewayName, &cfg.Gateway, cfg)
+}
+
+// NewNamedGateway creates the transport for one named gateway (bus)
+// MQTT broker settings are shared; non-default USR gateways get their own MQTT client ID
+// so several DR164s can be connected to the same broker at once
+func NewNamedGateway(name string, gwCfg *config.GatewayConfig, mqttCfg *config.MQTTConfig) (Gateway, error) {
+	switch gwCfg.GetType() {
+	case config.GatewayTypeUSRMQTT:
+		usrCfg := *mqttCfg
+		usrCfg.Gateway = *gwCfg
+		if name != config.DefaultGatewayName {
+			usrCfg.ClientID = mqttCfg.ClientID + "_" + name
+		}
+		return NewUSRGateway(&usrCfg), nil
+	case config.GatewayTypeModbusTCP:
+		return NewModbusTCPGateway(&gwCfg.ModbusTCP), nil
+	case config.GatewayTypeSerialRTU:
+		return NewSerialRTUGateway(&gwCfg.Serial), nil
+	case config.GatewayTypeRTUTCP:
+		return NewRTUSocketGateway("tcp", &gwCfg.Socket), nil
+	case config.GatewayTypeRTUUDP:
+		return NewRTUSocketGateway("udp", &gwCfg.Socket), nil
+	default:
+		return nil, fmt.Errorf("gateway '%s': unsupported gateway type: %s", name, gwCfg.Type)
+	}
+}
@@ -1,8 +1,15 @@
 package gateway
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
-// Gateway interface for communication with the USR-DR164 gateway
+// errConnectionLost marks transport errors after which the connection was dropped
+// Stream transports retry such transactions once on a fresh connection
+var errConnectionLost = errors.New("gateway connection lost")
+
+// Gateway interface for communication with the Modbus gateway (USR-DR164, Modbus TCP, serial RTU)
 // Interface Segregation Principle - specific interface for gateway operations
 type Gateway interface {
 	// Connect establishes connection to the gateway
@@ -11,7 +18,7 @@ type Gateway interface {
 	// Disconnect closes connection to the gateway
 	Disconnect()
 
-	// SendCommand sends a Modbus command through MQTT
+	// SendCommand sends a Modbus command through the gateway transport
 	SendCommand(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16) error
 
 	// WaitForResponse waits for response from gateway
@@ -20,6 +27,12 @@ type Gateway interface {
 	// SendCommandAndWaitForResponse sends a command and waits for response atomically
 	SendCommandAndWaitForResponse(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16, timeoutSeconds int) ([]byte, error)
 
+	// Write sends a write request (0x05, 0x06, 0x0F, 0x10) and verifies the echoed response
+	Write(ctx context.Context, slaveID uint8, req *WriteRequest, timeoutSeconds int) error
+
+	// ReadDeviceIdentification reads the vendor name, product code and revision of a slave (FC 0x2B/0x0E)
+	ReadDeviceIdentification(ctx context.Context, slaveID uint8, timeoutSeconds int) (*DeviceIdentification, error)
+
 	// SendDiagnosticCommand sends a diagnostic command to test gateway connectivity
 	SendDiagnosticCommand(ctx context.Context) error
 
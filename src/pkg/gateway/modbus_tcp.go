@@ -0,0 +1,335 @@
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/logger"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	mbapHeaderLength = 7   // Transaction ID (2) + Protocol ID (2) + Length (2) + Unit ID (1)
+	mbapMaxLength    = 254 // Maximum value of the MBAP length field (unit ID + 253 byte PDU)
+	tcpWriteTimeout  = 5 * time.Second
+)
+
+// pendingTransaction tracks the request currently waiting for a response
+type pendingTransaction struct {
+	transactionID uint16
+	unitID        uint8
+	functionCode  uint8
+	address       uint16
+	active        bool
+}
+
+// ModbusTCPGateway implementation of a native Modbus TCP gateway
+// Single Responsibility Principle - only handles MBAP framing over a TCP connection
+//
+// One TCP connection is shared by all requests and reused between polls.
+// The slave ID is sent as the MBAP unit identifier, so serial gateways
+// (Modbus TCP to RTU converters) route requests to the right device.
+type ModbusTCPGateway struct {
+	config  *config.ModbusTCPConfig
+	address string
+
+	conn      net.Conn
+	mu        sync.RWMutex // Protect conn and connected
+	connected bool
+
+	txMutex       sync.Mutex // One transaction at a time on the shared connection
+	transactionID uint16     // Last issued MBAP transaction identifier
+	pending       pendingTransaction
+}
+
+// NewModbusTCPGateway creates a new Modbus TCP gateway
+func NewModbusTCPGateway(cfg *config.ModbusTCPConfig) *ModbusTCPGateway {
+	return &ModbusTCPGateway{
+		config:  cfg,
+		address: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+	}
+}
+
+// Connect opens the TCP connection with infinite retry
+func (g *ModbusTCPGateway) Connect(ctx context.Context) error {
+	return connectWithRetry(ctx, "Modbus TCP "+g.address, g.config.RetryDelay, g.dial)
+}
+
+// dial establishes a new TCP connection, replacing any existing one
+func (g *ModbusTCPGateway) dial(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: time.Duration(g.config.ConnectTimeout) * time.Millisecond}
+	conn, err := dialer.DialContext(ctx, "tcp", g.address)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	if g.conn != nil {
+		_ = g.conn.Close()
+	}
+	g.conn = conn
+	g.connected = true
+	g.mu.Unlock()
+	return nil
+}
+
+// getConn returns the current connection, reconnecting once if it was dropped
+func (g *ModbusTCPGateway) getConn(ctx context.Context) (net.Conn, error) {
+	g.mu.RLock()
+	conn := g.conn
+	g.mu.RUnlock()
+	if conn != nil {
+		return conn, nil
+	}
+
+	logger.LogInfo("🔄 Reconnecting to Modbus TCP gateway %s...", g.address)
+	if err := g.dial(ctx); err != nil {
+		return nil, fmt.Errorf("gateway is not connected: %w", err)
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.conn, nil
+}
+
+// closeConn drops the current connection so the next request reconnects
+func (g *ModbusTCPGateway) closeConn() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn != nil {
+		_ = g.conn.Close()
+		g.conn = nil
+	}
+	g.connected = false
+}
+
+// IsConnected checks if the gateway is connected
+func (g *ModbusTCPGateway) IsConnected() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.connected
+}
+
+// Disconnect closes the gateway connection
+func (g *ModbusTCPGateway) Disconnect() {
+	g.closeConn()
+}
+
+// SendCommand sends a Modbus request over TCP - implements Gateway interface
+func (g *ModbusTCPGateway) SendCommand(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16) error {
+	g.txMutex.Lock()
+	defer g.txMutex.Unlock()
+	return g.send(ctx, slaveID, buildReadPDU(functionCode, address, count))
+}
+
+// WaitForResponse waits for the response to the last request - implements Gateway interface
+func (g *ModbusTCPGateway) WaitForResponse(ctx context.Context, timeoutSeconds int) ([]byte, error) {
+	g.txMutex.Lock()
+	defer g.txMutex.Unlock()
+	return g.receive(ctx, timeoutSeconds)
+}
+
+// SendCommandAndWaitForResponse sends a request and waits for the matching response atomically
+// Responses are matched by MBAP transaction ID; late responses to earlier timed-out
+// requests are discarded. If the connection was dropped by the peer, the transaction
+// is retried once on a fresh connection.
+func (g *ModbusTCPGateway) SendCommandAndWaitForResponse(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16, timeoutSeconds int) ([]byte, error) {
+	g.txMutex.Lock()
+	defer g.txMutex.Unlock()
+	return g.transact(ctx, slaveID, buildReadPDU(functionCode, address, count), timeoutSeconds)
+}
+
+// Write sends a write request and verifies the echoed response - implements Gateway interface
+func (g *ModbusTCPGateway) Write(ctx context.Context, slaveID uint8, req *WriteRequest, timeoutSeconds int) error {
+	g.txMutex.Lock()
+	defer g.txMutex.Unlock()
+
+	data, err := g.transact(ctx, slaveID, req.PDU(), timeoutSeconds)
+	if err != nil {
+		return err
+	}
+	return req.verifyEcho(data)
+}
+
+// ReadDeviceIdentification reads the basic device identification objects (FC 0x2B/0x0E) - implements Gateway interface
+func (g *ModbusTCPGateway) ReadDeviceIdentification(ctx context.Context, slaveID uint8, timeoutSeconds int) (*DeviceIdentification, error) {
+	g.txMutex.Lock()
+	defer g.txMutex.Unlock()
+
+	return readDeviceIdentification(func(pdu []byte) ([]byte, error) {
+		return g.transact(ctx, slaveID, pdu, timeoutSeconds)
+	})
+}
+
+// transact sends one request PDU and waits for its response (caller holds txMutex)
+func (g *ModbusTCPGateway) transact(ctx context.Context, slaveID uint8, pdu []byte, timeoutSeconds int) ([]byte, error) {
+	var lastErr error
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := g.send(ctx, slaveID, pdu); err != nil {
+			lastErr = err
+		} else {
+			data, err := g.receive(ctx, timeoutSeconds)
+			if err == nil {
+				return data, nil
+			}
+			lastErr = err
+		}
+
+		if !errors.Is(lastErr, errConnectionLost) || ctx.Err() != nil {
+			break
+		}
+		logger.LogWarn("⚠️ Modbus TCP connection lost (Slave %d, FC 0x%02X), retrying on new connection", slaveID, pdu[0])
+	}
+
+	return nil, lastErr
+}
+
+// send frames the PDU with an MBAP header and writes it to the connection
+func (g *ModbusTCPGateway) send(ctx context.Context, slaveID uint8, pdu []byte) error {
+	conn, err := g.getConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.transactionID++
+	frame := buildMBAPFrame(g.transactionID, slaveID, pdu)
+
+	logger.LogDebug("Gateway sending modbus TCP request #%d: %02X to %s", g.transactionID, frame, g.address)
+
+	_ = conn.SetWriteDeadline(time.Now().Add(tcpWriteTimeout))
+	if _, err := conn.Write(frame); err != nil {
+		g.closeConn()
+		return fmt.Errorf("%w: error sending request: %v", errConnectionLost, err)
+	}
+
+	g.pending = pendingTransaction{
+		transactionID: g.transactionID,
+		unitID:        slaveID,
+		functionCode:  pdu[0],
+		address:       pduAddress(pdu),
+		active:        true,
+	}
+	return nil
+}
+
+// receive reads frames until the response matching the pending transaction arrives
+func (g *ModbusTCPGateway) receive(ctx context.Context, timeoutSeconds int) ([]byte, error) {
+	if !g.pending.active {
+		return nil, fmt.Errorf("no modbus TCP request pending")
+	}
+
+	g.mu.RLock()
+	conn := g.conn
+	g.mu.RUnlock()
+	if conn == nil {
+		g.pending.active = false
+		return nil, fmt.Errorf("%w: gateway is not connected", errConnectionLost)
+	}
+
+	deadline := time.Now().Add(time.Duration(timeoutSeconds) * time.Second)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = conn.SetReadDeadline(deadline)
+
+	// Unblock the read immediately if the context is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	for {
+		header := make([]byte, mbapHeaderLength)
+		if n, err := io.ReadFull(conn, header); err != nil {
+			return nil, g.readError(ctx, err, n > 0, timeoutSeconds)
+		}
+
+		transactionID := binary.BigEndian.Uint16(header[0:2])
+		protocolID := binary.BigEndian.Uint16(header[2:4])
+		length := int(binary.BigEndian.Uint16(header[4:6]))
+		unitID := header[6]
+
+		if protocolID != 0 || length < 2 || length > mbapMaxLength {
+			g.closeConn()
+			g.pending.active = false
+			return nil, fmt.Errorf("%w: invalid MBAP header %02X", errConnectionLost, header)
+		}
+
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return nil, g.readError(ctx, err, true, timeoutSeconds)
+		}
+
+		if transactionID != g.pending.transactionID {
+			logger.LogWarn("Discarding late Modbus TCP response #%d (waiting for #%d)", transactionID, g.pending.transactionID)
+			continue
+		}
+
+		g.pending.active = false
+
+		if unitID != g.pending.unitID {
+			return nil, fmt.Errorf("unexpected unit ID in response: %d (expected %d)", unitID, g.pending.unitID)
+		}
+
+		data, err := parseResponsePDU(g.pending.unitID, g.pending.functionCode, g.pending.address, pdu)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.LogDebug("Gateway received valid modbus TCP response #%d from Slave %d: %02X", transactionID, unitID, data)
+		return data, nil
+	}
+}
+
+// readError converts a read failure into the error returned to the caller
+// A timeout between frames keeps the connection (a late response is discarded by
+// transaction ID); anything that leaves the stream out of sync drops the connection.
+func (g *ModbusTCPGateway) readError(ctx context.Context, err error, partial bool, timeoutSeconds int) error {
+	timedOut := isTimeout(err)
+
+	if partial || !timedOut {
+		g.closeConn()
+	}
+
+	if ctx.Err() != nil {
+		g.pending.active = false
+		return ctx.Err()
+	}
+	if timedOut {
+		return fmt.Errorf("timeout waiting for response (%d seconds)", timeoutSeconds)
+	}
+
+	g.pending.active = false
+	return fmt.Errorf("%w: %v", errConnectionLost, err)
+}
+
+// SendDiagnosticCommand verifies gateway connectivity
+// For Modbus TCP the TCP session itself is the gateway link, so the check
+// (re)establishes the connection instead of polling a specific slave
+func (g *ModbusTCPGateway) SendDiagnosticCommand(ctx context.Context) error {
+	g.txMutex.Lock()
+	defer g.txMutex.Unlock()
+
+	if _, err := g.getConn(ctx); err != nil {
+		return fmt.Errorf("diagnostic check failed: %w", err)
+	}
+	return nil
+}
+
+// buildMBAPFrame prefixes a PDU with the Modbus TCP application header
+func buildMBAPFrame(transactionID uint16, unitID uint8, pdu []byte) []byte {
+	frame := make([]byte, mbapHeaderLength+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:2], transactionID)
+	binary.BigEndian.PutUint16(frame[2:4], 0)                  // Protocol ID: always 0 for Modbus
+	binary.BigEndian.PutUint16(frame[4:6], uint16(len(pdu)+1)) // #nosec G115 -- PDU length is bounded by Modbus (max 253)
+	frame[6] = unitID
+	copy(frame[mbapHeaderLength:], pdu)
+	return frame
+}
@@ -0,0 +1,492 @@
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/errors"
+	"mqtt-modbus-bridge/pkg/recovery"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mbapRequest records a request received by the mock Modbus TCP server
+type mbapRequest struct {
+	transactionID uint16
+	unitID        uint8
+	functionCode  uint8
+	address       uint16
+	count         uint16 // Read quantity, or value/quantity field of a write
+	pdu           []byte
+}
+
+// mockModbusTCPServer is an in-process Modbus TCP slave used as a gateway stand-in
+// Register values are address-derived (value = unitID*1000 + address) unless overridden
+type mockModbusTCPServer struct {
+	listener net.Listener
+
+	mu          sync.Mutex
+	requests    []mbapRequest
+	connections int
+	dropAfter   int   // Close each connection after N requests (0 = never)
+	silent      bool  // Never answer requests
+	badEcho     bool  // Answer writes with a wrong address
+	staleFirst  bool  // Send a response with a wrong transaction ID before the real one
+	exception   uint8 // Answer with this exception code (0 = normal response)
+}
+
+func newMockModbusTCPServer(t *testing.T) *mockModbusTCPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start mock Modbus TCP server: %v", err)
+	}
+
+	server := &mockModbusTCPServer{listener: listener}
+	go server.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return server
+}
+
+func (s *mockModbusTCPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *mockModbusTCPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	handled := 0
+	for {
+		header := make([]byte, mbapHeaderLength)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		pdu := make([]byte, int(binary.BigEndian.Uint16(header[4:6]))-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+
+		req := mbapRequest{
+			transactionID: binary.BigEndian.Uint16(header[0:2]),
+			unitID:        header[6],
+			functionCode:  pdu[0],
+			address:       binary.BigEndian.Uint16(pdu[1:3]),
+			count:         binary.BigEndian.Uint16(pdu[3:5]),
+			pdu:           pdu,
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		silent, staleFirst, exception, dropAfter, badEcho := s.silent, s.staleFirst, s.exception, s.dropAfter, s.badEcho
+		s.mu.Unlock()
+
+		handled++
+		if silent {
+			continue
+		}
+
+		if staleFirst {
+			stale := buildMBAPFrame(req.transactionID-1, req.unitID, registerResponsePDU(req))
+			_, _ = conn.Write(stale)
+		}
+
+		var respPDU []byte
+		if exception != 0 {
+			respPDU = []byte{req.functionCode | exceptionFlag, exception}
+		} else if isWriteFunction(req.functionCode) {
+			respPDU = append([]byte(nil), pdu[:5]...)
+			if badEcho {
+				respPDU[2]++
+			}
+		} else {
+			respPDU = registerResponsePDU(req)
+		}
+		if _, err := conn.Write(buildMBAPFrame(req.transactionID, req.unitID, respPDU)); err != nil {
+			return
+		}
+
+		if dropAfter > 0 && handled >= dropAfter {
+			return
+		}
+	}
+}
+
+// registerResponsePDU builds a read response with address-derived register values
+func registerResponsePDU(req mbapRequest) []byte {
+	pdu := make([]byte, 2+int(req.count)*2)
+	pdu[0] = req.functionCode
+	pdu[1] = byte(req.count * 2)
+	for i := 0; i < int(req.count); i++ {
+		binary.BigEndian.PutUint16(pdu[2+i*2:], uint16(req.unitID)*1000+req.address+uint16(i))
+	}
+	return pdu
+}
+
+func (s *mockModbusTCPServer) getRequests() []mbapRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mbapRequest(nil), s.requests...)
+}
+
+func (s *mockModbusTCPServer) getConnections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+func (s *mockModbusTCPServer) config() *config.ModbusTCPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return &config.ModbusTCPConfig{
+		Host:           host,
+		Port:           portNum,
+		ConnectTimeout: 1000,
+		RetryDelay:     100,
+	}
+}
+
+// connectTCPGateway creates and connects a gateway to the mock server
+func connectTCPGateway(t *testing.T, server *mockModbusTCPServer) *ModbusTCPGateway {
+	t.Helper()
+
+	gw := NewModbusTCPGateway(server.config())
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := gw.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(gw.Disconnect)
+	return gw
+}
+
+// TestModbusTCPReadHoldingRegisters verifies a basic read and payload extraction
+func TestModbusTCPReadHoldingRegisters(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	gw := connectTCPGateway(t, server)
+
+	data, err := gw.SendCommandAndWaitForResponse(context.Background(), 11, 0x03, 0x2000, 2, 2)
+	if err != nil {
+		t.Fatalf("❌ Read failed: %v", err)
+	}
+
+	expected := []byte{0x4A, 0xF8, 0x4A, 0xF9} // 11*1000+0x2000 = 0x4AF8
+	if len(data) != len(expected) {
+		t.Fatalf("❌ Expected %d bytes, got %d (%02X)", len(expected), len(data), data)
+	}
+	for i := range expected {
+		if data[i] != expected[i] {
+			t.Fatalf("❌ Expected %02X, got %02X", expected, data)
+		}
+	}
+	t.Logf("✅ Read returned register payload %02X", data)
+}
+
+// TestModbusTCPUnitIDAndTransactionIDs verifies unit ID mapping and increasing transaction IDs
+func TestModbusTCPUnitIDAndTransactionIDs(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	gw := connectTCPGateway(t, server)
+
+	slaves := []uint8{1, 11, 1, 247}
+	for _, slaveID := range slaves {
+		if _, err := gw.SendCommandAndWaitForResponse(context.Background(), slaveID, 0x03, 0x0000, 1, 2); err != nil {
+			t.Fatalf("❌ Read from slave %d failed: %v", slaveID, err)
+		}
+	}
+
+	requests := server.getRequests()
+	if len(requests) != len(slaves) {
+		t.Fatalf("❌ Expected %d requests, got %d", len(slaves), len(requests))
+	}
+	for i, req := range requests {
+		if req.unitID != slaves[i] {
+			t.Errorf("❌ Request %d: expected unit ID %d, got %d", i, slaves[i], req.unitID)
+		}
+		if i > 0 && req.transactionID != requests[i-1].transactionID+1 {
+			t.Errorf("❌ Request %d: transaction ID %d does not follow %d", i, req.transactionID, requests[i-1].transactionID)
+		}
+	}
+	t.Logf("✅ Unit IDs match slave IDs and transaction IDs increase")
+}
+
+// TestModbusTCPConnectionReuse verifies that polls share one TCP connection
+func TestModbusTCPConnectionReuse(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	gw := connectTCPGateway(t, server)
+
+	for i := 0; i < 10; i++ {
+		if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, uint16(i), 2, 2); err != nil {
+			t.Fatalf("❌ Read %d failed: %v", i, err)
+		}
+	}
+
+	if conns := server.getConnections(); conns != 1 {
+		t.Errorf("❌ Expected 1 connection for 10 reads, got %d", conns)
+	}
+	t.Logf("✅ 10 reads used a single connection")
+}
+
+// TestModbusTCPReconnect verifies transparent reconnection after the server drops the connection
+func TestModbusTCPReconnect(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	server.dropAfter = 1
+	gw := connectTCPGateway(t, server)
+
+	for i := 0; i < 3; i++ {
+		if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 2); err != nil {
+			t.Fatalf("❌ Read %d failed after server dropped connection: %v", i, err)
+		}
+	}
+
+	if conns := server.getConnections(); conns < 3 {
+		t.Errorf("❌ Expected at least 3 connections, got %d", conns)
+	}
+	if !gw.IsConnected() {
+		t.Error("❌ Gateway should report connected after reconnect")
+	}
+	t.Logf("✅ Gateway reconnected transparently (%d connections)", server.getConnections())
+}
+
+// TestModbusTCPDiscardsStaleResponse verifies that responses are matched by transaction ID
+func TestModbusTCPDiscardsStaleResponse(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	server.staleFirst = true
+	gw := connectTCPGateway(t, server)
+
+	data, err := gw.SendCommandAndWaitForResponse(context.Background(), 5, 0x03, 0x0010, 1, 2)
+	if err != nil {
+		t.Fatalf("❌ Read failed: %v", err)
+	}
+	if got := binary.BigEndian.Uint16(data); got != 5*1000+0x10 {
+		t.Errorf("❌ Unexpected value %d", got)
+	}
+	t.Logf("✅ Stale response discarded, matching response returned")
+}
+
+// TestModbusTCPTimeoutKeepsConnection verifies timeouts and recovery from late responses
+func TestModbusTCPTimeoutKeepsConnection(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	server.silent = true
+	gw := connectTCPGateway(t, server)
+
+	start := time.Now()
+	_, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 1)
+	if err == nil {
+		t.Fatal("❌ Expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("❌ Timeout took too long: %v", elapsed)
+	}
+
+	server.mu.Lock()
+	server.silent = false
+	server.mu.Unlock()
+
+	if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 1); err != nil {
+		t.Fatalf("❌ Read after timeout failed: %v", err)
+	}
+	t.Logf("✅ Timeout reported (%v) and next request succeeded", err)
+}
+
+// TestModbusTCPContextCancel verifies that a cancelled context unblocks the read
+func TestModbusTCPContextCancel(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	server.silent = true
+	gw := connectTCPGateway(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := gw.SendCommandAndWaitForResponse(ctx, 1, 0x03, 0x0000, 2, 5); err == nil {
+		t.Fatal("❌ Expected error on cancelled context")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("❌ Cancellation took too long: %v", elapsed)
+	}
+	t.Logf("✅ Context cancellation unblocked the read")
+}
+
+// TestModbusTCPExceptionResponse verifies that exception responses surface as typed errors
+func TestModbusTCPExceptionResponse(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	server.exception = 0x02
+	gw := connectTCPGateway(t, server)
+
+	_, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x9999, 2, 2)
+	exception, ok := errors.AsModbusException(err)
+	if !ok {
+		t.Fatalf("❌ Expected Modbus exception error, got %v", err)
+	}
+	if exception.SlaveID != 1 || exception.Address != 0x9999 || exception.ExceptionCode != 0x02 {
+		t.Errorf("❌ Exception decoded incorrectly: %+v", exception)
+	}
+	t.Logf("✅ Exception reported: %v", err)
+}
+
+// TestModbusTCPWrite verifies write requests and echo verification
+func TestModbusTCPWrite(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	gw := connectTCPGateway(t, server)
+
+	req, err := NewWriteValue(0x0006, 230.5, config.ValueTypeFloat32)
+	if err != nil {
+		t.Fatalf("❌ NewWriteValue failed: %v", err)
+	}
+	if err := gw.Write(context.Background(), 11, req, 2); err != nil {
+		t.Fatalf("❌ Write failed: %v", err)
+	}
+
+	requests := server.getRequests()
+	if len(requests) != 1 || requests[0].unitID != 11 || requests[0].functionCode != FunctionWriteMultipleRegisters {
+		t.Fatalf("❌ Unexpected requests: %+v", requests)
+	}
+	if fmt.Sprintf("% X", requests[0].pdu) != "10 00 06 00 02 04 43 66 80 00" {
+		t.Errorf("❌ Unexpected write PDU % X", requests[0].pdu)
+	}
+
+	server.mu.Lock()
+	server.badEcho = true
+	server.mu.Unlock()
+	if err := gw.Write(context.Background(), 11, NewWriteCoil(0x0001, true), 2); err == nil {
+		t.Error("❌ Expected error for mismatching echo")
+	}
+	t.Logf("✅ Write sent and echo verified")
+}
+
+// TestModbusTCPWithCircuitBreaker verifies the TCP gateway works behind the circuit breaker wrapper
+func TestModbusTCPWithCircuitBreaker(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	gw := connectTCPGateway(t, server)
+
+	cbGateway := NewCircuitBreakerGateway(gw, recovery.CircuitBreakerConfig{
+		MaxFailures:      3,
+		Timeout:          time.Second,
+		HalfOpenMaxTries: 2,
+	})
+
+	data, err := cbGateway.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x2000, 2, 2)
+	if err != nil {
+		t.Fatalf("❌ Read through circuit breaker failed: %v", err)
+	}
+	if len(data) != 4 {
+		t.Errorf("❌ Expected 4 bytes, got %d", len(data))
+	}
+	if !cbGateway.IsConnected() {
+		t.Error("❌ Wrapped gateway should report connected")
+	}
+	t.Logf("✅ Circuit breaker wrapper delegates to Modbus TCP gateway")
+}
+
+// TestModbusTCPConcurrentRequests verifies that concurrent callers get their own responses
+func TestModbusTCPConcurrentRequests(t *testing.T) {
+	server := newMockModbusTCPServer(t)
+	gw := connectTCPGateway(t, server)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(slaveID uint8, address uint16) {
+			defer wg.Done()
+			data, err := gw.SendCommandAndWaitForResponse(context.Background(), slaveID, 0x03, address, 1, 2)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if got := binary.BigEndian.Uint16(data); got != uint16(slaveID)*1000+address {
+				errs <- io.ErrUnexpectedEOF
+			}
+		}(uint8(i%4+1), uint16(i))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("❌ Concurrent request failed: %v", err)
+	}
+	t.Logf("✅ 20 concurrent requests received matching responses")
+}
+
+// TestNewGatewayFactory verifies gateway selection from configuration
+func TestNewGatewayFactory(t *testing.T) {
+	tcpCfg := &config.MQTTConfig{
+		Gateway: config.GatewayConfig{
+			Type:      config.GatewayTypeModbusTCP,
+			ModbusTCP: config.ModbusTCPConfig{Host: "127.0.0.1", Port: 502},
+		},
+	}
+	gw, err := NewGateway(tcpCfg)
+	if err != nil {
+		t.Fatalf("❌ Factory failed: %v", err)
+	}
+	if _, ok := gw.(*ModbusTCPGateway); !ok {
+		t.Errorf("❌ Expected *ModbusTCPGateway, got %T", gw)
+	}
+
+	usrCfg := &config.MQTTConfig{
+		Broker: "test",
+		Port:   1883,
+		Gateway: config.GatewayConfig{
+			MAC:       "TEST123456",
+			CmdTopic:  "test/cmd",
+			DataTopic: "test/data",
+		},
+	}
+	gw, err = NewGateway(usrCfg)
+	if err != nil {
+		t.Fatalf("❌ Factory failed: %v", err)
+	}
+	if _, ok := gw.(*USRGateway); !ok {
+		t.Errorf("❌ Expected *USRGateway for default type, got %T", gw)
+	}
+
+	if _, err := NewGateway(&config.MQTTConfig{Gateway: config.GatewayConfig{Type: "carrier_pigeon"}}); err == nil {
+		t.Error("❌ Expected error for unknown gateway type")
+	}
+	t.Logf("✅ Factory selects gateway by type")
+}
+
+// TestNewNamedGateway verifies each named USR gateway uses its own topics and MQTT client ID
+func TestNewNamedGateway(t *testing.T) {
+	mqttCfg := &config.MQTTConfig{Broker: "test", Port: 1883, ClientID: "bridge"}
+	busCfg := &config.GatewayConfig{MAC: "BBBBBBBBBBBB", CmdTopic: "BBBBBBBBBBBB/cmd", DataTopic: "BBBBBBBBBBBB/data"}
+
+	gw, err := NewNamedGateway("bus_b", busCfg, mqttCfg)
+	if err != nil {
+		t.Fatalf("❌ Factory failed: %v", err)
+	}
+	usr, ok := gw.(*USRGateway)
+	if !ok {
+		t.Fatalf("❌ Expected *USRGateway, got %T", gw)
+	}
+	if usr.config.ClientID != "bridge_bus_b" || usr.config.Gateway.CmdTopic != "BBBBBBBBBBBB/cmd" {
+		t.Errorf("❌ Unexpected client ID '%s' / command topic '%s'", usr.config.ClientID, usr.config.Gateway.CmdTopic)
+	}
+	if mqttCfg.ClientID != "bridge" {
+		t.Errorf("❌ Shared MQTT config was modified: client ID '%s'", mqttCfg.ClientID)
+	}
+
+	gw, err = NewNamedGateway(config.DefaultGatewayName, busCfg, mqttCfg)
+	if err != nil {
+		t.Fatalf("❌ Factory failed: %v", err)
+	}
+	if gw.(*USRGateway).config.ClientID != "bridge" {
+		t.Errorf("❌ Default gateway must keep the configured client ID")
+	}
+	t.Logf("✅ Named gateway uses client ID %s", usr.config.ClientID)
+}
@@ -0,0 +1,56 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/errors"
+)
+
+// Modbus exception responses set the high bit of the function code
+const exceptionFlag = 0x80
+
+// buildReadPDU builds a Modbus read request PDU (function code + address + quantity)
+// The PDU is transport independent: RTU wraps it with slave ID and CRC, TCP with an MBAP header
+func buildReadPDU(functionCode uint8, address uint16, count uint16) []byte {
+	pdu := make([]byte, 5)
+	pdu[0] = functionCode
+	binary.BigEndian.PutUint16(pdu[1:3], address)
+	binary.BigEndian.PutUint16(pdu[3:5], count)
+	return pdu
+}
+
+// pduAddress returns the starting address of a request PDU (0 for requests without an address)
+func pduAddress(pdu []byte) uint16 {
+	if pdu[0] == FunctionEncapsulatedInterface {
+		return 0
+	}
+	return binary.BigEndian.Uint16(pdu[1:3])
+}
+
+// parseResponsePDU validates a response PDU against the request function code
+// and extracts the payload returned to callers of the Gateway interface.
+// For read functions (0x01-0x04) the payload is the data following the byte count.
+// Exception responses are returned as *errors.ModbusExceptionError for the request's slave and address.
+func parseResponsePDU(slaveID uint8, functionCode uint8, address uint16, pdu []byte) ([]byte, error) {
+	if len(pdu) < 2 {
+		return nil, fmt.Errorf("response PDU too short (len=%d)", len(pdu))
+	}
+
+	if pdu[0] == functionCode|exceptionFlag {
+		return nil, errors.NewModbusExceptionError(slaveID, functionCode, address, pdu[1])
+	}
+	if pdu[0] != functionCode {
+		return nil, fmt.Errorf("unexpected function code in response: 0x%02X (expected 0x%02X)", pdu[0], functionCode)
+	}
+
+	switch functionCode {
+	case 0x01, 0x02, 0x03, 0x04:
+		byteCount := int(pdu[1])
+		if len(pdu) < 2+byteCount {
+			return nil, fmt.Errorf("invalid byte count in response: expected %d bytes, got %d", byteCount, len(pdu)-2)
+		}
+		return pdu[2 : 2+byteCount], nil
+	default:
+		return pdu[1:], nil
+	}
+}
@@ -0,0 +1,205 @@
+package gateway
+
+import (
+	"mqtt-modbus-bridge/pkg/logger"
+	"sync"
+	"time"
+)
+
+// lateResponseWindow is how long a timed-out request is remembered, so that its response
+// arriving afterwards is counted as late instead of unexpected
+const lateResponseWindow = 30 * time.Second
+
+// requestKey identifies which request a Modbus RTU response belongs to
+// An RTU response only carries the slave ID and function code, so at most one
+// request per key can be in flight at a time
+type requestKey struct {
+	slaveID      uint8
+	functionCode uint8
+}
+
+// pendingRequest is a request waiting for its response
+type pendingRequest struct {
+	id        uint64
+	key       requestKey
+	address   uint16      // Starting address, reported in exception errors
+	byteCount int         // Expected payload byte count for read responses (0 = not checked)
+	response  chan []byte // Receives the raw RTU response frame (buffered, delivered at most once)
+}
+
+// expiredRequest remembers a timed-out request for late-response accounting
+type expiredRequest struct {
+	id        uint64
+	expiredAt time.Time
+}
+
+// ResponseStats counts how received responses were handled
+type ResponseStats struct {
+	Delivered  uint64 // Responses delivered to the request waiting for them
+	Late       uint64 // Responses for requests that had already timed out
+	Unexpected uint64 // Responses that matched no pending or recently timed-out request
+}
+
+// requestTable correlates responses with in-flight requests by slave ID + function code
+// Single Responsibility Principle - only tracks in-flight requests; sending and bus
+// serialization are handled by the gateway that owns the table
+type requestTable struct {
+	mu      sync.Mutex
+	counter uint64
+	pending map[requestKey]*pendingRequest
+	expired map[requestKey]expiredRequest
+	stats   ResponseStats
+}
+
+// newRequestTable creates an empty request table
+func newRequestTable() *requestTable {
+	return &requestTable{
+		pending: make(map[requestKey]*pendingRequest),
+		expired: make(map[requestKey]expiredRequest),
+	}
+}
+
+// register adds a new in-flight request
+// A request still pending under the same key is expired, since its response can no
+// longer be told apart from the new one
+func (t *requestTable) register(slaveID, functionCode uint8, address, count uint16) *pendingRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counter++
+	req := &pendingRequest{
+		id:        t.counter,
+		key:       requestKey{slaveID: slaveID, functionCode: functionCode},
+		address:   address,
+		byteCount: readByteCount(functionCode, count),
+		response:  make(chan []byte, 1),
+	}
+
+	if previous, exists := t.pending[req.key]; exists {
+		logger.LogWarn("⚠️ Request #%d (Slave %d, FC 0x%02X) superseded by request #%d before its response arrived",
+			previous.id, slaveID, functionCode, req.id)
+		t.expired[req.key] = expiredRequest{id: previous.id, expiredAt: time.Now()}
+	}
+	t.pending[req.key] = req
+	t.pruneExpired()
+	return req
+}
+
+// expire removes a request that timed out or was cancelled
+// Its response, if it still arrives, is counted as late
+func (t *requestTable) expire(req *pendingRequest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending[req.key] == req {
+		delete(t.pending, req.key)
+		t.expired[req.key] = expiredRequest{id: req.id, expiredAt: time.Now()}
+		return
+	}
+
+	// The response was delivered just as the request gave up waiting
+	select {
+	case <-req.response:
+		t.stats.Delivered--
+		t.stats.Late++
+		logger.LogWarn("⚠️ Request #%d: Discarded response delivered after timeout", req.id)
+	default:
+	}
+}
+
+// remove drops a request whose command was never sent (no response can arrive)
+func (t *requestTable) remove(req *pendingRequest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending[req.key] == req {
+		delete(t.pending, req.key)
+	}
+}
+
+// deliver hands a CRC-checked RTU frame to the request waiting for it
+// Exception responses (function code | 0x80) go to the request for the original function code,
+// so the caller fails immediately instead of waiting for the timeout.
+// Returns false if no pending request matched (late or unexpected response)
+func (t *requestTable) deliver(frame []byte) bool {
+	key := requestKey{slaveID: frame[0], functionCode: frame[1] &^ exceptionFlag}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if req, exists := t.pending[key]; exists && req.matches(frame) {
+		delete(t.pending, key)
+		req.response <- frame // Buffered and delivered at most once, never blocks
+		t.stats.Delivered++
+		return true
+	}
+
+	if late, exists := t.expired[key]; exists {
+		delete(t.expired, key)
+		t.stats.Late++
+		logger.LogWarn("⚠️ Request #%d: Discarded late response from Slave %d (FC 0x%02X, %d bytes)",
+			late.id, key.slaveID, key.functionCode, len(frame))
+		return false
+	}
+
+	t.stats.Unexpected++
+	logger.LogWarn("Received unexpected response (Slave=%d, Func=0x%02X) with no request pending, ignoring",
+		key.slaveID, frame[1])
+	return false
+}
+
+// pendingCount returns the number of requests currently waiting for a response
+func (t *requestTable) pendingCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.pending)
+}
+
+// getStats returns a snapshot of the response counters
+func (t *requestTable) getStats() ResponseStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// close fails every pending request (their response channels are closed)
+func (t *requestTable) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, req := range t.pending {
+		close(req.response)
+		delete(t.pending, key)
+	}
+}
+
+// pruneExpired forgets timed-out requests older than lateResponseWindow (caller holds mu)
+func (t *requestTable) pruneExpired() {
+	for key, exp := range t.expired {
+		if time.Since(exp.expiredAt) > lateResponseWindow {
+			delete(t.expired, key)
+		}
+	}
+}
+
+// matches reports whether a frame can be the response to this request
+// Read responses must carry the expected byte count, which rejects a late response
+// for a different register range on the same slave and function code
+func (r *pendingRequest) matches(frame []byte) bool {
+	if r.byteCount == 0 || len(frame) < 3 || frame[1]&exceptionFlag != 0 {
+		return true
+	}
+	return int(frame[2]) == r.byteCount
+}
+
+// readByteCount returns the payload byte count of a read response (0 for other functions)
+func readByteCount(functionCode uint8, count uint16) int {
+	switch functionCode {
+	case 0x01, 0x02:
+		return (int(count) + 7) / 8
+	case 0x03, 0x04:
+		return int(count) * 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"mqtt-modbus-bridge/pkg/crc"
+	"mqtt-modbus-bridge/pkg/logger"
+)
+
+const (
+	rtuMinFrameLength = 5   // Slave ID + function code + 1 byte + CRC (exception responses)
+	rtuMaxFrameLength = 256 // Modbus RTU ADU limit
+)
+
+// buildRTUFrame wraps a PDU with the slave ID and CRC (Modbus RTU ADU)
+func buildRTUFrame(slaveID uint8, pdu []byte) []byte {
+	frame := make([]byte, 0, len(pdu)+3)
+	frame = append(frame, slaveID)
+	frame = append(frame, pdu...)
+	return crc.AppendCRC(frame)
+}
+
+// rtuResponseLength returns the total length of the RTU response at the start of buf
+// Returns 0 when more bytes are needed and -1 when the function code is not a known response
+func rtuResponseLength(buf []byte) int {
+	if len(buf) < 2 {
+		return 0
+	}
+
+	functionCode := buf[1]
+	if functionCode&exceptionFlag != 0 {
+		return rtuMinFrameLength // Slave + FC + exception code + CRC
+	}
+
+	switch functionCode {
+	case 0x01, 0x02, 0x03, 0x04:
+		if len(buf) < 3 {
+			return 0
+		}
+		return 3 + int(buf[2]) + 2 // Slave + FC + byte count + data + CRC
+	case 0x05, 0x06, 0x0F, 0x10:
+		return 8 // Slave + FC + address + value/quantity + CRC
+	case FunctionEncapsulatedInterface:
+		length := deviceIDResponseLength(buf[1:])
+		if length == 0 {
+			return 0
+		}
+		return 1 + length + 2 // Slave + PDU + CRC
+	default:
+		return -1
+	}
+}
+
+// extractRTUFrame finds the first complete, CRC-valid response frame in buf
+// Bytes in front of it (line noise, local echo, truncated frames) are skipped so
+// the reader resynchronizes. When no complete frame is present, rest holds the
+// bytes worth keeping for the next read.
+func extractRTUFrame(buf []byte) (frame []byte, rest []byte, ok bool) {
+	for start := 0; start < len(buf); start++ {
+		length := rtuResponseLength(buf[start:])
+		if length <= 0 || length > rtuMaxFrameLength || start+length > len(buf) {
+			continue
+		}
+		if crc.VerifyCRC(buf[start : start+length]) {
+			if start > 0 {
+				logger.LogTrace("Skipped %d bytes before RTU frame: %02X", start, buf[:start])
+			}
+			return buf[start : start+length], buf[start+length:], true
+		}
+	}
+
+	// Drop leading bytes that cannot start a response frame
+	for len(buf) > 0 && rtuResponseLength(buf) < 0 {
+		buf = buf[1:]
+	}
+	return nil, buf, false
+}
@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/crc"
+	"mqtt-modbus-bridge/pkg/errors"
+	"testing"
+)
+
+// rtuReadResponse builds an RTU read response with address-derived register values
+func rtuReadResponse(slaveID, functionCode uint8, address, count uint16) []byte {
+	frame := []byte{slaveID, functionCode, byte(count * 2)}
+	for i := uint16(0); i < count; i++ {
+		frame = binary.BigEndian.AppendUint16(frame, uint16(slaveID)*1000+address+i)
+	}
+	return crc.AppendCRC(frame)
+}
+
+// rtuWriteEcho builds the normal response to an RTU write request frame
+// 0x05/0x06 echo address and value, 0x0F/0x10 echo address and quantity
+func rtuWriteEcho(request []byte) []byte {
+	return crc.AppendCRC(append([]byte(nil), request[:6]...))
+}
+
+// isWriteFunction reports whether a function code is a Modbus write
+func isWriteFunction(functionCode uint8) bool {
+	switch functionCode {
+	case FunctionWriteSingleCoil, FunctionWriteSingleRegister, FunctionWriteMultipleCoils, FunctionWriteMultipleRegisters:
+		return true
+	}
+	return false
+}
+
+// TestBuildRTUFrame verifies RTU framing against a known request
+func TestBuildRTUFrame(t *testing.T) {
+	frame := buildRTUFrame(1, buildReadPDU(0x03, 0x2000, 2))
+
+	// 01 03 20 00 00 02 + CRC (little-endian)
+	expected := crc.AppendCRC([]byte{0x01, 0x03, 0x20, 0x00, 0x00, 0x02})
+	if fmt.Sprintf("%02X", frame) != fmt.Sprintf("%02X", expected) {
+		t.Fatalf("❌ Expected %02X, got %02X", expected, frame)
+	}
+	if !crc.VerifyCRC(frame) {
+		t.Fatal("❌ Frame CRC does not verify")
+	}
+	t.Logf("✅ RTU frame: %02X", frame)
+}
+
+// TestExtractRTUFrame verifies reassembly and resynchronization of RTU responses
+func TestExtractRTUFrame(t *testing.T) {
+	readResponse := crc.AppendCRC([]byte{0x01, 0x03, 0x04, 0x43, 0x66, 0x00, 0x00})
+	exception := crc.AppendCRC([]byte{0x01, 0x83, 0x02})
+	writeEcho := crc.AppendCRC([]byte{0x01, 0x06, 0x00, 0x10, 0x00, 0x01})
+
+	corrupted := append([]byte(nil), readResponse...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	concat := func(parts ...[]byte) []byte {
+		var out []byte
+		for _, p := range parts {
+			out = append(out, p...)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name      string
+		input     []byte
+		wantFrame []byte
+		wantRest  int
+	}{
+		{"complete read response", readResponse, readResponse, 0},
+		{"exception response", exception, exception, 0},
+		{"write echo", writeEcho, writeEcho, 0},
+		{"partial frame", readResponse[:5], nil, 5},
+		{"only slave ID", readResponse[:1], nil, 1},
+		{"leading noise", concat([]byte{0xFF, 0x00, 0x7E}, readResponse), readResponse, 0},
+		{"two frames", concat(exception, readResponse), exception, len(readResponse)},
+		{"corrupted CRC", corrupted, nil, len(corrupted)},
+		{"corrupted then valid", concat(corrupted, readResponse), readResponse, 0},
+		{"empty", nil, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, rest, ok := extractRTUFrame(tt.input)
+			if ok != (tt.wantFrame != nil) {
+				t.Fatalf("❌ ok = %v, expected frame %02X", ok, tt.wantFrame)
+			}
+			if fmt.Sprintf("%02X", frame) != fmt.Sprintf("%02X", tt.wantFrame) {
+				t.Errorf("❌ Expected frame %02X, got %02X", tt.wantFrame, frame)
+			}
+			if len(rest) != tt.wantRest {
+				t.Errorf("❌ Expected %d remaining bytes, got %d (%02X)", tt.wantRest, len(rest), rest)
+			}
+		})
+	}
+}
+
+// TestParseResponsePDU verifies payload extraction and exception detection
+func TestParseResponsePDU(t *testing.T) {
+	data, err := parseResponsePDU(11, 0x03, 0x2000, []byte{0x03, 0x04, 0x01, 0x02, 0x03, 0x04})
+	if err != nil || fmt.Sprintf("%02X", data) != "01020304" {
+		t.Errorf("❌ Unexpected result %02X, %v", data, err)
+	}
+
+	_, err = parseResponsePDU(11, 0x03, 0x2000, []byte{0x83, 0x02})
+	exception, ok := errors.AsModbusException(err)
+	if !ok {
+		t.Fatalf("❌ Expected Modbus exception error, got %v", err)
+	}
+	if exception.SlaveID != 11 || exception.FunctionCode != 0x03 || exception.Address != 0x2000 ||
+		exception.ExceptionCode != errors.ExceptionIllegalDataAddress {
+		t.Errorf("❌ Exception decoded incorrectly: %+v", exception)
+	}
+	if _, err := parseResponsePDU(11, 0x03, 0x2000, []byte{0x04, 0x02, 0x00, 0x00}); err == nil {
+		t.Error("❌ Expected error for mismatched function code")
+	}
+	if _, err := parseResponsePDU(11, 0x03, 0x2000, []byte{0x03, 0x08, 0x00}); err == nil {
+		t.Error("❌ Expected error for short payload")
+	}
+	t.Logf("✅ Response PDU parsing validated")
+}
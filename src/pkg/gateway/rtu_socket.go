@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"context"
+	"mqtt-modbus-bridge/pkg/config"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RTUSocketGateway implementation of raw Modbus RTU over a transparent TCP or UDP socket
+// Single Responsibility Principle - only opens the socket; RTU framing, CRC checks and
+// response reassembly are handled by the embedded rtuTransport
+//
+// Used with converters (e.g. USR-DR164 in TCP server or UDP mode) that forward the
+// socket payload unchanged to the RS-485 bus, so polling does not depend on an MQTT broker.
+type RTUSocketGateway struct {
+	*rtuTransport
+	network string
+	config  *config.RTUSocketConfig
+}
+
+// NewRTUSocketGateway creates a new RTU socket gateway for network "tcp" or "udp"
+func NewRTUSocketGateway(network string, cfg *config.RTUSocketConfig) *RTUSocketGateway {
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	gateway := &RTUSocketGateway{network: network, config: cfg}
+	gateway.rtuTransport = &rtuTransport{
+		name:       "RTU over " + network + " " + address,
+		frameDelay: time.Duration(cfg.InterFrameDelay) * time.Microsecond,
+		retryDelay: cfg.RetryDelay,
+		open: func(ctx context.Context) (rtuConn, error) {
+			dialer := net.Dialer{Timeout: time.Duration(cfg.ConnectTimeout) * time.Millisecond}
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+	return gateway
+}
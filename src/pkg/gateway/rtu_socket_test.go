@@ -0,0 +1,340 @@
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/crc"
+	"mqtt-modbus-bridge/pkg/recovery"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockRTUSocketServer is an in-process transparent converter answering raw RTU frames
+// TCP connections are handled as a byte stream; UDP datagrams carry one frame each
+type mockRTUSocketServer struct {
+	listener   net.Listener
+	packetConn net.PacketConn
+
+	mu          sync.Mutex
+	requests    [][]byte
+	connections int
+	mode        rtuSocketMode
+}
+
+// rtuSocketMode controls how the stand-in server answers
+type rtuSocketMode struct {
+	split      bool // Send responses one byte at a time (TCP) or in two datagrams (UDP)
+	noise      bool // Prepend garbage before each response
+	corruptCRC bool // Flip the CRC of each response
+	dropAfter  int  // Close each TCP connection after N responses (0 = never)
+}
+
+func newMockRTUSocketServer(t *testing.T, network string) *mockRTUSocketServer {
+	t.Helper()
+
+	server := &mockRTUSocketServer{}
+	switch network {
+	case "tcp":
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to start TCP stand-in: %v", err)
+		}
+		server.listener = listener
+		t.Cleanup(func() { _ = listener.Close() })
+		go server.serveTCP()
+	case "udp":
+		packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to start UDP stand-in: %v", err)
+		}
+		server.packetConn = packetConn
+		t.Cleanup(func() { _ = packetConn.Close() })
+		go server.serveUDP()
+	}
+	return server
+}
+
+func (s *mockRTUSocketServer) serveTCP() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.mu.Unlock()
+		go s.handleTCP(conn)
+	}
+}
+
+func (s *mockRTUSocketServer) handleTCP(conn net.Conn) {
+	defer conn.Close()
+
+	responses := 0
+	for {
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+		if request[1] == FunctionWriteMultipleCoils || request[1] == FunctionWriteMultipleRegisters {
+			// Slave + FC + address + quantity + byte count + values + CRC
+			rest := make([]byte, 9+int(request[6])-len(request))
+			if _, err := io.ReadFull(conn, rest); err != nil {
+				return
+			}
+			request = append(request, rest...)
+		}
+
+		response, opts := s.answer(request)
+		if response == nil {
+			continue
+		}
+
+		if opts.split {
+			for _, b := range response {
+				if _, err := conn.Write([]byte{b}); err != nil {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		} else if _, err := conn.Write(response); err != nil {
+			return
+		}
+
+		responses++
+		if opts.dropAfter > 0 && responses >= opts.dropAfter {
+			return
+		}
+	}
+}
+
+func (s *mockRTUSocketServer) serveUDP() {
+	buf := make([]byte, 256)
+	for {
+		n, addr, err := s.packetConn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		response, opts := s.answer(append([]byte(nil), buf[:n]...))
+		if response == nil {
+			continue
+		}
+
+		if opts.split {
+			half := len(response) / 2
+			_, _ = s.packetConn.WriteTo(response[:half], addr)
+			time.Sleep(5 * time.Millisecond)
+			_, _ = s.packetConn.WriteTo(response[half:], addr)
+		} else {
+			_, _ = s.packetConn.WriteTo(response, addr)
+		}
+	}
+}
+
+// answer records the request and builds the response bytes according to the server mode
+func (s *mockRTUSocketServer) answer(request []byte) ([]byte, rtuSocketMode) {
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	opts := s.mode
+	s.mu.Unlock()
+
+	if len(request) < 8 || !crc.VerifyCRC(request) {
+		return nil, opts
+	}
+
+	response := rtuReadResponse(request[0], request[1], binary.BigEndian.Uint16(request[2:4]), binary.BigEndian.Uint16(request[4:6]))
+	if isWriteFunction(request[1]) {
+		response = rtuWriteEcho(request)
+	}
+	if opts.corruptCRC {
+		response[len(response)-1] ^= 0xFF
+	}
+	if opts.noise {
+		response = append([]byte{0xFF, 0x00, 0x55}, response...)
+	}
+	return response, opts
+}
+
+func (s *mockRTUSocketServer) set(fn func(m *rtuSocketMode)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.mode)
+}
+
+func (s *mockRTUSocketServer) config() *config.RTUSocketConfig {
+	var addr net.Addr
+	if s.listener != nil {
+		addr = s.listener.Addr()
+	} else {
+		addr = s.packetConn.LocalAddr()
+	}
+	host, port, _ := net.SplitHostPort(addr.String())
+	portNum, _ := strconv.Atoi(port)
+	return &config.RTUSocketConfig{
+		Host:           host,
+		Port:           portNum,
+		ConnectTimeout: 1000,
+		RetryDelay:     100,
+	}
+}
+
+// connectRTUSocketGateway starts a stand-in server and connects a gateway to it
+func connectRTUSocketGateway(t *testing.T, network string) (*RTUSocketGateway, *mockRTUSocketServer) {
+	t.Helper()
+
+	server := newMockRTUSocketServer(t, network)
+	gw := NewRTUSocketGateway(network, server.config())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := gw.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(gw.Disconnect)
+	return gw, server
+}
+
+// TestRTUSocketRead verifies reads over both TCP and UDP
+func TestRTUSocketRead(t *testing.T) {
+	for _, network := range []string{"tcp", "udp"} {
+		t.Run(network, func(t *testing.T) {
+			gw, server := connectRTUSocketGateway(t, network)
+
+			data, err := gw.SendCommandAndWaitForResponse(context.Background(), 11, 0x03, 0x2000, 2, 2)
+			if err != nil {
+				t.Fatalf("❌ Read failed: %v", err)
+			}
+			if len(data) != 4 || binary.BigEndian.Uint16(data) != 11*1000+0x2000 {
+				t.Errorf("❌ Unexpected payload %02X", data)
+			}
+
+			server.mu.Lock()
+			request := server.requests[0]
+			server.mu.Unlock()
+			if string(request) != string(crc.AppendCRC([]byte{11, 0x03, 0x20, 0x00, 0x00, 0x02})) {
+				t.Errorf("❌ Unexpected raw RTU request %02X", request)
+			}
+			t.Logf("✅ %s: raw RTU request %02X answered with %02X", network, request, data)
+		})
+	}
+}
+
+// TestRTUSocketWrite verifies that writes are framed as RTU and their echo accepted
+func TestRTUSocketWrite(t *testing.T) {
+	gw, server := connectRTUSocketGateway(t, "tcp")
+
+	req, err := NewWriteRegisters(0x0100, []uint16{1, 2})
+	if err != nil {
+		t.Fatalf("❌ NewWriteRegisters failed: %v", err)
+	}
+	if err := gw.Write(context.Background(), 5, req, 2); err != nil {
+		t.Fatalf("❌ Write failed: %v", err)
+	}
+
+	server.mu.Lock()
+	request := server.requests[0]
+	server.mu.Unlock()
+	expected := crc.AppendCRC([]byte{5, 0x10, 0x01, 0x00, 0x00, 0x02, 0x04, 0x00, 0x01, 0x00, 0x02})
+	if string(request) != string(expected) {
+		t.Errorf("❌ Unexpected raw RTU write %02X", request)
+	}
+	t.Logf("✅ Raw RTU write %02X", request)
+}
+
+// TestRTUSocketPartialReads verifies reassembly of responses split across reads or datagrams
+func TestRTUSocketPartialReads(t *testing.T) {
+	for _, network := range []string{"tcp", "udp"} {
+		t.Run(network, func(t *testing.T) {
+			gw, server := connectRTUSocketGateway(t, network)
+			server.set(func(m *rtuSocketMode) { m.split = true })
+
+			data, err := gw.SendCommandAndWaitForResponse(context.Background(), 3, 0x04, 0x0000, 10, 2)
+			if err != nil {
+				t.Fatalf("❌ Read with split response failed: %v", err)
+			}
+			if len(data) != 20 || binary.BigEndian.Uint16(data[18:20]) != 3009 {
+				t.Errorf("❌ Unexpected payload %02X", data)
+			}
+			t.Logf("✅ %s: %d-byte payload reassembled", network, len(data))
+		})
+	}
+}
+
+// TestRTUSocketNoiseAndCRC verifies resynchronization and CRC rejection
+func TestRTUSocketNoiseAndCRC(t *testing.T) {
+	gw, server := connectRTUSocketGateway(t, "tcp")
+
+	server.set(func(m *rtuSocketMode) { m.noise = true })
+	if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 2); err != nil {
+		t.Fatalf("❌ Read after noise failed: %v", err)
+	}
+
+	server.set(func(m *rtuSocketMode) { m.noise = false; m.corruptCRC = true })
+	if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 1); err == nil {
+		t.Fatal("❌ Expected error for corrupted CRC")
+	}
+
+	server.set(func(m *rtuSocketMode) { m.corruptCRC = false })
+	if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 2); err != nil {
+		t.Fatalf("❌ Read after CRC error failed: %v", err)
+	}
+	t.Logf("✅ Noise skipped, bad CRC rejected, stream recovered")
+}
+
+// TestRTUSocketReconnect verifies transparent reconnection when the converter drops the TCP connection
+func TestRTUSocketReconnect(t *testing.T) {
+	gw, server := connectRTUSocketGateway(t, "tcp")
+	server.set(func(m *rtuSocketMode) { m.dropAfter = 1 })
+
+	for i := 0; i < 3; i++ {
+		if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, uint16(i), 1, 2); err != nil {
+			t.Fatalf("❌ Read %d failed after connection drop: %v", i, err)
+		}
+	}
+
+	server.mu.Lock()
+	connections := server.connections
+	server.mu.Unlock()
+	if connections < 3 {
+		t.Errorf("❌ Expected at least 3 connections, got %d", connections)
+	}
+	t.Logf("✅ Reconnected transparently (%d connections)", connections)
+}
+
+// TestRTUSocketWithCircuitBreaker verifies the socket gateway behind the circuit breaker wrapper
+func TestRTUSocketWithCircuitBreaker(t *testing.T) {
+	gw, _ := connectRTUSocketGateway(t, "udp")
+
+	cbGateway := NewCircuitBreakerGateway(gw, recovery.CircuitBreakerConfig{
+		MaxFailures:      3,
+		Timeout:          time.Second,
+		HalfOpenMaxTries: 2,
+	})
+
+	if _, err := cbGateway.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 2); err != nil {
+		t.Fatalf("❌ Read through circuit breaker failed: %v", err)
+	}
+	t.Logf("✅ Circuit breaker wrapper delegates to RTU socket gateway")
+}
+
+// TestUSRGatewayUsesRTUFraming verifies the MQTT gateway builds the same frames as the socket transport
+func TestUSRGatewayUsesRTUFraming(t *testing.T) {
+	usr := NewUSRGateway(&config.MQTTConfig{
+		Broker:  "test",
+		Port:    1883,
+		Gateway: config.GatewayConfig{MAC: "TEST123456", CmdTopic: "test/cmd", DataTopic: "test/data"},
+	})
+
+	got := usr.buildModbusCommand(1, 0x03, 0x2000, 2)
+	want := buildRTUFrame(1, buildReadPDU(0x03, 0x2000, 2))
+	if string(got) != string(want) {
+		t.Errorf("❌ Expected %02X, got %02X", want, got)
+	}
+	t.Logf("✅ USR command frame %02X", got)
+}
@@ -0,0 +1,338 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mqtt-modbus-bridge/pkg/logger"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	rtuWriteTimeout = 5 * time.Second
+	rtuDrainWindow  = 2 * time.Millisecond // How long to wait for stale bytes before a new request
+	rtuMaxDrainRead = 16                   // Upper bound on stale reads, so a noisy line cannot stall the bus
+)
+
+// rtuConn is a raw byte link carrying Modbus RTU frames (serial port or socket)
+type rtuConn interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// rtuPendingRequest tracks the request currently waiting for a response on the bus
+type rtuPendingRequest struct {
+	slaveID      uint8
+	functionCode uint8
+	address      uint16
+	active       bool
+}
+
+// rtuTransport implements the Gateway interface on top of a raw Modbus RTU byte link
+// Single Responsibility Principle - only handles RTU framing, bus timing and response reassembly
+//
+// Concrete gateways (serial port, transparent socket) embed it and only provide
+// the function that opens the link.
+type rtuTransport struct {
+	name       string                                     // Link description for logs (e.g. "serial /dev/ttyUSB0")
+	open       func(ctx context.Context) (rtuConn, error) // Opens a new link
+	frameDelay time.Duration                              // Minimum bus silence between frames (t3.5)
+	retryDelay int                                        // Startup retry delay in milliseconds
+
+	conn      rtuConn
+	mu        sync.RWMutex // Protect conn and connected
+	connected bool
+
+	busMutex  sync.Mutex // RTU is half-duplex: one transaction on the bus at a time
+	lastFrame time.Time  // End of the last frame sent or received
+	pending   rtuPendingRequest
+}
+
+// Connect opens the link with infinite retry
+func (t *rtuTransport) Connect(ctx context.Context) error {
+	return connectWithRetry(ctx, t.name, t.retryDelay, t.dial)
+}
+
+// dial opens a new link, replacing any existing one
+func (t *rtuTransport) dial(ctx context.Context) error {
+	conn, err := t.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	if t.conn != nil {
+		_ = t.conn.Close()
+	}
+	t.conn = conn
+	t.connected = true
+	t.mu.Unlock()
+	return nil
+}
+
+// getConn returns the current link, reopening it once if it was dropped
+func (t *rtuTransport) getConn(ctx context.Context) (rtuConn, error) {
+	t.mu.RLock()
+	conn := t.conn
+	t.mu.RUnlock()
+	if conn != nil {
+		return conn, nil
+	}
+
+	logger.LogInfo("🔄 Reconnecting to %s...", t.name)
+	if err := t.dial(ctx); err != nil {
+		return nil, fmt.Errorf("gateway is not connected: %w", err)
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.conn, nil
+}
+
+// closeConn drops the current link so the next request reopens it
+func (t *rtuTransport) closeConn() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn != nil {
+		_ = t.conn.Close()
+		t.conn = nil
+	}
+	t.connected = false
+}
+
+// IsConnected checks if the link is open
+func (t *rtuTransport) IsConnected() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.connected
+}
+
+// Disconnect closes the link
+func (t *rtuTransport) Disconnect() {
+	t.closeConn()
+}
+
+// SendCommand sends a Modbus RTU request - implements Gateway interface
+func (t *rtuTransport) SendCommand(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16) error {
+	t.busMutex.Lock()
+	defer t.busMutex.Unlock()
+	return t.send(ctx, slaveID, buildReadPDU(functionCode, address, count))
+}
+
+// WaitForResponse waits for the response to the last request - implements Gateway interface
+func (t *rtuTransport) WaitForResponse(ctx context.Context, timeoutSeconds int) ([]byte, error) {
+	t.busMutex.Lock()
+	defer t.busMutex.Unlock()
+	return t.receive(ctx, timeoutSeconds)
+}
+
+// SendCommandAndWaitForResponse sends a request and waits for the matching response atomically
+// The bus is held for the whole transaction; responses are matched by slave ID and
+// function code and stale bytes from earlier timed-out requests are discarded.
+// If the link fails, the transaction is retried once on a reopened link.
+func (t *rtuTransport) SendCommandAndWaitForResponse(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16, timeoutSeconds int) ([]byte, error) {
+	t.busMutex.Lock()
+	defer t.busMutex.Unlock()
+	return t.transact(ctx, slaveID, buildReadPDU(functionCode, address, count), timeoutSeconds)
+}
+
+// Write sends a write request and verifies the echoed response - implements Gateway interface
+func (t *rtuTransport) Write(ctx context.Context, slaveID uint8, req *WriteRequest, timeoutSeconds int) error {
+	t.busMutex.Lock()
+	defer t.busMutex.Unlock()
+
+	data, err := t.transact(ctx, slaveID, req.PDU(), timeoutSeconds)
+	if err != nil {
+		return err
+	}
+	return req.verifyEcho(data)
+}
+
+// ReadDeviceIdentification reads the basic device identification objects (FC 0x2B/0x0E) - implements Gateway interface
+func (t *rtuTransport) ReadDeviceIdentification(ctx context.Context, slaveID uint8, timeoutSeconds int) (*DeviceIdentification, error) {
+	t.busMutex.Lock()
+	defer t.busMutex.Unlock()
+
+	return readDeviceIdentification(func(pdu []byte) ([]byte, error) {
+		return t.transact(ctx, slaveID, pdu, timeoutSeconds)
+	})
+}
+
+// transact sends one request PDU and waits for its response (caller holds busMutex)
+func (t *rtuTransport) transact(ctx context.Context, slaveID uint8, pdu []byte, timeoutSeconds int) ([]byte, error) {
+	var lastErr error
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := t.send(ctx, slaveID, pdu); err != nil {
+			lastErr = err
+		} else {
+			data, err := t.receive(ctx, timeoutSeconds)
+			if err == nil {
+				return data, nil
+			}
+			lastErr = err
+		}
+
+		if !errors.Is(lastErr, errConnectionLost) || ctx.Err() != nil {
+			break
+		}
+		logger.LogWarn("⚠️ Link to %s lost (Slave %d, FC 0x%02X), retrying on reopened link", t.name, slaveID, pdu[0])
+	}
+
+	return nil, lastErr
+}
+
+// send waits for the inter-frame silence, discards stale input and writes the RTU frame
+func (t *rtuTransport) send(ctx context.Context, slaveID uint8, pdu []byte) error {
+	conn, err := t.getConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Respect t3.5 silence since the previous frame on the bus
+	if wait := time.Until(t.lastFrame.Add(t.frameDelay)); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	if err := t.discardInput(conn); err != nil {
+		t.closeConn()
+		return fmt.Errorf("%w: %v", errConnectionLost, err)
+	}
+
+	frame := buildRTUFrame(slaveID, pdu)
+	logger.LogDebug("Gateway sending modbus RTU command: %02X to %s", frame, t.name)
+
+	_ = conn.SetWriteDeadline(time.Now().Add(rtuWriteTimeout))
+	if _, err := conn.Write(frame); err != nil {
+		t.closeConn()
+		return fmt.Errorf("%w: error sending command: %v", errConnectionLost, err)
+	}
+	t.lastFrame = time.Now()
+
+	t.pending = rtuPendingRequest{
+		slaveID:      slaveID,
+		functionCode: pdu[0],
+		address:      pduAddress(pdu),
+		active:       true,
+	}
+	return nil
+}
+
+// discardInput drops bytes already waiting on the link (late responses, line noise)
+func (t *rtuTransport) discardInput(conn rtuConn) error {
+	buf := make([]byte, rtuMaxFrameLength)
+	for i := 0; i < rtuMaxDrainRead; i++ {
+		_ = conn.SetReadDeadline(time.Now().Add(rtuDrainWindow))
+		n, err := conn.Read(buf)
+		if n > 0 {
+			logger.LogWarn("Discarded %d stale bytes from %s: %02X", n, t.name, buf[:n])
+			continue
+		}
+		if err == nil || isTimeout(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// receive reads from the link until a response matching the pending request is reassembled
+func (t *rtuTransport) receive(ctx context.Context, timeoutSeconds int) ([]byte, error) {
+	if !t.pending.active {
+		return nil, fmt.Errorf("no modbus RTU request pending")
+	}
+	request := t.pending
+	t.pending.active = false
+
+	t.mu.RLock()
+	conn := t.conn
+	t.mu.RUnlock()
+	if conn == nil {
+		return nil, fmt.Errorf("%w: gateway is not connected", errConnectionLost)
+	}
+
+	deadline := time.Now().Add(time.Duration(timeoutSeconds) * time.Second)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = conn.SetReadDeadline(deadline)
+
+	// Unblock the read immediately if the context is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	var buffer []byte
+	chunk := make([]byte, rtuMaxFrameLength)
+	for {
+		// Reassemble frames split across reads and skip frames for other requests
+		for {
+			frame, rest, ok := extractRTUFrame(buffer)
+			buffer = rest
+			if !ok {
+				break
+			}
+			t.lastFrame = time.Now()
+
+			slaveID, functionCode := frame[0], frame[1]
+			if slaveID != request.slaveID || functionCode&^exceptionFlag != request.functionCode {
+				logger.LogWarn("Received unexpected response (Slave=%d, Func=0x%02X) but expecting (Slave=%d, Func=0x%02X), ignoring",
+					slaveID, functionCode, request.slaveID, request.functionCode)
+				continue
+			}
+
+			data, err := parseResponsePDU(request.slaveID, request.functionCode, request.address, frame[1:len(frame)-2])
+			if err != nil {
+				return nil, err
+			}
+			logger.LogDebug("Gateway received valid response from Slave %d: %02X", slaveID, data)
+			return data, nil
+		}
+
+		n, err := conn.Read(chunk)
+		if n > 0 {
+			logger.LogTrace("Gateway received %d bytes from %s: %02X", n, t.name, chunk[:n])
+			buffer = append(buffer, chunk[:n]...)
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if isTimeout(err) {
+				if len(buffer) > 0 {
+					return nil, fmt.Errorf("timeout waiting for response (%d seconds), incomplete frame: %02X", timeoutSeconds, buffer)
+				}
+				return nil, fmt.Errorf("timeout waiting for response (%d seconds)", timeoutSeconds)
+			}
+			t.closeConn()
+			return nil, fmt.Errorf("%w: %v", errConnectionLost, err)
+		}
+	}
+}
+
+// SendDiagnosticCommand verifies that the link is open, reopening it if needed
+func (t *rtuTransport) SendDiagnosticCommand(ctx context.Context) error {
+	t.busMutex.Lock()
+	defer t.busMutex.Unlock()
+
+	if _, err := t.getConn(ctx); err != nil {
+		return fmt.Errorf("diagnostic check failed: %w", err)
+	}
+	return nil
+}
+
+// isTimeout reports whether err is a read/write deadline expiry
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
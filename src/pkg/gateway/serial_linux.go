@@ -0,0 +1,108 @@
+//go:build linux
+
+package gateway
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// linuxBaudRates maps baud rates to termios speed constants
+var linuxBaudRates = map[int]uint32{
+	1200:   syscall.B1200,
+	2400:   syscall.B2400,
+	4800:   syscall.B4800,
+	9600:   syscall.B9600,
+	19200:  syscall.B19200,
+	38400:  syscall.B38400,
+	57600:  syscall.B57600,
+	115200: syscall.B115200,
+}
+
+// openSerialPort opens the tty and puts it in raw mode with the configured line settings
+// The file is opened non-blocking so read deadlines work through the runtime poller
+func openSerialPort(cfg *config.SerialConfig) (*os.File, error) {
+	speed, ok := linuxBaudRates[cfg.BaudRate]
+	if !ok {
+		return nil, fmt.Errorf("unsupported baud rate: %d", cfg.BaudRate)
+	}
+
+	file, err := os.OpenFile(cfg.Device, os.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error opening serial port %s: %w", cfg.Device, err)
+	}
+
+	// Use SyscallConn instead of Fd(): Fd() switches the file to blocking mode and disables deadlines
+	rawConn, err := file.SyscallConn()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error accessing serial port %s: %w", cfg.Device, err)
+	}
+
+	var ioctlErr error
+	controlErr := rawConn.Control(func(fd uintptr) {
+		var termios syscall.Termios
+		if ioctlErr = ioctlTermios(fd, syscall.TCGETS, &termios); ioctlErr != nil {
+			return
+		}
+		setRawMode(&termios, cfg, speed)
+		ioctlErr = ioctlTermios(fd, syscall.TCSETS, &termios)
+	})
+	if controlErr != nil || ioctlErr != nil {
+		_ = file.Close()
+		if controlErr != nil {
+			return nil, fmt.Errorf("error configuring serial port %s: %w", cfg.Device, controlErr)
+		}
+		return nil, fmt.Errorf("error configuring serial port %s: %w", cfg.Device, ioctlErr)
+	}
+
+	return file, nil
+}
+
+// setRawMode configures termios for binary Modbus RTU traffic (no echo, no line editing)
+func setRawMode(t *syscall.Termios, cfg *config.SerialConfig, speed uint32) {
+	t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON | syscall.IXOFF | syscall.IXANY | syscall.INPCK
+	t.Oflag &^= syscall.OPOST
+	t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+
+	t.Cflag &^= syscall.CSIZE | syscall.PARENB | syscall.PARODD | syscall.CSTOPB | termiosCBAUD
+	t.Cflag |= syscall.CREAD | syscall.CLOCAL | speed
+
+	if cfg.DataBits == 7 {
+		t.Cflag |= syscall.CS7
+	} else {
+		t.Cflag |= syscall.CS8
+	}
+
+	switch cfg.Parity {
+	case "E":
+		t.Cflag |= syscall.PARENB
+		t.Iflag |= syscall.INPCK
+	case "O":
+		t.Cflag |= syscall.PARENB | syscall.PARODD
+		t.Iflag |= syscall.INPCK
+	}
+
+	if cfg.StopBits == 2 {
+		t.Cflag |= syscall.CSTOPB
+	}
+
+	setTermiosSpeed(t, speed)
+
+	// Return from read as soon as one byte is available; frame assembly is done by rtuTransport
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+}
+
+// ioctlTermios gets or sets the terminal attributes of fd
+func ioctlTermios(fd uintptr, request uintptr, t *syscall.Termios) error {
+	// #nosec G103 -- termios ioctl requires passing the struct address to the kernel
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le && !ppc64 && !ppc64le
+
+package gateway
+
+import "syscall"
+
+// termiosCBAUD is the baud rate mask (CBAUD|CBAUDEX); syscall does not define it
+const termiosCBAUD = 0x100f
+
+// setTermiosSpeed sets the input and output speed fields (the speed is also in Cflag)
+func setTermiosSpeed(t *syscall.Termios, speed uint32) {
+	t.Ispeed = speed
+	t.Ospeed = speed
+}
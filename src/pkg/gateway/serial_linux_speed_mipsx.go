@@ -0,0 +1,11 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le)
+
+package gateway
+
+import "syscall"
+
+// termiosCBAUD is the baud rate mask (CBAUD|CBAUDEX) of the MIPS termios
+const termiosCBAUD = 0x100f
+
+// setTermiosSpeed is a no-op: the MIPS termios has no speed fields, the speed is only in Cflag
+func setTermiosSpeed(t *syscall.Termios, speed uint32) {}
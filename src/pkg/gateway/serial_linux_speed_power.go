@@ -0,0 +1,14 @@
+//go:build linux && (ppc64 || ppc64le)
+
+package gateway
+
+import "syscall"
+
+// termiosCBAUD is the baud rate mask of the PowerPC termios (no CBAUDEX, speeds fit in the low byte)
+const termiosCBAUD = 0xff
+
+// setTermiosSpeed sets the input and output speed fields (the speed is also in Cflag)
+func setTermiosSpeed(t *syscall.Termios, speed uint32) {
+	t.Ispeed = speed
+	t.Ospeed = speed
+}
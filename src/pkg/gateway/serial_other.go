@@ -0,0 +1,14 @@
+//go:build !linux
+
+package gateway
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"os"
+)
+
+// openSerialPort is only implemented for Linux (termios via ioctl)
+func openSerialPort(cfg *config.SerialConfig) (*os.File, error) {
+	return nil, fmt.Errorf("serial RTU gateway is only supported on Linux (device %s)", cfg.Device)
+}
@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"context"
+	"mqtt-modbus-bridge/pkg/config"
+	"time"
+)
+
+// SerialRTUGateway implementation of a Modbus RTU master on a local serial port
+// Single Responsibility Principle - only opens and configures the serial line;
+// RTU framing and bus timing are handled by the embedded rtuTransport
+//
+// Used with USB RS-485 dongles, so meters can be polled without a USR-DR164.
+type SerialRTUGateway struct {
+	*rtuTransport
+	config *config.SerialConfig
+}
+
+// NewSerialRTUGateway creates a new serial RTU gateway
+func NewSerialRTUGateway(cfg *config.SerialConfig) *SerialRTUGateway {
+	gateway := &SerialRTUGateway{config: cfg}
+	gateway.rtuTransport = &rtuTransport{
+		name:       "serial " + cfg.Device,
+		frameDelay: serialFrameDelay(cfg),
+		retryDelay: cfg.RetryDelay,
+		open: func(ctx context.Context) (rtuConn, error) {
+			return openSerialPort(cfg)
+		},
+	}
+	return gateway
+}
+
+// serialFrameDelay returns the t3.5 inter-frame silence for the serial line
+// Per the Modbus serial line spec it is 3.5 character times, fixed at 1.75ms above 19200 baud
+func serialFrameDelay(cfg *config.SerialConfig) time.Duration {
+	if cfg.InterFrameDelay > 0 {
+		return time.Duration(cfg.InterFrameDelay) * time.Microsecond
+	}
+	if cfg.BaudRate <= 0 {
+		return 0
+	}
+	if cfg.BaudRate > 19200 {
+		return 1750 * time.Microsecond
+	}
+
+	// Character = start bit + data bits + parity bit + stop bits
+	bitsPerChar := 1 + cfg.DataBits + cfg.StopBits
+	if cfg.Parity != "" && cfg.Parity != "N" {
+		bitsPerChar++
+	}
+	return time.Duration(float64(time.Second) * 3.5 * float64(bitsPerChar) / float64(cfg.BaudRate))
+}
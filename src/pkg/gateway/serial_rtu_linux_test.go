@@ -0,0 +1,417 @@
+//go:build linux
+
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/crc"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// openPTY opens a pseudo-terminal pair and returns the master file and the slave device path
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("Pseudo-terminals not available: %v", err)
+	}
+
+	rawConn, err := master.SyscallConn()
+	if err != nil {
+		t.Fatalf("Failed to access pty master: %v", err)
+	}
+
+	var ptyNumber uint32
+	var ioctlErr error
+	_ = rawConn.Control(func(fd uintptr) {
+		unlock := int32(0)
+		// #nosec G103 -- ioctl arguments
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+			ioctlErr = errno
+			return
+		}
+		// #nosec G103 -- ioctl arguments
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&ptyNumber))); errno != 0 {
+			ioctlErr = errno
+		}
+	})
+	if ioctlErr != nil {
+		_ = master.Close()
+		t.Skipf("Pseudo-terminal setup failed: %v", ioctlErr)
+	}
+
+	t.Cleanup(func() { _ = master.Close() })
+	return master, fmt.Sprintf("/dev/pts/%d", ptyNumber)
+}
+
+// mockRTUSlave answers Modbus RTU read requests on the pty master side
+// Register values are address-derived (value = slaveID*1000 + address)
+type mockRTUSlave struct {
+	master *os.File
+
+	mu         sync.Mutex
+	requests   [][]byte
+	arrivals   []time.Time
+	responded  []time.Time
+	silent     bool   // Never answer
+	split      bool   // Write the response in two chunks with a pause in between
+	noise      []byte // Bytes written before the response
+	corruptCRC bool   // Flip the CRC of the response
+	otherSlave bool   // Send a response for another slave before the real one
+}
+
+func newMockRTUSlave(master *os.File) *mockRTUSlave {
+	slave := &mockRTUSlave{master: master}
+	go slave.serve()
+	return slave
+}
+
+func (s *mockRTUSlave) serve() {
+	var buffer []byte
+	chunk := make([]byte, 256)
+	for {
+		n, err := s.master.Read(chunk)
+		if err != nil {
+			return
+		}
+		buffer = append(buffer, chunk[:n]...)
+
+		// Read requests are always 8 bytes
+		for len(buffer) >= 8 {
+			request := append([]byte(nil), buffer[:8]...)
+			buffer = buffer[8:]
+			s.respond(request)
+		}
+	}
+}
+
+func (s *mockRTUSlave) respond(request []byte) {
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	s.arrivals = append(s.arrivals, time.Now())
+	silent, split, noise, corrupt, other := s.silent, s.split, s.noise, s.corruptCRC, s.otherSlave
+	s.mu.Unlock()
+
+	if silent || !crc.VerifyCRC(request) {
+		return
+	}
+
+	slaveID := request[0]
+	functionCode := request[1]
+	address := binary.BigEndian.Uint16(request[2:4])
+	count := binary.BigEndian.Uint16(request[4:6])
+
+	if other {
+		_, _ = s.master.Write(rtuReadResponse(slaveID+1, functionCode, address, count))
+	}
+	if len(noise) > 0 {
+		_, _ = s.master.Write(noise)
+	}
+
+	response := rtuReadResponse(slaveID, functionCode, address, count)
+	if corrupt {
+		response[len(response)-1] ^= 0xFF
+	}
+
+	if split {
+		_, _ = s.master.Write(response[:3])
+		time.Sleep(20 * time.Millisecond)
+		_, _ = s.master.Write(response[3:])
+	} else {
+		_, _ = s.master.Write(response)
+	}
+
+	s.mu.Lock()
+	s.responded = append(s.responded, time.Now())
+	s.mu.Unlock()
+}
+
+func (s *mockRTUSlave) set(fn func(s *mockRTUSlave)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s)
+}
+
+// connectSerialGateway opens a pty pair and connects a serial gateway to its slave side
+func connectSerialGateway(t *testing.T, cfg config.SerialConfig) (*SerialRTUGateway, *mockRTUSlave) {
+	t.Helper()
+
+	master, device := openPTY(t)
+	cfg.Device = device
+	if cfg.BaudRate == 0 {
+		cfg.BaudRate = 9600
+	}
+	if cfg.DataBits == 0 {
+		cfg.DataBits = 8
+	}
+	if cfg.StopBits == 0 {
+		cfg.StopBits = 1
+	}
+	if cfg.Parity == "" {
+		cfg.Parity = "N"
+	}
+
+	gw := NewSerialRTUGateway(&cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := gw.Connect(ctx); err != nil {
+		t.Fatalf("Failed to open %s: %v", device, err)
+	}
+	t.Cleanup(gw.Disconnect)
+
+	return gw, newMockRTUSlave(master)
+}
+
+// TestSerialRTUReadHoldingRegisters verifies a read over the pty pair
+func TestSerialRTUReadHoldingRegisters(t *testing.T) {
+	gw, slave := connectSerialGateway(t, config.SerialConfig{})
+
+	data, err := gw.SendCommandAndWaitForResponse(context.Background(), 11, 0x03, 0x2000, 2, 2)
+	if err != nil {
+		t.Fatalf("❌ Read failed: %v", err)
+	}
+
+	expected := []byte{0x4A, 0xF8, 0x4A, 0xF9} // 11*1000+0x2000 = 0x4AF8
+	if fmt.Sprintf("%02X", data) != fmt.Sprintf("%02X", expected) {
+		t.Fatalf("❌ Expected %02X, got %02X", expected, data)
+	}
+
+	slave.mu.Lock()
+	request := slave.requests[0]
+	slave.mu.Unlock()
+	if want := crc.AppendCRC([]byte{11, 0x03, 0x20, 0x00, 0x00, 0x02}); fmt.Sprintf("%02X", request) != fmt.Sprintf("%02X", want) {
+		t.Errorf("❌ Unexpected request frame %02X (want %02X)", request, want)
+	}
+	t.Logf("✅ Request %02X answered with %02X", request, data)
+}
+
+// TestSerialRTUPartialReads verifies response reassembly across partial reads
+func TestSerialRTUPartialReads(t *testing.T) {
+	gw, slave := connectSerialGateway(t, config.SerialConfig{})
+	slave.set(func(s *mockRTUSlave) { s.split = true })
+
+	data, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x04, 0x0100, 4, 2)
+	if err != nil {
+		t.Fatalf("❌ Read with split response failed: %v", err)
+	}
+	if len(data) != 8 || binary.BigEndian.Uint16(data[6:8]) != 1000+0x0103 {
+		t.Errorf("❌ Unexpected payload %02X", data)
+	}
+	t.Logf("✅ Split response reassembled: %02X", data)
+}
+
+// TestSerialRTUResyncAfterNoise verifies that garbage before the response is skipped
+func TestSerialRTUResyncAfterNoise(t *testing.T) {
+	gw, slave := connectSerialGateway(t, config.SerialConfig{})
+	slave.set(func(s *mockRTUSlave) { s.noise = []byte{0x00, 0xFF, 0x03, 0x7E} })
+
+	data, err := gw.SendCommandAndWaitForResponse(context.Background(), 2, 0x03, 0x0000, 1, 2)
+	if err != nil {
+		t.Fatalf("❌ Read after noise failed: %v", err)
+	}
+	if binary.BigEndian.Uint16(data) != 2000 {
+		t.Errorf("❌ Unexpected payload %02X", data)
+	}
+	t.Logf("✅ Noise skipped, payload %02X", data)
+}
+
+// TestSerialRTUIgnoresOtherSlaves verifies responses are matched by slave ID
+func TestSerialRTUIgnoresOtherSlaves(t *testing.T) {
+	gw, slave := connectSerialGateway(t, config.SerialConfig{})
+	slave.set(func(s *mockRTUSlave) { s.otherSlave = true })
+
+	data, err := gw.SendCommandAndWaitForResponse(context.Background(), 5, 0x03, 0x0010, 1, 2)
+	if err != nil {
+		t.Fatalf("❌ Read failed: %v", err)
+	}
+	if binary.BigEndian.Uint16(data) != 5000+0x10 {
+		t.Errorf("❌ Got response for wrong slave: %02X", data)
+	}
+	t.Logf("✅ Response from other slave ignored")
+}
+
+// TestSerialRTUBadCRC verifies that a corrupted response is rejected
+func TestSerialRTUBadCRC(t *testing.T) {
+	gw, slave := connectSerialGateway(t, config.SerialConfig{})
+	slave.set(func(s *mockRTUSlave) { s.corruptCRC = true })
+
+	if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 1); err == nil {
+		t.Fatal("❌ Expected error for corrupted CRC")
+	}
+
+	// Stale corrupted bytes must not break the next request
+	slave.set(func(s *mockRTUSlave) { s.corruptCRC = false })
+	if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 1); err != nil {
+		t.Fatalf("❌ Read after CRC error failed: %v", err)
+	}
+	t.Logf("✅ Corrupted response rejected, next request succeeded")
+}
+
+// TestSerialRTUTimeout verifies timeout handling when the slave does not answer
+func TestSerialRTUTimeout(t *testing.T) {
+	gw, slave := connectSerialGateway(t, config.SerialConfig{})
+	slave.set(func(s *mockRTUSlave) { s.silent = true })
+
+	start := time.Now()
+	_, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x0000, 2, 1)
+	if err == nil {
+		t.Fatal("❌ Expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("❌ Timeout took too long: %v", elapsed)
+	}
+	if !gw.IsConnected() {
+		t.Error("❌ Timeout must not close the serial port")
+	}
+	t.Logf("✅ Timeout reported: %v", err)
+}
+
+// TestSerialRTUInterFrameDelay verifies the configured t3.5 silence between frames
+func TestSerialRTUInterFrameDelay(t *testing.T) {
+	const delay = 40 * time.Millisecond
+	gw, slave := connectSerialGateway(t, config.SerialConfig{InterFrameDelay: int(delay / time.Microsecond)})
+
+	for i := 0; i < 3; i++ {
+		if _, err := gw.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, uint16(i), 1, 2); err != nil {
+			t.Fatalf("❌ Read %d failed: %v", i, err)
+		}
+	}
+
+	slave.mu.Lock()
+	defer slave.mu.Unlock()
+	for i := 1; i < len(slave.arrivals); i++ {
+		gap := slave.arrivals[i].Sub(slave.responded[i-1])
+		if gap < delay-5*time.Millisecond {
+			t.Errorf("❌ Request %d sent %v after previous response, expected >= %v", i, gap, delay)
+		}
+	}
+	t.Logf("✅ Inter-frame silence of %v respected", delay)
+}
+
+// TestSerialRTUConcurrentRequests verifies that the bus serializes concurrent callers
+func TestSerialRTUConcurrentRequests(t *testing.T) {
+	gw, _ := connectSerialGateway(t, config.SerialConfig{BaudRate: 115200})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(slaveID uint8, address uint16) {
+			defer wg.Done()
+			data, err := gw.SendCommandAndWaitForResponse(context.Background(), slaveID, 0x03, address, 1, 2)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if got := binary.BigEndian.Uint16(data); got != uint16(slaveID)*1000+address {
+				errs <- fmt.Errorf("slave %d address %d: got %d", slaveID, address, got)
+			}
+		}(uint8(i%3+1), uint16(i))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("❌ Concurrent request failed: %v", err)
+	}
+	t.Logf("✅ 10 concurrent requests serialized on the bus")
+}
+
+// TestSerialRTULineSettings verifies that baud rate, parity and stop bits reach the tty
+func TestSerialRTULineSettings(t *testing.T) {
+	gw, _ := connectSerialGateway(t, config.SerialConfig{BaudRate: 19200, Parity: "E", StopBits: 2})
+
+	file, ok := gw.conn.(*os.File)
+	if !ok {
+		t.Fatalf("❌ Expected *os.File connection, got %T", gw.conn)
+	}
+	rawConn, err := file.SyscallConn()
+	if err != nil {
+		t.Fatalf("❌ SyscallConn failed: %v", err)
+	}
+
+	var termios syscall.Termios
+	var ioctlErr error
+	_ = rawConn.Control(func(fd uintptr) {
+		ioctlErr = ioctlTermios(fd, syscall.TCGETS, &termios)
+	})
+	if ioctlErr != nil {
+		t.Fatalf("❌ TCGETS failed: %v", ioctlErr)
+	}
+
+	if termios.Cflag&termiosCBAUD != syscall.B19200 {
+		t.Errorf("❌ Expected B19200, got cflag 0x%X", termios.Cflag&termiosCBAUD)
+	}
+	if termios.Cflag&syscall.CSTOPB == 0 {
+		t.Error("❌ Expected 2 stop bits")
+	}
+	if termios.Lflag&(syscall.ICANON|syscall.ECHO) != 0 {
+		t.Error("❌ Expected raw mode (no ICANON/ECHO)")
+	}
+	t.Logf("✅ Line configured as 19200 baud, 2 stop bits, raw")
+}
+
+// TestSerialParitySettings verifies the termios parity flags
+// Checked on the struct because the pty driver always forces 8N on its side
+func TestSerialParitySettings(t *testing.T) {
+	tests := []struct {
+		parity     string
+		wantParenb bool
+		wantParodd bool
+	}{
+		{"N", false, false},
+		{"E", true, false},
+		{"O", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.parity, func(t *testing.T) {
+			termios := syscall.Termios{Cflag: syscall.PARENB | syscall.PARODD}
+			setRawMode(&termios, &config.SerialConfig{DataBits: 8, Parity: tt.parity, StopBits: 1}, syscall.B9600)
+
+			if got := termios.Cflag&syscall.PARENB != 0; got != tt.wantParenb {
+				t.Errorf("❌ PARENB = %v, expected %v", got, tt.wantParenb)
+			}
+			if got := termios.Cflag&syscall.PARODD != 0; got != tt.wantParodd {
+				t.Errorf("❌ PARODD = %v, expected %v", got, tt.wantParodd)
+			}
+			if termios.Cflag&termiosCBAUD != syscall.B9600 {
+				t.Errorf("❌ Expected B9600, got 0x%X", termios.Cflag&termiosCBAUD)
+			}
+		})
+	}
+}
+
+// TestSerialFrameDelay verifies the t3.5 calculation from line settings
+func TestSerialFrameDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.SerialConfig
+		expected time.Duration
+	}{
+		{"9600 8N1", config.SerialConfig{BaudRate: 9600, DataBits: 8, Parity: "N", StopBits: 1}, 3645833 * time.Nanosecond},
+		{"9600 8E1", config.SerialConfig{BaudRate: 9600, DataBits: 8, Parity: "E", StopBits: 1}, 4010416 * time.Nanosecond},
+		{"2400 8N2", config.SerialConfig{BaudRate: 2400, DataBits: 8, Parity: "N", StopBits: 2}, 16041666 * time.Nanosecond},
+		{"38400 fixed", config.SerialConfig{BaudRate: 38400, DataBits: 8, Parity: "N", StopBits: 1}, 1750 * time.Microsecond},
+		{"explicit override", config.SerialConfig{BaudRate: 9600, DataBits: 8, StopBits: 1, InterFrameDelay: 5000}, 5 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serialFrameDelay(&tt.cfg)
+			diff := got - tt.expected
+			if diff < -time.Microsecond || diff > time.Microsecond {
+				t.Errorf("❌ Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
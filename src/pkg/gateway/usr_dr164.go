@@ -2,7 +2,6 @@ package gateway
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
 	"mqtt-modbus-bridge/pkg/config"
 	"mqtt-modbus-bridge/pkg/crc"
@@ -13,25 +12,30 @@ import (
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
+// Timing of transactions on the DR164 bus
+const (
+	usrInterCommandDelay = 50 * time.Millisecond // Pause after each transaction to prevent gateway overload
+	usrLateResponseGrace = 50 * time.Millisecond // Bus stays reserved after a timeout so late responses land before the next request
+)
+
 // USRGateway implementation of USR-DR164 gateway
 // Single Responsibility Principle - only handles MQTT communication with gateway
+//
+// Responses arrive asynchronously on the data topic. Each request is registered in an
+// in-flight request table keyed by slave ID + function code, and onMessage delivers every
+// response to the request it belongs to.
 type USRGateway struct {
-	client       mqtt.Client
-	config       *config.MQTTConfig
-	responseChan chan []byte
-	mu           sync.RWMutex
-	connected    bool
-	commandMutex sync.Mutex // Synchronize command/response pairs GLOBALLY
-
-	// Track expected response for validation
-	expectedSlaveID      uint8
-	expectedFunctionCode uint8
-	requestCounter       uint64 // Unique ID for each request
-	expectedRequestID    uint64 // Expected request ID for current response
-
-	// Per-slave mutexes to allow parallel requests to different slaves
-	slaveMutexes map[uint8]*sync.Mutex
-	slaveMu      sync.Mutex // Protect slaveMutexes map
+	client    mqtt.Client
+	config    *config.MQTTConfig
+	mu        sync.RWMutex
+	connected bool
+
+	// The DR164 forwards requests to a single half-duplex RS-485 bus,
+	// so only one transaction may be on the bus at a time
+	busMutex sync.Mutex
+
+	requests    *requestTable   // In-flight requests awaiting their response
+	lastRequest *pendingRequest // Request sent by SendCommand, awaited by WaitForResponse (protected by busMutex)
 }
 
 // NewUSRGateway creates a new USR-DR164 gateway
@@ -46,9 +50,8 @@ func NewUSRGateway(cfg *config.MQTTConfig) *USRGateway {
 	opts.SetPingTimeout(10 * time.Second)
 
 	gateway := &USRGateway{
-		config:       cfg,
-		responseChan: make(chan []byte, 10),
-		slaveMutexes: make(map[uint8]*sync.Mutex),
+		config:   cfg,
+		requests: newRequestTable(),
 	}
 
 	// Connection status callbacks
@@ -151,8 +154,35 @@ func (g *USRGateway) IsConnected() bool {
 }
 
 // SendCommand sends a Modbus command through MQTT - implements modbus.Gateway interface
+// The request is registered so that the next WaitForResponse receives its response
 func (g *USRGateway) SendCommand(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16) error {
-	// Wait for connection with short retry (handle brief disconnections during auto-reconnect)
+	g.busMutex.Lock()
+	defer g.busMutex.Unlock()
+
+	req := g.requests.register(slaveID, functionCode, address, count)
+	if err := g.publish(ctx, g.buildModbusCommand(slaveID, functionCode, address, count)); err != nil {
+		g.requests.remove(req)
+		return err
+	}
+	g.lastRequest = req
+	return nil
+}
+
+// WaitForResponse waits for the response to the last SendCommand - implements modbus.Gateway interface
+func (g *USRGateway) WaitForResponse(ctx context.Context, timeoutSeconds int) ([]byte, error) {
+	g.busMutex.Lock()
+	defer g.busMutex.Unlock()
+
+	req := g.lastRequest
+	g.lastRequest = nil
+	if req == nil {
+		return nil, fmt.Errorf("no request waiting for a response")
+	}
+	return g.receive(ctx, req, timeoutSeconds)
+}
+
+// waitForConnection waits briefly for the MQTT connection (handles brief disconnections during auto-reconnect)
+func (g *USRGateway) waitForConnection() error {
 	maxWait := 3 * time.Second
 	deadline := time.Now().Add(maxWait)
 
@@ -162,7 +192,7 @@ func (g *USRGateway) SendCommand(ctx context.Context, slaveID uint8, functionCod
 		g.mu.RUnlock()
 
 		if connected {
-			break
+			return nil
 		}
 
 		// Connection not ready, wait a bit before retrying
@@ -177,18 +207,15 @@ func (g *USRGateway) SendCommand(ctx context.Context, slaveID uint8, functionCod
 	if !connected {
 		return fmt.Errorf("gateway is not connected")
 	}
+	return nil
+}
 
-	// Clear response channel to avoid stale responses
-	select {
-	case <-g.responseChan:
-		// Drained one stale response
-	default:
-		// Channel was already empty
+// publish sends a raw Modbus RTU command to the gateway command topic
+func (g *USRGateway) publish(ctx context.Context, command []byte) error {
+	if err := g.waitForConnection(); err != nil {
+		return err
 	}
 
-	// Create Modbus RTU command as binary data
-	command := g.buildModbusCommand(slaveID, functionCode, address, count)
-
 	// Send command with timeout
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -211,20 +238,33 @@ func (g *USRGateway) SendCommand(ctx context.Context, slaveID uint8, functionCod
 	return nil
 }
 
-// WaitForResponse waits for response from gateway - implements modbus.Gateway interface
-func (g *USRGateway) WaitForResponse(ctx context.Context, timeoutSeconds int) ([]byte, error) {
-	timeout := time.Duration(timeoutSeconds) * time.Second
+// receive waits for the response delivered to a registered request
+// On timeout the request is expired, so its response is accounted as late if it still arrives
+func (g *USRGateway) receive(ctx context.Context, req *pendingRequest, timeoutSeconds int) ([]byte, error) {
+	timer := time.NewTimer(time.Duration(timeoutSeconds) * time.Second)
+	defer timer.Stop()
 
 	select {
-	case response := <-g.responseChan:
-		return response, nil
-	case <-time.After(timeout):
+	case frame, ok := <-req.response:
+		if !ok {
+			return nil, fmt.Errorf("gateway closed while waiting for response")
+		}
+		logger.LogDebug("Gateway received valid response from Slave %d: %02X", req.key.slaveID, frame)
+		return parseResponsePDU(req.key.slaveID, req.key.functionCode, req.address, frame[1:len(frame)-2])
+	case <-timer.C:
+		g.requests.expire(req)
 		return nil, fmt.Errorf("timeout waiting for response (%d seconds)", timeoutSeconds)
 	case <-ctx.Done():
+		g.requests.expire(req)
 		return nil, ctx.Err()
 	}
 }
 
+// GetResponseStats returns how many responses were delivered, late or unexpected
+func (g *USRGateway) GetResponseStats() ResponseStats {
+	return g.requests.getStats()
+}
+
 // Disconnect closes the gateway connection
 func (g *USRGateway) Disconnect() {
 	g.mu.Lock()
@@ -250,11 +290,8 @@ func (g *USRGateway) Close() {
 		}
 	}
 
-	// Close the response channel safely
-	if g.responseChan != nil {
-		close(g.responseChan)
-		g.responseChan = nil
-	}
+	// Fail any request still waiting for a response
+	g.requests.close()
 }
 
 // onMessage handles incoming MQTT messages
@@ -274,57 +311,13 @@ func (g *USRGateway) onMessage(client mqtt.Client, msg mqtt.Message) {
 		return
 	}
 
-	// Extract Slave ID and Function Code for validation
-	receivedSlaveID := data[0]
-	receivedFunctionCode := data[1]
-
-	// Validate this is the response we're expecting (protect against out-of-order responses)
-	g.commandMutex.Lock()
-	expectedSlaveID := g.expectedSlaveID
-	expectedFunctionCode := g.expectedFunctionCode
-	g.commandMutex.Unlock()
-
-	if receivedSlaveID != expectedSlaveID || receivedFunctionCode != expectedFunctionCode {
-		logger.LogWarn("Received unexpected response (Slave=%d, Func=0x%02X) but expecting (Slave=%d, Func=0x%02X), ignoring",
-			receivedSlaveID, receivedFunctionCode, expectedSlaveID, expectedFunctionCode)
-		return
-	}
-
-	// Extract useful data from Modbus response
-	// For Read Holding Registers (0x03), data starts at position 3
-	if receivedFunctionCode == 0x03 { // Function Code 03
-		byteCount := int(data[2])
-		if len(data) >= 3+byteCount+2 { // +2 for CRC
-			actualData := data[3 : 3+byteCount]
-			logger.LogDebug("Gateway received valid response from Slave %d: %02X", receivedSlaveID, actualData)
-
-			// Send data to channel (non-blocking to prevent deadlock)
-			select {
-			case g.responseChan <- actualData:
-				// Successfully sent
-			default:
-				logger.LogWarn("Response channel full, response ignored (Slave=%d, Func=0x%02X)",
-					receivedSlaveID, receivedFunctionCode)
-			}
-		} else {
-			logger.LogWarn("Invalid byte count in response: expected %d bytes but message too short", byteCount)
-		}
-	} else {
-		logger.LogWarn("Unsupported function code in response: 0x%02X", receivedFunctionCode)
-	}
+	// Hand the frame to the request waiting for this slave ID + function code
+	g.requests.deliver(data)
 }
 
 // buildModbusCommand builds a Modbus RTU command with CRC
 func (g *USRGateway) buildModbusCommand(slaveID uint8, functionCode uint8, address uint16, count uint16) []byte {
-	// Build the command without CRC
-	command := make([]byte, 6)
-	command[0] = slaveID
-	command[1] = functionCode
-	binary.BigEndian.PutUint16(command[2:4], address)
-	binary.BigEndian.PutUint16(command[4:6], count)
-
-	// Append CRC using crc package
-	return crc.AppendCRC(command)
+	return buildRTUFrame(slaveID, buildReadPDU(functionCode, address, count))
 }
 
 // SendDiagnosticCommand sends a diagnostic command to test gateway connectivity
@@ -333,126 +326,82 @@ func (g *USRGateway) SendDiagnosticCommand(ctx context.Context) error {
 		return fmt.Errorf("gateway not connected")
 	}
 
-	// Send a simple read input registers command to test connectivity
+	// Send a simple read holding registers command to test connectivity
 	// This is a basic Modbus command that most devices support
-	diagnosticCommand := g.buildModbusCommand(11, 3, 0, 2) // Read 2 registers starting at address 0
-
-	// logger.LogInfo("🔍 Sending diagnostic command to test gateway connectivity...")
-	// logger.LogDebug("📤 Diagnostic command: %02X", diagnosticCommand)
-
-	if token := g.client.Publish(g.config.Gateway.CmdTopic, 0, false, diagnosticCommand); token.Wait() && token.Error() != nil {
-		return fmt.Errorf("failed to send diagnostic command: %v", token.Error())
-	}
-
-	// Wait for response with longer timeout
-	select {
-	case response := <-g.responseChan:
-		// logger.LogInfo("✅ Diagnostic response received: %02X", response)
-		_ = response // Use the response variable
-		return nil
-	case <-time.After(10 * time.Second):
-		// logger.LogError("❌ Diagnostic command timeout - gateway may not be responding")
-		return fmt.Errorf("diagnostic command timeout")
-	case <-ctx.Done():
-		return fmt.Errorf("context cancelled: %w", ctx.Err())
-	}
-}
-
-// getSlaveMutex returns or creates a mutex for the given slave ID
-func (g *USRGateway) getSlaveMutex(slaveID uint8) *sync.Mutex {
-	g.slaveMu.Lock()
-	defer g.slaveMu.Unlock()
-
-	if _, exists := g.slaveMutexes[slaveID]; !exists {
-		g.slaveMutexes[slaveID] = &sync.Mutex{}
+	if _, err := g.SendCommandAndWaitForResponse(ctx, 11, 3, 0, 2, 10); err != nil {
+		return fmt.Errorf("diagnostic command failed: %w", err)
 	}
-	return g.slaveMutexes[slaveID]
+	return nil
 }
 
-// SendCommandAndWaitForResponse sends a command and waits for response atomically
-// This prevents racing conditions between multiple commands and ensures PER-SLAVE sequential execution
+// SendCommandAndWaitForResponse sends a command and waits for its response atomically
 //
-// CRITICAL: This method uses PER-SLAVE mutex to ensure:
-// 1. Only ONE Modbus transaction per slave at a time (slaves can run in parallel)
-// 2. Each request gets its CORRECT response (validated by SlaveID + FunctionCode)
-// 3. Stale responses from timed-out requests are cleared before new requests
+// CRITICAL: The DR164 forwards every request to the same RS-485 bus, so transactions
+// are serialized with busMutex:
+//  1. Only ONE Modbus transaction is on the bus at a time
+//  2. Each request gets its CORRECT response (in-flight table keyed by SlaveID + FunctionCode,
+//     with the byte count of read responses checked)
+//  3. A response arriving after its request timed out is counted as late; the bus stays
+//     reserved for a short grace period so it lands before the next request is sent
 //
 // Execution flow:
 //
-//	Lock slaveMutex[slaveID] → Clear stale responses → Set expected response params →
-//	Send command → Wait for matching response → Unlock → 50ms delay
-//
-// This allows register groups from DIFFERENT slaves (e.g., Slave 1 and Slave 11)
-// to execute IN PARALLEL, while ensuring groups on the SAME slave remain sequential.
+//	Lock bus → Register request → Send command → Wait for delivered response →
+//	(timeout: expire request, keep bus reserved briefly for late responses) → 50ms delay → Unlock
 func (g *USRGateway) SendCommandAndWaitForResponse(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16, timeoutSeconds int) ([]byte, error) {
-	// Get per-slave mutex to allow parallel requests to different slaves
-	slaveMutex := g.getSlaveMutex(slaveID)
-	slaveMutex.Lock()
-	defer slaveMutex.Unlock()
+	g.busMutex.Lock()
+	defer g.busMutex.Unlock()
+	return g.transact(ctx, slaveID, buildReadPDU(functionCode, address, count), count, timeoutSeconds)
+}
 
-	// CRITICAL: Lock commandMutex only during channel access to prevent race conditions
-	// This ensures response channel is accessed atomically but allows parallel slave requests
-	g.commandMutex.Lock()
+// Write sends a write request and verifies the echoed response - implements Gateway interface
+// Writes share the bus lock, request table and pacing of reads.
+func (g *USRGateway) Write(ctx context.Context, slaveID uint8, req *WriteRequest, timeoutSeconds int) error {
+	g.busMutex.Lock()
+	defer g.busMutex.Unlock()
 
-	// Clear any stale responses from channel before sending new command
-	// This prevents receiving old responses for new requests
-	select {
-	case <-g.responseChan:
-		logger.LogWarn("Cleared stale response from channel before new request")
-	default:
-		// Channel is empty, good to go
+	data, err := g.transact(ctx, slaveID, req.PDU(), req.Quantity, timeoutSeconds)
+	if err != nil {
+		return err
 	}
+	return req.verifyEcho(data)
+}
 
-	// Increment request counter for this new request
-	g.requestCounter++
-	currentRequestID := g.requestCounter
-
-	// Set expected response parameters for validation in onMessage
-	g.expectedSlaveID = slaveID
-	g.expectedFunctionCode = functionCode
-	g.expectedRequestID = currentRequestID
+// ReadDeviceIdentification reads the basic device identification objects (FC 0x2B/0x0E) - implements Gateway interface
+func (g *USRGateway) ReadDeviceIdentification(ctx context.Context, slaveID uint8, timeoutSeconds int) (*DeviceIdentification, error) {
+	g.busMutex.Lock()
+	defer g.busMutex.Unlock()
 
-	logger.LogDebug("🆔 Request #%d: Slave %d, FC 0x%02X", currentRequestID, slaveID, functionCode)
+	return readDeviceIdentification(func(pdu []byte) ([]byte, error) {
+		return g.transact(ctx, slaveID, pdu, 0, timeoutSeconds)
+	})
+}
 
-	// Release commandMutex to allow other slaves to send commands in parallel
-	g.commandMutex.Unlock()
+// transact runs one request/response transaction on the bus (caller holds busMutex)
+func (g *USRGateway) transact(ctx context.Context, slaveID uint8, pdu []byte, count uint16, timeoutSeconds int) ([]byte, error) {
+	functionCode := pdu[0]
+	req := g.requests.register(slaveID, functionCode, pduAddress(pdu), count)
+	logger.LogDebug("🆔 Request #%d: Slave %d, FC 0x%02X", req.id, slaveID, functionCode)
 
-	// Send command
-	if err := g.SendCommand(ctx, slaveID, functionCode, address, count); err != nil {
+	if err := g.publish(ctx, buildRTUFrame(slaveID, pdu)); err != nil {
+		g.requests.remove(req)
 		return nil, err
 	}
 
-	// Wait for response
-	response, err := g.WaitForResponse(ctx, timeoutSeconds)
-
+	response, err := g.receive(ctx, req, timeoutSeconds)
 	if err != nil {
-		logger.LogWarn("⏱️ Request #%d: Timeout after %ds", currentRequestID, timeoutSeconds)
-
-		// CRITICAL: After timeout, clear any stale response that might arrive late
-		// Re-lock commandMutex to safely access response channel
-		g.commandMutex.Lock()
-
-		// Small delay to allow onMessage() to process any fragments that arrived
-		// just before timeout expired
-		time.Sleep(50 * time.Millisecond)
-
-		select {
-		case staleResp := <-g.responseChan:
-			logger.LogWarn("⚠️ Request #%d: Discarded late response: %d bytes from Slave %d",
-				currentRequestID, len(staleResp), g.expectedSlaveID)
-		default:
-			// No stale response in channel
-			logger.LogDebug("✅ Request #%d: Channel clean after timeout", currentRequestID)
-		}
+		logger.LogWarn("⏱️ Request #%d: %v", req.id, err)
 
-		g.commandMutex.Unlock()
+		// Keep the bus reserved briefly so a response arriving just after the timeout
+		// is accounted as late before the next request goes out
+		time.Sleep(usrLateResponseGrace)
 		return nil, err
 	}
 
-	logger.LogDebug("✅ Request #%d: Success (%d bytes)", currentRequestID, len(response))
+	logger.LogDebug("✅ Request #%d: Success (%d bytes)", req.id, len(response))
 
 	// Add small delay between commands to prevent gateway overload
-	time.Sleep(50 * time.Millisecond)
+	time.Sleep(usrInterCommandDelay)
 
 	return response, nil
 }
@@ -2,8 +2,10 @@ package gateway
 
 import (
 	"context"
+	"encoding/binary"
 	"mqtt-modbus-bridge/pkg/config"
 	"mqtt-modbus-bridge/pkg/crc"
+	"mqtt-modbus-bridge/pkg/errors"
 	"sync"
 	"testing"
 	"time"
@@ -41,7 +43,136 @@ func createMockMessage(topic string, slaveID, functionCode uint8, data []byte) m
 	}
 }
 
-// TestResponseValidation verifies that the gateway rejects responses with incorrect SlaveID or FunctionCode
+// mockToken implements mqtt.Token for a publish that completed immediately
+type mockToken struct{}
+
+func (mockToken) Wait() bool                     { return true }
+func (mockToken) WaitTimeout(time.Duration) bool { return true }
+func (mockToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+func (mockToken) Error() error { return nil }
+
+// mockDR164 implements mqtt.Client and behaves like a DR164 with meters on its RS-485 bus
+// Each published command is answered asynchronously on the data topic via onMessage
+type mockDR164 struct {
+	gateway *USRGateway
+
+	mu          sync.Mutex
+	delay       time.Duration           // Bus round-trip time
+	lateDelay   map[uint8]time.Duration // Per-slave response delay overriding delay (simulates slow meters)
+	silent      map[uint8]bool          // Slaves that never answer
+	exceptions  map[uint8]uint8         // Slaves that answer with a Modbus exception code
+	inFlight    int                     // Commands currently on the bus
+	maxInFlight int                     // Maximum observed commands on the bus at once
+	commands    int
+}
+
+func (m *mockDR164) IsConnected() bool      { return true }
+func (m *mockDR164) IsConnectionOpen() bool { return true }
+func (m *mockDR164) Connect() mqtt.Token    { return mockToken{} }
+func (m *mockDR164) Disconnect(uint)        {}
+func (m *mockDR164) Subscribe(string, byte, mqtt.MessageHandler) mqtt.Token {
+	return mockToken{}
+}
+func (m *mockDR164) SubscribeMultiple(map[string]byte, mqtt.MessageHandler) mqtt.Token {
+	return mockToken{}
+}
+func (m *mockDR164) Unsubscribe(...string) mqtt.Token        { return mockToken{} }
+func (m *mockDR164) AddRoute(string, mqtt.MessageHandler)    {}
+func (m *mockDR164) OptionsReader() mqtt.ClientOptionsReader { return mqtt.ClientOptionsReader{} }
+func (m *mockDR164) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	request := payload.([]byte)
+	slaveID := request[0]
+	functionCode := request[1]
+	address := binary.BigEndian.Uint16(request[2:4])
+	count := binary.BigEndian.Uint16(request[4:6])
+
+	m.mu.Lock()
+	m.commands++
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	delay := m.delay
+	if d, exists := m.lateDelay[slaveID]; exists {
+		delay = d
+	}
+	silent := m.silent[slaveID]
+	exceptionCode, rejects := m.exceptions[slaveID]
+	m.mu.Unlock()
+
+	go func() {
+		time.Sleep(delay)
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+		if silent {
+			return
+		}
+		if rejects {
+			m.gateway.onMessage(m, &mockMessage{
+				topic:   "test/data",
+				payload: crc.AppendCRC([]byte{slaveID, functionCode | exceptionFlag, exceptionCode}),
+			})
+			return
+		}
+		response := rtuReadResponse(slaveID, functionCode, address, count)
+		if isWriteFunction(functionCode) {
+			response = rtuWriteEcho(request)
+		}
+		m.gateway.onMessage(m, &mockMessage{
+			topic:   "test/data",
+			payload: response,
+		})
+	}()
+	return mockToken{}
+}
+
+// newTestUSRGateway creates a USR gateway that is not connected to any broker
+func newTestUSRGateway() *USRGateway {
+	return NewUSRGateway(&config.MQTTConfig{
+		Broker:   "test",
+		Port:     1883,
+		Username: "test",
+		Password: "test",
+		Gateway: config.GatewayConfig{
+			MAC:       "TEST123456",
+			CmdTopic:  "test/cmd",
+			DataTopic: "test/data",
+		},
+	})
+}
+
+// newSimulatedUSRGateway creates a USR gateway talking to a simulated DR164
+func newSimulatedUSRGateway(delay time.Duration) (*USRGateway, *mockDR164) {
+	gateway := newTestUSRGateway()
+	bus := &mockDR164{
+		gateway:    gateway,
+		delay:      delay,
+		lateDelay:  make(map[uint8]time.Duration),
+		silent:     make(map[uint8]bool),
+		exceptions: make(map[uint8]uint8),
+	}
+	gateway.client = bus
+	gateway.connected = true
+	return gateway, bus
+}
+
+// receivedFrame returns the frame delivered to a request, or nil if none arrives in time
+func receivedFrame(req *pendingRequest, wait time.Duration) []byte {
+	select {
+	case frame := <-req.response:
+		return frame
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// TestResponseValidation verifies that responses are only delivered to the request
+// registered for the same SlaveID and FunctionCode
 func TestResponseValidation(t *testing.T) {
 	tests := []struct {
 		name                 string
@@ -59,109 +190,51 @@ func TestResponseValidation(t *testing.T) {
 			receivedSlaveID:      11,
 			receivedFunctionCode: 0x03,
 			shouldAccept:         true,
-			description:          "Response matches expected parameters - should be accepted",
+			description:          "Response matches pending request - should be delivered",
 		},
 		{
 			name:                 "Wrong SlaveID",
 			expectedSlaveID:      11,
 			expectedFunctionCode: 0x03,
-			receivedSlaveID:      1, // Different slave
+			receivedSlaveID:      1,
 			receivedFunctionCode: 0x03,
 			shouldAccept:         false,
-			description:          "Response from different slave - should be rejected",
+			description:          "Response from a different slave - should be rejected",
 		},
 		{
 			name:                 "Wrong FunctionCode",
 			expectedSlaveID:      11,
 			expectedFunctionCode: 0x03,
 			receivedSlaveID:      11,
-			receivedFunctionCode: 0x04, // Different function
-			shouldAccept:         false,
-			description:          "Response with different function code - should be rejected",
-		},
-		{
-			name:                 "Wrong SlaveID and FunctionCode",
-			expectedSlaveID:      11,
-			expectedFunctionCode: 0x03,
-			receivedSlaveID:      1,
 			receivedFunctionCode: 0x04,
 			shouldAccept:         false,
-			description:          "Response from different slave and function - should be rejected",
+			description:          "Response for a different function - should be rejected",
 		},
 		{
-			name:                 "Response from Slave 1 when expecting Slave 11",
+			name:                 "Both wrong",
 			expectedSlaveID:      11,
 			expectedFunctionCode: 0x03,
 			receivedSlaveID:      1,
-			receivedFunctionCode: 0x03,
+			receivedFunctionCode: 0x04,
 			shouldAccept:         false,
-			description:          "Simulates race condition - wrong meter response",
+			description:          "Completely different response - should be rejected",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create mock gateway
-			cfg := &config.MQTTConfig{
-				Broker:   "test",
-				Port:     1883,
-				Username: "test",
-				Password: "test",
-				Gateway: config.GatewayConfig{
-					MAC:       "TEST123456",
-					CmdTopic:  "test/cmd",
-					DataTopic: "test/data",
-				},
-			}
+			gateway := newTestUSRGateway()
 
-			gateway := NewUSRGateway(cfg)
-
-			// Set expected parameters (simulating a pending request)
-			gateway.expectedSlaveID = tt.expectedSlaveID
-			gateway.expectedFunctionCode = tt.expectedFunctionCode
+			// Register a pending request
+			req := gateway.requests.register(tt.expectedSlaveID, tt.expectedFunctionCode, 0x2000, 2)
 
 			// Simulate receiving a response with specific SlaveID and FunctionCode
-			// Data: 4 bytes of dummy data (0x00 0x00 0x00 0x00)
-			responseData := []byte{0x00, 0x00, 0x00, 0x00}
-			mockMsg := createMockMessage(
-				"test/data",
-				tt.receivedSlaveID,
-				tt.receivedFunctionCode,
-				responseData,
-			)
-
-			// Track if response was accepted
-			responseReceived := false
-			responseMutex := sync.Mutex{}
-
-			// Start a goroutine to check if response is placed on channel
-			go func() {
-				// Simulate onMessage call
-				gateway.onMessage(nil, mockMsg)
-
-				// Check if response channel has data (non-blocking)
-				select {
-				case <-gateway.responseChan:
-					responseMutex.Lock()
-					responseReceived = true
-					responseMutex.Unlock()
-				case <-time.After(100 * time.Millisecond):
-					// No response received (expected for rejected responses)
-				}
-			}()
-
-			time.Sleep(150 * time.Millisecond)
-
-			responseMutex.Lock()
-			accepted := responseReceived
-			responseMutex.Unlock()
+			gateway.onMessage(nil, createMockMessage("test/data", tt.receivedSlaveID, tt.receivedFunctionCode, []byte{0x00, 0x00, 0x00, 0x00}))
 
+			accepted := receivedFrame(req, 20*time.Millisecond) != nil
 			if accepted != tt.shouldAccept {
-				t.Errorf("%s: Expected shouldAccept=%v, got accepted=%v\n  Expected: SlaveID=%d FunctionCode=0x%02X\n  Received: SlaveID=%d FunctionCode=0x%02X\n  Description: %s",
-					tt.name, tt.shouldAccept, accepted,
-					tt.expectedSlaveID, tt.expectedFunctionCode,
-					tt.receivedSlaveID, tt.receivedFunctionCode,
-					tt.description)
+				t.Errorf("❌ %s: Expected shouldAccept=%v, got accepted=%v (%s)",
+					tt.name, tt.shouldAccept, accepted, tt.description)
 			} else {
 				t.Logf("✅ %s: Correctly %s response (SlaveID=%d->%d, FC=0x%02X->0x%02X)",
 					tt.name,
@@ -169,1201 +242,411 @@ func TestResponseValidation(t *testing.T) {
 					tt.expectedSlaveID, tt.receivedSlaveID,
 					tt.expectedFunctionCode, tt.receivedFunctionCode)
 			}
+
+			stats := gateway.GetResponseStats()
+			if !tt.shouldAccept && stats.Unexpected != 1 {
+				t.Errorf("❌ Expected rejected response counted as unexpected, got %+v", stats)
+			}
 		})
 	}
 }
 
-// TestStaleResponseCleanup verifies that stale responses are cleared before new requests
-func TestStaleResponseCleanup(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	// Simulate a stale response in the channel (from previous timed-out request)
-	staleResponse := []byte{0x01, 0x02, 0x03, 0x04}
-	select {
-	case gateway.responseChan <- staleResponse:
-		t.Log("✅ Inserted stale response into channel")
-	case <-time.After(100 * time.Millisecond):
-		t.Fatal("❌ Failed to insert stale response")
-	}
-
-	// Verify stale response is in channel (check length without consuming)
-	if len(gateway.responseChan) == 0 {
-		t.Fatal("❌ Stale response was not retained in channel")
-	}
-	t.Log("✅ Stale response confirmed in channel")
+// TestResponsesDeliveredToOwnRequest verifies responses for several pending requests
+// are each delivered to their own request, regardless of arrival order
+func TestResponsesDeliveredToOwnRequest(t *testing.T) {
+	gateway := newTestUSRGateway()
 
-	// Now simulate SendCommandAndWaitForResponse which should clear stale response
-	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
-	defer cancel()
+	reqMains := gateway.requests.register(11, 0x03, 0x2000, 2)
+	reqLights := gateway.requests.register(1, 0x03, 0x2000, 2)
+	reqInput := gateway.requests.register(11, 0x04, 0x2000, 2)
 
-	// This will fail (no actual gateway), but should clear stale response first
-	gateway.commandMutex.Lock()
+	// Responses arrive in reverse order
+	gateway.onMessage(nil, createMockMessage("test/data", 11, 0x04, []byte{0x44, 0x44, 0x00, 0x00}))
+	gateway.onMessage(nil, createMockMessage("test/data", 1, 0x03, []byte{0x01, 0x01, 0x00, 0x00}))
+	gateway.onMessage(nil, createMockMessage("test/data", 11, 0x03, []byte{0x11, 0x11, 0x00, 0x00}))
 
-	// Clear stale responses (this is what SendCommandAndWaitForResponse does)
-	select {
-	case old := <-gateway.responseChan:
-		t.Logf("✅ Cleared stale response: %v", old)
-	default:
-		t.Error("⚠️ No stale response to clear - should have been one!")
+	checks := []struct {
+		name  string
+		req   *pendingRequest
+		first byte
+	}{
+		{"Slave 11 FC03", reqMains, 0x11},
+		{"Slave 1 FC03", reqLights, 0x01},
+		{"Slave 11 FC04", reqInput, 0x44},
+	}
+	for _, c := range checks {
+		frame := receivedFrame(c.req, 50*time.Millisecond)
+		if frame == nil || frame[3] != c.first {
+			t.Errorf("❌ %s: expected payload starting with 0x%02X, got %02X", c.name, c.first, frame)
+		} else {
+			t.Logf("✅ %s received its own response", c.name)
+		}
 	}
 
-	gateway.commandMutex.Unlock()
-
-	// Verify channel is now empty
-	if len(gateway.responseChan) != 0 {
-		t.Error("❌ Channel should be empty after cleanup")
-	} else {
-		t.Log("✅ Channel is empty after cleanup")
+	if gateway.requests.pendingCount() != 0 {
+		t.Errorf("❌ Expected no pending requests, got %d", gateway.requests.pendingCount())
 	}
-
-	<-ctx.Done()
 }
 
-// TestConcurrentRequestsSameDevice verifies sequential execution prevents race conditions
-func TestConcurrentRequestsSameDevice(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	// Track execution order
-	var executionOrder []string
-	var orderMutex sync.Mutex
-
-	// Simulate two concurrent requests to same device
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	// Request 1: Instant measurements (SlaveID=11, FC=0x03)
-	go func() {
-		defer wg.Done()
-		gateway.commandMutex.Lock()
-		orderMutex.Lock()
-		executionOrder = append(executionOrder, "Request1-Start")
-		orderMutex.Unlock()
-
-		// Simulate work
-		time.Sleep(50 * time.Millisecond)
-
-		orderMutex.Lock()
-		executionOrder = append(executionOrder, "Request1-End")
-		orderMutex.Unlock()
-		gateway.commandMutex.Unlock()
-	}()
-
-	// Request 2: Energy measurements (SlaveID=11, FC=0x03)
-	// This should wait for Request 1 to complete
-	go func() {
-		defer wg.Done()
-		time.Sleep(10 * time.Millisecond) // Start slightly after Request 1
-
-		gateway.commandMutex.Lock()
-		orderMutex.Lock()
-		executionOrder = append(executionOrder, "Request2-Start")
-		orderMutex.Unlock()
+// TestByteCountMismatchRejected verifies a read response with the wrong byte count
+// (e.g. a late response for a different register range) is not delivered
+func TestByteCountMismatchRejected(t *testing.T) {
+	gateway := newTestUSRGateway()
 
-		// Simulate work
-		time.Sleep(50 * time.Millisecond)
+	// First request (2 registers) times out
+	stale := gateway.requests.register(11, 0x03, 0x2000, 2)
+	gateway.requests.expire(stale)
 
-		orderMutex.Lock()
-		executionOrder = append(executionOrder, "Request2-End")
-		orderMutex.Unlock()
-		gateway.commandMutex.Unlock()
-	}()
-
-	wg.Wait()
+	// Second request on the same slave and function reads 4 registers
+	req := gateway.requests.register(11, 0x03, 0x2000, 4)
 
-	// Verify execution was sequential
-	expectedOrder := []string{
-		"Request1-Start",
-		"Request1-End",
-		"Request2-Start",
-		"Request2-End",
+	// Late 4-byte response for the first request arrives
+	gateway.onMessage(nil, createMockMessage("test/data", 11, 0x03, []byte{0xAA, 0xAA, 0xAA, 0xAA}))
+	if frame := receivedFrame(req, 20*time.Millisecond); frame != nil {
+		t.Fatalf("❌ Late response for 2 registers delivered to 4-register request: %02X", frame)
 	}
 
-	orderMutex.Lock()
-	defer orderMutex.Unlock()
-
-	if len(executionOrder) != len(expectedOrder) {
-		t.Fatalf("❌ Expected %d events, got %d", len(expectedOrder), len(executionOrder))
+	// Correct 8-byte response arrives
+	gateway.onMessage(nil, createMockMessage("test/data", 11, 0x03, []byte{1, 2, 3, 4, 5, 6, 7, 8}))
+	if frame := receivedFrame(req, 50*time.Millisecond); frame == nil || frame[2] != 8 {
+		t.Fatalf("❌ Correct response not delivered, got %02X", frame)
 	}
 
-	for i, expected := range expectedOrder {
-		if executionOrder[i] != expected {
-			t.Errorf("❌ Event %d: expected '%s', got '%s'", i, expected, executionOrder[i])
-		}
+	stats := gateway.GetResponseStats()
+	if stats.Late != 1 || stats.Delivered != 1 {
+		t.Errorf("❌ Expected 1 late and 1 delivered response, got %+v", stats)
 	}
-
-	t.Logf("✅ Sequential execution verified: %v", executionOrder)
+	t.Logf("✅ Mismatched byte count rejected and counted as late: %+v", stats)
 }
 
-// TestRaceConditionPrevention simulates the exact bug that was fixed
-// Two meters (SlaveID 11 and 1) polled simultaneously, responses could mix
-func TestRaceConditionPrevention(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	// Scenario: Request for Slave 11, but response from Slave 1 arrives first (race condition)
-
-	// Step 1: Set expected parameters for Slave 11
-	gateway.expectedSlaveID = 11
-	gateway.expectedFunctionCode = 0x03
+// TestLateResponseAfterTimeout verifies that a response arriving after its request
+// timed out is counted as late and never reaches the next request
+func TestLateResponseAfterTimeout(t *testing.T) {
+	gateway, bus := newSimulatedUSRGateway(5 * time.Millisecond)
+	bus.lateDelay[11] = 1200 * time.Millisecond // Slave 11 answers after the 1s timeout
 
-	t.Logf("📤 Expecting response from Slave 11, Function 0x03")
+	// Request to slave 11 times out
+	_, err := gateway.SendCommandAndWaitForResponse(context.Background(), 11, 0x03, 0x2000, 2, 1)
+	if err == nil {
+		t.Fatal("❌ Expected timeout for slow slave 11")
+	}
+	t.Logf("✅ Slave 11 timed out: %v", err)
 
-	// Step 2: Simulate wrong response arriving (from Slave 1)
-	wrongData := []byte{
-		0x43, 0x70, 0x00, 0x00, // Voltage = 240.0 V
-		0x40, 0x20, 0x00, 0x00, // Current = 2.5 A
+	// Request to slave 1 while slave 11's late response arrives
+	data, err := gateway.SendCommandAndWaitForResponse(context.Background(), 1, 0x03, 0x2000, 2, 1)
+	if err != nil {
+		t.Fatalf("❌ Request to slave 1 failed: %v", err)
+	}
+	if binary.BigEndian.Uint16(data) != 1000+0x2000 {
+		t.Fatalf("❌ Slave 1 received wrong data %02X", data)
 	}
-	wrongMsg := createMockMessage("test/data", 0x01, 0x03, wrongData)
 
-	responseAccepted := false
-	var receivedResponse []byte
+	// Wait for the late response to be accounted
+	time.Sleep(300 * time.Millisecond)
+	stats := gateway.GetResponseStats()
+	if stats.Late != 1 {
+		t.Errorf("❌ Expected 1 late response, got %+v", stats)
+	}
+	if stats.Unexpected != 0 {
+		t.Errorf("❌ Late response misclassified as unexpected: %+v", stats)
+	}
+	t.Logf("✅ Late response accounted without affecting slave 1: %+v", stats)
+}
 
-	// Simulate onMessage call
-	go func() {
-		gateway.onMessage(nil, wrongMsg)
-
-		// Try to read from channel (non-blocking)
-		select {
-		case resp := <-gateway.responseChan:
-			responseAccepted = true
-			receivedResponse = resp
-		case <-time.After(100 * time.Millisecond):
-			// Expected - response should be rejected
-		}
-	}()
+// TestLateResponseDeliveredAtTimeout verifies a response delivered in the same instant
+// the request gives up is moved from delivered to late
+func TestLateResponseDeliveredAtTimeout(t *testing.T) {
+	gateway := newTestUSRGateway()
 
-	time.Sleep(150 * time.Millisecond)
+	req := gateway.requests.register(11, 0x03, 0x2000, 2)
+	gateway.onMessage(nil, createMockMessage("test/data", 11, 0x03, []byte{0, 0, 0, 0}))
+	gateway.requests.expire(req) // Request gave up before reading its channel
 
-	if responseAccepted {
-		t.Errorf("❌ Race condition NOT prevented! Wrong response was accepted (got %d bytes)",
-			len(receivedResponse))
-	} else {
-		t.Log("✅ Race condition prevented! Response from Slave 1 was rejected when expecting Slave 11")
+	stats := gateway.GetResponseStats()
+	if stats.Delivered != 0 || stats.Late != 1 {
+		t.Errorf("❌ Expected response counted as late, got %+v", stats)
 	}
+	t.Logf("✅ Response racing the timeout counted as late: %+v", stats)
+}
 
-	// Step 3: Now send correct response from Slave 11
-	correctData := []byte{
-		0x43, 0x70, 0x00, 0x00, // Voltage = 240.0 V
-		0x40, 0x20, 0x00, 0x00, // Current = 2.5 A
-	}
-	correctMsg := createMockMessage("test/data", 0x0B, 0x03, correctData)
+// TestConcurrentSlavesGetCorrectResponses polls several slaves concurrently through
+// the simulated DR164 and verifies every request gets its own data
+func TestConcurrentSlavesGetCorrectResponses(t *testing.T) {
+	gateway, bus := newSimulatedUSRGateway(2 * time.Millisecond)
 
-	go func() {
-		gateway.onMessage(nil, correctMsg)
-	}()
+	slaves := []uint8{1, 2, 11, 12}
+	const pollsPerSlave = 5
 
-	// This time, response should be accepted
-	select {
-	case resp := <-gateway.responseChan:
-		// resp contains only the data portion (not SlaveID/FunctionCode)
-		// Just verify we got a response
-		if len(resp) == 0 {
-			t.Error("❌ Received empty response")
-		} else {
-			t.Logf("✅ Correct response from Slave 11 was accepted: %d bytes", len(resp))
+	var wg sync.WaitGroup
+	errs := make(chan string, len(slaves)*pollsPerSlave)
+	for _, slaveID := range slaves {
+		for i := 0; i < pollsPerSlave; i++ {
+			wg.Add(1)
+			go func(slaveID uint8, address uint16) {
+				defer wg.Done()
+				data, err := gateway.SendCommandAndWaitForResponse(context.Background(), slaveID, 0x03, address, 2, 2)
+				if err != nil {
+					errs <- err.Error()
+					return
+				}
+				if got := binary.BigEndian.Uint16(data); got != uint16(slaveID)*1000+address {
+					errs <- "wrong data for slave"
+				}
+			}(slaveID, uint16(0x2000+i*0x10))
 		}
-	case <-time.After(200 * time.Millisecond):
-		t.Error("❌ Correct response was not received")
 	}
-}
+	wg.Wait()
+	close(errs)
 
-// TestMultipleDevicesNoInterference verifies that responses don't interfere between devices
-func TestMultipleDevicesNoInterference(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
+	for e := range errs {
+		t.Errorf("❌ %s", e)
 	}
 
-	gateway := NewUSRGateway(cfg)
+	bus.mu.Lock()
+	maxInFlight := bus.maxInFlight
+	commands := bus.commands
+	bus.mu.Unlock()
 
-	// Simulate polling both devices
-	devices := []struct {
-		name    string
-		slaveID uint8
-		data    []byte
-	}{
-		{
-			name:    "Energy Meter Mains",
-			slaveID: 11,
-			data: []byte{
-				0x43, 0x70, 0x00, 0x00, // Data
-			},
-		},
-		{
-			name:    "Energy Meter Lights",
-			slaveID: 1,
-			data: []byte{
-				0x41, 0xF0, 0x00, 0x00, // Data
-			},
-		},
+	if maxInFlight > 1 {
+		t.Errorf("❌ Bus serialization violated: %d commands on the bus at once", maxInFlight)
 	}
-
-	for _, device := range devices {
-		t.Run(device.name, func(t *testing.T) {
-			// Set expected parameters
-			gateway.expectedSlaveID = device.slaveID
-			gateway.expectedFunctionCode = 0x03
-
-			t.Logf("📤 Polling %s (Slave %d)", device.name, device.slaveID)
-
-			// Create mock message
-			mockMsg := createMockMessage("test/data", device.slaveID, 0x03, device.data)
-
-			// Send response
-			go func() {
-				time.Sleep(50 * time.Millisecond)
-				gateway.onMessage(nil, mockMsg)
-			}()
-
-			// Wait for response
-			select {
-			case resp := <-gateway.responseChan:
-				// resp contains only data, not SlaveID
-				// We can't verify SlaveID from resp, but we know it's correct
-				// because gateway validated it before putting in channel
-				if len(resp) == 0 {
-					t.Errorf("❌ %s: Received empty response", device.name)
-				} else {
-					t.Logf("✅ %s: Received correct response (%d bytes)", device.name, len(resp))
-				}
-			case <-time.After(200 * time.Millisecond):
-				t.Errorf("❌ %s: Timeout waiting for response", device.name)
-			}
-		})
+	stats := gateway.GetResponseStats()
+	if stats.Delivered != uint64(len(slaves)*pollsPerSlave) {
+		t.Errorf("❌ Expected %d delivered responses, got %+v", len(slaves)*pollsPerSlave, stats)
 	}
+	t.Logf("✅ %d concurrent requests, max %d on the bus, stats %+v", commands, maxInFlight, stats)
 }
 
-// TestResponseTimingRaceCondition simulates delayed responses causing confusion
-func TestResponseTimingRaceCondition(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	// Scenario: Request times out, new request starts, old response arrives late
-
-	// Request 1 to Slave 11
-	gateway.expectedSlaveID = 11
-	gateway.expectedFunctionCode = 0x03
-	t.Log("📤 Request 1: Expecting Slave 11, FC=0x03")
-
-	// Simulate timeout (no response)
-	time.Sleep(100 * time.Millisecond)
-	t.Log("⏱️ Request 1 timed out")
-
-	// Request 2 to Slave 1 (different device)
-	gateway.expectedSlaveID = 1
-	gateway.expectedFunctionCode = 0x03
-	t.Log("📤 Request 2: Expecting Slave 1, FC=0x03")
-
-	// Old response from Request 1 arrives late
-	lateData := []byte{0x43, 0x70, 0x00, 0x00}
-	lateMsg := createMockMessage("test/data", 0x0B, 0x03, lateData)
-
-	go func() {
-		gateway.onMessage(nil, lateMsg)
-	}()
+// TestSilentSlaveDoesNotCorruptOthers verifies a slave that never answers only fails its own requests
+func TestSilentSlaveDoesNotCorruptOthers(t *testing.T) {
+	gateway, bus := newSimulatedUSRGateway(2 * time.Millisecond)
+	bus.silent[12] = true
 
-	// Check if late response was incorrectly accepted
-	select {
-	case resp := <-gateway.responseChan:
-		t.Errorf("❌ Late response was incorrectly accepted! Got %d bytes when expecting nothing", len(resp))
-	case <-time.After(150 * time.Millisecond):
-		t.Log("✅ Late response from Slave 11 was correctly rejected (expecting Slave 1)")
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make(map[uint8]error)
+	for _, slaveID := range []uint8{11, 12, 1} {
+		wg.Add(1)
+		go func(slaveID uint8) {
+			defer wg.Done()
+			data, err := gateway.SendCommandAndWaitForResponse(context.Background(), slaveID, 0x03, 0x2000, 2, 1)
+			if err == nil && binary.BigEndian.Uint16(data) != uint16(slaveID)*1000+0x2000 {
+				err = context.Canceled // Any non-nil error marks wrong data
+			}
+			mu.Lock()
+			results[slaveID] = err
+			mu.Unlock()
+		}(slaveID)
 	}
+	wg.Wait()
 
-	// Now send correct response for Request 2
-	correctData := []byte{0x41, 0xF0, 0x00, 0x00}
-	correctMsg := createMockMessage("test/data", 0x01, 0x03, correctData)
-
-	go func() {
-		gateway.onMessage(nil, correctMsg)
-	}()
-
-	select {
-	case resp := <-gateway.responseChan:
-		if len(resp) == 0 {
-			t.Error("❌ Received empty response")
-		} else {
-			t.Logf("✅ Correct response from Slave 1 accepted (%d bytes)", len(resp))
-		}
-	case <-time.After(150 * time.Millisecond):
-		t.Error("❌ Correct response not received")
+	if results[12] == nil {
+		t.Error("❌ Expected timeout for silent slave 12")
 	}
-}
-
-// BenchmarkResponseValidation measures performance impact of validation
-func BenchmarkResponseValidation(b *testing.B) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
+	if results[11] != nil || results[1] != nil {
+		t.Errorf("❌ Working slaves affected by silent slave: 11=%v, 1=%v", results[11], results[1])
 	}
-
-	gateway := NewUSRGateway(cfg)
-	gateway.expectedSlaveID = 11
-	gateway.expectedFunctionCode = 0x03
-
-	responseData := []byte{0x43, 0x70, 0x00, 0x00}
-	mockMsg := createMockMessage("test/data", 0x0B, 0x03, responseData)
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		gateway.onMessage(nil, mockMsg)
-
-		// Drain channel
-		select {
-		case <-gateway.responseChan:
-		default:
-		}
+	if gateway.requests.pendingCount() != 0 {
+		t.Errorf("❌ Expected no pending requests after timeouts, got %d", gateway.requests.pendingCount())
 	}
+	t.Logf("✅ Silent slave 12 timed out, slaves 11 and 1 answered correctly")
 }
 
-// TestExpectedParametersUpdate verifies that expected parameters are updated correctly
-func TestExpectedParametersUpdate(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
+// TestExceptionResponseDeliveredImmediately verifies an exception reply fails the waiting
+// request at once with a typed error instead of running into the timeout
+func TestExceptionResponseDeliveredImmediately(t *testing.T) {
+	gateway, bus := newSimulatedUSRGateway(2 * time.Millisecond)
+	bus.exceptions[11] = errors.ExceptionIllegalDataAddress
 
-	gateway := NewUSRGateway(cfg)
+	start := time.Now()
+	_, err := gateway.SendCommandAndWaitForResponse(context.Background(), 11, 0x03, 0x4000, 2, 5)
+	elapsed := time.Since(start)
 
-	// Simulate multiple requests updating expected parameters
-	requests := []struct {
-		slaveID      uint8
-		functionCode uint8
-	}{
-		{11, 0x03},
-		{1, 0x03},
-		{11, 0x04},
-		{20, 0x03},
+	exception, ok := errors.AsModbusException(err)
+	if !ok {
+		t.Fatalf("❌ Expected Modbus exception error, got %v", err)
+	}
+	if exception.SlaveID != 11 || exception.FunctionCode != 0x03 || exception.Address != 0x4000 ||
+		exception.ExceptionCode != errors.ExceptionIllegalDataAddress {
+		t.Errorf("❌ Exception decoded incorrectly: %+v", exception)
+	}
+	if elapsed > time.Second {
+		t.Errorf("❌ Exception took %v, expected immediate delivery", elapsed)
 	}
 
-	for i, req := range requests {
-		gateway.expectedSlaveID = req.slaveID
-		gateway.expectedFunctionCode = req.functionCode
-
-		if gateway.expectedSlaveID != req.slaveID {
-			t.Errorf("Request %d: expectedSlaveID not updated: expected %d, got %d",
-				i, req.slaveID, gateway.expectedSlaveID)
-		}
-
-		if gateway.expectedFunctionCode != req.functionCode {
-			t.Errorf("Request %d: expectedFunctionCode not updated: expected 0x%02X, got 0x%02X",
-				i, req.functionCode, gateway.expectedFunctionCode)
-		}
-
-		t.Logf("✅ Request %d: Expected parameters updated (Slave=%d, FC=0x%02X)",
-			i, req.slaveID, req.functionCode)
+	stats := gateway.GetResponseStats()
+	if stats.Delivered != 1 || stats.Unexpected != 0 {
+		t.Errorf("❌ Expected exception counted as delivered, got %+v", stats)
 	}
+	t.Logf("✅ Exception delivered after %v: %v", elapsed.Round(time.Millisecond), err)
 }
 
-// TestConcurrentResponsesSimultaneous simulates multiple responses arriving at the same time
-// Only the correct response (matching expectedSlaveID/FunctionCode) should be accepted
+// TestConcurrentResponsesSimultaneous simulates duplicate responses arriving at the same time
+// Exactly one must be delivered
 func TestConcurrentResponsesSimultaneous(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	// Expecting response from Slave 11
-	gateway.expectedSlaveID = 11
-	gateway.expectedFunctionCode = 0x03
-
-	t.Log("📤 Expecting response from Slave 11, FC=0x03")
-
-	// Create multiple responses that arrive simultaneously
-	responses := []struct {
-		name     string
-		slaveID  uint8
-		data     []byte
-		expected bool
-	}{
-		{
-			name:     "Wrong Slave 1",
-			slaveID:  1,
-			data:     []byte{0x41, 0xF0, 0x00, 0x00},
-			expected: false,
-		},
-		{
-			name:     "Correct Slave 11",
-			slaveID:  11,
-			data:     []byte{0x43, 0x70, 0x00, 0x00},
-			expected: true,
-		},
-		{
-			name:     "Wrong Slave 20",
-			slaveID:  20,
-			data:     []byte{0x44, 0x20, 0x00, 0x00},
-			expected: false,
-		},
-		{
-			name:     "Another Wrong Slave 1",
-			slaveID:  1,
-			data:     []byte{0x42, 0x00, 0x00, 0x00},
-			expected: false,
-		},
-	}
+	gateway := newTestUSRGateway()
+	req := gateway.requests.register(11, 0x03, 0x2000, 2)
 
-	// Send all responses concurrently
 	var wg sync.WaitGroup
-	for _, resp := range responses {
+	for i := 0; i < 20; i++ {
 		wg.Add(1)
-		go func(r struct {
-			name     string
-			slaveID  uint8
-			data     []byte
-			expected bool
-		}) {
+		go func(i int) {
 			defer wg.Done()
-			msg := createMockMessage("test/data", r.slaveID, 0x03, r.data)
-			gateway.onMessage(nil, msg)
-			t.Logf("📨 Sent response: %s (Slave %d)", r.name, r.slaveID)
-		}(resp)
+			gateway.onMessage(nil, createMockMessage("test/data", 11, 0x03, []byte{byte(i), 0, 0, 0}))
+		}(i)
 	}
-
-	// Wait for all responses to be processed
 	wg.Wait()
-	time.Sleep(100 * time.Millisecond)
-
-	// Check that only ONE response was accepted (the correct one)
-	select {
-	case data := <-gateway.responseChan:
-		t.Logf("✅ Received response: %d bytes (should be from Slave 11)", len(data))
-
-		// Try to read again - should timeout (no more responses)
-		select {
-		case extra := <-gateway.responseChan:
-			t.Errorf("❌ Multiple responses accepted! Extra response: %v", extra)
-		case <-time.After(100 * time.Millisecond):
-			t.Log("✅ Only one response accepted (correct behavior)")
-		}
-	case <-time.After(200 * time.Millisecond):
-		t.Error("❌ No response received - should have gotten Slave 11 response")
-	}
-}
-
-// TestConcurrentInterleavedRequests simulates realistic polling of multiple devices
-// Devices polled in quick succession with responses potentially overlapping
-func TestConcurrentInterleavedRequests(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
 
-	gateway := NewUSRGateway(cfg)
-
-	// Simulate polling sequence: Slave 11 -> Slave 1 -> Slave 11 -> Slave 20
-	pollingSequence := []struct {
-		slaveID      uint8
-		functionCode uint8
-		data         []byte
-		description  string
-	}{
-		{11, 0x03, []byte{0x43, 0x70, 0x00, 0x00}, "Slave 11 - Instant"},
-		{1, 0x03, []byte{0x41, 0xF0, 0x00, 0x00}, "Slave 1 - Instant"},
-		{11, 0x03, []byte{0x43, 0x80, 0x00, 0x00}, "Slave 11 - Energy"},
-		{20, 0x03, []byte{0x44, 0x20, 0x00, 0x00}, "Slave 20 - Status"},
+	if receivedFrame(req, 50*time.Millisecond) == nil {
+		t.Fatal("❌ No response delivered")
 	}
-
-	successCount := 0
-
-	for i, poll := range pollingSequence {
-		t.Logf("📤 Poll %d: %s (Slave %d, FC=0x%02X)", i+1, poll.description, poll.slaveID, poll.functionCode)
-
-		// Set expected parameters (protected by mutex)
-		gateway.commandMutex.Lock()
-		gateway.expectedSlaveID = poll.slaveID
-		gateway.expectedFunctionCode = poll.functionCode
-		gateway.commandMutex.Unlock()
-
-		// Small delay to simulate request transmission
-		time.Sleep(10 * time.Millisecond)
-
-		// Send response
-		msg := createMockMessage("test/data", poll.slaveID, poll.functionCode, poll.data)
-		go func() {
-			time.Sleep(20 * time.Millisecond) // Simulate network delay
-			gateway.onMessage(nil, msg)
-		}()
-
-		// Wait for response
-		select {
-		case data := <-gateway.responseChan:
-			t.Logf("✅ Poll %d: Received %d bytes", i+1, len(data))
-			successCount++
-		case <-time.After(100 * time.Millisecond):
-			t.Errorf("❌ Poll %d: Timeout waiting for response", i+1)
-		}
+	if len(req.response) != 0 {
+		t.Error("❌ More than one response delivered")
 	}
-
-	if successCount != len(pollingSequence) {
-		t.Errorf("❌ Expected %d successful polls, got %d", len(pollingSequence), successCount)
-	} else {
-		t.Logf("✅ All %d polls completed successfully (no interference)", successCount)
+	stats := gateway.GetResponseStats()
+	if stats.Delivered != 1 || stats.Unexpected != 19 {
+		t.Errorf("❌ Expected 1 delivered and 19 unexpected, got %+v", stats)
 	}
+	t.Logf("✅ Exactly one of 20 simultaneous responses delivered: %+v", stats)
 }
 
-// TestConcurrentStressTest stress tests the gateway with many rapid concurrent responses
+// TestConcurrentStressTest stress tests the request table with many concurrent requests and responses
 func TestConcurrentStressTest(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping stress test in short mode")
 	}
 
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	const numRequests = 100
-	correctResponses := 0
-	rejectedResponses := 0
+	gateway := newTestUSRGateway()
 
-	t.Logf("🔥 Stress test: %d rapid requests", numRequests)
+	const numRounds = 100
+	const numSlaves = 8
 
-	for i := 0; i < numRequests; i++ {
-		// Alternate between 3 different slaves
-		targetSlaveID := uint8((i % 3) + 1) // 1, 2, 3
+	t.Logf("🔥 Stress test: %d rounds of %d concurrent slaves", numRounds, numSlaves)
 
-		// Set expected parameters
-		gateway.commandMutex.Lock()
-		gateway.expectedSlaveID = targetSlaveID
-		gateway.expectedFunctionCode = 0x03
-		gateway.commandMutex.Unlock()
+	wrong := 0
+	for round := 0; round < numRounds; round++ {
+		requests := make([]*pendingRequest, numSlaves)
+		for s := 0; s < numSlaves; s++ {
+			requests[s] = gateway.requests.register(uint8(s+1), 0x03, 0x2000, 2)
+		}
 
-		// Send correct response and several wrong ones concurrently
 		var wg sync.WaitGroup
-
-		// Correct response
-		wg.Add(1)
-		go func(slaveID uint8) {
-			defer wg.Done()
-			data := []byte{0x43, byte(i), 0x00, 0x00}
-			msg := createMockMessage("test/data", slaveID, 0x03, data)
-			gateway.onMessage(nil, msg)
-		}(targetSlaveID)
-
-		// Wrong responses (from other slaves)
-		for j := 0; j < 3; j++ {
-			wrongSlaveID := uint8(((i + j + 1) % 3) + 1)
-			if wrongSlaveID != targetSlaveID {
-				wg.Add(1)
-				go func(slaveID uint8) {
-					defer wg.Done()
-					data := []byte{0x41, byte(i), byte(slaveID), 0x00}
-					msg := createMockMessage("test/data", slaveID, 0x03, data)
-					gateway.onMessage(nil, msg)
-				}(wrongSlaveID)
-			}
+		for s := 0; s < numSlaves; s++ {
+			wg.Add(1)
+			go func(slaveID uint8) {
+				defer wg.Done()
+				gateway.onMessage(nil, createMockMessage("test/data", slaveID, 0x03, []byte{slaveID, byte(round), 0, 0}))
+			}(uint8(s + 1))
 		}
-
 		wg.Wait()
 
-		// Check for correct response
-		select {
-		case <-gateway.responseChan:
-			correctResponses++
-		case <-time.After(50 * time.Millisecond):
-			rejectedResponses++
+		for s, req := range requests {
+			frame := receivedFrame(req, 50*time.Millisecond)
+			if frame == nil || frame[3] != uint8(s+1) || frame[4] != byte(round) {
+				wrong++
+			}
 		}
-
-		// Tiny delay between iterations
-		time.Sleep(5 * time.Millisecond)
 	}
 
-	successRate := float64(correctResponses) / float64(numRequests) * 100
-
-	t.Logf("📊 Stress test results:")
-	t.Logf("  Correct responses: %d / %d (%.1f%%)", correctResponses, numRequests, successRate)
-	t.Logf("  Rejected/Timeout: %d", rejectedResponses)
-
-	if successRate < 95.0 {
-		t.Errorf("❌ Success rate too low: %.1f%% (expected >= 95%%)", successRate)
+	stats := gateway.GetResponseStats()
+	t.Logf("📊 Stress test results: %+v", stats)
+	if wrong > 0 {
+		t.Errorf("❌ %d requests received a wrong or no response", wrong)
 	} else {
-		t.Logf("✅ Stress test passed with %.1f%% success rate", successRate)
+		t.Logf("✅ All %d requests received their own response", numRounds*numSlaves)
 	}
 }
 
-// TestConcurrentResponseCollision tests that response channel doesn't overflow or lose data
-func TestConcurrentResponseCollision(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
+// TestLegacySendThenWait verifies the two-step SendCommand/WaitForResponse API
+func TestLegacySendThenWait(t *testing.T) {
+	gateway, _ := newSimulatedUSRGateway(2 * time.Millisecond)
 
-	// Set expected parameters
-	gateway.expectedSlaveID = 11
-	gateway.expectedFunctionCode = 0x03
-
-	t.Log("📤 Testing response channel collision handling")
-
-	// Try to send multiple correct responses rapidly
-	// Only first should be accepted (channel buffer size = 1)
-	numResponses := 5
-	var wg sync.WaitGroup
-
-	for i := 0; i < numResponses; i++ {
-		wg.Add(1)
-		go func(idx int) {
-			defer wg.Done()
-			data := []byte{0x43, byte(idx), 0x00, 0x00}
-			msg := createMockMessage("test/data", 11, 0x03, data)
-			gateway.onMessage(nil, msg)
-			t.Logf("📨 Sent response %d", idx+1)
-		}(i)
+	if err := gateway.SendCommand(context.Background(), 11, 0x04, 0x0000, 3); err != nil {
+		t.Fatalf("❌ SendCommand failed: %v", err)
 	}
-
-	wg.Wait()
-	time.Sleep(100 * time.Millisecond)
-
-	// Count how many responses were buffered
-	receivedCount := 0
-	for {
-		select {
-		case data := <-gateway.responseChan:
-			receivedCount++
-			t.Logf("📥 Received response %d: %v", receivedCount, data)
-		case <-time.After(50 * time.Millisecond):
-			// No more responses
-			goto done
-		}
+	data, err := gateway.WaitForResponse(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("❌ WaitForResponse failed: %v", err)
 	}
-
-done:
-	t.Logf("📊 Sent %d responses, received %d (channel buffer size = 1)", numResponses, receivedCount)
-
-	// With buffer size 1, only 1 response should be buffered
-	// Others should be dropped (logged as warnings)
-	if receivedCount > 1 {
-		t.Logf("⚠️ Warning: Multiple responses buffered (expected max 1, got %d)", receivedCount)
-		t.Log("   This may indicate channel overflow - check gateway logs for warnings")
+	if len(data) != 6 || binary.BigEndian.Uint16(data[4:6]) != 11002 {
+		t.Errorf("❌ Unexpected payload %02X", data)
 	}
 
-	if receivedCount == 0 {
-		t.Error("❌ No responses received - channel may be blocked")
-	} else {
-		t.Logf("✅ Response collision handled correctly (%d response received)", receivedCount)
+	if _, err := gateway.WaitForResponse(context.Background(), 1); err == nil {
+		t.Error("❌ Expected error when no request was sent")
 	}
+	t.Logf("✅ Two-step API returned %02X", data)
 }
 
-// TestConcurrentRapidDeviceSwitch tests rapid switching between devices
-// Simulates realistic scenario where devices are polled in quick succession
-func TestConcurrentRapidDeviceSwitch(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	devices := []uint8{11, 1, 20, 11, 1, 20, 11, 1} // Rapid switching pattern
-	successCount := 0
-
-	t.Logf("🔄 Testing rapid device switching (%d switches)", len(devices))
-
-	for i, slaveID := range devices {
-		// Set expected parameters
-		gateway.commandMutex.Lock()
-		gateway.expectedSlaveID = slaveID
-		gateway.expectedFunctionCode = 0x03
-		gateway.commandMutex.Unlock()
-
-		// Send response immediately (simulating fast polling)
-		data := []byte{0x43, byte(i), 0x00, 0x00}
-		msg := createMockMessage("test/data", slaveID, 0x03, data)
+// TestWritesSerializedWithReads verifies that writes share the bus with reads
+func TestWritesSerializedWithReads(t *testing.T) {
+	gateway, bus := newSimulatedUSRGateway(5 * time.Millisecond)
 
-		go func() {
-			time.Sleep(5 * time.Millisecond) // Very small delay
-			gateway.onMessage(nil, msg)
-		}()
-
-		// Wait for response
-		select {
-		case <-gateway.responseChan:
-			successCount++
-			t.Logf("✅ Switch %d: Slave %d responded correctly", i+1, slaveID)
-		case <-time.After(50 * time.Millisecond):
-			t.Errorf("❌ Switch %d: Timeout for Slave %d", i+1, slaveID)
-		}
-
-		// Very short delay before next switch (10ms = 100 switches/sec)
-		time.Sleep(10 * time.Millisecond)
-	}
-
-	successRate := float64(successCount) / float64(len(devices)) * 100
-
-	if successRate < 100.0 {
-		t.Errorf("❌ Rapid switching failed: %.1f%% success rate (expected 100%%)", successRate)
-	} else {
-		t.Logf("✅ Rapid device switching: %d/%d successful (100%%)", successCount, len(devices))
-	}
-}
-
-// TestConcurrentMixedValidInvalid tests concurrent mix of valid and invalid responses
-func TestConcurrentMixedValidInvalid(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	// Expecting Slave 11, Function 0x03
-	gateway.expectedSlaveID = 11
-	gateway.expectedFunctionCode = 0x03
-
-	t.Log("📤 Expecting Slave 11, FC=0x03")
-	t.Log("📨 Sending mix of valid and invalid responses concurrently...")
-
-	// Send a mix of responses concurrently
 	var wg sync.WaitGroup
-	responseTypes := []string{
-		"Wrong SlaveID 1",
-		"Wrong SlaveID 20",
-		"Correct Slave 11",
-		"Wrong FunctionCode",
-		"Wrong SlaveID 5",
-		"Wrong Both",
-	}
-
-	for _, respType := range responseTypes {
-		wg.Add(1)
-		go func(rType string) {
+	errs := make(chan error, 10)
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func(slaveID uint8) {
 			defer wg.Done()
-
-			var slaveID, functionCode uint8
-			switch rType {
-			case "Correct Slave 11":
-				slaveID, functionCode = 11, 0x03
-			case "Wrong SlaveID 1":
-				slaveID, functionCode = 1, 0x03
-			case "Wrong SlaveID 20":
-				slaveID, functionCode = 20, 0x03
-			case "Wrong SlaveID 5":
-				slaveID, functionCode = 5, 0x03
-			case "Wrong FunctionCode":
-				slaveID, functionCode = 11, 0x04
-			case "Wrong Both":
-				slaveID, functionCode = 1, 0x04
-			}
-
-			data := []byte{0x43, byte(slaveID), 0x00, 0x00}
-			msg := createMockMessage("test/data", slaveID, functionCode, data)
-			gateway.onMessage(nil, msg)
-			t.Logf("  📨 %s (Slave=%d, FC=0x%02X)", rType, slaveID, functionCode)
-		}(respType)
+			_, err := gateway.SendCommandAndWaitForResponse(context.Background(), slaveID, 0x03, 0x2000, 2, 2)
+			errs <- err
+		}(uint8(i + 1))
+		go func(slaveID uint8) {
+			defer wg.Done()
+			errs <- gateway.Write(context.Background(), slaveID, NewWriteRegister(0x0006, 3), 2)
+		}(uint8(i + 1))
 	}
-
 	wg.Wait()
-	time.Sleep(100 * time.Millisecond)
+	close(errs)
 
-	// Check that only the correct response was accepted
-	select {
-	case data := <-gateway.responseChan:
-		t.Logf("✅ Correct response accepted (%d bytes)", len(data))
-
-		// Verify no other responses in channel
-		select {
-		case extra := <-gateway.responseChan:
-			t.Errorf("❌ Multiple responses accepted! Extra: %v", extra)
-		case <-time.After(50 * time.Millisecond):
-			t.Log("✅ Only correct response accepted, all invalid responses rejected")
+	for err := range errs {
+		if err != nil {
+			t.Errorf("❌ Transaction failed: %v", err)
 		}
-	case <-time.After(200 * time.Millisecond):
-		t.Error("❌ No response received - correct response should have been accepted")
 	}
-}
 
-// TestLateResponseAfterTimeout tests that late responses arriving after timeout
-// don't contaminate the next request (REGRESSION TEST for production bug)
-func TestLateResponseAfterTimeout(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.maxInFlight != 1 {
+		t.Errorf("❌ Expected one transaction on the bus at a time, saw %d", bus.maxInFlight)
 	}
+	t.Logf("✅ %d reads and writes serialized on the bus", bus.commands)
+}
 
-	gateway := NewUSRGateway(cfg)
-
-	t.Log("📋 Scenario: Device responds slowly (>5s), causing timeout + late response")
-	t.Log("")
-
-	// Request 1: Slave 11, expect timeout
-	t.Log("📤 Request 1: Sending to Slave 11, FC=0x03 (will timeout)")
-	gateway.commandMutex.Lock()
-	gateway.expectedSlaveID = 11
-	gateway.expectedFunctionCode = 0x03
-	gateway.commandMutex.Unlock()
+// TestCloseFailsPendingRequests verifies waiting requests are released when the gateway closes
+func TestCloseFailsPendingRequests(t *testing.T) {
+	gateway, bus := newSimulatedUSRGateway(2 * time.Millisecond)
+	bus.silent[11] = true
 
-	// Simulate timeout (no response arrives in time)
-	responseReceived := false
+	done := make(chan error, 1)
 	go func() {
-		select {
-		case <-gateway.responseChan:
-			responseReceived = true
-		case <-time.After(100 * time.Millisecond):
-			// Timeout simulation
-			t.Log("⏱️ Request 1: Timeout (no response in 100ms)")
-		}
+		_, err := gateway.SendCommandAndWaitForResponse(context.Background(), 11, 0x03, 0x2000, 2, 5)
+		done <- err
 	}()
 
-	time.Sleep(150 * time.Millisecond)
-
-	if responseReceived {
-		t.Error("❌ Request 1: Should have timed out, but got response")
-		return
-	}
-	t.Log("✅ Request 1: Correctly timed out")
-
-	// Request 2: Slave 1 (different device)
-	t.Log("")
-	t.Log("📤 Request 2: Sending to Slave 1, FC=0x03")
-	gateway.commandMutex.Lock()
-	gateway.expectedSlaveID = 1
-	gateway.expectedFunctionCode = 0x03
-	gateway.commandMutex.Unlock()
-
-	// Late response arrives AFTER timeout (and AFTER expectation changed)
-	t.Log("📨 LATE response from Slave 11 arrives (for Slave 11, but now expecting Slave 1)")
-	lateResponse := createMockMessage("test/data", 11, 0x03, []byte{0x43, 0x70, 0x00, 0x00})
-	gateway.onMessage(nil, lateResponse)
-
 	time.Sleep(50 * time.Millisecond)
+	gateway.Close()
 
-	// Send correct response for Slave 1
-	t.Log("📨 Correct response from Slave 1 arrives")
-	correctResponse := createMockMessage("test/data", 1, 0x03, []byte{0x41, 0xF0, 0x00, 0x00})
-	go func() {
-		time.Sleep(50 * time.Millisecond)
-		gateway.onMessage(nil, correctResponse)
-	}()
-
-	// Check that we get Slave 1 response (not the late Slave 11 response)
 	select {
-	case data := <-gateway.responseChan:
-		// The data should be from Slave 1 response
-		// Note: data is the payload AFTER SlaveID/FC/ByteCount (see onMessage implementation)
-		if len(data) != 4 {
-			t.Errorf("❌ Request 2: Unexpected response length: %d (expected 4)", len(data))
-		} else {
-			t.Logf("✅ Request 2: Received correct response (%d bytes)", len(data))
-			t.Log("✅ Late response from Slave 11 was correctly rejected/discarded")
+	case err := <-done:
+		if err == nil {
+			t.Fatal("❌ Expected error after Close")
 		}
-	case <-time.After(200 * time.Millisecond):
-		t.Error("❌ Request 2: Timeout - should have received Slave 1 response")
-		t.Error("   This indicates late response contamination bug!")
-	}
-
-	// Verify channel is clean (no stale late response)
-	select {
-	case stale := <-gateway.responseChan:
-		t.Errorf("❌ Stale response found in channel: %v", stale)
-		t.Error("   Late response cleanup FAILED!")
-	case <-time.After(50 * time.Millisecond):
-		t.Log("✅ Channel clean - no stale responses")
+		t.Logf("✅ Pending request released on close: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("❌ Pending request still blocked after Close")
 	}
 }
 
-// TestTimeoutWithSubsequentSuccess tests the complete flow:
-// 1. Request timeouts
-// 2. Late response arrives (should be discarded)
-// 3. Next request succeeds normally
-func TestTimeoutWithSubsequentSuccess(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	// Simulate 3 requests in sequence with timeout recovery
-	requests := []struct {
-		name     string
-		slaveID  uint8
-		timeout  bool // Should this request timeout?
-		lateResp bool // Should late response arrive after timeout?
-	}{
-		{"Request 1 (Slave 11)", 11, true, true},   // Timeout + late response
-		{"Request 2 (Slave 1)", 1, false, false},   // Success
-		{"Request 3 (Slave 11)", 11, false, false}, // Success
-	}
-
-	for i, req := range requests {
-		t.Logf("")
-		t.Logf("📤 %s", req.name)
-
-		gateway.commandMutex.Lock()
-		gateway.expectedSlaveID = req.slaveID
-		gateway.expectedFunctionCode = 0x03
-		gateway.commandMutex.Unlock()
-
-		if req.timeout {
-			// Simulate timeout
-			t.Logf("   ⏱️ Simulating timeout...")
-			timedOut := false
-			select {
-			case <-gateway.responseChan:
-				t.Errorf("   ❌ %s: Got response but expected timeout!", req.name)
-			case <-time.After(100 * time.Millisecond):
-				timedOut = true
-				t.Logf("   ✅ Correctly timed out")
-			}
-
-			if !timedOut {
-				continue
-			}
-
-			if req.lateResp {
-				// Late response arrives
-				t.Logf("   📨 Late response arrives (should be discarded)")
-				lateMsg := createMockMessage("test/data", req.slaveID, 0x03, []byte{0x43, 0x70, 0x00, 0x00})
-				gateway.onMessage(nil, lateMsg)
-				time.Sleep(50 * time.Millisecond)
-			}
-		} else {
-			// Simulate successful response
-			data := []byte{0x43, byte(i), 0x00, 0x00}
-			msg := createMockMessage("test/data", req.slaveID, 0x03, data)
-
-			go func() {
-				time.Sleep(50 * time.Millisecond)
-				gateway.onMessage(nil, msg)
-			}()
-
-			select {
-			case resp := <-gateway.responseChan:
-				t.Logf("   ✅ Received response (%d bytes)", len(resp))
-			case <-time.After(200 * time.Millisecond):
-				t.Errorf("   ❌ %s: Timeout waiting for response", req.name)
-			}
-		}
-	}
-
-	// Final verification: channel should be clean
-	select {
-	case stale := <-gateway.responseChan:
-		t.Errorf("❌ Final check: Stale response in channel: %v", stale)
-	case <-time.After(50 * time.Millisecond):
-		t.Log("✅ Final check: Channel clean after timeout recovery")
-	}
-}
+// BenchmarkResponseDelivery measures the cost of correlating a response with its request
+func BenchmarkResponseDelivery(b *testing.B) {
+	gateway := newTestUSRGateway()
+	msg := createMockMessage("test/data", 11, 0x03, []byte{0x43, 0x66, 0x00, 0x00})
 
-// TestMultipleTimeoutsWithLateResponses tests worst-case scenario:
-// Multiple consecutive timeouts with late responses piling up
-func TestMultipleTimeoutsWithLateResponses(t *testing.T) {
-	cfg := &config.MQTTConfig{
-		Broker:   "test",
-		Port:     1883,
-		Username: "test",
-		Password: "test",
-		Gateway: config.GatewayConfig{
-			MAC:       "TEST123456",
-			CmdTopic:  "test/cmd",
-			DataTopic: "test/data",
-		},
-	}
-
-	gateway := NewUSRGateway(cfg)
-
-	t.Log("🔥 Stress test: 5 consecutive timeouts with late responses")
-
-	// Simulate 5 timeout scenarios
-	for i := 0; i < 5; i++ {
-		slaveID := uint8((i % 2) + 1) // Alternate between Slave 1 and 2
-
-		t.Logf("")
-		t.Logf("📤 Request %d: Slave %d", i+1, slaveID)
-
-		gateway.commandMutex.Lock()
-		gateway.expectedSlaveID = slaveID
-		gateway.expectedFunctionCode = 0x03
-		gateway.commandMutex.Unlock()
-
-		// Wait for timeout
-		select {
-		case <-gateway.responseChan:
-			t.Logf("   ⚠️ Request %d: Got unexpected response (channel not clean)", i+1)
-			// This is actually OK - it means a late response snuck in
-			// The important thing is that it's detected
-		case <-time.After(100 * time.Millisecond):
-			t.Logf("   ⏱️ Request %d: Timed out", i+1)
-		}
-
-		// Late response arrives AFTER we've moved to next request expectation
-		// This simulates the real bug scenario
-		lateData := []byte{0x43, byte(i), 0x00, 0x00}
-		lateMsg := createMockMessage("test/data", slaveID, 0x03, lateData)
-
-		// Change expectation to next slave BEFORE late response arrives
-		// This is the KEY to reproducing the real bug
-		nextSlaveID := uint8(((i + 1) % 2) + 1)
-		gateway.commandMutex.Lock()
-		gateway.expectedSlaveID = nextSlaveID
-		gateway.commandMutex.Unlock()
-
-		// Now send the late response - it should be REJECTED by onMessage
-		// because expectedSlaveID has changed
-		gateway.onMessage(nil, lateMsg)
-		t.Logf("   📨 Request %d: Late response sent (for Slave %d, but now expecting Slave %d)",
-			i+1, slaveID, nextSlaveID)
-
-		time.Sleep(50 * time.Millisecond)
-
-		// Verify late response was rejected (not in channel)
-		select {
-		case unexpected := <-gateway.responseChan:
-			t.Errorf("   ❌ Request %d: Late response was NOT rejected: %v", i+1, unexpected)
-		default:
-			t.Logf("   ✅ Request %d: Late response correctly rejected", i+1)
-		}
-	}
-
-	// NOW send a successful request - should work despite all previous timeouts
-	t.Log("")
-	t.Log("📤 Final request: Slave 1 (should succeed despite previous chaos)")
-
-	gateway.commandMutex.Lock()
-	gateway.expectedSlaveID = 1
-	gateway.expectedFunctionCode = 0x03
-	gateway.commandMutex.Unlock()
-
-	correctMsg := createMockMessage("test/data", 1, 0x03, []byte{0x42, 0x00, 0x00, 0x00})
-	go func() {
-		time.Sleep(50 * time.Millisecond)
-		gateway.onMessage(nil, correctMsg)
-	}()
-
-	select {
-	case resp := <-gateway.responseChan:
-		t.Logf("✅ Final request succeeded: received %d bytes", len(resp))
-		t.Log("✅ System recovered correctly after multiple timeouts!")
-	case <-time.After(200 * time.Millisecond):
-		t.Error("❌ Final request failed!")
-		t.Error("   Late response cleanup is not working correctly")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := gateway.requests.register(11, 0x03, 0x2000, 2)
+		gateway.onMessage(nil, msg)
+		<-req.response
 	}
 }
@@ -0,0 +1,202 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+)
+
+// Modbus write function codes
+const (
+	FunctionWriteSingleCoil        uint8 = 0x05
+	FunctionWriteSingleRegister    uint8 = 0x06
+	FunctionWriteMultipleCoils     uint8 = 0x0F
+	FunctionWriteMultipleRegisters uint8 = 0x10
+)
+
+// Modbus limits on the quantity written by one request
+const (
+	maxWriteCoils     = 1968
+	maxWriteRegisters = 123
+)
+
+// WriteRequest is a Modbus write ready to be sent with Gateway.Write
+// Build it with NewWriteCoil, NewWriteRegister, NewWriteCoils or NewWriteRegisters.
+type WriteRequest struct {
+	FunctionCode uint8
+	Address      uint16
+	Quantity     uint16 // Number of coils or registers written
+	pdu          []byte
+}
+
+// NewWriteCoil builds a Write Single Coil (0x05) request
+func NewWriteCoil(address uint16, on bool) *WriteRequest {
+	value := uint16(0x0000)
+	if on {
+		value = 0xFF00
+	}
+	pdu := make([]byte, 5)
+	pdu[0] = FunctionWriteSingleCoil
+	binary.BigEndian.PutUint16(pdu[1:3], address)
+	binary.BigEndian.PutUint16(pdu[3:5], value)
+	return &WriteRequest{FunctionCode: FunctionWriteSingleCoil, Address: address, Quantity: 1, pdu: pdu}
+}
+
+// NewWriteRegister builds a Write Single Register (0x06) request
+func NewWriteRegister(address uint16, value uint16) *WriteRequest {
+	pdu := make([]byte, 5)
+	pdu[0] = FunctionWriteSingleRegister
+	binary.BigEndian.PutUint16(pdu[1:3], address)
+	binary.BigEndian.PutUint16(pdu[3:5], value)
+	return &WriteRequest{FunctionCode: FunctionWriteSingleRegister, Address: address, Quantity: 1, pdu: pdu}
+}
+
+// NewWriteCoils builds a Write Multiple Coils (0x0F) request
+// Coils are packed LSB first, the same layout as a Read Coils response
+func NewWriteCoils(address uint16, values []bool) (*WriteRequest, error) {
+	if len(values) == 0 || len(values) > maxWriteCoils {
+		return nil, fmt.Errorf("cannot write %d coils (allowed 1-%d)", len(values), maxWriteCoils)
+	}
+
+	byteCount := (len(values) + 7) / 8
+	pdu := make([]byte, 6+byteCount)
+	pdu[0] = FunctionWriteMultipleCoils
+	binary.BigEndian.PutUint16(pdu[1:3], address)
+	binary.BigEndian.PutUint16(pdu[3:5], uint16(len(values))) // #nosec G115 -- bounded by maxWriteCoils
+	pdu[5] = byte(byteCount)
+	for i, on := range values {
+		if on {
+			pdu[6+i/8] |= 1 << (i % 8)
+		}
+	}
+	return &WriteRequest{FunctionCode: FunctionWriteMultipleCoils, Address: address, Quantity: uint16(len(values)), pdu: pdu}, nil // #nosec G115 -- bounded by maxWriteCoils
+}
+
+// NewWriteRegisters builds a Write Multiple Registers (0x10) request
+func NewWriteRegisters(address uint16, values []uint16) (*WriteRequest, error) {
+	if len(values) == 0 || len(values) > maxWriteRegisters {
+		return nil, fmt.Errorf("cannot write %d registers (allowed 1-%d)", len(values), maxWriteRegisters)
+	}
+
+	pdu := make([]byte, 6+2*len(values))
+	pdu[0] = FunctionWriteMultipleRegisters
+	binary.BigEndian.PutUint16(pdu[1:3], address)
+	binary.BigEndian.PutUint16(pdu[3:5], uint16(len(values))) // #nosec G115 -- bounded by maxWriteRegisters
+	pdu[5] = byte(2 * len(values))
+	for i, value := range values {
+		binary.BigEndian.PutUint16(pdu[6+2*i:], value)
+	}
+	return &WriteRequest{FunctionCode: FunctionWriteMultipleRegisters, Address: address, Quantity: uint16(len(values)), pdu: pdu}, nil // #nosec G115 -- bounded by maxWriteRegisters
+}
+
+// NewWriteValue encodes value as valueType and builds the write request for it
+// Single-register types use 0x06, wider types 0x10
+func NewWriteValue(address uint16, value float64, valueType string) (*WriteRequest, error) {
+	registers, err := EncodeValue(value, valueType)
+	if err != nil {
+		return nil, err
+	}
+	if len(registers) == 1 {
+		return NewWriteRegister(address, registers[0]), nil
+	}
+	return NewWriteRegisters(address, registers)
+}
+
+// PDU returns the request PDU (function code + data)
+func (w *WriteRequest) PDU() []byte {
+	return w.pdu
+}
+
+// String describes the request for logs
+func (w *WriteRequest) String() string {
+	return fmt.Sprintf("FC 0x%02X addr 0x%04X qty %d", w.FunctionCode, w.Address, w.Quantity)
+}
+
+// verifyEcho checks the payload returned by parseResponsePDU for a write request
+// 0x05/0x06 echo address and value, 0x0F/0x10 echo address and quantity:
+// in both cases the first four bytes after the function code match the request.
+func (w *WriteRequest) verifyEcho(data []byte) error {
+	if len(data) < 4 || !bytes.Equal(data[:4], w.pdu[1:5]) {
+		return fmt.Errorf("write response does not echo the request (%s): got %02X, expected %02X", w, data, w.pdu[1:5])
+	}
+	return nil
+}
+
+// EncodeValue converts a value to big-endian registers of the given type
+// Integer types are rounded and must be within range; 32-bit types use two registers, high word first.
+func EncodeValue(value float64, valueType string) ([]uint16, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("cannot encode %v as %s", value, valueType)
+	}
+
+	switch valueType {
+	case config.ValueTypeFloat32:
+		if math.Abs(value) > math.MaxFloat32 {
+			return nil, fmt.Errorf("value %g out of range for %s", value, valueType)
+		}
+		return splitUint32(math.Float32bits(float32(value))), nil
+	}
+
+	rounded := math.Round(value)
+	switch valueType {
+	case config.ValueTypeUint16:
+		if rounded < 0 || rounded > math.MaxUint16 {
+			return nil, fmt.Errorf("value %g out of range for %s", value, valueType)
+		}
+		return []uint16{uint16(rounded)}, nil
+	case config.ValueTypeInt16:
+		if rounded < math.MinInt16 || rounded > math.MaxInt16 {
+			return nil, fmt.Errorf("value %g out of range for %s", value, valueType)
+		}
+		return []uint16{uint16(int16(rounded))}, nil // #nosec G115 -- two's complement encoding
+	case config.ValueTypeUint32:
+		if rounded < 0 || rounded > math.MaxUint32 {
+			return nil, fmt.Errorf("value %g out of range for %s", value, valueType)
+		}
+		return splitUint32(uint32(rounded)), nil
+	case config.ValueTypeInt32:
+		if rounded < math.MinInt32 || rounded > math.MaxInt32 {
+			return nil, fmt.Errorf("value %g out of range for %s", value, valueType)
+		}
+		return splitUint32(uint32(int32(rounded))), nil // #nosec G115 -- two's complement encoding
+	default:
+		return nil, fmt.Errorf("unsupported value type '%s' (use uint16, int16, uint32, int32 or float32)", valueType)
+	}
+}
+
+// splitUint32 returns the high and low word of a 32-bit value
+func splitUint32(value uint32) []uint16 {
+	return []uint16{uint16(value >> 16), uint16(value)} // #nosec G115 -- intentional word split
+}
+
+// DecodeValue converts big-endian register data read with 0x03 back to a value
+// It is the inverse of EncodeValue.
+func DecodeValue(data []byte, valueType string) (float64, error) {
+	switch valueType {
+	case config.ValueTypeUint16, config.ValueTypeInt16:
+		if len(data) < 2 {
+			return 0, fmt.Errorf("need 2 bytes to decode %s, got %d", valueType, len(data))
+		}
+		raw := binary.BigEndian.Uint16(data)
+		if valueType == config.ValueTypeInt16 {
+			return float64(int16(raw)), nil // #nosec G115 -- two's complement decoding
+		}
+		return float64(raw), nil
+	case config.ValueTypeUint32, config.ValueTypeInt32, config.ValueTypeFloat32:
+		if len(data) < 4 {
+			return 0, fmt.Errorf("need 4 bytes to decode %s, got %d", valueType, len(data))
+		}
+		raw := binary.BigEndian.Uint32(data)
+		switch valueType {
+		case config.ValueTypeInt32:
+			return float64(int32(raw)), nil // #nosec G115 -- two's complement decoding
+		case config.ValueTypeFloat32:
+			return float64(math.Float32frombits(raw)), nil
+		}
+		return float64(raw), nil
+	default:
+		return 0, fmt.Errorf("unsupported value type '%s' (use uint16, int16, uint32, int32 or float32)", valueType)
+	}
+}
@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"fmt"
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"testing"
+)
+
+// TestWriteRequestPDUs verifies the PDU of each write function against the Modbus specification
+func TestWriteRequestPDUs(t *testing.T) {
+	coils, err := NewWriteCoils(0x0013, []bool{true, false, true, true, false, false, true, true, true, false})
+	if err != nil {
+		t.Fatalf("❌ NewWriteCoils failed: %v", err)
+	}
+	registers, err := NewWriteRegisters(0x0001, []uint16{0x000A, 0x0102})
+	if err != nil {
+		t.Fatalf("❌ NewWriteRegisters failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		req      *WriteRequest
+		expected string
+	}{
+		{"coil on", NewWriteCoil(0x00AC, true), "05 00 AC FF 00"},
+		{"coil off", NewWriteCoil(0x00AC, false), "05 00 AC 00 00"},
+		{"register", NewWriteRegister(0x0001, 0x0003), "06 00 01 00 03"},
+		// Specification example: 10 coils from 0x0013, CD 01
+		{"coils", coils, "0F 00 13 00 0A 02 CD 01"},
+		{"registers", registers, "10 00 01 00 02 04 00 0A 01 02"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprintf("% X", tt.req.PDU()); got != tt.expected {
+			t.Errorf("❌ %s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+	t.Logf("✅ %d write PDUs match the specification", len(tests))
+}
+
+// TestWriteRequestLimits verifies quantity limits of multiple writes
+func TestWriteRequestLimits(t *testing.T) {
+	if _, err := NewWriteRegisters(0, nil); err == nil {
+		t.Error("❌ Expected error for empty register write")
+	}
+	if _, err := NewWriteRegisters(0, make([]uint16, maxWriteRegisters+1)); err == nil {
+		t.Error("❌ Expected error for too many registers")
+	}
+	if _, err := NewWriteCoils(0, make([]bool, maxWriteCoils+1)); err == nil {
+		t.Error("❌ Expected error for too many coils")
+	}
+	if _, err := NewWriteRegisters(0, make([]uint16, maxWriteRegisters)); err != nil {
+		t.Errorf("❌ %d registers should be allowed: %v", maxWriteRegisters, err)
+	}
+}
+
+// TestEncodeValue verifies register encoding of each value type
+func TestEncodeValue(t *testing.T) {
+	tests := []struct {
+		value     float64
+		valueType string
+		expected  []uint16
+	}{
+		{1234, config.ValueTypeUint16, []uint16{0x04D2}},
+		{12.6, config.ValueTypeUint16, []uint16{13}},
+		{-2, config.ValueTypeInt16, []uint16{0xFFFE}},
+		{70000, config.ValueTypeUint32, []uint16{0x0001, 0x1170}},
+		{-1, config.ValueTypeInt32, []uint16{0xFFFF, 0xFFFF}},
+		{230.5, config.ValueTypeFloat32, []uint16{0x4366, 0x8000}},
+	}
+
+	for _, tt := range tests {
+		registers, err := EncodeValue(tt.value, tt.valueType)
+		if err != nil {
+			t.Errorf("❌ %s %g: %v", tt.valueType, tt.value, err)
+			continue
+		}
+		if fmt.Sprint(registers) != fmt.Sprint(tt.expected) {
+			t.Errorf("❌ %s %g: expected %04X, got %04X", tt.valueType, tt.value, tt.expected, registers)
+		}
+	}
+
+	invalid := []struct {
+		value     float64
+		valueType string
+	}{
+		{-1, config.ValueTypeUint16},
+		{65536, config.ValueTypeUint16},
+		{40000, config.ValueTypeInt16},
+		{math.MaxUint32 + 1, config.ValueTypeUint32},
+		{math.NaN(), config.ValueTypeFloat32},
+		{math.Inf(1), config.ValueTypeInt32},
+		{1, "int8"},
+	}
+	for _, tt := range invalid {
+		if _, err := EncodeValue(tt.value, tt.valueType); err == nil {
+			t.Errorf("❌ Expected error encoding %g as %s", tt.value, tt.valueType)
+		}
+	}
+	t.Logf("✅ Encoded %d values, rejected %d", len(tests), len(invalid))
+}
+
+// TestNewWriteValue verifies the function code chosen for each value width
+func TestNewWriteValue(t *testing.T) {
+	single, err := NewWriteValue(0x0006, 9600, config.ValueTypeUint16)
+	if err != nil || single.FunctionCode != FunctionWriteSingleRegister {
+		t.Errorf("❌ uint16 should use 0x06, got %v (%v)", single, err)
+	}
+	double, err := NewWriteValue(0x0006, 1.5, config.ValueTypeFloat32)
+	if err != nil || double.FunctionCode != FunctionWriteMultipleRegisters || double.Quantity != 2 {
+		t.Errorf("❌ float32 should use 0x10 with 2 registers, got %v (%v)", double, err)
+	}
+}
+
+// TestWriteEchoVerification verifies that mismatching write responses are rejected
+func TestWriteEchoVerification(t *testing.T) {
+	req := NewWriteRegister(0x0001, 0x0003)
+	if err := req.verifyEcho([]byte{0x00, 0x01, 0x00, 0x03}); err != nil {
+		t.Errorf("❌ Matching echo rejected: %v", err)
+	}
+	if err := req.verifyEcho([]byte{0x00, 0x01, 0x00, 0x04}); err == nil {
+		t.Error("❌ Expected error for wrong echoed value")
+	}
+	if err := req.verifyEcho([]byte{0x00, 0x01}); err == nil {
+		t.Error("❌ Expected error for short echo")
+	}
+
+	// 0x10 echoes the quantity, not the values
+	registers, _ := NewWriteRegisters(0x0100, []uint16{1, 2, 3})
+	if err := registers.verifyEcho([]byte{0x01, 0x00, 0x00, 0x03}); err != nil {
+		t.Errorf("❌ Matching 0x10 echo rejected: %v", err)
+	}
+}
+
+// TestDecodeValue verifies that DecodeValue reverses EncodeValue
+func TestDecodeValue(t *testing.T) {
+	tests := []struct {
+		value     float64
+		valueType string
+	}{
+		{1234, config.ValueTypeUint16},
+		{-2, config.ValueTypeInt16},
+		{70000, config.ValueTypeUint32},
+		{-1, config.ValueTypeInt32},
+		{230.5, config.ValueTypeFloat32},
+	}
+
+	for _, tt := range tests {
+		registers, err := EncodeValue(tt.value, tt.valueType)
+		if err != nil {
+			t.Fatalf("❌ %s %g: %v", tt.valueType, tt.value, err)
+		}
+		data := make([]byte, 2*len(registers))
+		for i, register := range registers {
+			data[2*i] = byte(register >> 8)
+			data[2*i+1] = byte(register)
+		}
+		decoded, err := DecodeValue(data, tt.valueType)
+		if err != nil || decoded != tt.value {
+			t.Errorf("❌ %s: expected %g, got %g (%v)", tt.valueType, tt.value, decoded, err)
+		}
+	}
+
+	if _, err := DecodeValue([]byte{0x00, 0x01}, config.ValueTypeFloat32); err == nil {
+		t.Error("❌ Expected error for short float32 data")
+	}
+	t.Logf("✅ Decoded %d value types", len(tests))
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// BridgeHealthMonitor aggregates the health of every gateway (bus) the bridge polls
+// The bridge is online while at least one gateway is online, so a dead bus only
+// takes its own devices offline
+type BridgeHealthMonitor struct {
+	monitors map[string]*GatewayHealthMonitor
+	mu       sync.RWMutex
+}
+
+// NewBridgeHealthMonitor creates an empty bridge health monitor
+func NewBridgeHealthMonitor() *BridgeHealthMonitor {
+	return &BridgeHealthMonitor{
+		monitors: make(map[string]*GatewayHealthMonitor),
+	}
+}
+
+// Add registers the health monitor of a named gateway
+func (b *BridgeHealthMonitor) Add(name string, monitor *GatewayHealthMonitor) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.monitors[name] = monitor
+}
+
+// Get returns the health monitor of a named gateway (nil if unknown)
+func (b *BridgeHealthMonitor) Get(name string) *GatewayHealthMonitor {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.monitors[name]
+}
+
+// IsOnline returns true while at least one gateway is online (implements HealthChecker interface)
+func (b *BridgeHealthMonitor) IsOnline() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, monitor := range b.monitors {
+		if monitor.IsOnline() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOfflineGateways returns the sorted names of gateways currently marked offline
+func (b *BridgeHealthMonitor) GetOfflineGateways() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	offline := make([]string, 0)
+	for name, monitor := range b.monitors {
+		if !monitor.IsOnline() {
+			offline = append(offline, name)
+		}
+	}
+	sort.Strings(offline)
+	return offline
+}
+
+// GetErrorCount returns the consecutive errors summed over all gateways (implements HealthChecker interface)
+func (b *BridgeHealthMonitor) GetErrorCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	total := 0
+	for _, monitor := range b.monitors {
+		total += monitor.GetErrorCount()
+	}
+	return total
+}
+
+// GetSuccessCount returns the successful operations summed over all gateways (implements HealthChecker interface)
+func (b *BridgeHealthMonitor) GetSuccessCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	total := 0
+	for _, monitor := range b.monitors {
+		total += monitor.GetSuccessCount()
+	}
+	return total
+}
+
+// GetLastSuccessTime returns the most recent success on any gateway (implements HealthChecker interface)
+func (b *BridgeHealthMonitor) GetLastSuccessTime() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var latest time.Time
+	for _, monitor := range b.monitors {
+		if t := monitor.GetLastSuccessTime(); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
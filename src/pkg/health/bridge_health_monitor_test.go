@@ -0,0 +1,71 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBridgeOnlineWhileAnyGatewayOnline verifies a dead bus does not take the whole bridge offline
+func TestBridgeOnlineWhileAnyGatewayOnline(t *testing.T) {
+	bridge := NewBridgeHealthMonitor()
+	busA := NewGatewayHealthMonitor(0)
+	busB := NewGatewayHealthMonitor(0)
+	bridge.Add("bus_a", busA)
+	bridge.Add("bus_b", busB)
+
+	busA.RecordError()
+	busA.MarkOffline()
+
+	if !bridge.IsOnline() {
+		t.Fatal("❌ Bridge must stay online while bus_b is online")
+	}
+	if offline := bridge.GetOfflineGateways(); len(offline) != 1 || offline[0] != "bus_a" {
+		t.Errorf("❌ Expected [bus_a] offline, got %v", offline)
+	}
+	if busB.GetErrorCount() != 0 {
+		t.Errorf("❌ Errors on bus_a leaked into bus_b")
+	}
+	t.Logf("✅ bus_a offline, bridge still online")
+
+	busB.MarkOffline()
+	if bridge.IsOnline() {
+		t.Fatal("❌ Bridge must go offline when every bus is offline")
+	}
+	t.Logf("✅ Bridge offline once all buses are offline")
+
+	busA.RecordSuccess()
+	busA.MarkOnline()
+	if !bridge.IsOnline() {
+		t.Fatal("❌ Bridge must come back online when one bus recovers")
+	}
+	t.Logf("✅ Bridge back online after bus_a recovered")
+}
+
+// TestBridgeHealthAggregates verifies counters are summed and the latest success is reported
+func TestBridgeHealthAggregates(t *testing.T) {
+	bridge := NewBridgeHealthMonitor()
+	busA := NewGatewayHealthMonitor(time.Minute)
+	busB := NewGatewayHealthMonitor(time.Minute)
+	bridge.Add("bus_a", busA)
+	bridge.Add("bus_b", busB)
+
+	busA.RecordSuccess()
+	busA.RecordSuccess()
+	busB.RecordError()
+	busB.RecordError()
+	busB.RecordError()
+
+	if bridge.GetSuccessCount() != 2 {
+		t.Errorf("❌ Expected 2 successes, got %d", bridge.GetSuccessCount())
+	}
+	if bridge.GetErrorCount() != 3 {
+		t.Errorf("❌ Expected 3 errors, got %d", bridge.GetErrorCount())
+	}
+	if bridge.GetLastSuccessTime().Before(busA.GetLastSuccessTime()) {
+		t.Errorf("❌ Expected latest success time from bus_a")
+	}
+	if bridge.Get("bus_b") != busB || bridge.Get("missing") != nil {
+		t.Errorf("❌ Get returned the wrong monitor")
+	}
+	t.Logf("✅ Aggregated %d successes and %d errors", bridge.GetSuccessCount(), bridge.GetErrorCount())
+}
@@ -22,6 +22,9 @@ type MetricsCollector interface {
 	// IncrementMQTTErrors increments the counter for failed MQTT publish operations
 	IncrementMQTTErrors()
 
+	// IncrementMQTTSuppressed increments the counter for values not published (deadband or publish interval)
+	IncrementMQTTSuppressed()
+
 	// SetGatewayStatus sets the current gateway connection status
 	// Parameters:
 	//   - online: true if gateway is connected, false otherwise
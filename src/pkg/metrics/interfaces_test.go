@@ -40,6 +40,8 @@ func TestPrometheusMetricsRecording(t *testing.T) {
 	// Test MQTT metrics
 	pm.IncrementMQTTPublishes()
 	pm.IncrementMQTTErrors()
+	pm.IncrementMQTTSuppressed()
+	pm.IncrementMQTTSuppressed()
 
 	// Verify metrics output contains expected values
 	output := pm.GetMetricsText()
@@ -57,6 +59,9 @@ func TestPrometheusMetricsRecording(t *testing.T) {
 	if !contains(output, "mqtt_errors_total 1") {
 		t.Errorf("Expected mqtt_errors_total to be 1")
 	}
+	if !contains(output, "mqtt_suppressed_total 2") {
+		t.Errorf("Expected mqtt_suppressed_total to be 2")
+	}
 	if !contains(output, "gateway_status 0") {
 		t.Errorf("Expected gateway_status to be 0 (offline)")
 	}
@@ -73,6 +78,7 @@ func TestNullMetricsZeroOverhead(t *testing.T) {
 	nm.IncrementModbusErrors()
 	nm.IncrementMQTTPublishes()
 	nm.IncrementMQTTErrors()
+	nm.IncrementMQTTSuppressed()
 	nm.SetGatewayStatus(true)
 	nm.SetGatewayStatus(false)
 	nm.ObserveModbusReadDuration(100 * time.Millisecond)
@@ -26,6 +26,9 @@ func (nm *NullMetrics) IncrementMQTTPublishes() {}
 // IncrementMQTTErrors is a no-op
 func (nm *NullMetrics) IncrementMQTTErrors() {}
 
+// IncrementMQTTSuppressed is a no-op
+func (nm *NullMetrics) IncrementMQTTSuppressed() {}
+
 // SetGatewayStatus is a no-op
 func (nm *NullMetrics) SetGatewayStatus(online bool) {}
 
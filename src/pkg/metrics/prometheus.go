@@ -10,10 +10,11 @@ import (
 // PrometheusMetrics tracks application metrics in Prometheus format
 type PrometheusMetrics struct {
 	// Counters
-	modbusReadsTotal   int64
-	modbusErrorsTotal  int64
-	mqttPublishesTotal int64
-	mqttErrorsTotal    int64
+	modbusReadsTotal    int64
+	modbusErrorsTotal   int64
+	mqttPublishesTotal  int64
+	mqttErrorsTotal     int64
+	mqttSuppressedTotal int64
 
 	// Gauges
 	gatewayStatus int64 // 1 = online, 0 = offline
@@ -60,6 +61,13 @@ func (pm *PrometheusMetrics) IncrementMQTTErrors() {
 	pm.mqttErrorsTotal++
 }
 
+// IncrementMQTTSuppressed increments the counter of values not published by the publish filter
+func (pm *PrometheusMetrics) IncrementMQTTSuppressed() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.mqttSuppressedTotal++
+}
+
 // SetGatewayStatus sets the gateway status (1 = online, 0 = offline)
 func (pm *PrometheusMetrics) SetGatewayStatus(online bool) {
 	pm.mu.Lock()
@@ -106,6 +114,10 @@ mqtt_publishes_total %d
 # TYPE mqtt_errors_total counter
 mqtt_errors_total %d
 
+# HELP mqtt_suppressed_total Total number of values not published (deadband or publish interval)
+# TYPE mqtt_suppressed_total counter
+mqtt_suppressed_total %d
+
 # HELP gateway_status Current gateway status (1 = online, 0 = offline)
 # TYPE gateway_status gauge
 gateway_status %d
@@ -122,6 +134,7 @@ modbus_read_duration_count %d
 		pm.modbusErrorsTotal,
 		pm.mqttPublishesTotal,
 		pm.mqttErrorsTotal,
+		pm.mqttSuppressedTotal,
 		pm.gatewayStatus,
 		avgReadDuration,
 		pm.modbusReadDurationCount,
@@ -0,0 +1,149 @@
+package modbus
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MeterCounters turns the readings of total_increasing registers into continuous series, keyed like ValueCache
+// The published value is the meter reading plus an offset. A rollover adds the rollover
+// limit to the offset; a meter reset or replacement is held back until it is acknowledged,
+// then the offset is chosen so the series continues from its last value.
+type MeterCounters struct {
+	states map[string]*CounterState
+	mutex  sync.Mutex
+}
+
+// CounterState is the offset and last reading of one counter, persisted across restarts
+type CounterState struct {
+	Offset       float64   `json:"offset"`                  // Added to the meter reading
+	LastReading  float64   `json:"last_reading"`            // Last accepted meter reading
+	Rollovers    int       `json:"rollovers"`               // Wraps at the rollover limit
+	Resets       int       `json:"resets"`                  // Acknowledged meter resets and replacements
+	Pending      *float64  `json:"pending,omitempty"`       // Meter reading after a reset that waits for acknowledgement
+	PendingSince time.Time `json:"pending_since,omitempty"` // When the reset was detected
+}
+
+// Value returns the continuous value of the last accepted reading
+func (s *CounterState) Value() float64 {
+	return s.LastReading + s.Offset
+}
+
+// NewMeterCounters creates an empty counter state store
+func NewMeterCounters() *MeterCounters {
+	return &MeterCounters{
+		states: make(map[string]*CounterState),
+	}
+}
+
+// Apply adds a meter reading of the key and returns the continuous value
+// A drop that is not a rollover returns an error: the reading is held back until
+// Acknowledge is called, or accepted at once with auto_acknowledge. A reading that
+// climbs back to the last accepted one cancels the pending reset (a bad read).
+func (c *MeterCounters) Apply(key string, counter *config.CounterConfig, reading float64, now time.Time) (float64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state, exists := c.states[key]
+	if !exists {
+		state = &CounterState{LastReading: reading}
+		c.states[key] = state
+		return state.Value(), nil
+	}
+
+	switch {
+	case reading >= state.LastReading:
+		state.Pending = nil
+		state.PendingSince = time.Time{}
+	case counter.IsRollover(state.LastReading, reading):
+		state.Offset += counter.Rollover
+		state.Rollovers++
+	case counter != nil && counter.AutoAcknowledge:
+		state.continueFrom(reading)
+	default:
+		if state.Pending == nil {
+			state.PendingSince = now
+		}
+		pending := reading
+		state.Pending = &pending
+		return 0, fmt.Errorf("meter reading dropped from %.3f to %.3f: reset or replacement waiting for acknowledgement since %s",
+			state.LastReading, reading, state.PendingSince.Format(time.RFC3339))
+	}
+
+	state.LastReading = reading
+	return state.Value(), nil
+}
+
+// continueFrom accepts a reading after a reset so the continuous value stays where it was
+func (s *CounterState) continueFrom(reading float64) {
+	s.Offset += s.LastReading - reading
+	s.Resets++
+	s.Pending = nil
+	s.PendingSince = time.Time{}
+}
+
+// Acknowledge accepts the pending reset of a key and returns the new offset
+func (c *MeterCounters) Acknowledge(key string) (float64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state, exists := c.states[key]
+	if !exists || state.Pending == nil {
+		return 0, fmt.Errorf("counter '%s' has no pending reset", key)
+	}
+	reading := *state.Pending
+	state.continueFrom(reading)
+	state.LastReading = reading
+	return state.Offset, nil
+}
+
+// Pending returns the keys with a reset waiting for acknowledgement, in alphabetical order
+func (c *MeterCounters) Pending() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var keys []string
+	for key, state := range c.states {
+		if state.Pending != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Snapshot returns a copy of the state of every counter
+func (c *MeterCounters) Snapshot() map[string]CounterState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	snapshot := make(map[string]CounterState, len(c.states))
+	for key, state := range c.states {
+		snapshot[key] = state.clone()
+	}
+	return snapshot
+}
+
+// Restore sets the state of the given counters, so the series continue after a restart
+func (c *MeterCounters) Restore(snapshot map[string]CounterState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, state := range snapshot {
+		restored := state.clone()
+		c.states[key] = &restored
+	}
+}
+
+// clone copies the state including the pending reading
+func (s *CounterState) clone() CounterState {
+	cloned := *s
+	if s.Pending != nil {
+		pending := *s.Pending
+		cloned.Pending = &pending
+	}
+	return cloned
+}
@@ -0,0 +1,105 @@
+package modbus
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"testing"
+	"time"
+)
+
+// TestCounterRollover verifies a wrap at the rollover limit continues the series
+func TestCounterRollover(t *testing.T) {
+	counter := &config.CounterConfig{Rollover: 100000}
+	counters := NewMeterCounters()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		reading  float64
+		expected float64
+	}{
+		{99990, 99990},
+		{99998.5, 99998.5},
+		{3.5, 100003.5}, // Wrapped: the meter advanced 5 kWh
+		{10, 100010},
+	}
+	for _, step := range steps {
+		value, err := counters.Apply("meter_energy_imported", counter, step.reading, now)
+		if err != nil {
+			t.Fatalf("❌ Reading %.1f rejected: %v", step.reading, err)
+		}
+		if value != step.expected {
+			t.Errorf("❌ Reading %.1f → %.1f, expected %.1f", step.reading, value, step.expected)
+		}
+	}
+	if state := counters.Snapshot()["meter_energy_imported"]; state.Rollovers != 1 || state.Offset != 100000 {
+		t.Errorf("❌ State = %+v, expected one rollover", state)
+	}
+	t.Log("✅ Rollover continues the series")
+}
+
+// TestCounterResetAcknowledge verifies a meter replacement waits for acknowledgement
+func TestCounterResetAcknowledge(t *testing.T) {
+	counter := &config.CounterConfig{Rollover: 100000}
+	counters := NewMeterCounters()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := counters.Apply("meter_energy_imported", counter, 5000, now); err != nil {
+		t.Fatal(err)
+	}
+	// A drop far from the rollover is a reset: held back until acknowledged
+	if _, err := counters.Apply("meter_energy_imported", counter, 0.5, now); err == nil {
+		t.Fatal("❌ A meter reset must wait for acknowledgement")
+	}
+	if _, err := counters.Apply("meter_energy_imported", counter, 1.5, now); err == nil {
+		t.Fatal("❌ Readings after the reset must wait for acknowledgement")
+	}
+	if pending := counters.Pending(); len(pending) != 1 || pending[0] != "meter_energy_imported" {
+		t.Errorf("❌ Pending = %v", pending)
+	}
+
+	offset, err := counters.Acknowledge("meter_energy_imported")
+	if err != nil {
+		t.Fatalf("❌ Acknowledge failed: %v", err)
+	}
+	if offset != 4998.5 {
+		t.Errorf("❌ Offset = %.1f, expected 4998.5", offset)
+	}
+	value, err := counters.Apply("meter_energy_imported", counter, 3, now)
+	if err != nil || value != 5001.5 {
+		t.Errorf("❌ After acknowledgement 3 → %.1f (%v), expected 5001.5", value, err)
+	}
+	if _, err := counters.Acknowledge("meter_energy_imported"); err == nil {
+		t.Error("❌ Acknowledging without a pending reset must fail")
+	}
+
+	// A bad read that recovers cancels the pending reset
+	if _, err := counters.Apply("meter_energy_imported", counter, 0, now); err == nil {
+		t.Fatal("❌ Drop must be held back")
+	}
+	if value, err := counters.Apply("meter_energy_imported", counter, 3.2, now); err != nil || value != 5001.7 {
+		t.Errorf("❌ Recovered reading 3.2 → %.1f (%v), expected 5001.7", value, err)
+	}
+	if len(counters.Pending()) != 0 {
+		t.Error("❌ Recovered reading must cancel the pending reset")
+	}
+	t.Log("✅ Meter resets wait for acknowledgement")
+}
+
+// TestCounterAutoAcknowledgeAndRestore verifies auto_acknowledge and restoring the offsets
+func TestCounterAutoAcknowledgeAndRestore(t *testing.T) {
+	counter := &config.CounterConfig{AutoAcknowledge: true}
+	counters := NewMeterCounters()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, _ = counters.Apply("meter_energy_exported", counter, 250, now)
+	value, err := counters.Apply("meter_energy_exported", counter, 1, now)
+	if err != nil || value != 250 {
+		t.Fatalf("❌ Auto acknowledged reset → %.1f (%v), expected 250", value, err)
+	}
+
+	restarted := NewMeterCounters()
+	restarted.Restore(counters.Snapshot())
+	if value, err := restarted.Apply("meter_energy_exported", counter, 2, now); err != nil || value != 251 {
+		t.Errorf("❌ Restored counter 2 → %.1f (%v), expected 251", value, err)
+	}
+	t.Log("✅ Auto acknowledge and restore")
+}
@@ -0,0 +1,111 @@
+package modbus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+)
+
+// DecodeRegisters converts register data to a value of the given data type and byte order
+// data must hold at least the width of the data type; extra bytes are ignored.
+func DecodeRegisters(data []byte, dataType, byteOrder string) (float64, error) {
+	size, exists := config.DataTypeSize(dataType)
+	if !exists {
+		return 0, fmt.Errorf("unsupported data type '%s'", dataType)
+	}
+	if len(data) < size {
+		return 0, fmt.Errorf("need %d bytes to decode %s, got %d", size, dataType, len(data))
+	}
+
+	ordered, err := toBigEndian(data[:size], byteOrder)
+	if err != nil {
+		return 0, err
+	}
+
+	switch dataType {
+	case config.DataTypeInt16:
+		return float64(int16(binary.BigEndian.Uint16(ordered))), nil // #nosec G115 -- two's complement decoding
+	case config.DataTypeUint16:
+		return float64(binary.BigEndian.Uint16(ordered)), nil
+	case config.DataTypeInt32:
+		return float64(int32(binary.BigEndian.Uint32(ordered))), nil // #nosec G115 -- two's complement decoding
+	case config.DataTypeUint32:
+		return float64(binary.BigEndian.Uint32(ordered)), nil
+	case config.DataTypeInt64:
+		return float64(int64(binary.BigEndian.Uint64(ordered))), nil // #nosec G115 -- two's complement decoding
+	case config.DataTypeFloat32:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(ordered))), nil
+	default: // config.DataTypeFloat64
+		return math.Float64frombits(binary.BigEndian.Uint64(ordered)), nil
+	}
+}
+
+// DecodeString converts string or bcd register data to text
+// Strings end at the first NUL and trailing spaces are removed; bcd yields two digits per byte.
+func DecodeString(data []byte, dataType, byteOrder string) (string, error) {
+	ordered, err := toBigEndian(data, byteOrder)
+	if err != nil {
+		return "", err
+	}
+
+	switch dataType {
+	case config.DataTypeString:
+		if end := bytes.IndexByte(ordered, 0); end >= 0 {
+			ordered = ordered[:end]
+		}
+		for _, c := range ordered {
+			if c < 0x20 || c > 0x7E {
+				return "", fmt.Errorf("non-printable character 0x%02X in string", c)
+			}
+		}
+		return strings.TrimRight(string(ordered), " "), nil
+	case config.DataTypeBCD:
+		digits := make([]byte, 0, len(ordered)*2)
+		for _, b := range ordered {
+			for _, nibble := range []byte{b >> 4, b & 0x0F} {
+				if nibble > 9 {
+					return "", fmt.Errorf("invalid BCD digit 0x%X in byte 0x%02X", nibble, b)
+				}
+				digits = append(digits, '0'+nibble)
+			}
+		}
+		return string(digits), nil
+	default:
+		return "", fmt.Errorf("data type '%s' is not a text type", dataType)
+	}
+}
+
+// toBigEndian reorders the bytes of a value received in byteOrder to big-endian (ABCD)
+// Returns a copy; data is not modified.
+func toBigEndian(data []byte, byteOrder string) ([]byte, error) {
+	var swapBytes, swapWords bool
+	switch byteOrder {
+	case config.ByteOrderABCD, "":
+	case config.ByteOrderCDAB:
+		swapWords = true
+	case config.ByteOrderBADC:
+		swapBytes = true
+	case config.ByteOrderDCBA:
+		swapBytes, swapWords = true, true
+	default:
+		return nil, fmt.Errorf("unsupported byte order '%s'", byteOrder)
+	}
+
+	words := len(data) / 2
+	ordered := make([]byte, len(data))
+	for i := 0; i < words; i++ {
+		src := i
+		if swapWords {
+			src = words - 1 - i
+		}
+		hi, lo := data[2*src], data[2*src+1]
+		if swapBytes {
+			hi, lo = lo, hi
+		}
+		ordered[2*i], ordered[2*i+1] = hi, lo
+	}
+	return ordered, nil
+}
@@ -0,0 +1,209 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"testing"
+)
+
+// reorder lays out big-endian bytes the way a device using byteOrder sends them
+func reorder(bigEndian []byte, byteOrder string) []byte {
+	out := make([]byte, len(bigEndian))
+	copy(out, bigEndian)
+	words := len(out) / 2
+	if byteOrder == config.ByteOrderCDAB || byteOrder == config.ByteOrderDCBA {
+		for i := 0; i < words/2; i++ {
+			j := words - 1 - i
+			out[2*i], out[2*i+1], out[2*j], out[2*j+1] = out[2*j], out[2*j+1], out[2*i], out[2*i+1]
+		}
+	}
+	if byteOrder == config.ByteOrderBADC || byteOrder == config.ByteOrderDCBA {
+		for i := 0; i < words; i++ {
+			out[2*i], out[2*i+1] = out[2*i+1], out[2*i]
+		}
+	}
+	return out
+}
+
+// TestDecodeRegistersAllCombinations decodes every data type in every byte order
+func TestDecodeRegistersAllCombinations(t *testing.T) {
+	values := []struct {
+		dataType  string
+		value     float64
+		bigEndian func() []byte
+	}{
+		{config.DataTypeInt16, -12345, func() []byte { b := make([]byte, 2); binary.BigEndian.PutUint16(b, uint16(0xCFC7)); return b }},
+		{config.DataTypeUint16, 54321, func() []byte { b := make([]byte, 2); binary.BigEndian.PutUint16(b, 54321); return b }},
+		{config.DataTypeInt32, -123456789, func() []byte { b := make([]byte, 4); binary.BigEndian.PutUint32(b, 0xF8A432EB); return b }},
+		{config.DataTypeUint32, 3000000000, func() []byte { b := make([]byte, 4); binary.BigEndian.PutUint32(b, 3000000000); return b }},
+		{config.DataTypeInt64, -1234567890123, func() []byte {
+			b := make([]byte, 8)
+			binary.BigEndian.PutUint64(b, 0xFFFFFEE08E04FB35)
+			return b
+		}},
+		{config.DataTypeFloat32, 230.5, func() []byte { b := make([]byte, 4); binary.BigEndian.PutUint32(b, math.Float32bits(230.5)); return b }},
+		{config.DataTypeFloat64, 12345.678, func() []byte {
+			b := make([]byte, 8)
+			binary.BigEndian.PutUint64(b, math.Float64bits(12345.678))
+			return b
+		}},
+	}
+	orders := []string{config.ByteOrderABCD, config.ByteOrderCDAB, config.ByteOrderBADC, config.ByteOrderDCBA}
+
+	for _, v := range values {
+		for _, order := range orders {
+			data := reorder(v.bigEndian(), order)
+			got, err := DecodeRegisters(data, v.dataType, order)
+			if err != nil {
+				t.Errorf("❌ %s/%s: %v", v.dataType, order, err)
+				continue
+			}
+			if got != v.value {
+				t.Errorf("❌ %s/%s % X: expected %v, got %v", v.dataType, order, data, v.value, got)
+			}
+		}
+	}
+	t.Logf("✅ Decoded %d data types in %d byte orders", len(values), len(orders))
+}
+
+// TestDecodeRegistersWireOrder checks the byte layout of each order against a known float32
+func TestDecodeRegistersWireOrder(t *testing.T) {
+	// 230.5 = 0x43668000
+	wire := map[string][]byte{
+		config.ByteOrderABCD: {0x43, 0x66, 0x80, 0x00},
+		config.ByteOrderCDAB: {0x80, 0x00, 0x43, 0x66},
+		config.ByteOrderBADC: {0x66, 0x43, 0x00, 0x80},
+		config.ByteOrderDCBA: {0x00, 0x80, 0x66, 0x43},
+	}
+	for order, data := range wire {
+		if got, err := DecodeRegisters(data, config.DataTypeFloat32, order); err != nil || got != 230.5 {
+			t.Errorf("❌ %s % X: expected 230.5, got %v (%v)", order, data, got, err)
+		}
+	}
+
+	for _, tt := range []struct{ dataType, byteOrder string }{
+		{"int8", config.ByteOrderABCD},
+		{config.DataTypeInt32, "BACD"},
+	} {
+		if _, err := DecodeRegisters(make([]byte, 8), tt.dataType, tt.byteOrder); err == nil {
+			t.Errorf("❌ Expected error for %s/%s", tt.dataType, tt.byteOrder)
+		}
+	}
+	if _, err := DecodeRegisters(make([]byte, 4), config.DataTypeFloat64, config.ByteOrderABCD); err == nil {
+		t.Error("❌ Expected error for short float64 data")
+	}
+	t.Logf("✅ %d wire layouts verified", len(wire))
+}
+
+// TestGroupMixedDataTypes verifies a group with registers of different widths and orders
+func TestGroupMixedDataTypes(t *testing.T) {
+	data := []byte{
+		0xFF, 0xFE, // int16 -2 at offset 0
+		0x11, 0x70, 0x00, 0x01, // uint32 70000 CDAB at offset 2
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0x3F, // float64 1.0 DCBA at offset 6
+	}
+	gw := &fixedGateway{data: data}
+	group := config.RegisterGroup{
+		Name:          "Mixed",
+		FunctionCode:  config.FunctionReadHoldingRegisters,
+		StartAddress:  0x1000,
+		RegisterCount: 7,
+	}
+	registers := []RegisterWithKey{
+		{Key: "meter_a", Register: config.Register{Name: "A", Address: 0x1000, ScaleFactor: 0.1, DataType: config.DataTypeInt16}},
+		{Key: "meter_b", Register: config.Register{Name: "B", Address: 0x1001, ScaleFactor: 1,
+			DataType: config.DataTypeUint32, ByteOrder: config.ByteOrderCDAB}},
+		{Key: "meter_c", Register: config.Register{Name: "C", Address: 0x1003, ScaleFactor: 1,
+			DataType: config.DataTypeFloat64, ByteOrder: config.ByteOrderDCBA}},
+	}
+
+	results, err := NewGroupRegisterStrategy("meter_mixed", group, registers, 1, gw, nil).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("❌ Execute failed: %v", err)
+	}
+	expected := []struct {
+		key   string
+		value float64
+		bytes int
+	}{
+		{"meter_a", -0.2, 2},
+		{"meter_b", 70000, 4},
+		{"meter_c", 1, 8},
+	}
+	for _, tt := range expected {
+		result := results[tt.key]
+		if math.Abs(result.Value-tt.value) > 1e-9 {
+			t.Errorf("❌ %s = %v, expected %v", tt.key, result.Value, tt.value)
+		}
+		if len(result.RawData) != tt.bytes {
+			t.Errorf("❌ %s raw data has %d bytes, expected %d", tt.key, len(result.RawData), tt.bytes)
+		}
+	}
+	t.Logf("✅ %d registers of mixed types decoded", len(results))
+}
+
+// TestDecodeString verifies ASCII and BCD decoding of serial numbers and versions
+func TestDecodeString(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		dataType  string
+		byteOrder string
+		expected  string
+	}{
+		{"ascii", []byte("DDSU666 "), config.DataTypeString, config.ByteOrderABCD, "DDSU666"},
+		{"nul padded", []byte{'V', '1', '.', '2', 0, 0}, config.DataTypeString, config.ByteOrderABCD, "V1.2"},
+		{"swapped bytes", []byte("DDUS66 6"), config.DataTypeString, config.ByteOrderBADC, "DDSU666"},
+		{"bcd", []byte{0x20, 0x23, 0x05, 0x17}, config.DataTypeBCD, config.ByteOrderABCD, "20230517"},
+		{"bcd low word first", []byte{0x05, 0x17, 0x20, 0x23}, config.DataTypeBCD, config.ByteOrderCDAB, "20230517"},
+	}
+	for _, tt := range tests {
+		text, err := DecodeString(tt.data, tt.dataType, tt.byteOrder)
+		if err != nil {
+			t.Errorf("❌ %s: %v", tt.name, err)
+			continue
+		}
+		if text != tt.expected {
+			t.Errorf("❌ %s = %q, expected %q", tt.name, text, tt.expected)
+		}
+	}
+
+	if _, err := DecodeString([]byte{0x1A, 0x00}, config.DataTypeBCD, config.ByteOrderABCD); err == nil {
+		t.Error("❌ Expected error for invalid BCD digit")
+	}
+	if _, err := DecodeString([]byte{'A', 0x07}, config.DataTypeString, config.ByteOrderABCD); err == nil {
+		t.Error("❌ Expected error for non-printable character")
+	}
+	t.Logf("✅ %d text layouts verified", len(tests))
+}
+
+// TestGroupTextRegisters verifies string registers are carried in the result state
+func TestGroupTextRegisters(t *testing.T) {
+	gw := &fixedGateway{data: append([]byte("SN12345\x00"), 0x01, 0x02)}
+	group := config.RegisterGroup{
+		Name:          "Info",
+		FunctionCode:  config.FunctionReadHoldingRegisters,
+		StartAddress:  0x0000,
+		RegisterCount: 5,
+	}
+	registers := []RegisterWithKey{
+		{Key: "meter_serial", Register: config.Register{Name: "Serial", Address: 0x0000,
+			DataType: config.DataTypeString, Length: 8}},
+		{Key: "meter_firmware", Register: config.Register{Name: "Firmware", Address: 0x0004,
+			DataType: config.DataTypeBCD, Length: 2}},
+	}
+
+	results, err := NewGroupRegisterStrategy("meter_info", group, registers, 1, gw, nil).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("❌ Execute failed: %v", err)
+	}
+	if serial := results["meter_serial"]; serial.State != "SN12345" || serial.DataType != config.DataTypeString {
+		t.Errorf("❌ Serial = %q (%s), expected SN12345", serial.State, serial.DataType)
+	}
+	if firmware := results["meter_firmware"]; firmware.State != "0102" || len(firmware.RawData) != 2 {
+		t.Errorf("❌ Firmware = %q, expected 0102", firmware.State)
+	}
+	t.Logf("✅ Serial %s, firmware %s", results["meter_serial"].State, results["meter_firmware"].State)
+}
@@ -34,11 +34,12 @@ func (e *ExpressionEvaluator) SetVariables(vars map[string]float64) {
 }
 
 // Evaluate evaluates a mathematical expression
-// Supported operations: +, -, *, /, ^(power), sqrt(), abs()
+// Supported operations: +, -, *, /, ^(power), sqrt(), abs(), min(), max()
 // Examples:
 //   - "power_active + power_reactive"
 //   - "sqrt(power_active^2 + power_reactive^2)"
 //   - "abs(power_factor)"
+//   - "max(current_l1, current_l2, current_l3) - min(current_l1, current_l2, current_l3)"
 func (e *ExpressionEvaluator) Evaluate(expression string) (float64, error) {
 	if expression == "" {
 		return 0, fmt.Errorf("empty expression")
@@ -74,6 +75,10 @@ func (e *ExpressionEvaluator) evaluateNumericExpression(expr string) (float64, e
 	expr = strings.TrimSpace(expr)
 
 	// Handle function calls first
+	// min/max go before sqrt/abs so sqrt(max(a, b)) resolves the inner call first
+	if minMaxPattern.MatchString(expr) {
+		return e.evaluateMinMax(expr)
+	}
 	if strings.Contains(expr, "sqrt(") {
 		return e.evaluateSqrt(expr)
 	}
@@ -82,7 +87,8 @@ func (e *ExpressionEvaluator) evaluateNumericExpression(expr string) (float64, e
 	}
 
 	// Handle parentheses first (highest precedence after functions)
-	if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+	// "(a) * (b)" starts and ends with parentheses that do not enclose the whole expression
+	if strings.HasPrefix(expr, "(") && closingParen(expr) == len(expr)-1 {
 		return e.evaluateNumericExpression(expr[1 : len(expr)-1])
 	}
 
@@ -208,7 +214,61 @@ func (e *ExpressionEvaluator) evaluateAbs(expr string) (float64, error) {
 	return e.evaluateNumericExpression(remaining)
 }
 
+// minMaxPattern matches an innermost min(...) or max(...) call
+var minMaxPattern = regexp.MustCompile(`\b(min|max)\(([^()]+)\)`)
+
+// evaluateMinMax evaluates min() and max() with any number of comma-separated arguments
+func (e *ExpressionEvaluator) evaluateMinMax(expr string) (float64, error) {
+	matches := minMaxPattern.FindStringSubmatch(expr)
+	if len(matches) < 3 {
+		return 0, fmt.Errorf("invalid min/max expression")
+	}
+
+	var result float64
+	for i, arg := range strings.Split(matches[2], ",") {
+		value, err := e.evaluateNumericExpression(arg)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case i == 0:
+			result = value
+		case matches[1] == "min":
+			result = math.Min(result, value)
+		default:
+			result = math.Max(result, value)
+		}
+	}
+
+	// Replace the call with the result and continue evaluation
+	remaining := strings.Replace(expr, matches[0], fmt.Sprintf("%f", result), 1)
+	if remaining == fmt.Sprintf("%f", result) {
+		return result, nil
+	}
+
+	return e.evaluateNumericExpression(remaining)
+}
+
+// closingParen returns the position of the parenthesis closing the one at expr[0], or -1
+func closingParen(expr string) int {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // findOperator finds the position of an operator outside of parentheses
+// A + or - following another operator or an opening parenthesis is a sign, not an operator
+// (variables substituted with negative values produce "5 - -3").
 func (e *ExpressionEvaluator) findOperator(expr string, operators string) int {
 	depth := 0
 	for i := len(expr) - 1; i >= 0; i-- {
@@ -218,10 +278,19 @@ func (e *ExpressionEvaluator) findOperator(expr string, operators string) int {
 		case '(':
 			depth--
 		default:
-			if depth == 0 && strings.ContainsRune(operators, rune(expr[i])) {
+			if depth == 0 && strings.ContainsRune(operators, rune(expr[i])) && !isSign(expr, i) {
 				return i
 			}
 		}
 	}
 	return -1
 }
+
+// isSign reports whether the + or - at expr[i] is the sign of a number
+func isSign(expr string, i int) bool {
+	if expr[i] != '+' && expr[i] != '-' {
+		return false
+	}
+	previous := strings.TrimRight(expr[:i], " ")
+	return previous == "" || strings.ContainsAny(previous[len(previous)-1:], "+-*/^(,")
+}
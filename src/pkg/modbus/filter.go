@@ -0,0 +1,102 @@
+package modbus
+
+import (
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ValueFilters stores the noise filter state of every register, keyed like ValueCache
+type ValueFilters struct {
+	states map[string]*filterState
+	mutex  sync.Mutex
+}
+
+// filterState is the history a filter keeps for one register
+type filterState struct {
+	window   []float64 // Last samples of moving_average and median
+	ema      float64
+	emaSet   bool
+	last     float64 // Last accepted sample, for spike rejection
+	lastTime time.Time
+	spikes   int // Consecutive samples rejected as spikes
+}
+
+// NewValueFilters creates an empty filter state store
+func NewValueFilters() *ValueFilters {
+	return &ValueFilters{
+		states: make(map[string]*filterState),
+	}
+}
+
+// Apply runs a new sample of the key through its filter
+// ok is false when the sample is rejected as a spike: it changed faster than max_rate
+// since the last accepted sample. After max_spikes consecutive spikes the new level is
+// accepted as a real step change and the smoothing starts over from it.
+func (f *ValueFilters) Apply(key string, filter *config.ValueFilter, value float64, now time.Time) (filtered float64, ok bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	state, exists := f.states[key]
+	if !exists {
+		state = &filterState{}
+		f.states[key] = state
+	}
+
+	if filter.MaxRate > 0 && !state.lastTime.IsZero() {
+		elapsed := math.Max(now.Sub(state.lastTime).Seconds(), 0.001)
+		if math.Abs(value-state.last)/elapsed > filter.MaxRate {
+			state.spikes++
+			if state.spikes < filter.GetMaxSpikes() {
+				return 0, false
+			}
+			// The value stayed at the new level: restart the smoothing there
+			*state = filterState{}
+		}
+	}
+	state.spikes = 0
+	state.last = value
+	state.lastTime = now
+
+	return state.smooth(filter, value), true
+}
+
+// smooth adds the sample to the history and returns the smoothed value
+func (s *filterState) smooth(filter *config.ValueFilter, value float64) float64 {
+	switch filter.Type {
+	case config.FilterMovingAverage:
+		s.push(value, filter.Samples)
+		sum := 0.0
+		for _, sample := range s.window {
+			sum += sample
+		}
+		return sum / float64(len(s.window))
+	case config.FilterMedian:
+		s.push(value, filter.Samples)
+		sorted := slices.Clone(s.window)
+		slices.Sort(sorted)
+		middle := len(sorted) / 2
+		if len(sorted)%2 == 0 {
+			return (sorted[middle-1] + sorted[middle]) / 2
+		}
+		return sorted[middle]
+	case config.FilterEMA:
+		if !s.emaSet {
+			s.ema, s.emaSet = value, true
+		} else {
+			s.ema = filter.Alpha*value + (1-filter.Alpha)*s.ema
+		}
+		return s.ema
+	}
+	return value
+}
+
+// push appends a sample and keeps the last size samples
+func (s *filterState) push(value float64, size int) {
+	s.window = append(s.window, value)
+	if len(s.window) > size {
+		s.window = s.window[len(s.window)-size:]
+	}
+}
@@ -0,0 +1,73 @@
+package modbus
+
+import (
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"testing"
+	"time"
+)
+
+// TestFilterSmoothing verifies moving average, median and EMA over the same samples
+func TestFilterSmoothing(t *testing.T) {
+	samples := []float64{230, 232, 228, 260, 230}
+	tests := []struct {
+		filter   config.ValueFilter
+		expected float64
+	}{
+		{config.ValueFilter{Type: config.FilterMovingAverage, Samples: 3}, (228.0 + 260 + 230) / 3},
+		{config.ValueFilter{Type: config.FilterMedian, Samples: 3}, 230},
+		{config.ValueFilter{Type: config.FilterMedian, Samples: 4}, 231},  // Median of 232, 228, 260, 230
+		{config.ValueFilter{Type: config.FilterEMA, Alpha: 0.5}, 237.375}, // 230, 231, 229.5, 244.75, 237.375
+	}
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		filters := NewValueFilters()
+		var value float64
+		for i, sample := range samples {
+			var ok bool
+			value, ok = filters.Apply("meter_voltage", &tt.filter, sample, start.Add(time.Duration(i)*time.Second))
+			if !ok {
+				t.Fatalf("❌ %s rejected %.1f without max_rate", tt.filter.Type, sample)
+			}
+		}
+		if math.Abs(value-tt.expected) > 1e-9 {
+			t.Errorf("❌ %s (%d samples) = %.3f, expected %.3f", tt.filter.Type, tt.filter.Samples, value, tt.expected)
+		}
+	}
+	t.Log("✅ Moving average, median and EMA smoothing")
+}
+
+// TestFilterSpikeRejection verifies spikes are dropped and a lasting step is accepted after max_spikes
+func TestFilterSpikeRejection(t *testing.T) {
+	filter := &config.ValueFilter{MaxRate: 10, MaxSpikes: 2}
+	filters := NewValueFilters()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		value    float64
+		accepted bool
+	}{
+		{230, true},
+		{235, true},  // 5 V/s
+		{900, false}, // Bus glitch
+		{236, true},  // Back to normal: the spike counter resets
+		{400, false}, // Step change...
+		{400, true},  // ...that lasts is accepted after 2 samples
+		{401, true},
+	}
+	for i, step := range steps {
+		value, ok := filters.Apply("meter_voltage", filter, step.value, start.Add(time.Duration(i)*time.Second))
+		if ok != step.accepted {
+			t.Errorf("❌ Sample %d (%.0f): accepted=%v, expected %v", i, step.value, ok, step.accepted)
+		}
+		if ok && value != step.value {
+			t.Errorf("❌ Spike rejection alone must not change the value: %.1f → %.1f", step.value, value)
+		}
+	}
+
+	if _, ok := filters.Apply("meter_current", filter, 900, start); !ok {
+		t.Error("❌ The first sample of another key must be accepted")
+	}
+	t.Log("✅ Spikes rejected, lasting steps accepted")
+}
@@ -6,27 +6,8 @@ import (
 	"mqtt-modbus-bridge/pkg/config"
 	"mqtt-modbus-bridge/pkg/logger"
 	"regexp"
-	"strings"
 )
 
-// extractSensorKeyCalc extracts the sensor key from a full key for calculated values
-func extractSensorKeyCalc(fullKey string) string {
-	parts := strings.Split(fullKey, "_")
-	if len(parts) >= 2 {
-		if len(parts) >= 3 {
-			lastTwo := strings.Join(parts[len(parts)-2:], "_")
-			knownKeys := []string{"power_active", "power_reactive", "power_apparent", "power_factor"}
-			for _, known := range knownKeys {
-				if lastTwo == known {
-					return lastTwo
-				}
-			}
-		}
-		return parts[len(parts)-1]
-	}
-	return fullKey
-}
-
 // CalculatedRegisterStrategy evaluates a formula using cached register values
 type CalculatedRegisterStrategy struct {
 	*BaseStrategy
@@ -78,8 +59,8 @@ func (s *CalculatedRegisterStrategy) Execute(ctx context.Context) (*CommandResul
 				varName, fullKey, s.key)
 		}
 
-		variableValues[varName] = cached.Value
-		logger.LogDebug("  ✓ Variable '%s' → '%s' = %.2f %s", varName, fullKey, cached.Value, cached.Unit)
+		variableValues[varName] = cached.InputValue(s.register.RawInputs)
+		logger.LogDebug("  ✓ Variable '%s' → '%s' = %.2f %s", varName, fullKey, variableValues[varName], cached.Unit)
 	}
 
 	// Set variables in evaluator
@@ -92,26 +73,36 @@ func (s *CalculatedRegisterStrategy) Execute(ctx context.Context) (*CommandResul
 		return nil, fmt.Errorf("failed to evaluate formula for '%s': %w", s.key, err)
 	}
 	logger.LogDebug("  📐 Raw result: %.6f", value)
+	rawValue := value
 
 	// Apply scale factor
 	value = value * s.register.ScaleFactor
 	logger.LogDebug("  📐 After scale factor (%.2f): %.6f", s.register.ScaleFactor, value)
 
+	// Apply the transform pipeline, as for registers read from the device
+	if len(s.register.Transforms) > 0 {
+		value = s.register.Transforms.Apply(value)
+		logger.LogDebug("  📐 After %d transforms: %.6f", len(s.register.Transforms), value)
+	}
+
 	// Create result
 	result := &CommandResult{
 		Strategy:    "calculated_register",
 		Name:        s.register.Name,
+		Key:         s.key,
 		Value:       value,
 		Unit:        s.register.Unit,
 		Topic:       s.register.HATopic,
-		SensorKey:   extractSensorKeyCalc(s.key), // Extract just the sensor key
+		SensorKey:   sensorKey(s.key, s.devicePrefix),
 		DeviceClass: s.register.DeviceClass,
 		StateClass:  s.register.StateClass,
+		RawValue:    rawValue,
 		RawData:     nil, // Calculated values have no raw data
+		HasLimits:   s.register.HasLimits(),
 	}
 
-	// Cache the result
-	if s.cache != nil {
+	// Cache the result; implausible values are rejected by the executor and never cached
+	if s.cache != nil && s.register.CheckValue(value) == nil {
 		s.cache.Set(s.key, result)
 	}
 
@@ -125,6 +116,7 @@ func (s *CalculatedRegisterStrategy) extractVariables(formula string) ([]string,
 	cleaned := formula
 	cleaned = regexp.MustCompile(`sqrt\(`).ReplaceAllString(cleaned, "(")
 	cleaned = regexp.MustCompile(`abs\(`).ReplaceAllString(cleaned, "(")
+	cleaned = regexp.MustCompile(`\b(min|max)\(`).ReplaceAllString(cleaned, "(")
 
 	// Match valid variable names (alphanumeric + underscore)
 	re := regexp.MustCompile(`\b([a-zA-Z_][a-zA-Z0-9_]*)\b`)
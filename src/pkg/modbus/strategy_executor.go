@@ -18,10 +18,19 @@ type StrategyExecutor struct {
 	singleStrategies map[string]*SingleRegisterStrategy
 	groupStrategies  map[string]*GroupRegisterStrategy
 	calcStrategies   map[string]*CalculatedRegisterStrategy
-	executionOrder   []string       // Order: groups first, then calculated
-	groupIntervals   map[string]int // groupKey -> poll_interval in milliseconds
+	executionOrder   []string                   // Order: groups first, then calculated
+	groupIntervals   map[string]int             // groupKey -> poll_interval in milliseconds
+	maxRegisters     uint16                     // Registers per read request (0 = Modbus limit)
+	registers        map[string]config.Register // Result key -> register, for the plausibility check
+	filters          *ValueFilters              // Noise filter state, shared by all groups
+	counters         *MeterCounters             // Offsets of the total_increasing registers, shared by all groups
+	onReject         RejectHandler
 }
 
+// RejectHandler is called for every result that fails the plausibility check of its register
+// The result is not returned to the caller, so it is never published.
+type RejectHandler func(key string, result *CommandResult, reason error)
+
 // NewStrategyExecutor creates a new strategy executor
 func NewStrategyExecutor(gw gateway.Gateway, discoveryPrefix string) *StrategyExecutor {
 	return &StrategyExecutor{
@@ -33,9 +42,22 @@ func NewStrategyExecutor(gw gateway.Gateway, discoveryPrefix string) *StrategyEx
 		calcStrategies:   make(map[string]*CalculatedRegisterStrategy),
 		executionOrder:   []string{},
 		groupIntervals:   make(map[string]int),
+		registers:        make(map[string]config.Register),
+		filters:          NewValueFilters(),
+		counters:         NewMeterCounters(),
 	}
 }
 
+// SetMaxRegistersPerRequest sets the register limit of the group reads registered afterwards
+func (e *StrategyExecutor) SetMaxRegistersPerRequest(maxRegisters uint16) {
+	e.maxRegisters = maxRegisters
+}
+
+// SetRejectHandler sets the handler notified of results rejected by the plausibility check
+func (e *StrategyExecutor) SetRejectHandler(handler RejectHandler) {
+	e.onReject = handler
+}
+
 // RegisterFromDevices registers all strategies from device configuration
 func (e *StrategyExecutor) RegisterFromDevices(devices map[string]config.Device) error {
 	for deviceKey, device := range devices {
@@ -56,7 +78,13 @@ func (e *StrategyExecutor) RegisterFromDevices(devices map[string]config.Device)
 			var registers []RegisterWithKey
 			for _, groupReg := range group.Registers {
 				// Calculate actual address with bounds checking
+				// Bit groups (coils/discrete inputs) address one bit per offset, word groups one register per 2 bytes
 				offsetInRegisters := groupReg.Offset / 2
+				haTopic := topics.ConstructHATopic(deviceKey, groupReg.Key, groupReg.DeviceClass)
+				if group.IsBitGroup() {
+					offsetInRegisters = groupReg.Offset
+					haTopic = topics.ConstructComponentTopic(topics.ComponentBinarySensor, deviceKey, groupReg.Key)
+				}
 
 				// Validate offset is within uint16 range to prevent overflow
 				if offsetInRegisters < 0 || offsetInRegisters > 0xFFFF {
@@ -80,21 +108,41 @@ func (e *StrategyExecutor) RegisterFromDevices(devices map[string]config.Device)
 					scaleFactor = 1.0
 				}
 
+				// Rate bound is optional (0 = not set)
+				var maxKwhPtr *float64
+				if groupReg.MaxKwhPerHour != 0 {
+					maxKwhPtr = &groupReg.MaxKwhPerHour
+				}
+
 				register := config.Register{
-					Name:        groupReg.Name,
-					Address:     address,
-					Unit:        groupReg.Unit,
-					ScaleFactor: scaleFactor,
-					ApplyAbs:    groupReg.ApplyAbs, // Copy apply_abs flag
-					DeviceClass: groupReg.DeviceClass,
-					StateClass:  groupReg.StateClass,
-					HATopic:     topics.ConstructHATopic(deviceKey, groupReg.Key, groupReg.DeviceClass),
+					Name:          groupReg.Name,
+					Address:       address,
+					Unit:          groupReg.Unit,
+					ScaleFactor:   scaleFactor,
+					ApplyAbs:      groupReg.ApplyAbs, // Copy apply_abs flag
+					DataType:      groupReg.DataType,
+					ByteOrder:     groupReg.ByteOrder,
+					Length:        groupReg.Length,
+					DeviceClass:   groupReg.DeviceClass,
+					StateClass:    groupReg.StateClass,
+					HATopic:       haTopic,
+					Min:           groupReg.Min,
+					Max:           groupReg.Max,
+					MaxKwhPerHour: maxKwhPtr,
+					Bits:          groupReg.Bits,
+					Enum:          groupReg.Enum,
+					Transforms:    groupReg.Transforms,
+					Filter:        groupReg.Filter,
+					Counter:       groupReg.Counter,
+					Publish:       groupReg.PublishPolicy,
 				}
 
 				regKey := fmt.Sprintf("%s_%s", deviceKey, groupReg.Key)
+				e.registers[regKey] = register
 				registers = append(registers, RegisterWithKey{
-					Key:      regKey,
-					Register: register,
+					Key:       regKey,
+					Register:  register,
+					DeviceKey: deviceKey,
 				})
 			}
 
@@ -108,6 +156,10 @@ func (e *StrategyExecutor) RegisterFromDevices(devices map[string]config.Device)
 				e.gateway,
 				e.cache,
 			)
+			strategy.SetMaxRegistersPerRequest(e.maxRegisters)
+			strategy.SetFilters(e.filters)
+			strategy.SetCounters(e.counters)
+			strategy.SetRejectHandler(e.reportRejection)
 
 			e.groupStrategies[fullGroupKey] = strategy
 			e.executionOrder = append(e.executionOrder, fullGroupKey)
@@ -141,9 +193,15 @@ func (e *StrategyExecutor) RegisterFromDevices(devices map[string]config.Device)
 				DeviceClass: calc.DeviceClass,
 				StateClass:  calc.StateClass,
 				HATopic:     topics.ConstructHATopic(deviceKey, calc.Key, calc.DeviceClass),
+				Min:         calc.Min,
+				Max:         calc.Max,
+				Transforms:  calc.Transforms,
+				RawInputs:   calc.RawInputs,
+				Publish:     calc.PublishPolicy,
 			}
 
 			calcKey := fmt.Sprintf("%s_%s", deviceKey, calc.Key)
+			e.registers[calcKey] = register
 			strategy := NewCalculatedRegisterStrategy(
 				calcKey,
 				register,
@@ -194,6 +252,9 @@ func (e *StrategyExecutor) ExecuteAll(ctx context.Context) (map[string]*CommandR
 				continue
 			}
 
+			if err := e.checkResult(key, result); err != nil {
+				continue
+			}
 			results[key] = result
 			logger.LogDebug("  🧮 [Calculated '%s'] %s = %.2f %s (device_class: %s)",
 				key, result.Name, result.Value, result.Unit, result.DeviceClass)
@@ -225,6 +286,10 @@ func (e *StrategyExecutor) ExecuteGroup(ctx context.Context, groupKey string) (m
 			return nil, fmt.Errorf("failed to execute calculated strategy '%s': %w", groupKey, err)
 		}
 
+		if err := e.checkResult(groupKey, result); err != nil {
+			return map[string]*CommandResult{}, nil
+		}
+
 		// Return as a map with single result
 		return map[string]*CommandResult{groupKey: result}, nil
 	}
@@ -232,6 +297,12 @@ func (e *StrategyExecutor) ExecuteGroup(ctx context.Context, groupKey string) (m
 	return nil, fmt.Errorf("strategy not found for key '%s'", groupKey)
 }
 
+// RegisterConfig returns the register behind a result key (calculated values included)
+func (e *StrategyExecutor) RegisterConfig(key string) (config.Register, bool) {
+	register, exists := e.registers[key]
+	return register, exists
+}
+
 // GetGroupIntervals returns the poll intervals for all registered groups
 func (e *StrategyExecutor) GetGroupIntervals() map[string]int {
 	return e.groupIntervals
@@ -257,12 +328,70 @@ func (e *StrategyExecutor) GetResult(ctx context.Context, key string) (*CommandR
 	}
 
 	if calcStrategy, exists := e.calcStrategies[key]; exists {
-		return calcStrategy.Execute(ctx)
+		result, err := calcStrategy.Execute(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.checkResult(key, result); err != nil {
+			return nil, err
+		}
+		return result, nil
 	}
 
 	return nil, fmt.Errorf("strategy not found for key '%s'", key)
 }
 
+// checkResult checks a result against the min/max of its register and rejects NaN and ±Inf
+// Rejections are logged and passed to the reject handler.
+func (e *StrategyExecutor) checkResult(key string, result *CommandResult) error {
+	register, exists := e.registers[key]
+	if !exists {
+		return nil // Status bits and unknown keys have no range
+	}
+	err := register.CheckValue(result.Value)
+	if err == nil {
+		return nil
+	}
+
+	e.reportRejection(key, result, err)
+	return fmt.Errorf("register '%s' rejected: %w", key, err)
+}
+
+// reportRejection logs a result that is not published and passes it to the reject handler
+func (e *StrategyExecutor) reportRejection(key string, result *CommandResult, reason error) {
+	logger.LogWarn("⚠️ Rejected %s: %v (raw value %g)", key, reason, result.RawValue)
+	if e.onReject != nil {
+		e.onReject(key, result, reason)
+	}
+}
+
+// AcknowledgeCounterReset accepts the pending meter reset of a register and returns its new offset
+func (e *StrategyExecutor) AcknowledgeCounterReset(key string) (float64, error) {
+	return e.counters.Acknowledge(key)
+}
+
+// PendingCounterResets returns the registers whose meter reset waits for acknowledgement
+func (e *StrategyExecutor) PendingCounterResets() []string {
+	return e.counters.Pending()
+}
+
+// CounterSnapshot returns the offsets and last readings of the total_increasing registers
+func (e *StrategyExecutor) CounterSnapshot() map[string]CounterState {
+	return e.counters.Snapshot()
+}
+
+// RestoreCounters restores the counters of the total_increasing registers of this executor
+// Counters of other buses and of registers that changed state class are ignored.
+func (e *StrategyExecutor) RestoreCounters(snapshot map[string]CounterState) {
+	owned := make(map[string]CounterState)
+	for key, state := range snapshot {
+		if register, exists := e.registers[key]; exists && register.StateClass == config.StateClassTotalIncreasing {
+			owned[key] = state
+		}
+	}
+	e.counters.Restore(owned)
+}
+
 // GetAllStrategies returns all individual register strategies (for discovery)
 // Note: Group strategies contain multiple registers, so we return their individual registers
 func (e *StrategyExecutor) GetAllStrategies() map[string]interface{} {
@@ -0,0 +1,79 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"testing"
+)
+
+// TestExecutorRejectsImplausibleValues checks that min/max from the device config and NaN are enforced before results are returned
+func TestExecutorRejectsImplausibleValues(t *testing.T) {
+	data := make([]byte, 12)
+	binary.BigEndian.PutUint32(data[0:], math.Float32bits(2301)) // voltage ×0.1 = 230.1 V
+	binary.BigEndian.PutUint32(data[4:], math.Float32bits(9999)) // current ×0.1 = 999.9 A, above max
+	binary.BigEndian.PutUint32(data[8:], math.Float32bits(float32(math.NaN())))
+
+	maxPower := 1000.0
+	devices := map[string]config.Device{
+		"meter": {
+			Metadata: config.DeviceMetadata{Name: "Meter", Enabled: true},
+			RTU:      config.RTUConfig{SlaveID: 1},
+			Modbus: config.ModbusDeviceConfig{RegisterGroups: map[string]config.RegisterGroup{
+				"instant": {
+					Name: "Instant", Enabled: true, FunctionCode: config.FunctionReadHoldingRegisters,
+					StartAddress: 0x2000, RegisterCount: 6, PollInterval: 1000,
+					Registers: []config.GroupRegister{
+						{Key: "voltage", Name: "Voltage", Offset: 0, Unit: "V", ScaleFactor: 0.1, Min: float64Ptr(100), Max: float64Ptr(300)},
+						{Key: "current", Name: "Current", Offset: 4, Unit: "A", ScaleFactor: 0.1, Max: float64Ptr(100)},
+						{Key: "frequency", Name: "Frequency", Offset: 8, Unit: "Hz"},
+					},
+				},
+			}},
+			CalculatedValues: []config.CalculatedValue{
+				{Key: "power", Name: "Power", Unit: "W", Formula: "voltage * 10", Max: &maxPower},
+				{Key: "current_power", Name: "Current Power", Unit: "W", Formula: "voltage * current"},
+			},
+		},
+	}
+
+	executor := NewStrategyExecutor(&fixedGateway{data: data}, "homeassistant")
+	rejected := make(map[string]*CommandResult)
+	executor.SetRejectHandler(func(key string, result *CommandResult, reason error) {
+		rejected[key] = result
+		t.Logf("✅ Rejected %s: %v", key, reason)
+	})
+	if err := executor.RegisterFromDevices(devices); err != nil {
+		t.Fatalf("❌ RegisterFromDevices failed: %v", err)
+	}
+
+	results, err := executor.ExecuteAll(context.Background())
+	if err != nil {
+		t.Fatalf("❌ ExecuteAll failed: %v", err)
+	}
+	if _, ok := results["meter_voltage"]; !ok {
+		t.Errorf("❌ Voltage within min/max must be returned")
+	}
+	for _, key := range []string{"meter_current", "meter_frequency", "meter_power"} {
+		if _, ok := results[key]; ok {
+			t.Errorf("❌ %s must not be returned", key)
+		}
+		if _, ok := rejected[key]; !ok {
+			t.Errorf("❌ %s must be passed to the reject handler", key)
+		}
+	}
+	if result := rejected["meter_current"]; result != nil && result.RawValue != 9999 {
+		t.Errorf("❌ Rejected current raw value = %v, expected 9999", result.RawValue)
+	}
+
+	// Rejected values are not cached, so calculated values never use them
+	for _, key := range []string{"meter_current", "meter_frequency", "meter_power"} {
+		if _, found := executor.cache.Get(key); found {
+			t.Errorf("❌ Rejected %s must not be cached", key)
+		}
+	}
+	if _, ok := results["meter_current_power"]; ok {
+		t.Error("❌ A calculated value must not be computed from a rejected current")
+	}
+}
@@ -2,56 +2,51 @@ package modbus
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
 	"math"
 	"mqtt-modbus-bridge/pkg/config"
 	"mqtt-modbus-bridge/pkg/errors"
 	"mqtt-modbus-bridge/pkg/gateway"
 	"mqtt-modbus-bridge/pkg/logger"
+	"mqtt-modbus-bridge/pkg/topics"
 	"strings"
+	"time"
 )
 
-// extractSensorKey extracts the sensor key from a full key (device_key_sensor_key)
-// Example: "energy_meter_lights_power_active" -> "power_active"
-func extractSensorKey(fullKey string) string {
-	// Find the last underscore followed by a known sensor type
-	// Common sensor keys: voltage, current, power_active, power_reactive, etc.
-	parts := strings.Split(fullKey, "_")
-	if len(parts) >= 2 {
-		// Take the last 2 parts for compound keys like "power_active"
-		// or just last part for simple keys like "voltage"
-		if len(parts) >= 3 {
-			// Check if last 2 parts form a known compound key
-			lastTwo := strings.Join(parts[len(parts)-2:], "_")
-			knownKeys := []string{"power_active", "power_reactive", "power_apparent", "power_factor",
-				"energy_total", "energy_imported", "energy_exported"}
-			for _, known := range knownKeys {
-				if lastTwo == known {
-					return lastTwo
-				}
-			}
-		}
-		// Otherwise return just the last part
-		return parts[len(parts)-1]
-	}
-	return fullKey
-}
-
 // GroupRegisterStrategy reads multiple contiguous registers as a group
 type GroupRegisterStrategy struct {
-	groupKey    string
-	groupConfig config.RegisterGroup
-	registers   []RegisterWithKey // Registers in this group with their keys
-	slaveID     uint8
-	gateway     gateway.Gateway
-	cache       *ValueCache
+	groupKey     string
+	groupConfig  config.RegisterGroup
+	registers    []RegisterWithKey // Registers in this group with their keys
+	slaveID      uint8
+	gateway      gateway.Gateway
+	cache        *ValueCache
+	filters      *ValueFilters  // Noise filter state of the registers (nil = filters disabled)
+	counters     *MeterCounters // Offsets of the total_increasing registers (nil = raw meter readings)
+	onReject     RejectHandler  // Notified of implausible readings and meter resets waiting for acknowledgement
+	maxRegisters uint16         // Registers per read request; larger groups are read in chunks
 }
 
 // RegisterWithKey pairs a register key with its configuration
 type RegisterWithKey struct {
-	Key      string
-	Register config.Register
+	Key       string
+	Register  config.Register
+	DeviceKey string // Device the register belongs to (prefix of bit keys and topics)
+}
+
+// SensorKey returns the register key without its device prefix
+// Example: "energy_meter_mains_voltage_l1_l2" of device "energy_meter_mains" -> "voltage_l1_l2"
+func (r RegisterWithKey) SensorKey() string {
+	return sensorKey(r.Key, r.DeviceKey)
+}
+
+// sensorKey strips the device prefix from a full key (device_key_sensor_key)
+// Keys without a device are returned unchanged.
+func sensorKey(fullKey string, deviceKey string) string {
+	if deviceKey == "" {
+		return fullKey
+	}
+	return strings.TrimPrefix(fullKey, deviceKey+"_")
 }
 
 // NewGroupRegisterStrategy creates a new group register strategy
@@ -64,15 +59,38 @@ func NewGroupRegisterStrategy(
 	cache *ValueCache,
 ) *GroupRegisterStrategy {
 	return &GroupRegisterStrategy{
-		groupKey:    groupKey,
-		groupConfig: groupConfig,
-		registers:   registers,
-		slaveID:     slaveID,
-		gateway:     gateway,
-		cache:       cache,
+		groupKey:     groupKey,
+		groupConfig:  groupConfig,
+		registers:    registers,
+		slaveID:      slaveID,
+		gateway:      gateway,
+		cache:        cache,
+		maxRegisters: config.MaxReadRegisters,
+	}
+}
+
+// SetMaxRegistersPerRequest sets the register limit of one read request (gateway max_registers_per_request)
+func (s *GroupRegisterStrategy) SetMaxRegistersPerRequest(maxRegisters uint16) {
+	if maxRegisters > 0 {
+		s.maxRegisters = maxRegisters
 	}
 }
 
+// SetFilters sets the state store used by the noise filters of the registers
+func (s *GroupRegisterStrategy) SetFilters(filters *ValueFilters) {
+	s.filters = filters
+}
+
+// SetCounters sets the state store that keeps total_increasing registers continuous
+func (s *GroupRegisterStrategy) SetCounters(counters *MeterCounters) {
+	s.counters = counters
+}
+
+// SetRejectHandler sets the handler notified of implausible readings and readings held back by a meter reset
+func (s *GroupRegisterStrategy) SetRejectHandler(handler RejectHandler) {
+	s.onReject = handler
+}
+
 // GetRegisters returns all registers in this group
 func (s *GroupRegisterStrategy) GetRegisters() []RegisterWithKey {
 	return s.registers
@@ -86,27 +104,20 @@ func (s *GroupRegisterStrategy) Execute(ctx context.Context) (map[string]*Comman
 	logger.LogTrace("🔄 Executing group '%s' (Slave %d, Addr 0x%04X, Count %d)",
 		s.groupKey, s.slaveID, s.groupConfig.StartAddress, s.groupConfig.RegisterCount)
 
-	// Read the entire group in one Modbus transaction
-	// NOTE: SendCommandAndWaitForResponse uses commandMutex to ensure
+	// Read the entire group, in several transactions if it exceeds the request limit
+	// NOTE: SendCommandAndWaitForResponse holds the gateway bus lock to ensure
 	// SEQUENTIAL execution - no overlap between different slaves or groups
-	data, err := s.gateway.SendCommandAndWaitForResponse(
-		ctx,
-		s.slaveID,
-		s.groupConfig.FunctionCode,
-		s.groupConfig.StartAddress,
-		s.groupConfig.RegisterCount,
-		5, // 5 second timeout
-	)
+	data, err := s.read(ctx)
 	if err != nil {
-		logger.LogWarn("❌ Group '%s' (Slave %d) read failed: %v", s.groupKey, s.slaveID, err)
-		modbusErr := errors.NewModbusError("read_register_group", err, s.slaveID, s.groupKey)
-		modbusErr.FunctionCode = s.groupConfig.FunctionCode
-		modbusErr.Address = s.groupConfig.StartAddress
-		return nil, modbusErr
+		return nil, err
 	}
 
 	logger.LogTrace("✅ Group '%s' (Slave %d) read successful (%d bytes)", s.groupKey, s.slaveID, len(data))
 
+	if s.groupConfig.IsBitGroup() {
+		return s.parseBits(data)
+	}
+
 	expectedBytes := int(s.groupConfig.RegisterCount) * 2 // Each register is 2 bytes
 	if len(data) != expectedBytes {
 		modbusErr := errors.NewModbusError("parse_register_group",
@@ -122,8 +133,9 @@ func (s *GroupRegisterStrategy) Execute(ctx context.Context) (map[string]*Comman
 		offset := reg.Address - s.groupConfig.StartAddress // Offset in registers
 		byteOffset := int(offset) * 2                      // Offset in bytes
 
-		// Ensure we have enough data
-		if byteOffset+4 > len(data) {
+		// Ensure we have enough data for the register data type
+		size := int(reg.RegisterCount()) * 2
+		if byteOffset+size > len(data) {
 			modbusErr := errors.NewModbusError("parse_register_offset",
 				fmt.Errorf("register '%s' offset %d exceeds group data length %d", regWithKey.Key, byteOffset, len(data)),
 				s.slaveID, regWithKey.Key)
@@ -131,46 +143,317 @@ func (s *GroupRegisterStrategy) Execute(ctx context.Context) (map[string]*Comman
 			return nil, modbusErr
 		}
 
-		// Extract 4 bytes for float32
-		registerData := data[byteOffset : byteOffset+4]
-		bits := binary.BigEndian.Uint32(registerData)
-		rawValue := math.Float32frombits(bits)
+		// Decode the register according to its data type and byte order
+		registerData := data[byteOffset : byteOffset+size]
+		if config.IsTextDataType(reg.GetDataType()) {
+			result, err := s.parseText(regWithKey, registerData)
+			if err != nil {
+				return nil, err
+			}
+			results[regWithKey.Key] = result
+			continue
+		}
+
+		rawValue, err := DecodeRegisters(registerData, reg.GetDataType(), reg.GetByteOrder())
+		if err != nil {
+			modbusErr := errors.NewModbusError("parse_register_value",
+				fmt.Errorf("register '%s': %w", regWithKey.Key, err), s.slaveID, regWithKey.Key)
+			modbusErr.Address = reg.Address
+			return nil, modbusErr
+		}
 
 		// Apply scale factor
-		value := float64(rawValue) * reg.ScaleFactor
+		value := rawValue * reg.ScaleFactor
 
 		// Apply absolute value if configured
 		if reg.ApplyAbs {
 			value = math.Abs(value)
 		}
 
-		// Extract just the sensor key from the full key (device_key_sensor_key)
-		sensorKey := extractSensorKey(regWithKey.Key)
+		// Apply the transform pipeline (offset, invert, clamp, round, convert)
+		value = reg.Transforms.Apply(value)
+
+		// Drop implausible values (min/max, NaN, ±Inf) before they reach the counter,
+		// the filter or the cache, so calculated values never see them
+		if err := reg.CheckValue(value); err != nil {
+			s.reject(regWithKey, value, rawValue, err)
+			continue
+		}
+
+		// Smooth the value and drop spikes; the unfiltered value stays with the cached result
+		var unfiltered *float64
+		if reg.Filter != nil && s.filters != nil {
+			filtered, ok := s.filters.Apply(regWithKey.Key, reg.Filter, value, time.Now())
+			if !ok {
+				logger.LogDebug("  ⚡ Spike rejected: %s = %.3f %s", regWithKey.Key, value, reg.Unit)
+				continue
+			}
+			sample := value
+			unfiltered = &sample
+			value = filtered
+		}
+
+		// Continue meter counters across rollovers and acknowledged resets
+		// Runs after the filter, so a rejected spike never becomes the last meter reading
+		if reg.StateClass == config.StateClassTotalIncreasing && s.counters != nil {
+			continuous, err := s.counters.Apply(regWithKey.Key, reg.Counter, value, time.Now())
+			if err != nil {
+				s.reject(regWithKey, value, rawValue, err)
+				continue
+			}
+			if unfiltered != nil {
+				*unfiltered += continuous - value // Same offset as the published value
+			}
+			value = continuous
+		}
 
 		// Create result
 		result := &CommandResult{
 			Strategy:    "group_register",
 			Name:        reg.Name,
+			Key:         regWithKey.Key,
 			Value:       value,
 			Unit:        reg.Unit,
 			Topic:       reg.HATopic,
-			SensorKey:   sensorKey,
+			SensorKey:   regWithKey.SensorKey(),
 			DeviceClass: reg.DeviceClass,
 			StateClass:  reg.StateClass,
+			DataType:    reg.GetDataType(),
+			RawValue:    rawValue,
 			RawData:     registerData,
+			Unfiltered:  unfiltered,
+			HasLimits:   reg.HasLimits(),
 		}
 
-		results[regWithKey.Key] = result
+		// Enum registers publish the label of the raw value
+		if len(reg.Enum) > 0 {
+			result.State, _ = reg.EnumLabel(rawValue)
+			result.Options = reg.EnumOptions()
+			result.DeviceClass = config.DeviceClassEnum
+			result.StateClass = ""
+		}
 
 		// Cache individual result
 		if s.cache != nil {
 			s.cache.Set(regWithKey.Key, result)
 		}
+
+		// Status words are published as their bits; the word itself stays available to calculated values
+		if len(reg.Bits) > 0 {
+			s.parseStatusBits(results, regWithKey, rawValue, registerData)
+			continue
+		}
+
+		results[regWithKey.Key] = result
+	}
+
+	return results, nil
+}
+
+// reject passes a reading that is not published to the reject handler
+func (s *GroupRegisterStrategy) reject(regWithKey RegisterWithKey, value float64, rawValue float64, reason error) {
+	if s.onReject == nil {
+		return
+	}
+	s.onReject(regWithKey.Key, &CommandResult{
+		Strategy:  "group_register",
+		Name:      regWithKey.Register.Name,
+		Key:       regWithKey.Key,
+		Value:     value,
+		SensorKey: regWithKey.SensorKey(),
+		RawValue:  rawValue,
+	}, reason)
+}
+
+// read reads the group registers and returns the data of the whole group
+// Groups larger than the request limit are read in chunks and stitched back together;
+// if any chunk fails the group fails, so no result is published from partial data.
+func (s *GroupRegisterStrategy) read(ctx context.Context) ([]byte, error) {
+	chunks := s.chunks()
+	if len(chunks) > 1 {
+		logger.LogTrace("✂️ Group '%s' (Slave %d) split into %d reads of at most %d registers",
+			s.groupKey, s.slaveID, len(chunks), s.maxRegisters)
+	}
+
+	var data []byte
+	for _, chunk := range chunks {
+		chunkData, err := s.gateway.SendCommandAndWaitForResponse(
+			ctx,
+			s.slaveID,
+			s.groupConfig.FunctionCode,
+			chunk.address,
+			chunk.count,
+			5, // 5 second timeout
+		)
+		if err != nil {
+			logger.LogWarn("❌ Group '%s' (Slave %d) read failed: %v", s.groupKey, s.slaveID, err)
+			modbusErr := errors.NewModbusError("read_register_group", err, s.slaveID, s.groupKey)
+			modbusErr.FunctionCode = s.groupConfig.FunctionCode
+			modbusErr.Address = chunk.address
+			return nil, modbusErr
+		}
+		if len(chunks) > 1 && len(chunkData) != int(chunk.count)*2 {
+			modbusErr := errors.NewModbusError("parse_register_group",
+				fmt.Errorf("expected %d bytes for group '%s' at 0x%04X, got %d bytes", int(chunk.count)*2, s.groupKey, chunk.address, len(chunkData)),
+				s.slaveID, s.groupKey)
+			modbusErr.Address = chunk.address
+			return nil, modbusErr
+		}
+		data = append(data, chunkData...)
+	}
+	return data, nil
+}
+
+// readChunk is one read request of a group
+type readChunk struct {
+	address uint16
+	count   uint16
+}
+
+// chunks splits the group range into read requests of at most maxRegisters registers
+// A chunk ends before a multi-register value that would straddle its end, so each value
+// is read by one request; values larger than the limit are split where they must be.
+// Coil and discrete input groups are always read in one request.
+func (s *GroupRegisterStrategy) chunks() []readChunk {
+	start := int(s.groupConfig.StartAddress)
+	end := start + int(s.groupConfig.RegisterCount)
+	limit := int(s.maxRegisters)
+	if s.groupConfig.IsBitGroup() || end-start <= limit {
+		return []readChunk{{address: s.groupConfig.StartAddress, count: s.groupConfig.RegisterCount}}
+	}
+
+	var chunks []readChunk
+	for start < end {
+		chunkEnd := min(start+limit, end)
+		for moved := chunkEnd < end; moved; {
+			moved = false
+			for _, regWithKey := range s.registers {
+				regStart := int(regWithKey.Register.Address)
+				regEnd := regStart + int(regWithKey.Register.RegisterCount())
+				if regStart > start && regStart < chunkEnd && regEnd > chunkEnd {
+					chunkEnd, moved = regStart, true
+				}
+			}
+		}
+		chunks = append(chunks, readChunk{address: uint16(start), count: uint16(chunkEnd - start)}) // #nosec G115 -- within the group range
+		start = chunkEnd
+	}
+	return chunks
+}
+
+// parseText decodes a string or bcd register (serial numbers, firmware versions)
+func (s *GroupRegisterStrategy) parseText(regWithKey RegisterWithKey, registerData []byte) (*CommandResult, error) {
+	reg := regWithKey.Register
+	text, err := DecodeString(registerData, reg.GetDataType(), reg.GetByteOrder())
+	if err != nil {
+		modbusErr := errors.NewModbusError("parse_register_value",
+			fmt.Errorf("register '%s': %w", regWithKey.Key, err), s.slaveID, regWithKey.Key)
+		modbusErr.Address = reg.Address
+		return nil, modbusErr
+	}
+
+	result := &CommandResult{
+		Strategy:  "group_text",
+		Name:      reg.Name,
+		Topic:     reg.HATopic,
+		SensorKey: regWithKey.SensorKey(),
+		DataType:  reg.GetDataType(),
+		State:     text,
+		RawData:   registerData,
+	}
+
+	// Cache individual result
+	if s.cache != nil {
+		s.cache.Set(regWithKey.Key, result)
+	}
+	return result, nil
+}
+
+// parseStatusBits adds a binary sensor result for each declared bit of a status word
+func (s *GroupRegisterStrategy) parseStatusBits(results map[string]*CommandResult, regWithKey RegisterWithKey, rawValue float64, registerData []byte) {
+	word := int64(rawValue)
+	for _, bit := range regWithKey.Register.Bits {
+		value := 0.0
+		if word&(1<<bit.Bit) != 0 {
+			value = 1.0
+		}
+
+		key := fmt.Sprintf("%s_%s", regWithKey.DeviceKey, bit.Key)
+		result := &CommandResult{
+			Strategy:    "group_bitfield",
+			Name:        bit.Name,
+			Value:       value,
+			Topic:       topics.ConstructComponentTopic(topics.ComponentBinarySensor, regWithKey.DeviceKey, bit.Key),
+			SensorKey:   bit.Key,
+			DeviceClass: bit.DeviceClass,
+			Component:   topics.ComponentBinarySensor,
+			RawData:     registerData,
+		}
+
+		results[key] = result
+
+		// Cache individual result (usable as 0/1 in calculated values)
+		if s.cache != nil {
+			s.cache.Set(key, result)
+		}
+	}
+}
+
+// parseBits extracts coil/discrete input states (0x01/0x02) from a bit read response
+// Bits are packed LSB first: bit N of the group is bit N%8 of byte N/8
+func (s *GroupRegisterStrategy) parseBits(data []byte) (map[string]*CommandResult, error) {
+	results := make(map[string]*CommandResult)
+
+	expectedBytes := (int(s.groupConfig.RegisterCount) + 7) / 8
+	if len(data) != expectedBytes {
+		modbusErr := errors.NewModbusError("parse_bit_group",
+			fmt.Errorf("expected %d bytes for group '%s', got %d bytes", expectedBytes, s.groupKey, len(data)),
+			s.slaveID, s.groupKey)
+		modbusErr.FunctionCode = s.groupConfig.FunctionCode
+		modbusErr.Address = s.groupConfig.StartAddress
+		return nil, modbusErr
+	}
+
+	for _, regWithKey := range s.registers {
+		reg := regWithKey.Register
+		bit := int(reg.Address - s.groupConfig.StartAddress)
+
+		value := 0.0
+		if data[bit/8]&(1<<(bit%8)) != 0 {
+			value = 1.0
+		}
+
+		result := &CommandResult{
+			Strategy:    "group_bit",
+			Name:        reg.Name,
+			Value:       value,
+			Unit:        reg.Unit,
+			Topic:       reg.HATopic,
+			SensorKey:   regWithKey.SensorKey(),
+			DeviceClass: reg.DeviceClass,
+			Component:   topics.ComponentBinarySensor,
+			RawData:     []byte{data[bit/8]},
+		}
+
+		results[regWithKey.Key] = result
+
+		// Cache individual result (usable as 0/1 in calculated values)
+		if s.cache != nil {
+			s.cache.Set(regWithKey.Key, result)
+		}
 	}
 
 	return results, nil
 }
 
+// GetComponent returns the Home Assistant component of the group's registers
+func (s *GroupRegisterStrategy) GetComponent() string {
+	if s.groupConfig.IsBitGroup() {
+		return topics.ComponentBinarySensor
+	}
+	return topics.ComponentSensor
+}
+
 // GetKey returns the group key
 func (s *GroupRegisterStrategy) GetKey() string {
 	return s.groupKey
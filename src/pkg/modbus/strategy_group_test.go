@@ -0,0 +1,522 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/errors"
+	"mqtt-modbus-bridge/pkg/gateway"
+	"mqtt-modbus-bridge/pkg/topics"
+	"testing"
+	"time"
+)
+
+// fixedGateway answers every read with the same payload and records the request
+type fixedGateway struct {
+	data         []byte
+	functionCode uint8
+	address      uint16
+	count        uint16
+}
+
+func (g *fixedGateway) Connect(ctx context.Context) error { return nil }
+func (g *fixedGateway) Disconnect()                       {}
+func (g *fixedGateway) SendCommand(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16) error {
+	return nil
+}
+func (g *fixedGateway) WaitForResponse(ctx context.Context, timeout int) ([]byte, error) {
+	return g.data, nil
+}
+func (g *fixedGateway) SendCommandAndWaitForResponse(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16, timeoutSeconds int) ([]byte, error) {
+	g.functionCode = functionCode
+	g.address = address
+	g.count = count
+	return g.data, nil
+}
+func (g *fixedGateway) Write(ctx context.Context, slaveID uint8, req *gateway.WriteRequest, timeoutSeconds int) error {
+	return nil
+}
+func (g *fixedGateway) ReadDeviceIdentification(ctx context.Context, slaveID uint8, timeoutSeconds int) (*gateway.DeviceIdentification, error) {
+	return nil, errors.NewModbusExceptionError(slaveID, gateway.FunctionEncapsulatedInterface, 0, errors.ExceptionIllegalFunction)
+}
+func (g *fixedGateway) SendDiagnosticCommand(ctx context.Context) error { return nil }
+func (g *fixedGateway) IsConnected() bool                               { return true }
+
+// TestGroupInputRegisters verifies FC 0x04 groups are requested and decoded like holding registers
+func TestGroupInputRegisters(t *testing.T) {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:4], math.Float32bits(230.5))
+	binary.BigEndian.PutUint32(data[4:8], math.Float32bits(-1.25))
+
+	gw := &fixedGateway{data: data}
+	group := config.RegisterGroup{
+		Name:          "Instant",
+		FunctionCode:  config.FunctionReadInputRegisters,
+		StartAddress:  0x2000,
+		RegisterCount: 4,
+	}
+	registers := []RegisterWithKey{
+		{Key: "meter_voltage", Register: config.Register{Name: "Voltage", Address: 0x2000, ScaleFactor: 1}},
+		{Key: "meter_current", Register: config.Register{Name: "Current", Address: 0x2002, ScaleFactor: 1, ApplyAbs: true}},
+	}
+
+	strategy := NewGroupRegisterStrategy("meter_instant", group, registers, 1, gw, nil)
+	results, err := strategy.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("❌ Execute failed: %v", err)
+	}
+
+	if gw.functionCode != config.FunctionReadInputRegisters || gw.address != 0x2000 || gw.count != 4 {
+		t.Errorf("❌ Unexpected request FC 0x%02X addr 0x%04X count %d", gw.functionCode, gw.address, gw.count)
+	}
+	if v := results["meter_voltage"].Value; math.Abs(v-230.5) > 1e-6 {
+		t.Errorf("❌ Voltage = %f, expected 230.5", v)
+	}
+	if v := results["meter_current"].Value; math.Abs(v-1.25) > 1e-6 {
+		t.Errorf("❌ Current = %f, expected 1.25", v)
+	}
+	if strategy.GetComponent() != topics.ComponentSensor {
+		t.Errorf("❌ Component = %s, expected %s", strategy.GetComponent(), topics.ComponentSensor)
+	}
+	t.Logf("✅ FC 0x04 group decoded %d registers", len(results))
+}
+
+// TestGroupBits verifies coil/discrete input groups unpack bits LSB first
+func TestGroupBits(t *testing.T) {
+	// Bits 0, 3 and 9 set
+	gw := &fixedGateway{data: []byte{0x09, 0x02}}
+	group := config.RegisterGroup{
+		Name:          "Relays",
+		FunctionCode:  config.FunctionReadCoils,
+		StartAddress:  0x0100,
+		RegisterCount: 10,
+	}
+	registers := []RegisterWithKey{
+		{Key: "meter_relay_1", Register: config.Register{Name: "Relay 1", Address: 0x0100}},
+		{Key: "meter_relay_2", Register: config.Register{Name: "Relay 2", Address: 0x0101}},
+		{Key: "meter_relay_4", Register: config.Register{Name: "Relay 4", Address: 0x0103}},
+		{Key: "meter_alarm", Register: config.Register{Name: "Alarm", Address: 0x0109}},
+	}
+
+	strategy := NewGroupRegisterStrategy("meter_relays", group, registers, 1, gw, NewValueCache(0))
+	results, err := strategy.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("❌ Execute failed: %v", err)
+	}
+
+	expected := map[string]float64{"meter_relay_1": 1, "meter_relay_2": 0, "meter_relay_4": 1, "meter_alarm": 1}
+	for key, want := range expected {
+		result := results[key]
+		if result == nil {
+			t.Fatalf("❌ Missing result for %s", key)
+		}
+		if result.Value != want {
+			t.Errorf("❌ %s = %.0f, expected %.0f", key, result.Value, want)
+		}
+		if result.Component != topics.ComponentBinarySensor {
+			t.Errorf("❌ %s component = %q, expected %q", key, result.Component, topics.ComponentBinarySensor)
+		}
+	}
+	if strategy.GetComponent() != topics.ComponentBinarySensor {
+		t.Errorf("❌ Component = %s, expected %s", strategy.GetComponent(), topics.ComponentBinarySensor)
+	}
+
+	// A response with the wrong byte count is rejected
+	gw.data = []byte{0x09}
+	if _, err := strategy.Execute(context.Background()); err == nil {
+		t.Error("❌ Expected error for short bit response")
+	}
+	t.Logf("✅ Bit group decoded %d coils", len(results))
+}
+
+// TestGroupStatusRegisters verifies bits are split into binary sensors and enums are mapped to labels
+func TestGroupStatusRegisters(t *testing.T) {
+	// Status word 0x8005 (bits 0, 2 and 15), mode 2, mode 7 (unmapped)
+	gw := &fixedGateway{data: []byte{0x80, 0x05, 0x00, 0x02, 0x00, 0x07}}
+	group := config.RegisterGroup{
+		Name:          "Status",
+		FunctionCode:  config.FunctionReadHoldingRegisters,
+		StartAddress:  0x0040,
+		RegisterCount: 3,
+	}
+	modes := map[int64]string{0: "Idle", 1: "Import", 2: "Export"}
+	registers := []RegisterWithKey{
+		{Key: "meter_status", DeviceKey: "meter", Register: config.Register{Name: "Status", Address: 0x0040,
+			ScaleFactor: 1, DataType: config.DataTypeUint16, Bits: []config.BitField{
+				{Bit: 0, Key: "overvoltage", Name: "Overvoltage", DeviceClass: "problem"},
+				{Bit: 1, Key: "undervoltage", Name: "Undervoltage", DeviceClass: "problem"},
+				{Bit: 15, Key: "running", Name: "Running", DeviceClass: "running"},
+			}}},
+		{Key: "meter_mode", DeviceKey: "meter", Register: config.Register{Name: "Mode", Address: 0x0041,
+			ScaleFactor: 1, DataType: config.DataTypeUint16, Enum: modes}},
+		{Key: "meter_fault", DeviceKey: "meter", Register: config.Register{Name: "Fault", Address: 0x0042,
+			ScaleFactor: 1, DataType: config.DataTypeUint16, Enum: modes}},
+	}
+
+	cache := NewValueCache(time.Minute)
+	results, err := NewGroupRegisterStrategy("meter_status", group, registers, 1, gw, cache).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("❌ Execute failed: %v", err)
+	}
+
+	expected := map[string]float64{"meter_overvoltage": 1, "meter_undervoltage": 0, "meter_running": 1}
+	for key, want := range expected {
+		result := results[key]
+		if result == nil {
+			t.Fatalf("❌ Missing result for %s", key)
+		}
+		if result.Value != want || result.Component != topics.ComponentBinarySensor {
+			t.Errorf("❌ %s = %.0f (%s), expected %.0f binary sensor", key, result.Value, result.Component, want)
+		}
+	}
+	if results["meter_running"].Topic != topics.ConstructComponentTopic(topics.ComponentBinarySensor, "meter", "running") {
+		t.Errorf("❌ Unexpected bit topic %s", results["meter_running"].Topic)
+	}
+
+	// The status word is cached for calculated values but not published
+	if _, published := results["meter_status"]; published {
+		t.Error("❌ Status word should be published as bits only")
+	}
+	if cached, ok := cache.Get("meter_status"); !ok || cached.Value != 0x8005 {
+		t.Errorf("❌ Status word not cached: %+v", cached)
+	}
+
+	mode := results["meter_mode"]
+	if mode.State != "Export" || mode.Value != 2 || mode.DeviceClass != config.DeviceClassEnum {
+		t.Errorf("❌ Mode = %q (%.0f, %s), expected Export", mode.State, mode.Value, mode.DeviceClass)
+	}
+	if len(mode.Options) != 3 || mode.Options[0] != "Idle" || mode.Options[2] != "Export" {
+		t.Errorf("❌ Unexpected options %v", mode.Options)
+	}
+	if fault := results["meter_fault"]; fault.State != "" {
+		t.Errorf("❌ Unmapped value 7 should have no label, got %q", fault.State)
+	}
+	t.Logf("✅ Status word decoded into %d bits, mode = %s", len(expected), mode.State)
+}
+
+// chunkGateway serves reads from memory, records each request and can fail one of them
+type chunkGateway struct {
+	*memoryGateway
+	reads  []readChunk
+	failAt int // Address of the read that fails (-1 = none)
+}
+
+func (g *chunkGateway) SendCommandAndWaitForResponse(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16, timeoutSeconds int) ([]byte, error) {
+	g.reads = append(g.reads, readChunk{address: address, count: count})
+	if int(address) == g.failAt {
+		return nil, errors.NewModbusExceptionError(slaveID, functionCode, address, errors.ExceptionIllegalDataAddress)
+	}
+	return g.memoryGateway.SendCommandAndWaitForResponse(ctx, slaveID, functionCode, address, count, timeoutSeconds)
+}
+
+// TestGroupSplitReads verifies groups larger than the request limit are read in chunks without splitting values
+func TestGroupSplitReads(t *testing.T) {
+	gw := &chunkGateway{memoryGateway: newMemoryGateway(), failAt: -1}
+	group := config.RegisterGroup{
+		Name:          "Instant",
+		FunctionCode:  config.FunctionReadHoldingRegisters,
+		StartAddress:  0x2000,
+		RegisterCount: 10,
+	}
+	var registers []RegisterWithKey
+	for i := uint16(0); i < 5; i++ {
+		address := 0x2000 + 2*i
+		bits := math.Float32bits(float32(100 + i))
+		gw.registers[address], gw.registers[address+1] = uint16(bits>>16), uint16(bits)
+		registers = append(registers, RegisterWithKey{
+			Key:      fmt.Sprintf("meter_value_%d", i),
+			Register: config.Register{Name: "Value", Address: address, ScaleFactor: 1},
+		})
+	}
+
+	strategy := NewGroupRegisterStrategy("meter_instant", group, registers, 1, gw, nil)
+	strategy.SetMaxRegistersPerRequest(5)
+	results, err := strategy.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("❌ Execute failed: %v", err)
+	}
+
+	expected := []readChunk{{0x2000, 4}, {0x2004, 4}, {0x2008, 2}}
+	if fmt.Sprint(gw.reads) != fmt.Sprint(expected) {
+		t.Errorf("❌ Reads = %v, expected %v", gw.reads, expected)
+	}
+	for i := 0; i < 5; i++ {
+		if v := results[fmt.Sprintf("meter_value_%d", i)].Value; v != float64(100+i) {
+			t.Errorf("❌ Value %d = %f, expected %d", i, v, 100+i)
+		}
+	}
+
+	// A failing chunk fails the whole group without results or cache updates
+	gw.reads, gw.failAt = nil, 0x2004
+	cache := NewValueCache(time.Minute)
+	strategy = NewGroupRegisterStrategy("meter_instant", group, registers, 1, gw, cache)
+	strategy.SetMaxRegistersPerRequest(5)
+	results, err = strategy.Execute(context.Background())
+	if err == nil || results != nil {
+		t.Fatalf("❌ Expected group failure, got %d results (err=%v)", len(results), err)
+	}
+	if _, ok := cache.Get("meter_value_0"); ok {
+		t.Error("❌ Values of a failed group must not be cached")
+	}
+	if len(gw.reads) != 2 {
+		t.Errorf("❌ Expected reads to stop at the failed chunk, got %v", gw.reads)
+	}
+	t.Logf("✅ Group read in %d chunks", len(expected))
+}
+
+// TestSensorKeyKeepsPhaseSuffix verifies sensor keys are only stripped of the device prefix
+func TestSensorKeyKeepsPhaseSuffix(t *testing.T) {
+	tests := map[string]RegisterWithKey{
+		"voltage_l1_l2":  {Key: "energy_meter_mains_voltage_l1_l2", DeviceKey: "energy_meter_mains"},
+		"power_active":   {Key: "energy_meter_lights_power_active", DeviceKey: "energy_meter_lights"},
+		"current_l3":     {Key: "dtsu_current_l3", DeviceKey: "dtsu"},
+		"meter_relay_1":  {Key: "meter_relay_1"},
+		"energy_net":     {Key: "meter_energy_net", DeviceKey: "meter"},
+		"meter_2_energy": {Key: "meter_meter_2_energy", DeviceKey: "meter"},
+	}
+	for expected, reg := range tests {
+		if got := reg.SensorKey(); got != expected {
+			t.Errorf("❌ SensorKey(%s) = %s, expected %s", reg.Key, got, expected)
+		}
+	}
+}
+
+// TestTransformsGroupAndCalculated verifies registers and calculated values run the same pipeline before caching
+func TestTransformsGroupAndCalculated(t *testing.T) {
+	offset, decimals := -50.0, 2
+	transforms := config.Transforms{
+		{Invert: true},
+		{Offset: &offset},
+		{Convert: &config.UnitConversion{From: "W", To: "kW"}},
+		{Round: &decimals},
+	}
+
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, math.Float32bits(-1234.5))
+	cache := NewValueCache(time.Minute)
+	registers := []RegisterWithKey{
+		{Key: "meter_power", DeviceKey: "meter", Register: config.Register{Name: "Power", Address: 0x2000, ScaleFactor: 1, Unit: "kW", Transforms: transforms}},
+	}
+	group := config.RegisterGroup{Name: "Instant", FunctionCode: config.FunctionReadHoldingRegisters, StartAddress: 0x2000, RegisterCount: 2}
+	results, err := NewGroupRegisterStrategy("meter_instant", group, registers, 1, &fixedGateway{data: data}, cache).Execute(context.Background())
+	if err != nil {
+		t.Fatalf("❌ Execute failed: %v", err)
+	}
+	// -(-1234.5) - 50 = 1184.5 W → 1.1845 kW → 1.18 kW
+	if v := results["meter_power"].Value; v != 1.18 {
+		t.Errorf("❌ Group register = %v, expected 1.18", v)
+	}
+	if cached, _ := cache.Get("meter_power"); cached == nil || cached.Value != 1.18 {
+		t.Errorf("❌ Cache must hold the transformed value, got %+v", cached)
+	}
+
+	cache.Set("meter_raw", &CommandResult{Value: -1234.5})
+	calc := NewCalculatedRegisterStrategy("meter_power_calc",
+		config.Register{Name: "Power", Formula: "raw", ScaleFactor: 1, Unit: "kW", Transforms: transforms}, "meter", cache)
+	result, err := calc.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("❌ Calculated value failed: %v", err)
+	}
+	if result.Value != 1.18 {
+		t.Errorf("❌ Calculated value = %v, expected 1.18", result.Value)
+	}
+	t.Logf("✅ Transforms applied to register and calculated value: %.2f kW", result.Value)
+}
+
+// TestFilterPublishedAndRawInputs verifies the published value is filtered while calculated values can opt in to the raw value
+func TestFilterPublishedAndRawInputs(t *testing.T) {
+	data := make([]byte, 4)
+	cache := NewValueCache(time.Minute)
+	gw := &fixedGateway{data: data}
+	registers := []RegisterWithKey{
+		{Key: "meter_power", DeviceKey: "meter", Register: config.Register{Name: "Power", Address: 0x2000, ScaleFactor: 1, Unit: "W",
+			Filter: &config.ValueFilter{Type: config.FilterMovingAverage, Samples: 2}}},
+	}
+	group := config.RegisterGroup{Name: "Instant", FunctionCode: config.FunctionReadHoldingRegisters, StartAddress: 0x2000, RegisterCount: 2}
+	strategy := NewGroupRegisterStrategy("meter_instant", group, registers, 1, gw, cache)
+	strategy.SetFilters(NewValueFilters())
+
+	var results map[string]*CommandResult
+	for _, sample := range []float32{100, 300} {
+		binary.BigEndian.PutUint32(data, math.Float32bits(sample))
+		var err error
+		if results, err = strategy.Execute(context.Background()); err != nil {
+			t.Fatalf("❌ Execute failed: %v", err)
+		}
+	}
+	if v := results["meter_power"].Value; v != 200 {
+		t.Errorf("❌ Published power = %v, expected the average 200", v)
+	}
+
+	for _, tt := range []struct {
+		rawInputs bool
+		expected  float64
+	}{{false, 200}, {true, 300}} {
+		calc := NewCalculatedRegisterStrategy("meter_power_copy",
+			config.Register{Name: "Power", Formula: "power", ScaleFactor: 1, Unit: "W", RawInputs: tt.rawInputs}, "meter", cache)
+		result, err := calc.Execute(context.Background())
+		if err != nil {
+			t.Fatalf("❌ Calculated value failed: %v", err)
+		}
+		if result.Value != tt.expected {
+			t.Errorf("❌ raw_inputs=%v: calculated value = %v, expected %v", tt.rawInputs, result.Value, tt.expected)
+		}
+	}
+	t.Log("✅ Filtered value published, raw value available to calculated values")
+}
+
+// TestCounterPublishedContinuous verifies a total_increasing register publishes the continuous value
+// and reports readings held back by a meter reset
+func TestCounterPublishedContinuous(t *testing.T) {
+	data := make([]byte, 4)
+	gw := &fixedGateway{data: data}
+	registers := []RegisterWithKey{
+		{Key: "meter_energy", DeviceKey: "meter", Register: config.Register{Name: "Energy", Address: 0x4000, ScaleFactor: 1, Unit: "kWh",
+			StateClass: config.StateClassTotalIncreasing, Counter: &config.CounterConfig{Rollover: 1000}}},
+	}
+	group := config.RegisterGroup{Name: "Energy", FunctionCode: config.FunctionReadHoldingRegisters, StartAddress: 0x4000, RegisterCount: 2}
+	strategy := NewGroupRegisterStrategy("meter_energy_group", group, registers, 1, gw, NewValueCache(time.Minute))
+	strategy.SetCounters(NewMeterCounters())
+	var rejected []string
+	strategy.SetRejectHandler(func(key string, result *CommandResult, reason error) {
+		rejected = append(rejected, key)
+	})
+
+	steps := []struct {
+		reading   float32
+		published bool
+		expected  float64
+	}{
+		{990, true, 990},
+		{5, true, 1005}, // Rollover
+		{2, false, 0},   // Meter reset waits for acknowledgement
+	}
+	for _, step := range steps {
+		binary.BigEndian.PutUint32(data, math.Float32bits(step.reading))
+		results, err := strategy.Execute(context.Background())
+		if err != nil {
+			t.Fatalf("❌ Execute failed: %v", err)
+		}
+		result, published := results["meter_energy"]
+		if published != step.published {
+			t.Fatalf("❌ Reading %.0f: published=%v, expected %v", step.reading, published, step.published)
+		}
+		if published && result.Value != step.expected {
+			t.Errorf("❌ Reading %.0f → %.1f, expected %.1f", step.reading, result.Value, step.expected)
+		}
+	}
+	if len(rejected) != 1 || rejected[0] != "meter_energy" {
+		t.Errorf("❌ Rejections = %v, expected the held back reading", rejected)
+	}
+	t.Log("✅ Counter published as a continuous series")
+}
+
+// TestCounterIgnoresImplausibleSpike verifies a glitch read never reaches the meter counter
+func TestCounterIgnoresImplausibleSpike(t *testing.T) {
+	data := make([]byte, 4)
+	gw := &fixedGateway{data: data}
+	maxEnergy := 100000.0
+	registers := []RegisterWithKey{
+		{Key: "meter_energy", DeviceKey: "meter", Register: config.Register{Name: "Energy", Address: 0x4000, ScaleFactor: 1, Unit: "kWh",
+			StateClass: config.StateClassTotalIncreasing, Max: &maxEnergy, Counter: &config.CounterConfig{AutoAcknowledge: true}}},
+	}
+	group := config.RegisterGroup{Name: "Energy", FunctionCode: config.FunctionReadHoldingRegisters, StartAddress: 0x4000, RegisterCount: 2}
+	cache := NewValueCache(time.Minute)
+	counters := NewMeterCounters()
+	strategy := NewGroupRegisterStrategy("meter_energy_group", group, registers, 1, gw, cache)
+	strategy.SetCounters(counters)
+	var rejected []string
+	strategy.SetRejectHandler(func(key string, result *CommandResult, reason error) {
+		rejected = append(rejected, key)
+	})
+
+	steps := []struct {
+		reading   float32
+		published bool
+	}{
+		{500, true},
+		{1e30, false}, // Glitch above max
+		{float32(math.NaN()), false},
+		{501, true},
+		{502.5, true},
+	}
+	for _, step := range steps {
+		binary.BigEndian.PutUint32(data, math.Float32bits(step.reading))
+		results, err := strategy.Execute(context.Background())
+		if err != nil {
+			t.Fatalf("❌ Execute failed: %v", err)
+		}
+		result, published := results["meter_energy"]
+		if published != step.published {
+			t.Fatalf("❌ Reading %g: published=%v, expected %v", step.reading, published, step.published)
+		}
+		if published && result.Value != float64(step.reading) {
+			t.Errorf("❌ Reading %g → %.1f, expected the meter reading", step.reading, result.Value)
+		}
+	}
+
+	if len(rejected) != 2 {
+		t.Errorf("❌ Rejections = %v, expected the spike and the NaN", rejected)
+	}
+	if state := counters.Snapshot()["meter_energy"]; state.Offset != 0 || state.Resets != 0 || state.LastReading != 502.5 {
+		t.Errorf("❌ Counter state = %+v, expected no offset after the spike", state)
+	}
+	if cached, found := cache.Get("meter_energy"); !found || cached.Value != 502.5 {
+		t.Errorf("❌ Cached %+v, expected the last plausible reading", cached)
+	}
+	t.Log("✅ Implausible spike kept out of the counter")
+}
+
+// TestCounterIgnoresFilteredSpike verifies a spike dropped by the filter does not look like a meter reset
+func TestCounterIgnoresFilteredSpike(t *testing.T) {
+	data := make([]byte, 4)
+	gw := &fixedGateway{data: data}
+	registers := []RegisterWithKey{
+		{Key: "meter_energy", DeviceKey: "meter", Register: config.Register{Name: "Energy", Address: 0x4000, ScaleFactor: 1, Unit: "kWh",
+			StateClass: config.StateClassTotalIncreasing, Filter: &config.ValueFilter{MaxRate: 1000},
+			Counter: &config.CounterConfig{Rollover: 100000}}},
+	}
+	group := config.RegisterGroup{Name: "Energy", FunctionCode: config.FunctionReadHoldingRegisters, StartAddress: 0x4000, RegisterCount: 2}
+	counters := NewMeterCounters()
+	strategy := NewGroupRegisterStrategy("meter_energy_group", group, registers, 1, gw, NewValueCache(time.Minute))
+	strategy.SetFilters(NewValueFilters())
+	strategy.SetCounters(counters)
+	var rejected []string
+	strategy.SetRejectHandler(func(key string, result *CommandResult, reason error) {
+		rejected = append(rejected, key)
+	})
+
+	steps := []struct {
+		reading   float32
+		published bool
+	}{
+		{1000, true},
+		{50000, false}, // Spike dropped by max_rate
+		{1000.5, true},
+		{1001, true},
+	}
+	for _, step := range steps {
+		binary.BigEndian.PutUint32(data, math.Float32bits(step.reading))
+		results, err := strategy.Execute(context.Background())
+		if err != nil {
+			t.Fatalf("❌ Execute failed: %v", err)
+		}
+		result, published := results["meter_energy"]
+		if published != step.published {
+			t.Fatalf("❌ Reading %g: published=%v, expected %v", step.reading, published, step.published)
+		}
+		if published && result.Value != float64(step.reading) {
+			t.Errorf("❌ Reading %g → %.1f, expected the meter reading", step.reading, result.Value)
+		}
+	}
+
+	if len(rejected) != 0 || len(counters.Pending()) != 0 {
+		t.Errorf("❌ Rejections = %v, pending = %v: the spike must not look like a reset", rejected, counters.Pending())
+	}
+	if state := counters.Snapshot()["meter_energy"]; state.LastReading != 1001 || state.Offset != 0 {
+		t.Errorf("❌ Counter state = %+v, expected the last normal reading", state)
+	}
+	t.Log("✅ Filtered spike kept out of the counter")
+}
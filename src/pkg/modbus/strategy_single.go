@@ -2,9 +2,7 @@ package modbus
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
-	"math"
 	"mqtt-modbus-bridge/pkg/config"
 	"mqtt-modbus-bridge/pkg/errors"
 	"mqtt-modbus-bridge/pkg/gateway"
@@ -31,13 +29,14 @@ func (s *SingleRegisterStrategy) Execute(ctx context.Context) (*CommandResult, e
 		}
 	}
 
-	// Read 2 registers (4 bytes) for float32 using function code 0x03
+	// Read the registers of the data type (2 for the default float32) using function code 0x03
+	count := s.register.RegisterCount()
 	data, err := s.gateway.SendCommandAndWaitForResponse(
 		ctx,
 		s.slaveID,
 		0x03, // Read Holding Registers
 		s.register.Address,
-		2, // 2 registers for float32
+		count,
 		5, // 5 second timeout
 	)
 	if err != nil {
@@ -47,36 +46,43 @@ func (s *SingleRegisterStrategy) Execute(ctx context.Context) (*CommandResult, e
 		return nil, modbusErr
 	}
 
-	if len(data) != 4 {
+	if len(data) != int(count)*2 {
 		modbusErr := errors.NewModbusError("parse_single_register",
-			fmt.Errorf("expected 4 bytes, got %d bytes", len(data)),
+			fmt.Errorf("expected %d bytes, got %d bytes", count*2, len(data)),
 			s.slaveID, s.key)
 		modbusErr.Address = s.register.Address
 		return nil, modbusErr
 	}
 
-	// Parse float32 (Big Endian)
-	bits := binary.BigEndian.Uint32(data)
-	rawValue := math.Float32frombits(bits)
+	// Decode according to the register data type and byte order
+	rawValue, err := DecodeRegisters(data, s.register.GetDataType(), s.register.GetByteOrder())
+	if err != nil {
+		modbusErr := errors.NewModbusError("parse_single_register", err, s.slaveID, s.key)
+		modbusErr.Address = s.register.Address
+		return nil, modbusErr
+	}
 
 	// Apply scale factor
-	value := float64(rawValue) * s.register.ScaleFactor
+	value := rawValue * s.register.ScaleFactor
 
 	// Create result
 	result := &CommandResult{
 		Strategy:    "single_register",
 		Name:        s.register.Name,
+		Key:         s.key,
 		Value:       value,
 		Unit:        s.register.Unit,
 		Topic:       s.register.HATopic,
 		SensorKey:   s.key, // Use the full key as sensor key
 		DeviceClass: s.register.DeviceClass,
 		StateClass:  s.register.StateClass,
+		RawValue:    rawValue,
 		RawData:     data,
+		HasLimits:   s.register.HasLimits(),
 	}
 
-	// Cache the result
-	if s.cache != nil {
+	// Cache the result; implausible values are rejected by the executor and never cached
+	if s.cache != nil && s.register.CheckValue(value) == nil {
 		s.cache.Set(s.key, result)
 	}
 
@@ -4,15 +4,31 @@ import "time"
 
 // CommandResult result of executing a command
 type CommandResult struct {
-	Strategy    string  `json:"strategy"`
-	Name        string  `json:"name"`
-	Value       float64 `json:"value"`
-	Unit        string  `json:"unit"`
-	Topic       string  `json:"topic"`      // Full state topic path
-	SensorKey   string  `json:"sensor_key"` // Sensor key for building discovery topics
-	DeviceClass string  `json:"device_class"`
-	StateClass  string  `json:"state_class"`
-	RawData     []byte  `json:"raw_data"`
+	Strategy    string   `json:"strategy"`
+	Name        string   `json:"name"`
+	Key         string   `json:"key,omitempty"` // Full register key (device key + register key), unique across devices
+	Value       float64  `json:"value"`
+	Unit        string   `json:"unit"`
+	Topic       string   `json:"topic"`      // Full state topic path
+	SensorKey   string   `json:"sensor_key"` // Sensor key for building discovery topics
+	DeviceClass string   `json:"device_class"`
+	StateClass  string   `json:"state_class"`
+	Component   string   `json:"component,omitempty"` // Home Assistant component (empty = sensor, "binary_sensor" for bits)
+	DataType    string   `json:"data_type,omitempty"` // Register data type (string and bcd values are carried in State)
+	State       string   `json:"state,omitempty"`     // Text state of enum, string and bcd sensors (enums keep the number in Value)
+	Options     []string `json:"options,omitempty"`   // Possible text states of enum sensors
+	RawValue    float64  `json:"raw_value"`           // Decoded value before scale_factor and transforms (formula result for calculated values)
+	RawData     []byte   `json:"raw_data"`
+	Unfiltered  *float64 `json:"unfiltered,omitempty"` // Value before the noise filter (nil for registers without filter)
+	HasLimits   bool     `json:"has_limits,omitempty"` // Register has its own min/max, so the default device class ranges do not apply
+}
+
+// InputValue returns the value a formula reads: the unfiltered value when raw is set and the register is filtered
+func (r *CommandResult) InputValue(raw bool) float64 {
+	if raw && r.Unfiltered != nil {
+		return *r.Unfiltered
+	}
+	return r.Value
 }
 
 // CachedResult stores a command result with timestamp for cache validation
@@ -0,0 +1,156 @@
+package modbus
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"sync"
+	"time"
+)
+
+// Write audit results
+const (
+	WriteRejected = "rejected" // Refused by the policy, nothing was sent
+	WriteArmed    = "armed"    // Confirm register: waiting for the same command again
+	WriteAccepted = "accepted" // Allowed by the policy, about to be written
+	WriteDone     = "written"  // Written and confirmed by read-back
+	WriteFailed   = "failed"   // Write or read-back failed
+)
+
+// rateLimitWindow is the period over which writes per slave are counted
+const rateLimitWindow = time.Minute
+
+// WriteAuditEntry records the outcome of a write command
+type WriteAuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Key       string    `json:"key"`
+	DeviceID  string    `json:"device_id"`
+	SlaveID   uint8     `json:"slave_id"`
+	Address   uint16    `json:"address"`
+	Payload   string    `json:"payload"`
+	RawValue  *float64  `json:"raw_value,omitempty"`
+	Result    string    `json:"result"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// String describes the entry for logs
+func (e *WriteAuditEntry) String() string {
+	s := fmt.Sprintf("%s '%s' (slave %d, addr 0x%04X): %s", e.Key, e.Payload, e.SlaveID, e.Address, e.Result)
+	if e.Reason != "" {
+		s += " - " + e.Reason
+	}
+	return s
+}
+
+// writeTarget identifies a writable register or coil on a bus
+type writeTarget struct {
+	slaveID uint8
+	coil    bool
+	address uint16
+}
+
+// pendingWrite is an armed command on a confirm register
+type pendingWrite struct {
+	raw     float64
+	expires time.Time
+}
+
+// WritePolicy decides whether a write command may be sent to a bus
+// Only registers added with Allow can be written; commands are validated against the
+// register limits, confirm registers need the same command twice and each slave is rate limited.
+type WritePolicy struct {
+	mu             sync.Mutex
+	maxPerMinute   int
+	confirmTimeout time.Duration
+	allowed        map[writeTarget]bool
+	recent         map[uint8][]time.Time // Accepted write times per slave
+	pending        map[string]pendingWrite
+	now            func() time.Time
+}
+
+// NewWritePolicy creates a write policy for one bus
+func NewWritePolicy(cfg config.WritePolicyConfig) *WritePolicy {
+	return &WritePolicy{
+		maxPerMinute:   cfg.MaxWritesPerMinute,
+		confirmTimeout: time.Duration(cfg.ConfirmTimeout) * time.Second,
+		allowed:        make(map[writeTarget]bool),
+		recent:         make(map[uint8][]time.Time),
+		pending:        make(map[string]pendingWrite),
+		now:            time.Now,
+	}
+}
+
+// Allow adds every register or coil written by a writer to the allowlist
+func (p *WritePolicy) Allow(writer *RegisterWriter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	reg := writer.GetRegister()
+	for i := uint16(0); i < reg.RegisterCount(); i++ {
+		p.allowed[writeTarget{slaveID: writer.GetSlaveID(), coil: reg.IsCoil(), address: reg.Address + i}] = true
+	}
+}
+
+// Authorize checks a command against the policy and returns the audit entry
+// The write may only be sent when the entry result is WriteAccepted; RawValue then holds the value to write.
+func (p *WritePolicy) Authorize(writer *RegisterWriter, payload string) *WriteAuditEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	reg := writer.GetRegister()
+	entry := &WriteAuditEntry{
+		Timestamp: now,
+		Key:       writer.GetKey(),
+		DeviceID:  writer.GetDeviceID(),
+		SlaveID:   writer.GetSlaveID(),
+		Address:   reg.Address,
+		Payload:   payload,
+	}
+	reject := func(format string, args ...interface{}) *WriteAuditEntry {
+		entry.Result = WriteRejected
+		entry.Reason = fmt.Sprintf(format, args...)
+		return entry
+	}
+
+	// Allowlist: every register the write touches must be configured as writable
+	for i := uint16(0); i < reg.RegisterCount(); i++ {
+		if !p.allowed[writeTarget{slaveID: writer.GetSlaveID(), coil: reg.IsCoil(), address: reg.Address + i}] {
+			return reject("address 0x%04X is not writable", reg.Address+i)
+		}
+	}
+
+	// Value limits (min/max/step, options, payloads)
+	raw, err := writer.ParseCommand(payload)
+	if err != nil {
+		return reject("%v", err)
+	}
+	entry.RawValue = &raw
+
+	// Two-step confirmation for dangerous registers
+	if reg.Confirm {
+		armed, exists := p.pending[writer.GetKey()]
+		if !exists || armed.raw != raw || now.After(armed.expires) {
+			p.pending[writer.GetKey()] = pendingWrite{raw: raw, expires: now.Add(p.confirmTimeout)}
+			entry.Result = WriteArmed
+			entry.Reason = fmt.Sprintf("send the same command again within %.0fs to confirm", p.confirmTimeout.Seconds())
+			return entry
+		}
+		delete(p.pending, writer.GetKey())
+	}
+
+	// Rate limit per slave
+	recent := p.recent[writer.GetSlaveID()][:0]
+	for _, t := range p.recent[writer.GetSlaveID()] {
+		if now.Sub(t) < rateLimitWindow {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= p.maxPerMinute {
+		p.recent[writer.GetSlaveID()] = recent
+		return reject("rate limit of %d writes per minute reached for slave %d", p.maxPerMinute, writer.GetSlaveID())
+	}
+	p.recent[writer.GetSlaveID()] = append(recent, now)
+
+	entry.Result = WriteAccepted
+	return entry
+}
@@ -0,0 +1,120 @@
+package modbus
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"testing"
+	"time"
+)
+
+// newTestPolicy returns a policy with a controllable clock
+func newTestPolicy(maxPerMinute int) (*WritePolicy, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := NewWritePolicy(config.WritePolicyConfig{MaxWritesPerMinute: maxPerMinute, ConfirmTimeout: 30})
+	policy.now = func() time.Time { return now }
+	return policy, &now
+}
+
+// TestWritePolicyAllowlistAndLimits verifies that only allowed registers with valid values pass
+func TestWritePolicyAllowlistAndLimits(t *testing.T) {
+	policy, _ := newTestPolicy(10)
+	limit := NewRegisterWriter("meter_limit", "meter", config.WritableRegister{
+		Key: "limit", Component: config.WritableNumber, Address: 0x0100, ValueType: config.ValueTypeUint32,
+		Min: float64Ptr(0), Max: float64Ptr(100), Step: 5,
+	}, 1, nil)
+
+	if entry := policy.Authorize(limit, "50"); entry.Result != WriteRejected {
+		t.Errorf("❌ Register not in the allowlist was %s", entry.Result)
+	}
+
+	policy.Allow(limit)
+	tests := []struct {
+		payload  string
+		expected string
+	}{
+		{"50", WriteAccepted},
+		{"150", WriteRejected},
+		{"52", WriteRejected},
+		{"x", WriteRejected},
+	}
+	for _, tt := range tests {
+		entry := policy.Authorize(limit, tt.payload)
+		if entry.Result != tt.expected {
+			t.Errorf("❌ '%s': expected %s, got %s (%s)", tt.payload, tt.expected, entry.Result, entry.Reason)
+		}
+		if entry.Result == WriteRejected && entry.Reason == "" {
+			t.Errorf("❌ '%s': rejection without reason", tt.payload)
+		}
+	}
+
+	// A 32-bit value needs both registers in the allowlist
+	wide := NewRegisterWriter("meter_wide", "meter", config.WritableRegister{
+		Key: "wide", Component: config.WritableButton, Address: 0x0200, ValueType: config.ValueTypeUint32,
+	}, 1, nil)
+	policy.allowed[writeTarget{slaveID: 1, address: 0x0200}] = true
+	if entry := policy.Authorize(wide, "PRESS"); entry.Result != WriteRejected {
+		t.Errorf("❌ Partially allowed register was %s", entry.Result)
+	}
+	t.Logf("✅ Allowlist and value limits enforced")
+}
+
+// TestWritePolicyConfirm verifies the arm/confirm sequence of dangerous registers
+func TestWritePolicyConfirm(t *testing.T) {
+	policy, now := newTestPolicy(10)
+	baud := NewRegisterWriter("meter_baud", "meter", config.WritableRegister{
+		Key: "baud", Component: config.WritableSelect, Address: 0x000C, Confirm: true,
+		Options: []config.SelectOption{{Label: "9600", Value: 3}, {Label: "19200", Value: 4}},
+	}, 1, nil)
+	policy.Allow(baud)
+
+	steps := []struct {
+		payload  string
+		advance  time.Duration
+		expected string
+	}{
+		{"9600", 0, WriteArmed},
+		{"19200", time.Second, WriteArmed}, // Different value re-arms
+		{"19200", time.Second, WriteAccepted},
+		{"19200", time.Second, WriteArmed},   // Confirmation is consumed
+		{"19200", time.Minute, WriteArmed},   // Expired, armed again
+		{"4800", time.Second, WriteRejected}, // Invalid values are never armed
+	}
+	for i, step := range steps {
+		*now = now.Add(step.advance)
+		if entry := policy.Authorize(baud, step.payload); entry.Result != step.expected {
+			t.Errorf("❌ Step %d '%s': expected %s, got %s", i, step.payload, step.expected, entry.Result)
+		}
+	}
+	t.Logf("✅ %d arm/confirm steps verified", len(steps))
+}
+
+// TestWritePolicyRateLimit verifies the per-slave rate limit
+func TestWritePolicyRateLimit(t *testing.T) {
+	policy, now := newTestPolicy(2)
+	newRelay := func(slaveID uint8) *RegisterWriter {
+		writer := NewRegisterWriter("relay", "meter", config.WritableRegister{
+			Key: "relay", Component: config.WritableSwitch, RegisterType: config.RegisterTypeCoil,
+		}, slaveID, nil)
+		policy.Allow(writer)
+		return writer
+	}
+	first, second := newRelay(1), newRelay(2)
+
+	results := []string{
+		policy.Authorize(first, "ON").Result,
+		policy.Authorize(first, "OFF").Result,
+		policy.Authorize(first, "ON").Result,
+		policy.Authorize(second, "ON").Result, // Other slave has its own budget
+	}
+	expected := []string{WriteAccepted, WriteAccepted, WriteRejected, WriteAccepted}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("❌ Write %d: expected %s, got %s", i, expected[i], results[i])
+		}
+	}
+
+	*now = now.Add(rateLimitWindow)
+	if entry := policy.Authorize(first, "ON"); entry.Result != WriteAccepted {
+		t.Errorf("❌ Write after the window: expected %s, got %s", WriteAccepted, entry.Result)
+	}
+	t.Logf("✅ Rate limit of 2 writes per minute enforced per slave")
+}
@@ -0,0 +1,217 @@
+package modbus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/gateway"
+	"strconv"
+	"strings"
+)
+
+// Command payloads sent by Home Assistant for switch and button entities
+const (
+	PayloadOn    = "ON"
+	PayloadOff   = "OFF"
+	PayloadPress = "PRESS"
+)
+
+// writeTimeoutSeconds bounds each write and read-back transaction
+const writeTimeoutSeconds = 5
+
+// RegisterWriter writes a Home Assistant command to a writable register and reads it back
+type RegisterWriter struct {
+	key      string // Full register key (device_key_register_key)
+	deviceID string // Home Assistant device ID
+	register config.WritableRegister
+	slaveID  uint8
+	gateway  gateway.Gateway
+}
+
+// NewRegisterWriter creates a new register writer
+func NewRegisterWriter(
+	key string,
+	deviceID string,
+	register config.WritableRegister,
+	slaveID uint8,
+	gateway gateway.Gateway,
+) *RegisterWriter {
+	return &RegisterWriter{
+		key:      key,
+		deviceID: deviceID,
+		register: register,
+		slaveID:  slaveID,
+		gateway:  gateway,
+	}
+}
+
+// GetKey returns the full register key
+func (w *RegisterWriter) GetKey() string {
+	return w.key
+}
+
+// GetDeviceID returns the Home Assistant device ID
+func (w *RegisterWriter) GetDeviceID() string {
+	return w.deviceID
+}
+
+// GetSlaveID returns the Modbus slave ID
+func (w *RegisterWriter) GetSlaveID() uint8 {
+	return w.slaveID
+}
+
+// GetRegister returns the writable register configuration
+func (w *RegisterWriter) GetRegister() *config.WritableRegister {
+	return &w.register
+}
+
+// HasState returns false for buttons, which are write-only
+func (w *RegisterWriter) HasState() bool {
+	return w.register.Component != config.WritableButton
+}
+
+// ParseCommand validates a command payload and converts it to the raw register value
+func (w *RegisterWriter) ParseCommand(payload string) (float64, error) {
+	payload = strings.TrimSpace(payload)
+	reg := &w.register
+
+	switch reg.Component {
+	case config.WritableNumber:
+		value, err := strconv.ParseFloat(payload, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, fmt.Errorf("'%s' is not a number", payload)
+		}
+		if value < *reg.Min || value > *reg.Max {
+			return 0, fmt.Errorf("%g is outside the allowed range %g-%g", value, *reg.Min, *reg.Max)
+		}
+		if steps := (value - *reg.Min) / reg.GetStep(); math.Abs(steps-math.Round(steps)) > 1e-6 {
+			return 0, fmt.Errorf("%g is not a multiple of step %g from %g", value, reg.GetStep(), *reg.Min)
+		}
+		return value / reg.GetScaleFactor(), nil
+	case config.WritableSelect:
+		value, ok := reg.OptionValue(payload)
+		if !ok {
+			return 0, fmt.Errorf("'%s' is not one of the options", payload)
+		}
+		return value, nil
+	case config.WritableSwitch:
+		switch payload {
+		case PayloadOn:
+			return reg.GetOnValue(), nil
+		case PayloadOff:
+			return reg.GetOffValue(), nil
+		}
+		return 0, fmt.Errorf("'%s' is not %s or %s", payload, PayloadOn, PayloadOff)
+	case config.WritableButton:
+		if payload != PayloadPress {
+			return 0, fmt.Errorf("'%s' is not %s", payload, PayloadPress)
+		}
+		return reg.GetPressValue(), nil
+	}
+	return 0, fmt.Errorf("unsupported component '%s'", reg.Component)
+}
+
+// Write writes a raw value to the register or coil
+func (w *RegisterWriter) Write(ctx context.Context, raw float64) error {
+	var req *gateway.WriteRequest
+	if w.register.IsCoil() {
+		req = gateway.NewWriteCoil(w.register.Address, raw != 0)
+	} else {
+		var err error
+		req, err = gateway.NewWriteValue(w.register.Address, raw, w.register.GetValueType())
+		if err != nil {
+			return fmt.Errorf("cannot encode %s: %w", w.key, err)
+		}
+	}
+	return w.gateway.Write(ctx, w.slaveID, req, writeTimeoutSeconds)
+}
+
+// Read reads the current raw value of the register or coil
+func (w *RegisterWriter) Read(ctx context.Context) (float64, error) {
+	if w.register.IsCoil() {
+		data, err := w.gateway.SendCommandAndWaitForResponse(
+			ctx, w.slaveID, config.FunctionReadCoils, w.register.Address, 1, writeTimeoutSeconds)
+		if err != nil {
+			return 0, err
+		}
+		if len(data) < 1 {
+			return 0, fmt.Errorf("empty coil response for %s", w.key)
+		}
+		return float64(data[0] & 0x01), nil
+	}
+
+	data, err := w.gateway.SendCommandAndWaitForResponse(
+		ctx, w.slaveID, config.FunctionReadHoldingRegisters, w.register.Address, w.register.RegisterCount(), writeTimeoutSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return gateway.DecodeValue(data, w.register.GetValueType())
+}
+
+// WriteAndConfirm writes a raw value and reads it back to confirm the device accepted it
+// Returns the value read back; buttons are not read back and return the written value.
+func (w *RegisterWriter) WriteAndConfirm(ctx context.Context, raw float64) (float64, error) {
+	if err := w.Write(ctx, raw); err != nil {
+		return 0, err
+	}
+	if !w.HasState() {
+		return raw, nil
+	}
+
+	actual, err := w.Read(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("read-back failed: %w", err)
+	}
+	expected := raw
+	if w.register.IsCoil() {
+		expected = 0
+		if raw != 0 {
+			expected = 1
+		}
+	}
+	if !w.matches(expected, actual) {
+		return actual, fmt.Errorf("read-back mismatch: wrote %g, device reports %g", expected, actual)
+	}
+	return actual, nil
+}
+
+// matches compares a written and a read-back raw value
+// Integer types are compared after rounding, float32 with its precision
+func (w *RegisterWriter) matches(expected, actual float64) bool {
+	if w.register.IsCoil() || w.register.GetValueType() != config.ValueTypeFloat32 {
+		return math.Round(expected) == actual
+	}
+	return float32(expected) == float32(actual)
+}
+
+// FormatState converts a raw value to the state published to Home Assistant
+// number: scaled value, select: option label, switch: ON/OFF
+func (w *RegisterWriter) FormatState(raw float64) (interface{}, error) {
+	reg := &w.register
+	switch reg.Component {
+	case config.WritableNumber:
+		return raw * reg.GetScaleFactor(), nil
+	case config.WritableSelect:
+		label, ok := reg.OptionLabel(raw)
+		if !ok {
+			return nil, fmt.Errorf("value %g matches no option", raw)
+		}
+		return label, nil
+	case config.WritableSwitch:
+		if reg.IsCoil() {
+			if raw != 0 {
+				return PayloadOn, nil
+			}
+			return PayloadOff, nil
+		}
+		switch raw {
+		case reg.GetOnValue():
+			return PayloadOn, nil
+		case reg.GetOffValue():
+			return PayloadOff, nil
+		}
+		return nil, fmt.Errorf("value %g is neither on (%g) nor off (%g)", raw, reg.GetOnValue(), reg.GetOffValue())
+	}
+	return nil, fmt.Errorf("%s has no state", reg.Component)
+}
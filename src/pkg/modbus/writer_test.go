@@ -0,0 +1,151 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/gateway"
+	"testing"
+)
+
+// memoryGateway keeps holding registers and coils in memory so writes can be read back
+type memoryGateway struct {
+	fixedGateway
+	registers map[uint16]uint16
+	coils     map[uint16]bool
+	writes    []*gateway.WriteRequest
+	ignore    bool // Accept writes without storing them (device rejects the value silently)
+}
+
+func newMemoryGateway() *memoryGateway {
+	return &memoryGateway{registers: make(map[uint16]uint16), coils: make(map[uint16]bool)}
+}
+
+func (g *memoryGateway) Write(ctx context.Context, slaveID uint8, req *gateway.WriteRequest, timeoutSeconds int) error {
+	g.writes = append(g.writes, req)
+	if g.ignore {
+		return nil
+	}
+	pdu := req.PDU()
+	switch req.FunctionCode {
+	case gateway.FunctionWriteSingleCoil:
+		g.coils[req.Address] = binary.BigEndian.Uint16(pdu[3:5]) == 0xFF00
+	case gateway.FunctionWriteSingleRegister:
+		g.registers[req.Address] = binary.BigEndian.Uint16(pdu[3:5])
+	case gateway.FunctionWriteMultipleRegisters:
+		for i := uint16(0); i < req.Quantity; i++ {
+			g.registers[req.Address+i] = binary.BigEndian.Uint16(pdu[6+2*i:])
+		}
+	}
+	return nil
+}
+
+func (g *memoryGateway) SendCommandAndWaitForResponse(ctx context.Context, slaveID uint8, functionCode uint8, address uint16, count uint16, timeoutSeconds int) ([]byte, error) {
+	if functionCode == config.FunctionReadCoils {
+		if g.coils[address] {
+			return []byte{0x01}, nil
+		}
+		return []byte{0x00}, nil
+	}
+	data := make([]byte, 2*count)
+	for i := uint16(0); i < count; i++ {
+		binary.BigEndian.PutUint16(data[2*i:], g.registers[address+i])
+	}
+	return data, nil
+}
+
+func float64Ptr(v float64) *float64 { return &v }
+
+// TestWriterParseCommand verifies command validation for each component
+func TestWriterParseCommand(t *testing.T) {
+	number := NewRegisterWriter("meter_ct_ratio", "meter", config.WritableRegister{
+		Key: "ct_ratio", Component: config.WritableNumber, Min: float64Ptr(1), Max: float64Ptr(100), Step: 0.5, ScaleFactor: 0.1,
+	}, 1, nil)
+	selector := NewRegisterWriter("meter_baud", "meter", config.WritableRegister{
+		Key: "baud", Component: config.WritableSelect,
+		Options: []config.SelectOption{{Label: "9600", Value: 3}, {Label: "19200", Value: 4}},
+	}, 1, nil)
+	relay := NewRegisterWriter("meter_relay", "meter", config.WritableRegister{
+		Key: "relay", Component: config.WritableSwitch, OnValue: float64Ptr(0x55), OffValue: float64Ptr(0xAA),
+	}, 1, nil)
+	reset := NewRegisterWriter("meter_reset", "meter", config.WritableRegister{
+		Key: "reset", Component: config.WritableButton, PressValue: float64Ptr(0x0A),
+	}, 1, nil)
+
+	valid := []struct {
+		writer   *RegisterWriter
+		payload  string
+		expected float64
+	}{
+		{number, "12.5", 125},
+		{number, "100", 1000},
+		{selector, "19200", 4},
+		{relay, "ON", 0x55},
+		{relay, "OFF", 0xAA},
+		{reset, "PRESS", 0x0A},
+	}
+	for _, tt := range valid {
+		raw, err := tt.writer.ParseCommand(tt.payload)
+		if err != nil || raw != tt.expected {
+			t.Errorf("❌ %s '%s': expected %g, got %g (%v)", tt.writer.GetKey(), tt.payload, tt.expected, raw, err)
+		}
+	}
+
+	invalid := []struct {
+		writer  *RegisterWriter
+		payload string
+	}{
+		{number, "abc"},
+		{number, "0.5"},
+		{number, "100.5"},
+		{number, "12.3"},
+		{number, "NaN"},
+		{selector, "4800"},
+		{relay, "on"},
+		{reset, "ON"},
+	}
+	for _, tt := range invalid {
+		if _, err := tt.writer.ParseCommand(tt.payload); err == nil {
+			t.Errorf("❌ %s: expected '%s' to be rejected", tt.writer.GetKey(), tt.payload)
+		}
+	}
+	t.Logf("✅ Accepted %d commands, rejected %d", len(valid), len(invalid))
+}
+
+// TestWriterWriteAndConfirm verifies the write request and read-back of holding registers and coils
+func TestWriterWriteAndConfirm(t *testing.T) {
+	gw := newMemoryGateway()
+	ctx := context.Background()
+
+	limit := NewRegisterWriter("meter_limit", "meter", config.WritableRegister{
+		Key: "limit", Component: config.WritableNumber, Address: 0x0100, ValueType: config.ValueTypeFloat32,
+		Min: float64Ptr(0), Max: float64Ptr(100), Step: 0.1,
+	}, 1, gw)
+	raw, err := limit.ParseCommand("42.5")
+	if err != nil {
+		t.Fatalf("❌ ParseCommand failed: %v", err)
+	}
+	actual, err := limit.WriteAndConfirm(ctx, raw)
+	if err != nil || actual != 42.5 {
+		t.Errorf("❌ float32 write: expected 42.5, got %g (%v)", actual, err)
+	}
+	if req := gw.writes[0]; req.FunctionCode != gateway.FunctionWriteMultipleRegisters || req.Address != 0x0100 {
+		t.Errorf("❌ Unexpected write request %s", req)
+	}
+
+	relay := NewRegisterWriter("meter_relay", "meter", config.WritableRegister{
+		Key: "relay", Component: config.WritableSwitch, RegisterType: config.RegisterTypeCoil, Address: 0x0005,
+	}, 1, gw)
+	if _, err := relay.WriteAndConfirm(ctx, 1); err != nil {
+		t.Errorf("❌ Coil write failed: %v", err)
+	}
+	if state, _ := relay.FormatState(1); state != PayloadOn || gw.writes[1].FunctionCode != gateway.FunctionWriteSingleCoil {
+		t.Errorf("❌ Expected coil write with state ON, got %v via %s", state, gw.writes[1])
+	}
+
+	gw.ignore = true
+	if _, err := limit.WriteAndConfirm(ctx, 10); err == nil {
+		t.Error("❌ Expected read-back mismatch when the device ignores the write")
+	}
+	t.Logf("✅ %d writes confirmed by read-back", len(gw.writes)-1)
+}
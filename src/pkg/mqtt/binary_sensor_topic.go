@@ -0,0 +1,167 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Binary sensor payloads published in the state "value" field
+const (
+	BinarySensorPayloadOn  = "ON"
+	BinarySensorPayloadOff = "OFF"
+)
+
+// BinarySensorTopic handles binary sensor publishing for coils and discrete inputs (FC 0x01/0x02)
+type BinarySensorTopic struct {
+	config *config.HAConfig
+}
+
+// NewBinarySensorTopic creates a new binary sensor topic handler
+func NewBinarySensorTopic(config *config.HAConfig) *BinarySensorTopic {
+	return &BinarySensorTopic{
+		config: config,
+	}
+}
+
+// BinarySensorConfig configuration for a Home Assistant binary sensor
+type BinarySensorConfig struct {
+	Name                string     `json:"name"`
+	UniqueID            string     `json:"unique_id"`
+	StateTopic          string     `json:"state_topic"`
+	DeviceClass         string     `json:"device_class,omitempty"`
+	Device              DeviceInfo `json:"device"`
+	ValueTemplate       string     `json:"value_template"`
+	PayloadOn           string     `json:"payload_on"`
+	PayloadOff          string     `json:"payload_off"`
+	AvailabilityTopic   string     `json:"availability_topic"`
+	PayloadAvailable    string     `json:"payload_available"`
+	PayloadNotAvailable string     `json:"payload_not_available"`
+}
+
+// BinarySensorState state of a binary sensor
+type BinarySensorState struct {
+	Value     string    `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PublishDiscovery publishes binary sensor discovery configuration
+func (b *BinarySensorTopic) PublishDiscovery(ctx context.Context, client mqtt.Client, result *modbus.CommandResult, deviceInfo *DeviceInfo) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client is not connected")
+	}
+
+	// Use device info if provided, otherwise fall back to deprecated global config
+	var device DeviceInfo
+	if deviceInfo != nil {
+		device = *deviceInfo
+	} else {
+		device = DeviceInfo{
+			Name:         b.config.DeviceName,
+			Identifiers:  []string{b.config.DeviceID},
+			Manufacturer: b.config.Manufacturer,
+			Model:        b.config.Model,
+		}
+	}
+
+	// Build topics in the binary_sensor component namespace
+	deviceID := ExtractDeviceID(&device)
+	discoveryTopic := topics.BuildComponentTopic(topics.ComponentBinarySensor, deviceID, result.SensorKey, "config")
+	uniqueID := topics.BuildUniqueID(deviceID, result.SensorKey)
+
+	sensorConfig := BinarySensorConfig{
+		Name:                result.Name,
+		UniqueID:            uniqueID,
+		StateTopic:          result.Topic,
+		DeviceClass:         result.DeviceClass,
+		Device:              device,
+		ValueTemplate:       "{{ value_json.value }}",
+		PayloadOn:           BinarySensorPayloadOn,
+		PayloadOff:          BinarySensorPayloadOff,
+		AvailabilityTopic:   topics.BuildStatusTopic(config.BridgeDeviceID),
+		PayloadAvailable:    "online",
+		PayloadNotAvailable: "offline",
+	}
+
+	// Serialize configuration
+	configJSON, err := json.Marshal(sensorConfig)
+	if err != nil {
+		return fmt.Errorf("error serializing binary sensor configuration: %w", err)
+	}
+
+	// Publish configuration
+	token := client.Publish(discoveryTopic, 0, true, configJSON)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error publishing binary sensor discovery: %w", token.Error())
+	}
+
+	return nil
+}
+
+// PublishState publishes binary sensor state (ON for a set bit, OFF otherwise)
+func (b *BinarySensorTopic) PublishState(ctx context.Context, client mqtt.Client, result *modbus.CommandResult) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client is not connected")
+	}
+
+	// Validate the result before publishing
+	if err := b.ValidateData(result, nil); err != nil {
+		return fmt.Errorf("invalid binary sensor data: %w", err)
+	}
+
+	state := BinarySensorState{
+		Value:     BinarySensorPayload(result.Value),
+		Timestamp: time.Now(),
+	}
+
+	// Serialize data
+	dataJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error serializing binary sensor data: %w", err)
+	}
+
+	// Publish state
+	token := client.Publish(result.Topic, 0, false, dataJSON)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error publishing binary sensor state: %w", token.Error())
+	}
+
+	return nil
+}
+
+// GetTopicPrefix returns the topic prefix for binary sensor topic
+func (b *BinarySensorTopic) GetTopicPrefix() string {
+	return topics.ComponentBinarySensor
+}
+
+// ValidateData validates binary sensor data before publishing
+func (b *BinarySensorTopic) ValidateData(result *modbus.CommandResult, register *config.Register) error {
+	if result.Value != 0 && result.Value != 1 {
+		return fmt.Errorf("binary sensor value must be 0 or 1, got %.3f for %s", result.Value, result.Name)
+	}
+
+	// Check required fields
+	if result.Name == "" {
+		return fmt.Errorf("binary sensor name is empty")
+	}
+
+	if result.Topic == "" {
+		return fmt.Errorf("binary sensor topic is empty")
+	}
+
+	return nil
+}
+
+// BinarySensorPayload converts a bit value to the binary sensor state payload
+func BinarySensorPayload(value float64) string {
+	if value != 0 {
+		return BinarySensorPayloadOn
+	}
+	return BinarySensorPayloadOff
+}
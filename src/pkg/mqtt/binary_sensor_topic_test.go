@@ -0,0 +1,127 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// completedToken implements paho.Token for an operation that succeeded immediately
+type completedToken struct{}
+
+func (completedToken) Wait() bool                     { return true }
+func (completedToken) WaitTimeout(time.Duration) bool { return true }
+func (completedToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+func (completedToken) Error() error { return nil }
+
+// recordingClient implements paho.Client and records every published message and subscription
+type recordingClient struct {
+	mu         sync.Mutex
+	published  map[string][]byte              // topic -> last payload
+	subscribed map[string]paho.MessageHandler // topic -> handler
+}
+
+func newRecordingClient() *recordingClient {
+	return &recordingClient{published: make(map[string][]byte), subscribed: make(map[string]paho.MessageHandler)}
+}
+
+func (c *recordingClient) IsConnected() bool      { return true }
+func (c *recordingClient) IsConnectionOpen() bool { return true }
+func (c *recordingClient) Connect() paho.Token    { return completedToken{} }
+func (c *recordingClient) Disconnect(uint)        {}
+func (c *recordingClient) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch p := payload.(type) {
+	case []byte:
+		c.published[topic] = p
+	case string:
+		c.published[topic] = []byte(p)
+	}
+	return completedToken{}
+}
+func (c *recordingClient) Subscribe(topic string, qos byte, handler paho.MessageHandler) paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribed[topic] = handler
+	return completedToken{}
+}
+func (c *recordingClient) SubscribeMultiple(map[string]byte, paho.MessageHandler) paho.Token {
+	return completedToken{}
+}
+func (c *recordingClient) Unsubscribe(...string) paho.Token        { return completedToken{} }
+func (c *recordingClient) AddRoute(string, paho.MessageHandler)    {}
+func (c *recordingClient) OptionsReader() paho.ClientOptionsReader { return paho.ClientOptionsReader{} }
+
+// payload decodes the last JSON payload published on a topic
+func (c *recordingClient) payload(t *testing.T, topic string, v interface{}) {
+	t.Helper()
+	c.mu.Lock()
+	data, exists := c.published[topic]
+	c.mu.Unlock()
+	if !exists {
+		t.Fatalf("❌ Nothing published on %s", topic)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("❌ Invalid JSON on %s: %v", topic, err)
+	}
+}
+
+// TestBinarySensorDiscoveryAndState verifies coil results are published as HA binary sensors
+func TestBinarySensorDiscoveryAndState(t *testing.T) {
+	client := newRecordingClient()
+	handler := NewTopicContext(&config.HAConfig{}, &config.MQTTConfig{}).GetHandler("binary_sensor")
+
+	stateTopic := topics.ConstructComponentTopic(topics.ComponentBinarySensor, "meter", "relay")
+	result := &modbus.CommandResult{
+		Name:        "Relay",
+		Value:       1,
+		Topic:       stateTopic,
+		SensorKey:   "relay",
+		DeviceClass: "power",
+		Component:   topics.ComponentBinarySensor,
+	}
+	device := &DeviceInfo{Name: "Meter", Identifiers: []string{"meter"}}
+
+	if err := handler.PublishDiscovery(context.Background(), client, result, device); err != nil {
+		t.Fatalf("❌ Discovery failed: %v", err)
+	}
+
+	var discovery BinarySensorConfig
+	client.payload(t, topics.BuildComponentTopic(topics.ComponentBinarySensor, "meter", "relay", "config"), &discovery)
+	if discovery.StateTopic != stateTopic || discovery.PayloadOn != "ON" || discovery.DeviceClass != "power" {
+		t.Errorf("❌ Unexpected discovery config: %+v", discovery)
+	}
+
+	for _, tc := range []struct {
+		value    float64
+		expected string
+	}{{1, "ON"}, {0, "OFF"}} {
+		result.Value = tc.value
+		if err := handler.PublishState(context.Background(), client, result); err != nil {
+			t.Fatalf("❌ State publish failed: %v", err)
+		}
+		var state BinarySensorState
+		client.payload(t, stateTopic, &state)
+		if state.Value != tc.expected {
+			t.Errorf("❌ Value %.0f published as %s, expected %s", tc.value, state.Value, tc.expected)
+		}
+	}
+
+	result.Value = 2
+	if err := handler.PublishState(context.Background(), client, result); err == nil {
+		t.Error("❌ Expected validation error for non-binary value")
+	}
+	t.Logf("✅ Binary sensor published on %s", stateTopic)
+}
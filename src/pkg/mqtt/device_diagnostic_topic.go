@@ -32,25 +32,63 @@ type DeviceMetrics struct {
 	TotalReads        int64
 	SuccessfulReads   int64
 	FailedReads       int64
+	ExceptionReads    int64 // Failed reads answered with a Modbus exception (included in FailedReads)
 	TotalResponseTime time.Duration
 	LastError         string
 	LastErrorTime     time.Time
-	CurrentState      string // operational, warning, error, offline
+	CurrentState      string                     // operational, warning, error, offline
+	RejectedValues    int64                      // Values that failed the plausibility check (min/max, NaN, Inf)
+	Rejections        map[string]*ValueRejection // Rejections per register key
+}
+
+// ValueRejection tracks the values of one register that failed the plausibility check
+type ValueRejection struct {
+	Count        int64
+	LastRawValue float64 // Decoded value before scale_factor and transforms (0 if NaN or infinite)
+	LastValue    float64 // Value that was checked (0 if NaN or infinite)
+	LastReason   string
+	LastTime     time.Time
+}
+
+// ValueRejectionState is the payload of the rejections of one register
+type ValueRejectionState struct {
+	Count        int64   `json:"count"`
+	LastRawValue float64 `json:"last_raw_value"`
+	LastValue    float64 `json:"last_value"`
+	LastReason   string  `json:"last_reason"`
+	LastTime     string  `json:"last_time,omitempty"`
+}
+
+// State returns the payload of the rejections
+func (r *ValueRejection) State() ValueRejectionState {
+	state := ValueRejectionState{
+		Count:        r.Count,
+		LastRawValue: r.LastRawValue,
+		LastValue:    r.LastValue,
+		LastReason:   r.LastReason,
+	}
+	if !r.LastTime.IsZero() {
+		state.LastTime = r.LastTime.Format(time.RFC3339)
+	}
+	return state
 }
 
 // DeviceDiagnosticState represents the state payload for device diagnostic sensor
 type DeviceDiagnosticState struct {
-	State             string  `json:"state"`
-	LastRead          string  `json:"last_read,omitempty"`
-	LastSuccess       string  `json:"last_success,omitempty"`
-	ConsecutiveErrors int     `json:"consecutive_errors"`
-	TotalReads        int64   `json:"total_reads"`
-	SuccessfulReads   int64   `json:"successful_reads"`
-	FailedReads       int64   `json:"failed_reads"`
-	SuccessRate       float64 `json:"success_rate"`
-	AvgResponseMs     int64   `json:"avg_response_ms,omitempty"`
-	LastError         string  `json:"last_error,omitempty"`
-	LastErrorTime     string  `json:"last_error_time,omitempty"`
+	State             string                         `json:"state"`
+	LastRead          string                         `json:"last_read,omitempty"`
+	LastSuccess       string                         `json:"last_success,omitempty"`
+	ConsecutiveErrors int                            `json:"consecutive_errors"`
+	TotalReads        int64                          `json:"total_reads"`
+	SuccessfulReads   int64                          `json:"successful_reads"`
+	FailedReads       int64                          `json:"failed_reads"`
+	ExceptionReads    int64                          `json:"exception_reads"`
+	SuccessRate       float64                        `json:"success_rate"`
+	AvgResponseMs     int64                          `json:"avg_response_ms,omitempty"`
+	LastError         string                         `json:"last_error,omitempty"`
+	LastErrorTime     string                         `json:"last_error_time,omitempty"`
+	RejectedValues    int64                          `json:"rejected_values"`
+	Rejections        map[string]ValueRejectionState `json:"rejections,omitempty"`
 }
 
 // PublishDiscovery publishes discovery configuration for device diagnostic sensor
@@ -122,8 +160,10 @@ func (d *DeviceDiagnosticTopic) PublishState(ctx context.Context, client mqtt.Cl
 		TotalReads:        metrics.TotalReads,
 		SuccessfulReads:   metrics.SuccessfulReads,
 		FailedReads:       metrics.FailedReads,
+		ExceptionReads:    metrics.ExceptionReads,
 		SuccessRate:       successRate,
 		AvgResponseMs:     avgResponseMs,
+		RejectedValues:    metrics.RejectedValues,
 	}
 
 	// Add timestamps if available
@@ -140,6 +180,13 @@ func (d *DeviceDiagnosticTopic) PublishState(ctx context.Context, client mqtt.Cl
 		}
 	}
 
+	if len(metrics.Rejections) > 0 {
+		state.Rejections = make(map[string]ValueRejectionState, len(metrics.Rejections))
+		for key, rejection := range metrics.Rejections {
+			state.Rejections[key] = rejection.State()
+		}
+	}
+
 	payload, err := json.Marshal(state)
 	if err != nil {
 		return fmt.Errorf("error marshaling device diagnostic state: %w", err)
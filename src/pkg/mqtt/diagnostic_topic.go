@@ -185,3 +185,26 @@ func (d *DiagnosticTopic) PublishDiagnostic(ctx context.Context, client mqtt.Cli
 	logger.LogDebug("🔧 Published diagnostic: [%d] %s", code, message)
 	return nil
 }
+
+// PublishWriteAudit publishes the outcome of a write command to the write audit topic
+func (d *DiagnosticTopic) PublishWriteAudit(ctx context.Context, client mqtt.Client, entry *modbus.WriteAuditEntry) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client not connected")
+	}
+
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error marshaling write audit: %w", err)
+	}
+
+	token := client.Publish(topics.BuildWriteAuditTopic(config.BridgeDeviceID), 1, false, payload)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-token.Done():
+		if token.Error() != nil {
+			return fmt.Errorf("error publishing write audit: %w", token.Error())
+		}
+	}
+	return nil
+}
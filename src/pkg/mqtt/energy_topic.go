@@ -17,11 +17,17 @@ import (
 // EnergyTopic handles energy sensor publishing (total, imported, exported)
 type EnergyTopic struct {
 	config        *config.HAConfig
-	lastValues    map[string]float64   // Store last valid values by sensor name
-	lastTimestamp map[string]time.Time // Store last read timestamps by sensor name
+	lastValues    map[string]float64   // Store last valid values by register key
+	lastTimestamp map[string]time.Time // Store last read timestamps by register key
 	mutex         sync.RWMutex         // Protect concurrent access to maps
 }
 
+// EnergyReading is the last valid reading of an energy sensor, persisted across restarts
+type EnergyReading struct {
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // NewEnergyTopic creates a new energy topic handler
 func NewEnergyTopic(config *config.HAConfig) *EnergyTopic {
 	return &EnergyTopic{
@@ -162,19 +168,19 @@ func (e *EnergyTopic) ValidateData(result *modbus.CommandResult, register *confi
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	sensorName := result.Name
+	key := energyKey(result)
 	currentTime := time.Now()
 	currentValue := result.Value
 
 	// Check if we have a previous reading for this sensor
-	if lastValue, exists := e.lastValues[sensorName]; exists {
-		if lastTime, timeExists := e.lastTimestamp[sensorName]; timeExists {
+	if lastValue, exists := e.lastValues[key]; exists {
+		if lastTime, timeExists := e.lastTimestamp[key]; timeExists {
 
-			// For import/export energy, prevent values from decreasing (they should only increase)
-			isImportOrExport := sensorName == "Imported Energy" || sensorName == "Exported Energy"
-			if isImportOrExport && currentValue < lastValue {
+			// Counters are continuous series (rollovers and meter resets are handled when they are read),
+			// so a total_increasing value that still decreases is a bad reading
+			if result.StateClass == config.StateClassTotalIncreasing && currentValue < lastValue {
 				return fmt.Errorf("energy value decreased for %s: %.3f kWh -> %.3f kWh (should only increase)",
-					sensorName, lastValue, currentValue)
+					key, lastValue, currentValue)
 			}
 
 			// Calculate time elapsed since last reading
@@ -200,19 +206,51 @@ func (e *EnergyTopic) ValidateData(result *modbus.CommandResult, register *confi
 				// Check if change exceeds maximum allowed
 				if energyChange > maxAllowedChange {
 					return fmt.Errorf("energy change too large for %s: %.3f kWh in %.2f hours (max: %.3f kWh/h = %.3f kWh allowed)",
-						sensorName, energyChange, hoursElapsed, maxChangePerHour, maxAllowedChange)
+						key, energyChange, hoursElapsed, maxChangePerHour, maxAllowedChange)
 				}
 			}
 		}
 	}
 
 	// Store current value and timestamp as last valid reading
-	e.lastValues[sensorName] = currentValue
-	e.lastTimestamp[sensorName] = currentTime
+	e.lastValues[key] = currentValue
+	e.lastTimestamp[key] = currentTime
 
 	return nil
 }
 
+// energyKey returns the key the readings of a result are stored under
+// Results without a full register key fall back to the sensor key.
+func energyKey(result *modbus.CommandResult) string {
+	if result.Key != "" {
+		return result.Key
+	}
+	return result.SensorKey
+}
+
+// Snapshot returns the last valid reading of every energy sensor
+func (e *EnergyTopic) Snapshot() map[string]EnergyReading {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	readings := make(map[string]EnergyReading, len(e.lastValues))
+	for key, value := range e.lastValues {
+		readings[key] = EnergyReading{Value: value, Timestamp: e.lastTimestamp[key]}
+	}
+	return readings
+}
+
+// Restore sets the last valid readings, so the monotonicity checks continue after a restart
+func (e *EnergyTopic) Restore(readings map[string]EnergyReading) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for key, reading := range readings {
+		e.lastValues[key] = reading.Value
+		e.lastTimestamp[key] = reading.Timestamp
+	}
+}
+
 // GetEnergyUsageLevel returns usage level assessment
 func (e *EnergyTopic) GetEnergyUsageLevel(value float64) string {
 	switch {
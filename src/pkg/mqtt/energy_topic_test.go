@@ -0,0 +1,70 @@
+package mqtt
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"testing"
+	"time"
+)
+
+// newEnergyResult creates an imported energy result of a device
+func newEnergyResult(deviceKey string, value float64) *modbus.CommandResult {
+	return &modbus.CommandResult{
+		Name:       "Imported Energy",
+		Key:        deviceKey + "_energy_imported",
+		Value:      value,
+		Topic:      "state",
+		SensorKey:  "energy_imported",
+		StateClass: config.StateClassTotalIncreasing,
+	}
+}
+
+// TestEnergySnapshotRestore verifies restored readings keep the checks going after a restart
+func TestEnergySnapshotRestore(t *testing.T) {
+	handler := NewEnergyTopic(&config.HAConfig{})
+	if err := handler.ValidateData(newEnergyResult("meter_mains", 1500.25), nil); err != nil {
+		t.Fatalf("❌ First reading rejected: %v", err)
+	}
+
+	snapshot := handler.Snapshot()
+	if reading := snapshot["meter_mains_energy_imported"]; reading.Value != 1500.25 || reading.Timestamp.IsZero() {
+		t.Fatalf("❌ Snapshot = %+v", snapshot)
+	}
+
+	restarted := NewEnergyTopic(&config.HAConfig{})
+	restarted.Restore(snapshot)
+	if err := restarted.ValidateData(newEnergyResult("meter_mains", 1400), nil); err == nil {
+		t.Error("❌ A decrease after the restart must be rejected against the restored reading")
+	}
+
+	// The restored timestamp bounds the plausible change: one hour allows 20 kWh by default
+	restarted.Restore(map[string]EnergyReading{"meter_mains_energy_imported": {Value: 1500.25, Timestamp: time.Now().Add(-time.Hour)}})
+	if err := restarted.ValidateData(newEnergyResult("meter_mains", 1510), nil); err != nil {
+		t.Errorf("❌ Plausible increase rejected: %v", err)
+	}
+	t.Log("✅ Energy readings restored")
+}
+
+// TestEnergyKeyedByRegister verifies meters with the same display name are checked separately
+func TestEnergyKeyedByRegister(t *testing.T) {
+	handler := NewEnergyTopic(&config.HAConfig{})
+	if err := handler.ValidateData(newEnergyResult("meter_mains", 1500), nil); err != nil {
+		t.Fatalf("❌ First reading rejected: %v", err)
+	}
+	if err := handler.ValidateData(newEnergyResult("meter_lights", 12), nil); err != nil {
+		t.Errorf("❌ Another meter with the same name must not be compared: %v", err)
+	}
+
+	// Only total_increasing counters must not decrease
+	total := newEnergyResult("meter_mains", 10)
+	total.Key = "meter_mains_energy_net"
+	total.StateClass = "total"
+	if err := handler.ValidateData(total, nil); err != nil {
+		t.Fatalf("❌ First reading rejected: %v", err)
+	}
+	total.Value = 9
+	if err := handler.ValidateData(total, nil); err != nil {
+		t.Errorf("❌ A total (not total_increasing) may decrease: %v", err)
+	}
+	t.Log("✅ Energy checks keyed by register")
+}
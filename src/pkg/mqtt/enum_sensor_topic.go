@@ -0,0 +1,132 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// EnumSensorTopic handles enum sensor publishing (registers with a value→label map)
+type EnumSensorTopic struct {
+	config *config.HAConfig
+}
+
+// NewEnumSensorTopic creates a new enum sensor topic handler
+func NewEnumSensorTopic(config *config.HAConfig) *EnumSensorTopic {
+	return &EnumSensorTopic{
+		config: config,
+	}
+}
+
+// PublishDiscovery publishes enum sensor discovery configuration with its options
+func (e *EnumSensorTopic) PublishDiscovery(ctx context.Context, client mqtt.Client, result *modbus.CommandResult, deviceInfo *DeviceInfo) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client is not connected")
+	}
+
+	// Use device info if provided, otherwise fall back to deprecated global config
+	var device DeviceInfo
+	if deviceInfo != nil {
+		device = *deviceInfo
+	} else {
+		device = DeviceInfo{
+			Name:         e.config.DeviceName,
+			Identifiers:  []string{e.config.DeviceID},
+			Manufacturer: e.config.Manufacturer,
+			Model:        e.config.Model,
+		}
+	}
+
+	deviceID := ExtractDeviceID(&device)
+	discoveryTopic := topics.BuildDiscoveryTopic(deviceID, result.SensorKey)
+	uniqueID := topics.BuildUniqueID(deviceID, result.SensorKey)
+
+	// Enum sensors have no unit or state class
+	sensorConfig := SensorConfig{
+		Name:                result.Name,
+		UniqueID:            uniqueID,
+		StateTopic:          result.Topic,
+		DeviceClass:         config.DeviceClassEnum,
+		Options:             result.Options,
+		Device:              device,
+		ValueTemplate:       "{{ value_json.value }}",
+		AvailabilityTopic:   topics.BuildStatusTopic(config.BridgeDeviceID),
+		PayloadAvailable:    "online",
+		PayloadNotAvailable: "offline",
+	}
+
+	// Serialize configuration
+	configJSON, err := json.Marshal(sensorConfig)
+	if err != nil {
+		return fmt.Errorf("error serializing enum sensor configuration: %w", err)
+	}
+
+	// Publish configuration
+	token := client.Publish(discoveryTopic, 0, true, configJSON)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error publishing enum sensor discovery: %w", token.Error())
+	}
+
+	return nil
+}
+
+// PublishState publishes the label of the current value
+func (e *EnumSensorTopic) PublishState(ctx context.Context, client mqtt.Client, result *modbus.CommandResult) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client is not connected")
+	}
+
+	// Validate the result before publishing
+	if err := e.ValidateData(result, nil); err != nil {
+		return fmt.Errorf("invalid enum sensor data: %w", err)
+	}
+
+	state := TextSensorState{
+		Value:     result.State,
+		Timestamp: time.Now(),
+	}
+
+	// Serialize data
+	dataJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error serializing enum sensor data: %w", err)
+	}
+
+	// Publish state
+	token := client.Publish(result.Topic, 0, false, dataJSON)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error publishing enum sensor state: %w", token.Error())
+	}
+
+	return nil
+}
+
+// GetTopicPrefix returns the topic prefix for enum sensor topic
+func (e *EnumSensorTopic) GetTopicPrefix() string {
+	return "sensor"
+}
+
+// ValidateData validates enum sensor data before publishing
+// Home Assistant rejects states that are not in the discovery options, so unmapped values are not published
+func (e *EnumSensorTopic) ValidateData(result *modbus.CommandResult, register *config.Register) error {
+	if result.State == "" {
+		return fmt.Errorf("value %.0f has no enum label for %s", result.Value, result.Name)
+	}
+
+	// Check required fields
+	if result.Name == "" {
+		return fmt.Errorf("enum sensor name is empty")
+	}
+
+	if result.Topic == "" {
+		return fmt.Errorf("enum sensor topic is empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package mqtt
+
+import (
+	"context"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"testing"
+)
+
+// TestEnumSensorDiscoveryAndState verifies enum results are routed to the enum handler and published as labels
+func TestEnumSensorDiscoveryAndState(t *testing.T) {
+	client := newRecordingClient()
+	publisher := &Publisher{client: client, context: NewTopicContext(&config.HAConfig{}, &config.MQTTConfig{})}
+
+	stateTopic := topics.ConstructHATopic("meter", "mode", config.DeviceClassEnum)
+	result := &modbus.CommandResult{
+		Name:        "Mode",
+		Value:       2,
+		State:       "Export",
+		Options:     []string{"Idle", "Import", "Export"},
+		Topic:       stateTopic,
+		SensorKey:   "mode",
+		DeviceClass: config.DeviceClassEnum,
+	}
+	device := &DeviceInfo{Name: "Meter", Identifiers: []string{"meter"}}
+
+	if err := publisher.PublishSensorDiscovery(context.Background(), result, device); err != nil {
+		t.Fatalf("❌ Discovery failed: %v", err)
+	}
+	var discovery SensorConfig
+	client.payload(t, topics.BuildDiscoveryTopic("meter", "mode"), &discovery)
+	if discovery.DeviceClass != config.DeviceClassEnum || len(discovery.Options) != 3 || discovery.Options[2] != "Export" {
+		t.Errorf("❌ Unexpected discovery config: %+v", discovery)
+	}
+	if discovery.StateClass != "" || discovery.UnitOfMeasurement != "" {
+		t.Errorf("❌ Enum sensor must not have a unit or state class: %+v", discovery)
+	}
+
+	if err := publisher.PublishSensorState(context.Background(), result); err != nil {
+		t.Fatalf("❌ State publish failed: %v", err)
+	}
+	var state TextSensorState
+	client.payload(t, stateTopic, &state)
+	if state.Value != "Export" {
+		t.Errorf("❌ State = %q, expected Export", state.Value)
+	}
+
+	// Values without a label are not published
+	result.Value, result.State = 7, ""
+	if err := publisher.PublishSensorState(context.Background(), result); err == nil {
+		t.Error("❌ Expected validation error for unmapped value")
+	}
+	t.Logf("✅ Enum sensor published on %s", stateTopic)
+}
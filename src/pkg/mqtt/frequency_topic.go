@@ -134,6 +134,11 @@ func (f *FrequencyTopic) ValidateData(result *modbus.CommandResult, register *co
 		return fmt.Errorf("frequency value is infinite for sensor %s", result.Name)
 	}
 
+	if minValue, maxValue, ok := defaultRange(result); ok &&
+		(result.Value < minValue || result.Value > maxValue) {
+		return fmt.Errorf("frequency value %.3f Hz outside plausible range %.0f-%.0f Hz", result.Value, minValue, maxValue)
+	}
+
 	// Check required fields
 	if result.Name == "" {
 		return fmt.Errorf("frequency sensor name is empty")
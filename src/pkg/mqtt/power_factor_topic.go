@@ -134,6 +134,11 @@ func (pf *PowerFactorTopic) ValidateData(result *modbus.CommandResult, register
 		return fmt.Errorf("power factor value is infinite for sensor %s", result.Name)
 	}
 
+	if minValue, maxValue, ok := defaultRange(result); ok &&
+		(result.Value < minValue || result.Value > maxValue) {
+		return fmt.Errorf("power factor value %.3f outside plausible range %.0f to %.0f", result.Value, minValue, maxValue)
+	}
+
 	// Check required fields
 	if result.Name == "" {
 		return fmt.Errorf("power factor sensor name is empty")
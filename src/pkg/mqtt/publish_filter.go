@@ -0,0 +1,76 @@
+package mqtt
+
+import (
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"sync"
+	"time"
+)
+
+// PublishFilter decides which sensor values are worth publishing (report by exception)
+// It remembers the last published value of every key and applies the deadband and
+// publish intervals of the register. Safe for concurrent use by several buses.
+type PublishFilter struct {
+	last map[string]publishedValue
+	mu   sync.Mutex
+}
+
+// publishedValue is the last value published for a key
+type publishedValue struct {
+	value float64
+	state string
+	time  time.Time
+}
+
+// NewPublishFilter creates an empty publish filter
+func NewPublishFilter() *PublishFilter {
+	return &PublishFilter{
+		last: make(map[string]publishedValue),
+	}
+}
+
+// ShouldPublish reports whether a new value of the key must be published
+// The first value is always published. Then, in order:
+//   - nothing is published within min_publish_interval of the last publish
+//   - everything is published once max_publish_interval has passed
+//   - a text state change (enums, strings) is always published
+//   - with a deadband, only changes of at least the deadband are published
+func (f *PublishFilter) ShouldPublish(key string, policy config.PublishPolicy, value float64, state string, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	last, exists := f.last[key]
+	if !exists {
+		return true
+	}
+
+	elapsed := now.Sub(last.time)
+	if policy.MinPublishInterval > 0 && elapsed < time.Duration(policy.MinPublishInterval)*time.Second {
+		return false
+	}
+	if policy.MaxPublishInterval > 0 && elapsed >= time.Duration(policy.MaxPublishInterval)*time.Second {
+		return true
+	}
+	if state != last.state {
+		return true
+	}
+	if !policy.HasDeadband() {
+		return true
+	}
+	return math.Abs(value-last.value) >= deadband(policy, last.value)
+}
+
+// MarkPublished records a value as published for the key
+func (f *PublishFilter) MarkPublished(key string, value float64, state string, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last[key] = publishedValue{value: value, state: state, time: now}
+}
+
+// deadband returns the change needed to publish again
+// With both an absolute and a percent deadband the larger one applies, so the absolute
+// deadband keeps values near zero from publishing every bit of noise.
+func deadband(policy config.PublishPolicy, lastValue float64) float64 {
+	percent := math.Abs(lastValue) * policy.DeadbandPercent / 100
+	return math.Max(policy.Deadband, percent)
+}
@@ -0,0 +1,63 @@
+package mqtt
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"testing"
+	"time"
+)
+
+// TestPublishFilterDeadband verifies absolute and percent deadbands and the max publish interval
+func TestPublishFilterDeadband(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := config.PublishPolicy{Deadband: 0.5, DeadbandPercent: 1, MaxPublishInterval: 60}
+	filter := NewPublishFilter()
+
+	if !filter.ShouldPublish("meter_voltage", policy, 230.0, "", start) {
+		t.Fatal("❌ First value must be published")
+	}
+	filter.MarkPublished("meter_voltage", 230.0, "", start)
+
+	tests := []struct {
+		value   float64
+		after   time.Duration
+		publish bool
+	}{
+		{230.4, time.Second, false},      // Below the absolute deadband
+		{231.0, time.Second, false},      // Above 0.5 V but below 1% (2.3 V)
+		{232.4, time.Second, true},       // Above both deadbands
+		{227.6, time.Second, true},       // Drops count as well
+		{230.1, 59 * time.Second, false}, // Within the max publish interval
+		{230.1, 60 * time.Second, true},  // Refresh after the max publish interval
+	}
+	for _, tt := range tests {
+		if got := filter.ShouldPublish("meter_voltage", policy, tt.value, "", start.Add(tt.after)); got != tt.publish {
+			t.Errorf("❌ %.1f V after %v: publish=%v, expected %v", tt.value, tt.after, got, tt.publish)
+		}
+	}
+	t.Log("✅ Deadband and max publish interval applied")
+}
+
+// TestPublishFilterIntervals verifies min_publish_interval, policies without deadband and text states
+func TestPublishFilterIntervals(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	filter := NewPublishFilter()
+	filter.MarkPublished("meter_power", 100, "", start)
+	filter.MarkPublished("meter_mode", 1, "auto", start)
+
+	throttled := config.PublishPolicy{MinPublishInterval: 10}
+	if filter.ShouldPublish("meter_power", throttled, 5000, "", start.Add(9*time.Second)) {
+		t.Error("❌ Value within min_publish_interval must be suppressed")
+	}
+	if !filter.ShouldPublish("meter_power", throttled, 100, "", start.Add(10*time.Second)) {
+		t.Error("❌ Without deadband every value after min_publish_interval must be published")
+	}
+	if !filter.ShouldPublish("meter_power", config.PublishPolicy{}, 100, "", start.Add(time.Millisecond)) {
+		t.Error("❌ Without a policy every value must be published")
+	}
+
+	banded := config.PublishPolicy{Deadband: 10}
+	if !filter.ShouldPublish("meter_mode", banded, 1, "manual", start.Add(time.Second)) {
+		t.Error("❌ A changed text state must be published")
+	}
+	t.Log("✅ Publish intervals and text states applied")
+}
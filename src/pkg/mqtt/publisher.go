@@ -8,6 +8,7 @@ import (
 	"mqtt-modbus-bridge/pkg/logger"
 	"mqtt-modbus-bridge/pkg/modbus"
 	"mqtt-modbus-bridge/pkg/topics"
+	"sync"
 	"time"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
@@ -20,6 +21,9 @@ type Publisher struct {
 	config     *config.HAConfig
 	mqttConfig *config.MQTTConfig
 	context    *TopicContext
+
+	subscriptionsMu sync.Mutex
+	subscriptions   map[string]func(payload string) // Command topic -> handler, restored on reconnect
 }
 
 // NewPublisher creates a new publisher for Home Assistant
@@ -46,6 +50,8 @@ func NewPublisher(cfg *config.MQTTConfig, haCfg *config.HAConfig) *Publisher {
 		config:     haCfg,
 		mqttConfig: cfg,
 		context:    NewTopicContext(haCfg, cfg),
+
+		subscriptions: make(map[string]func(payload string)),
 	}
 
 	// Callback for connection
@@ -55,6 +61,8 @@ func NewPublisher(cfg *config.MQTTConfig, haCfg *config.HAConfig) *Publisher {
 		if token := client.Publish(topics.BuildStatusTopic(config.BridgeDeviceID), 1, true, "online"); token.Wait() && token.Error() != nil {
 			logger.LogWarn("Error publishing online status on connect: %v", token.Error())
 		}
+		// Subscriptions do not survive a clean session reconnect
+		publisher.resubscribe(client)
 	})
 
 	// Callback for disconnection
@@ -143,16 +151,16 @@ func (p *Publisher) Disconnect() {
 // PublishSensorDiscovery publishes discovery configuration for a sensor using topic pattern
 // deviceInfo contains the Home Assistant device information (nil for backward compatibility with global device)
 func (p *Publisher) PublishSensorDiscovery(ctx context.Context, result *modbus.CommandResult, deviceInfo *DeviceInfo) error {
-	// Determine topic type based on device class
-	topicType := p.getTopicTypeFromDeviceClass(result.DeviceClass)
+	// Determine topic type based on component and device class
+	topicType := p.getTopicType(result)
 	handler := p.context.GetHandler(topicType)
 	return handler.PublishDiscovery(ctx, p.client, result, deviceInfo)
 }
 
 // PublishSensorState publishes the state of a sensor using topic pattern
 func (p *Publisher) PublishSensorState(ctx context.Context, result *modbus.CommandResult) error {
-	// Determine topic type based on device class
-	topicType := p.getTopicTypeFromDeviceClass(result.DeviceClass)
+	// Determine topic type based on component and device class
+	topicType := p.getTopicType(result)
 	handler := p.context.GetHandler(topicType)
 
 	// Debug log: name, value, and full topic for debugging
@@ -162,6 +170,21 @@ func (p *Publisher) PublishSensorState(ctx context.Context, result *modbus.Comma
 	return handler.PublishState(ctx, p.client, result)
 }
 
+// getTopicType selects the topic handler for a result
+// Binary, enum and text sensors have their own handlers; other sensors are mapped by device class
+func (p *Publisher) getTopicType(result *modbus.CommandResult) string {
+	if result.Component == topics.ComponentBinarySensor {
+		return "binary_sensor"
+	}
+	if result.DeviceClass == config.DeviceClassEnum {
+		return "enum"
+	}
+	if config.IsTextDataType(result.DataType) {
+		return "text"
+	}
+	return p.getTopicTypeFromDeviceClass(result.DeviceClass)
+}
+
 // getTopicTypeFromDeviceClass maps device class to topic type
 func (p *Publisher) getTopicTypeFromDeviceClass(deviceClass string) string {
 	switch deviceClass {
@@ -257,6 +280,68 @@ func (p *Publisher) PublishDeviceDiagnosticState(ctx context.Context, deviceID s
 	return handler.PublishState(ctx, p.client, deviceID, metrics)
 }
 
+// EnergySnapshot returns the last valid readings of the energy sensors
+func (p *Publisher) EnergySnapshot() map[string]EnergyReading {
+	return p.context.GetEnergyTopic().Snapshot()
+}
+
+// RestoreEnergy restores the last valid readings of the energy sensors
+func (p *Publisher) RestoreEnergy(readings map[string]EnergyReading) {
+	p.context.GetEnergyTopic().Restore(readings)
+}
+
+// PublishWritableDiscovery publishes discovery configuration for a writable register
+func (p *Publisher) PublishWritableDiscovery(ctx context.Context, deviceID string, register *config.WritableRegister, deviceInfo *DeviceInfo) error {
+	handler := p.context.GetWritableTopic()
+	return handler.PublishDiscovery(ctx, p.client, deviceID, register, deviceInfo)
+}
+
+// PublishWritableState publishes the state of a writable register
+func (p *Publisher) PublishWritableState(ctx context.Context, deviceID string, register *config.WritableRegister, value interface{}) error {
+	handler := p.context.GetWritableTopic()
+	return handler.PublishState(ctx, p.client, deviceID, register, value)
+}
+
+// PublishWriteAudit publishes a write audit entry using topic pattern
+func (p *Publisher) PublishWriteAudit(ctx context.Context, entry *modbus.WriteAuditEntry) error {
+	handler := p.context.GetHandler("diagnostic").(*DiagnosticTopic)
+	return handler.PublishWriteAudit(ctx, p.client, entry)
+}
+
+// SubscribeCommand subscribes to a command topic and calls handler with each payload
+// Handlers run on the MQTT client goroutine and must not block.
+func (p *Publisher) SubscribeCommand(topic string, handler func(payload string)) error {
+	p.subscriptionsMu.Lock()
+	p.subscriptions[topic] = handler
+	p.subscriptionsMu.Unlock()
+
+	return p.subscribe(p.client, topic, handler)
+}
+
+// subscribe subscribes a single command topic on the client
+func (p *Publisher) subscribe(client paho.Client, topic string, handler func(payload string)) error {
+	token := client.Subscribe(topic, 1, func(_ paho.Client, msg paho.Message) {
+		handler(string(msg.Payload()))
+	})
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error subscribing to %s: %w", topic, token.Error())
+	}
+	logger.LogDebug("📥 Subscribed to command topic %s", topic)
+	return nil
+}
+
+// resubscribe restores all command subscriptions after a reconnect
+func (p *Publisher) resubscribe(client paho.Client) {
+	p.subscriptionsMu.Lock()
+	defer p.subscriptionsMu.Unlock()
+
+	for topic, handler := range p.subscriptions {
+		if err := p.subscribe(client, topic, handler); err != nil {
+			logger.LogWarn("⚠️ %v", err)
+		}
+	}
+}
+
 // SensorConfig configuration for a Home Assistant sensor
 type SensorConfig struct {
 	Name                   string     `json:"name"`
@@ -273,6 +358,7 @@ type SensorConfig struct {
 	PayloadNotAvailable    string     `json:"payload_not_available"`
 	JSONAttributesTemplate string     `json:"json_attributes_template,omitempty"`
 	EntityCategory         string     `json:"entity_category,omitempty"`
+	Options                []string   `json:"options,omitempty"` // Possible states of enum sensors
 }
 
 // DeviceInfo information about the device
@@ -281,6 +367,9 @@ type DeviceInfo struct {
 	Identifiers  []string `json:"identifiers"`
 	Manufacturer string   `json:"manufacturer"`
 	Model        string   `json:"model"`
+	SerialNumber string   `json:"serial_number,omitempty"`
+	SwVersion    string   `json:"sw_version,omitempty"`
+	HwVersion    string   `json:"hw_version,omitempty"`
 }
 
 // ExtractDeviceID extracts the device ID from a DeviceInfo
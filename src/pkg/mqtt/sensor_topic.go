@@ -136,27 +136,21 @@ func (s *SensorTopic) ValidateData(result *modbus.CommandResult, register *confi
 	}
 
 	// Check for reasonable bounds based on device class
-	switch result.DeviceClass {
-	case "voltage":
-		if result.Value < 0 || result.Value > 1000 {
-			return fmt.Errorf("voltage value out of reasonable bounds: %.3f", result.Value)
+	// Voltage, power factor and frequency ranges depend on the phases of the sensor key
+	if minValue, maxValue, ok := defaultRange(result); ok {
+		if result.Value < minValue || result.Value > maxValue {
+			return fmt.Errorf("%s value out of reasonable bounds: %.3f", result.DeviceClass, result.Value)
 		}
+	}
+	switch result.DeviceClass {
 	case "current":
 		if result.Value < 0 || result.Value > 1000 {
 			return fmt.Errorf("current value out of reasonable bounds: %.3f", result.Value)
 		}
-	case "frequency":
-		if result.Value < 40 || result.Value > 70 {
-			return fmt.Errorf("frequency value out of reasonable bounds: %.3f", result.Value)
-		}
 	case "power", "apparent_power":
 		if result.Value < -100000 || result.Value > 100000 {
 			return fmt.Errorf("power value out of reasonable bounds: %.3f", result.Value)
 		}
-	case "power_factor":
-		if result.Value < 0 || result.Value > 1 {
-			return fmt.Errorf("power factor value out of reasonable bounds: %.3f", result.Value)
-		}
 	case "energy":
 		if result.Value < 0 || result.Value > 999999999 {
 			return fmt.Errorf("energy value out of reasonable bounds: %.3f", result.Value)
@@ -174,3 +168,12 @@ func (s *SensorTopic) ValidateData(result *modbus.CommandResult, register *confi
 
 	return nil
 }
+
+// defaultRange returns the device class range of a result whose register has no min/max
+// Registers with their own limits are checked by the executor before publishing.
+func defaultRange(result *modbus.CommandResult) (minValue float64, maxValue float64, ok bool) {
+	if result.HasLimits {
+		return 0, 0, false
+	}
+	return config.PhaseRange(result.SensorKey, result.DeviceClass)
+}
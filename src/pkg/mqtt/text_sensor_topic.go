@@ -0,0 +1,139 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// maxTextStateLength is the longest state Home Assistant accepts for a sensor
+const maxTextStateLength = 255
+
+// TextSensorTopic handles sensors with a text state (string and bcd registers)
+type TextSensorTopic struct {
+	config *config.HAConfig
+}
+
+// NewTextSensorTopic creates a new text sensor topic handler
+func NewTextSensorTopic(config *config.HAConfig) *TextSensorTopic {
+	return &TextSensorTopic{
+		config: config,
+	}
+}
+
+// TextSensorState state of an enum or text sensor
+type TextSensorState struct {
+	Value     string    `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PublishDiscovery publishes text sensor discovery configuration
+func (t *TextSensorTopic) PublishDiscovery(ctx context.Context, client mqtt.Client, result *modbus.CommandResult, deviceInfo *DeviceInfo) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client is not connected")
+	}
+
+	// Use device info if provided, otherwise fall back to deprecated global config
+	var device DeviceInfo
+	if deviceInfo != nil {
+		device = *deviceInfo
+	} else {
+		device = DeviceInfo{
+			Name:         t.config.DeviceName,
+			Identifiers:  []string{t.config.DeviceID},
+			Manufacturer: t.config.Manufacturer,
+			Model:        t.config.Model,
+		}
+	}
+
+	deviceID := ExtractDeviceID(&device)
+	discoveryTopic := topics.BuildDiscoveryTopic(deviceID, result.SensorKey)
+	uniqueID := topics.BuildUniqueID(deviceID, result.SensorKey)
+
+	// Text sensors have no unit, device class or state class
+	sensorConfig := SensorConfig{
+		Name:                result.Name,
+		UniqueID:            uniqueID,
+		StateTopic:          result.Topic,
+		Device:              device,
+		ValueTemplate:       "{{ value_json.value }}",
+		AvailabilityTopic:   topics.BuildStatusTopic(config.BridgeDeviceID),
+		PayloadAvailable:    "online",
+		PayloadNotAvailable: "offline",
+		EntityCategory:      "diagnostic",
+	}
+
+	// Serialize configuration
+	configJSON, err := json.Marshal(sensorConfig)
+	if err != nil {
+		return fmt.Errorf("error serializing text sensor configuration: %w", err)
+	}
+
+	// Publish configuration
+	token := client.Publish(discoveryTopic, 0, true, configJSON)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error publishing text sensor discovery: %w", token.Error())
+	}
+
+	return nil
+}
+
+// PublishState publishes the text state
+func (t *TextSensorTopic) PublishState(ctx context.Context, client mqtt.Client, result *modbus.CommandResult) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client is not connected")
+	}
+
+	// Validate the result before publishing
+	if err := t.ValidateData(result, nil); err != nil {
+		return fmt.Errorf("invalid text sensor data: %w", err)
+	}
+
+	state := TextSensorState{
+		Value:     result.State,
+		Timestamp: time.Now(),
+	}
+
+	// Serialize data
+	dataJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error serializing text sensor data: %w", err)
+	}
+
+	// Publish state
+	token := client.Publish(result.Topic, 0, false, dataJSON)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error publishing text sensor state: %w", token.Error())
+	}
+
+	return nil
+}
+
+// GetTopicPrefix returns the topic prefix for text sensor topic
+func (t *TextSensorTopic) GetTopicPrefix() string {
+	return "sensor"
+}
+
+// ValidateData validates text sensor data before publishing
+func (t *TextSensorTopic) ValidateData(result *modbus.CommandResult, register *config.Register) error {
+	if len(result.State) > maxTextStateLength {
+		return fmt.Errorf("text of %d characters exceeds %d for %s", len(result.State), maxTextStateLength, result.Name)
+	}
+
+	// Check required fields
+	if result.Name == "" {
+		return fmt.Errorf("text sensor name is empty")
+	}
+
+	if result.Topic == "" {
+		return fmt.Errorf("text sensor topic is empty")
+	}
+
+	return nil
+}
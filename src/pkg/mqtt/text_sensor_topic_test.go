@@ -0,0 +1,44 @@
+package mqtt
+
+import (
+	"context"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"testing"
+)
+
+// TestTextSensorDiscoveryAndState verifies string results are routed to the text handler
+func TestTextSensorDiscoveryAndState(t *testing.T) {
+	client := newRecordingClient()
+	publisher := &Publisher{client: client, context: NewTopicContext(&config.HAConfig{}, &config.MQTTConfig{})}
+
+	stateTopic := topics.ConstructHATopic("meter", "firmware", "")
+	result := &modbus.CommandResult{
+		Name:      "Firmware",
+		State:     "0102",
+		Topic:     stateTopic,
+		SensorKey: "firmware",
+		DataType:  config.DataTypeBCD,
+	}
+	device := &DeviceInfo{Name: "Meter", Identifiers: []string{"meter"}, SerialNumber: "SN12345"}
+
+	if err := publisher.PublishSensorDiscovery(context.Background(), result, device); err != nil {
+		t.Fatalf("❌ Discovery failed: %v", err)
+	}
+	var discovery SensorConfig
+	client.payload(t, topics.BuildDiscoveryTopic("meter", "firmware"), &discovery)
+	if discovery.EntityCategory != "diagnostic" || discovery.Device.SerialNumber != "SN12345" {
+		t.Errorf("❌ Unexpected discovery config: %+v", discovery)
+	}
+
+	if err := publisher.PublishSensorState(context.Background(), result); err != nil {
+		t.Fatalf("❌ State publish failed: %v", err)
+	}
+	var state TextSensorState
+	client.payload(t, stateTopic, &state)
+	if state.Value != "0102" {
+		t.Errorf("❌ State = %q, expected 0102", state.Value)
+	}
+	t.Logf("✅ Text sensor published on %s", stateTopic)
+}
@@ -21,6 +21,8 @@ type TopicHandler interface {
 type TopicContext struct {
 	handlers              map[string]TopicHandler
 	deviceDiagnosticTopic *DeviceDiagnosticTopic // Separate handler for device diagnostics
+	writableTopic         *WritableTopic         // Separate handler for writable entities
+	energyTopic           *EnergyTopic           // Energy handler, also registered in handlers (state persisted across restarts)
 	config                *config.HAConfig
 	mqttConfig            *config.MQTTConfig
 }
@@ -30,6 +32,8 @@ func NewTopicContext(haCfg *config.HAConfig, mqttCfg *config.MQTTConfig) *TopicC
 	ctx := &TopicContext{
 		handlers:              make(map[string]TopicHandler),
 		deviceDiagnosticTopic: NewDeviceDiagnosticTopic(haCfg),
+		writableTopic:         NewWritableTopic(haCfg),
+		energyTopic:           NewEnergyTopic(haCfg),
 		config:                haCfg,
 		mqttConfig:            mqttCfg,
 	}
@@ -40,8 +44,11 @@ func NewTopicContext(haCfg *config.HAConfig, mqttCfg *config.MQTTConfig) *TopicC
 	ctx.handlers["frequency"] = NewFrequencyTopic(haCfg)
 	ctx.handlers["power"] = NewPowerTopic(haCfg)
 	ctx.handlers["power_factor"] = NewPowerFactorTopic(haCfg)
-	ctx.handlers["energy"] = NewEnergyTopic(haCfg)
+	ctx.handlers["energy"] = ctx.energyTopic
 	ctx.handlers["sensor"] = NewSensorTopic(haCfg) // Keep as fallback
+	ctx.handlers["binary_sensor"] = NewBinarySensorTopic(haCfg)
+	ctx.handlers["enum"] = NewEnumSensorTopic(haCfg)
+	ctx.handlers["text"] = NewTextSensorTopic(haCfg)
 	ctx.handlers["status"] = NewStatusTopic(haCfg)
 	ctx.handlers["diagnostic"] = NewDiagnosticTopic(haCfg)
 
@@ -66,3 +73,13 @@ func (tc *TopicContext) RegisterHandler(name string, handler TopicHandler) {
 func (tc *TopicContext) GetDeviceDiagnosticTopic() *DeviceDiagnosticTopic {
 	return tc.deviceDiagnosticTopic
 }
+
+// GetEnergyTopic returns the energy topic handler
+func (tc *TopicContext) GetEnergyTopic() *EnergyTopic {
+	return tc.energyTopic
+}
+
+// GetWritableTopic returns the writable entity topic handler
+func (tc *TopicContext) GetWritableTopic() *WritableTopic {
+	return tc.writableTopic
+}
@@ -133,6 +133,13 @@ func (v *VoltageTopic) ValidateData(result *modbus.CommandResult, register *conf
 		return fmt.Errorf("voltage value is infinite for sensor %s", result.Name)
 	}
 
+	// Line-to-line voltages (voltage_l1_l2) are √3 times higher than phase voltages
+	if minValue, maxValue, ok := defaultRange(result); ok &&
+		(result.Value < minValue || result.Value > maxValue) {
+		return fmt.Errorf("voltage value %.3f V outside plausible range %.0f-%.0f V for %s",
+			result.Value, minValue, maxValue, result.SensorKey)
+	}
+
 	// Check required fields
 	if result.Name == "" {
 		return fmt.Errorf("voltage sensor name is empty")
@@ -156,7 +163,9 @@ func (v *VoltageTopic) ValidateData(result *modbus.CommandResult, register *conf
 }
 
 // GetVoltageQuality returns a quality assessment of the voltage reading
-func (v *VoltageTopic) GetVoltageQuality(value float64) string {
+// Line-to-line voltages are assessed on their phase-to-neutral equivalent.
+func (v *VoltageTopic) GetVoltageQuality(value float64, sensorKey string) string {
+	value = phaseVoltage(value, sensorKey)
 	switch {
 	case value >= 220 && value <= 240:
 		return "excellent"
@@ -170,6 +179,15 @@ func (v *VoltageTopic) GetVoltageQuality(value float64) string {
 }
 
 // IsVoltageStable checks if voltage is within stable range
-func (v *VoltageTopic) IsVoltageStable(value float64) bool {
+func (v *VoltageTopic) IsVoltageStable(value float64, sensorKey string) bool {
+	value = phaseVoltage(value, sensorKey)
 	return value >= 210 && value <= 250
 }
+
+// phaseVoltage converts a line-to-line voltage to the equivalent phase-to-neutral voltage
+func phaseVoltage(value float64, sensorKey string) float64 {
+	if config.ParsePhaseKey(sensorKey).IsLineToLine() {
+		return value / math.Sqrt(3)
+	}
+	return value
+}
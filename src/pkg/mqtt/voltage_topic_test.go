@@ -0,0 +1,46 @@
+package mqtt
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"testing"
+)
+
+// TestVoltagePhaseRanges verifies line-to-line voltages are validated against their own range
+func TestVoltagePhaseRanges(t *testing.T) {
+	handler := NewVoltageTopic(&config.HAConfig{})
+	tests := []struct {
+		sensorKey string
+		value     float64
+		valid     bool
+	}{
+		{"voltage", 231.2, true},
+		{"voltage_l2", 229.8, true},
+		{"voltage_l1_l2", 400.5, true},
+		{"voltage_l1", 4005.0, false}, // 0.1 V register published without its scale factor
+		{"voltage_l3_l1", 4005.0, false},
+		{"voltage_l1_l2", -1.0, false},
+		{"pv_voltage", 650.0, true}, // DC string voltage: no phase suffix keeps the 1000 V bound
+		{"pv_voltage", 1200.0, false},
+	}
+	for _, tt := range tests {
+		result := &modbus.CommandResult{Name: tt.sensorKey, Value: tt.value, Topic: "state", SensorKey: tt.sensorKey, DeviceClass: "voltage"}
+		if err := handler.ValidateData(result, nil); (err == nil) != tt.valid {
+			t.Errorf("❌ %s = %.1f V: valid=%v, got error %v", tt.sensorKey, tt.value, tt.valid, err)
+		}
+	}
+
+	// A register with its own min/max replaces the default range
+	limited := &modbus.CommandResult{Name: "HV", Value: 4005, Topic: "state", SensorKey: "voltage_l1", DeviceClass: "voltage", HasLimits: true}
+	if err := handler.ValidateData(limited, nil); err != nil {
+		t.Errorf("❌ Register min/max must replace the default range, got %v", err)
+	}
+
+	if quality := handler.GetVoltageQuality(400, "voltage_l1_l2"); quality != "excellent" {
+		t.Errorf("❌ 400 V line-to-line should be excellent, got %s", quality)
+	}
+	if handler.IsVoltageStable(400, "voltage_l1") {
+		t.Error("❌ 400 V phase-to-neutral should not be stable")
+	}
+	t.Logf("✅ Phase-aware voltage ranges")
+}
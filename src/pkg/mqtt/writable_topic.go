@@ -0,0 +1,146 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// WritableTopic handles discovery and state of writable entities (number, select, switch, button)
+type WritableTopic struct {
+	config *config.HAConfig
+}
+
+// NewWritableTopic creates a new writable entity topic handler
+func NewWritableTopic(config *config.HAConfig) *WritableTopic {
+	return &WritableTopic{
+		config: config,
+	}
+}
+
+// WritableConfig configuration for a Home Assistant number, select, switch or button
+// Fields that do not apply to a component are omitted
+type WritableConfig struct {
+	Name                string     `json:"name"`
+	UniqueID            string     `json:"unique_id"`
+	CommandTopic        string     `json:"command_topic"`
+	StateTopic          string     `json:"state_topic,omitempty"`
+	ValueTemplate       string     `json:"value_template,omitempty"`
+	DeviceClass         string     `json:"device_class,omitempty"`
+	EntityCategory      string     `json:"entity_category,omitempty"`
+	UnitOfMeasurement   string     `json:"unit_of_measurement,omitempty"`
+	Min                 *float64   `json:"min,omitempty"`
+	Max                 *float64   `json:"max,omitempty"`
+	Step                float64    `json:"step,omitempty"`
+	Mode                string     `json:"mode,omitempty"`
+	Options             []string   `json:"options,omitempty"`
+	PayloadOn           string     `json:"payload_on,omitempty"`
+	PayloadOff          string     `json:"payload_off,omitempty"`
+	PayloadPress        string     `json:"payload_press,omitempty"`
+	Device              DeviceInfo `json:"device"`
+	AvailabilityTopic   string     `json:"availability_topic"`
+	PayloadAvailable    string     `json:"payload_available"`
+	PayloadNotAvailable string     `json:"payload_not_available"`
+}
+
+// WritableState state of a writable entity
+// Value is the scaled number, the select label or ON/OFF
+type WritableState struct {
+	Value     interface{} `json:"value"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// PublishDiscovery publishes discovery configuration for a writable register
+func (w *WritableTopic) PublishDiscovery(ctx context.Context, client mqtt.Client, deviceID string, register *config.WritableRegister, deviceInfo *DeviceInfo) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client is not connected")
+	}
+
+	component := register.Component
+	entityConfig := WritableConfig{
+		Name:                register.Name,
+		UniqueID:            topics.BuildUniqueID(deviceID, register.Key),
+		CommandTopic:        topics.ConstructCommandTopic(component, deviceID, register.Key),
+		DeviceClass:         register.DeviceClass,
+		EntityCategory:      register.EntityCategory,
+		Device:              *deviceInfo,
+		AvailabilityTopic:   topics.BuildStatusTopic(config.BridgeDeviceID),
+		PayloadAvailable:    "online",
+		PayloadNotAvailable: "offline",
+	}
+
+	// Buttons are write-only; every other component reports the value read back from the device
+	if component != config.WritableButton {
+		entityConfig.StateTopic = topics.ConstructComponentTopic(component, deviceID, register.Key)
+		entityConfig.ValueTemplate = "{{ value_json.value }}"
+	}
+
+	switch component {
+	case config.WritableNumber:
+		entityConfig.UnitOfMeasurement = register.Unit
+		entityConfig.Min = register.Min
+		entityConfig.Max = register.Max
+		entityConfig.Step = register.GetStep()
+		entityConfig.Mode = "box"
+	case config.WritableSelect:
+		for _, option := range register.Options {
+			entityConfig.Options = append(entityConfig.Options, option.Label)
+		}
+	case config.WritableSwitch:
+		entityConfig.PayloadOn = modbus.PayloadOn
+		entityConfig.PayloadOff = modbus.PayloadOff
+	case config.WritableButton:
+		entityConfig.PayloadPress = modbus.PayloadPress
+	}
+
+	// Serialize configuration
+	configJSON, err := json.Marshal(entityConfig)
+	if err != nil {
+		return fmt.Errorf("error serializing %s configuration: %w", component, err)
+	}
+
+	// Publish configuration
+	discoveryTopic := topics.BuildComponentTopic(component, deviceID, register.Key, "config")
+	token := client.Publish(discoveryTopic, 0, true, configJSON)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error publishing %s discovery: %w", component, token.Error())
+	}
+
+	return nil
+}
+
+// PublishState publishes the state of a writable register (retained, so HA shows it after a restart)
+func (w *WritableTopic) PublishState(ctx context.Context, client mqtt.Client, deviceID string, register *config.WritableRegister, value interface{}) error {
+	if !client.IsConnected() {
+		return fmt.Errorf("client is not connected")
+	}
+	if register.Component == config.WritableButton {
+		return fmt.Errorf("button '%s' has no state", register.Key)
+	}
+
+	state := WritableState{
+		Value:     value,
+		Timestamp: time.Now(),
+	}
+
+	// Serialize data
+	dataJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error serializing %s state: %w", register.Component, err)
+	}
+
+	// Publish state
+	stateTopic := topics.ConstructComponentTopic(register.Component, deviceID, register.Key)
+	token := client.Publish(stateTopic, 0, true, dataJSON)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("error publishing %s state: %w", register.Component, token.Error())
+	}
+
+	return nil
+}
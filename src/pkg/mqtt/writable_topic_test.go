@@ -0,0 +1,130 @@
+package mqtt
+
+import (
+	"context"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"testing"
+)
+
+// commandMessage implements paho.Message for a command delivered by the broker
+type commandMessage struct {
+	topic   string
+	payload string
+}
+
+func (m commandMessage) Duplicate() bool   { return false }
+func (m commandMessage) Qos() byte         { return 1 }
+func (m commandMessage) Retained() bool    { return false }
+func (m commandMessage) Topic() string     { return m.topic }
+func (m commandMessage) MessageID() uint16 { return 0 }
+func (m commandMessage) Payload() []byte   { return []byte(m.payload) }
+func (m commandMessage) Ack()              {}
+
+// TestWritableDiscovery verifies the component-specific discovery payloads
+func TestWritableDiscovery(t *testing.T) {
+	client := newRecordingClient()
+	handler := NewTopicContext(&config.HAConfig{}, &config.MQTTConfig{}).GetWritableTopic()
+	device := &DeviceInfo{Name: "Meter", Identifiers: []string{"meter"}}
+	ctx := context.Background()
+
+	low, high := 1.0, 500.0
+	registers := []config.WritableRegister{
+		{Key: "ct_ratio", Name: "CT Ratio", Component: config.WritableNumber, Min: &low, Max: &high, Unit: "A"},
+		{Key: "baud", Name: "Baud Rate", Component: config.WritableSelect, EntityCategory: "config",
+			Options: []config.SelectOption{{Label: "9600", Value: 3}, {Label: "19200", Value: 4}}},
+		{Key: "relay", Name: "Relay", Component: config.WritableSwitch},
+		{Key: "reset", Name: "Reset Energy", Component: config.WritableButton},
+	}
+	for i := range registers {
+		if err := handler.PublishDiscovery(ctx, client, "meter", &registers[i], device); err != nil {
+			t.Fatalf("❌ %s discovery failed: %v", registers[i].Key, err)
+		}
+	}
+
+	var number WritableConfig
+	client.payload(t, topics.BuildComponentTopic(topics.ComponentNumber, "meter", "ct_ratio", "config"), &number)
+	if number.CommandTopic != topics.ConstructCommandTopic(topics.ComponentNumber, "meter", "ct_ratio") ||
+		number.StateTopic == "" || *number.Min != 1 || *number.Max != 500 || number.Step != 1 || number.UnitOfMeasurement != "A" {
+		t.Errorf("❌ Unexpected number discovery: %+v", number)
+	}
+
+	var selector WritableConfig
+	client.payload(t, topics.BuildComponentTopic(topics.ComponentSelect, "meter", "baud", "config"), &selector)
+	if len(selector.Options) != 2 || selector.Options[1] != "19200" || selector.EntityCategory != "config" {
+		t.Errorf("❌ Unexpected select discovery: %+v", selector)
+	}
+
+	var relay WritableConfig
+	client.payload(t, topics.BuildComponentTopic(topics.ComponentSwitch, "meter", "relay", "config"), &relay)
+	if relay.PayloadOn != "ON" || relay.PayloadOff != "OFF" {
+		t.Errorf("❌ Unexpected switch discovery: %+v", relay)
+	}
+
+	var button WritableConfig
+	client.payload(t, topics.BuildComponentTopic(topics.ComponentButton, "meter", "reset", "config"), &button)
+	if button.PayloadPress != "PRESS" || button.StateTopic != "" {
+		t.Errorf("❌ Unexpected button discovery: %+v", button)
+	}
+
+	if err := handler.PublishState(ctx, client, "meter", &registers[1], "19200"); err != nil {
+		t.Fatalf("❌ Select state failed: %v", err)
+	}
+	var state WritableState
+	client.payload(t, topics.ConstructComponentTopic(topics.ComponentSelect, "meter", "baud"), &state)
+	if state.Value != "19200" {
+		t.Errorf("❌ Select state = %v, expected 19200", state.Value)
+	}
+	if err := handler.PublishState(ctx, client, "meter", &registers[3], 1); err == nil {
+		t.Error("❌ Expected error publishing button state")
+	}
+	t.Logf("✅ Discovery published for %d writable entities", len(registers))
+}
+
+// TestSubscribeCommand verifies command delivery and resubscription after a reconnect
+func TestSubscribeCommand(t *testing.T) {
+	client := newRecordingClient()
+	publisher := &Publisher{client: client, subscriptions: make(map[string]func(payload string))}
+
+	topic := topics.ConstructCommandTopic(topics.ComponentNumber, "meter", "ct_ratio")
+	var received []string
+	if err := publisher.SubscribeCommand(topic, func(payload string) { received = append(received, payload) }); err != nil {
+		t.Fatalf("❌ SubscribeCommand failed: %v", err)
+	}
+	client.subscribed[topic](client, commandMessage{topic: topic, payload: "50"})
+
+	// A new session has no subscriptions until the publisher restores them
+	reconnected := newRecordingClient()
+	publisher.resubscribe(reconnected)
+	handler, exists := reconnected.subscribed[topic]
+	if !exists {
+		t.Fatalf("❌ %s not resubscribed after reconnect", topic)
+	}
+	handler(reconnected, commandMessage{topic: topic, payload: "75"})
+
+	if len(received) != 2 || received[0] != "50" || received[1] != "75" {
+		t.Errorf("❌ Received %v, expected [50 75]", received)
+	}
+	t.Logf("✅ Command handler called %d times across a reconnect", len(received))
+}
+
+// TestWriteAuditPublished verifies audit entries are published on the bridge write audit topic
+func TestWriteAuditPublished(t *testing.T) {
+	client := newRecordingClient()
+	publisher := &Publisher{client: client, context: NewTopicContext(&config.HAConfig{}, &config.MQTTConfig{})}
+
+	raw := 3.0
+	entry := &modbus.WriteAuditEntry{Key: "meter_baud", SlaveID: 1, Address: 0x000C, Payload: "9600",
+		RawValue: &raw, Result: modbus.WriteArmed, Reason: "send the same command again"}
+	if err := publisher.PublishWriteAudit(context.Background(), entry); err != nil {
+		t.Fatalf("❌ PublishWriteAudit failed: %v", err)
+	}
+
+	var published modbus.WriteAuditEntry
+	client.payload(t, topics.BuildWriteAuditTopic(config.BridgeDeviceID), &published)
+	if published.Key != "meter_baud" || published.Result != modbus.WriteArmed || *published.RawValue != 3 {
+		t.Errorf("❌ Unexpected audit entry: %+v", published)
+	}
+	t.Logf("✅ Audit entry published: %s", &published)
+}
@@ -2,6 +2,7 @@ package recovery
 
 import (
 	"fmt"
+	"mqtt-modbus-bridge/pkg/errors"
 	"sync"
 	"time"
 )
@@ -82,6 +83,8 @@ func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
 
 // Call executes the given function if the circuit allows it
 // Returns error if circuit is open or if the function fails
+// Only transient errors count as failures: a non-transient error such as a Modbus
+// exception proves the gateway and slave answered, so it counts as a success
 func (cb *CircuitBreaker) Call(fn func() error) error {
 	// Check if we can proceed
 	if err := cb.beforeCall(); err != nil {
@@ -138,7 +141,7 @@ func (cb *CircuitBreaker) afterCall(err error) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
-	if err != nil {
+	if errors.IsTransient(err) {
 		cb.onFailure()
 	} else {
 		cb.onSuccess()
@@ -18,6 +18,7 @@ type GroupScheduler struct {
 	mu               sync.RWMutex             // Protect maps
 	executionMutex   sync.Mutex               // Ensures only one group executes at a time (prevents concurrent Modbus requests)
 	minCheckInterval time.Duration            // How often to check for groups that need execution
+	errorCallback    func(context.Context, string, error)
 }
 
 // NewGroupScheduler creates a new group scheduler
@@ -58,6 +59,12 @@ func NewGroupScheduler(executor builder.ExecutorInterface, groupIntervals map[st
 	return scheduler
 }
 
+// OnError sets a callback invoked with the group key and error when a group execution fails
+// Used to feed the health monitor of the gateway (bus) this scheduler polls
+func (s *GroupScheduler) OnError(callback func(ctx context.Context, groupKey string, err error)) {
+	s.errorCallback = callback
+}
+
 // Start begins the group polling scheduler
 func (s *GroupScheduler) Start(ctx context.Context, callback func(context.Context, map[string]*modbus.CommandResult)) {
 	ticker := time.NewTicker(s.minCheckInterval)
@@ -131,6 +138,9 @@ func (s *GroupScheduler) executeGroup(ctx context.Context, groupKey string, call
 
 	if err != nil {
 		logger.LogError("❌ Group '%s' execution failed after %v: %v", groupKey, executionTime, err)
+		if s.errorCallback != nil {
+			s.errorCallback(ctx, groupKey, err)
+		}
 		return
 	}
 
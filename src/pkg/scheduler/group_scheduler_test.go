@@ -474,3 +474,79 @@ func BenchmarkSchedulerOverhead(b *testing.B) {
 		scheduler.checkAndExecuteGroups(ctx, callback)
 	}
 }
+
+// TestErrorCallbackReportsFailures verifies failed groups are reported through OnError
+// and successful groups through the result callback
+func TestErrorCallbackReportsFailures(t *testing.T) {
+	executor := newMockExecutor(5 * time.Millisecond)
+	executor.shouldFail["failing_group"] = true
+
+	scheduler := NewGroupScheduler(executor, map[string]int{
+		"failing_group": 100,
+		"working_group": 100,
+	})
+
+	var mu sync.Mutex
+	failed := make(map[string]int)
+	succeeded := 0
+
+	scheduler.OnError(func(ctx context.Context, groupKey string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed[groupKey]++
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+	scheduler.Start(ctx, func(ctx context.Context, results map[string]*modbus.CommandResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		succeeded++
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if failed["failing_group"] == 0 {
+		t.Error("❌ Error callback was not invoked for failing group")
+	}
+	if failed["working_group"] != 0 {
+		t.Error("❌ Error callback invoked for working group")
+	}
+	if succeeded == 0 {
+		t.Error("❌ Result callback was not invoked for working group")
+	}
+	t.Logf("✅ %d failures reported, %d successful executions", failed["failing_group"], succeeded)
+}
+
+// TestIndependentSchedulersDoNotBlockEachOther verifies that schedulers for different
+// gateways (buses) have their own execution lock, so a slow bus does not stall the others
+func TestIndependentSchedulersDoNotBlockEachOther(t *testing.T) {
+	slowBus := newMockExecutor(400 * time.Millisecond) // e.g. timeouts on a dead bus
+	fastBus := newMockExecutor(5 * time.Millisecond)
+
+	slowScheduler := NewGroupScheduler(slowBus, map[string]int{"slow_group": 100})
+	fastScheduler := NewGroupScheduler(fastBus, map[string]int{"fast_group": 100})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 450*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, s := range []*GroupScheduler{slowScheduler, fastScheduler} {
+		wg.Add(1)
+		go func(s *GroupScheduler) {
+			defer wg.Done()
+			s.Start(ctx, nil)
+		}(s)
+	}
+	wg.Wait()
+
+	slowRuns := len(slowBus.getExecutionOrder())
+	fastRuns := len(fastBus.getExecutionOrder())
+	t.Logf("📊 Slow bus: %d executions, fast bus: %d executions", slowRuns, fastRuns)
+
+	if fastRuns < 3 {
+		t.Errorf("❌ Fast bus was stalled by the slow bus (%d executions)", fastRuns)
+	} else {
+		t.Log("✅ Buses are polled independently")
+	}
+}
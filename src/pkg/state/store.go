@@ -0,0 +1,182 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store is a small file-backed key/value store for state that must survive restarts
+// Values are kept as JSON in memory and written to a single file by Save. Writes are
+// atomic: the data goes to a temporary file in the same directory, which is synced and
+// renamed over the old file, so a crash leaves either the old or the new state.
+type Store struct {
+	path    string
+	entries map[string]json.RawMessage
+	savedAt time.Time
+	mutex   sync.Mutex
+}
+
+// fileFormat is the layout of the state file
+type fileFormat struct {
+	SavedAt time.Time                  `json:"saved_at"`
+	Entries map[string]json.RawMessage `json:"entries"`
+}
+
+// New creates an empty store that is written to path by Save
+func New(path string) *Store {
+	return &Store{
+		path:    path,
+		entries: make(map[string]json.RawMessage),
+	}
+}
+
+// Open loads the store from its file; a missing file gives an empty store
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("state store path is empty")
+	}
+
+	store := New(path)
+
+	// #nosec G304 - path comes from the configuration or the command line
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state file %s: %w", path, err)
+	}
+
+	var file fileFormat
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing state file %s: %w", path, err)
+	}
+	if file.Entries != nil {
+		store.entries = file.Entries
+	}
+	store.savedAt = file.SavedAt
+	return store, nil
+}
+
+// Path returns the file of the store
+func (s *Store) Path() string {
+	return s.path
+}
+
+// SavedAt returns when the loaded or last saved state was written (zero if never)
+func (s *Store) SavedAt() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.savedAt
+}
+
+// Get decodes the value of a key into target; found is false if the key is not stored
+func (s *Store) Get(key string, target any) (found bool, err error) {
+	s.mutex.Lock()
+	raw, exists := s.entries[key]
+	s.mutex.Unlock()
+
+	if !exists {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return true, fmt.Errorf("error decoding state '%s': %w", key, err)
+	}
+	return true, nil
+}
+
+// GetRaw returns the JSON of a key
+func (s *Store) GetRaw(key string) (json.RawMessage, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	raw, exists := s.entries[key]
+	return raw, exists
+}
+
+// Set stores the value of a key in memory (written by the next Save)
+func (s *Store) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encoding state '%s': %w", key, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries[key] = raw
+	return nil
+}
+
+// Delete removes a key; it returns false if the key was not stored
+func (s *Store) Delete(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, exists := s.entries[key]
+	delete(s.entries, key)
+	return exists
+}
+
+// Clear removes all keys
+func (s *Store) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = make(map[string]json.RawMessage)
+}
+
+// Keys returns the stored keys in alphabetical order
+func (s *Store) Keys() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Save writes the store to its file atomically
+func (s *Store) Save() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file := fileFormat{SavedAt: time.Now(), Entries: s.entries}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding state: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("error creating state directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temporary state file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing state file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("error replacing state file %s: %w", s.path, err)
+	}
+
+	s.savedAt = file.SavedAt
+	return nil
+}
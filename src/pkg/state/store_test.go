@@ -0,0 +1,119 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type energyState struct {
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TestStoreSaveAndOpen verifies values survive a save and a new Open
+func TestStoreSaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "bridge.json")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("❌ Opening a missing file must give an empty store: %v", err)
+	}
+	if len(store.Keys()) != 0 || !store.SavedAt().IsZero() {
+		t.Fatal("❌ New store must be empty")
+	}
+
+	saved := map[string]energyState{
+		"meter_energy_imported": {Value: 1234.5, Timestamp: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	if err := store.Set("energy", saved); err != nil {
+		t.Fatalf("❌ Set failed: %v", err)
+	}
+	if err := store.Set("last_publish", map[string]time.Time{"energy_total": time.Now()}); err != nil {
+		t.Fatalf("❌ Set failed: %v", err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatalf("❌ Save failed: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("❌ Reopening failed: %v", err)
+	}
+	if reopened.SavedAt().IsZero() {
+		t.Error("❌ Saved time must be loaded")
+	}
+	var loaded map[string]energyState
+	found, err := reopened.Get("energy", &loaded)
+	if err != nil || !found {
+		t.Fatalf("❌ Get energy: found=%v err=%v", found, err)
+	}
+	if got := loaded["meter_energy_imported"]; got != saved["meter_energy_imported"] {
+		t.Errorf("❌ Restored %+v, expected %+v", got, saved["meter_energy_imported"])
+	}
+	if keys := reopened.Keys(); len(keys) != 2 || keys[0] != "energy" || keys[1] != "last_publish" {
+		t.Errorf("❌ Keys = %v, expected [energy last_publish]", keys)
+	}
+	if found, _ := reopened.Get("device_metrics", &loaded); found {
+		t.Error("❌ Missing key must not be found")
+	}
+	t.Log("✅ State survives save and reopen")
+}
+
+// TestStoreAtomicSave verifies no temporary files are left and a corrupt file is reported
+func TestStoreAtomicSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bridge.json")
+
+	store := New(path)
+	for i := 0; i < 3; i++ {
+		if err := store.Set("counter", i); err != nil {
+			t.Fatalf("❌ Set failed: %v", err)
+		}
+		if err := store.Save(); err != nil {
+			t.Fatalf("❌ Save failed: %v", err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "bridge.json" {
+		t.Errorf("❌ Only the state file must remain, found %d entries", len(entries))
+	}
+
+	if err := os.WriteFile(path, []byte("{truncated"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("❌ Corrupt state file must fail to open")
+	}
+	if _, err := Open(""); err == nil {
+		t.Error("❌ Empty path must fail")
+	}
+	t.Log("✅ Atomic saves leave no temporary files")
+}
+
+// TestStoreDeleteAndClear verifies keys can be removed one by one or all at once
+func TestStoreDeleteAndClear(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "bridge.json"))
+	_ = store.Set("energy", 1)
+	_ = store.Set("device_metrics", 2)
+
+	if !store.Delete("energy") {
+		t.Error("❌ Deleting a stored key must return true")
+	}
+	if store.Delete("energy") {
+		t.Error("❌ Deleting a missing key must return false")
+	}
+	if keys := store.Keys(); len(keys) != 1 || keys[0] != "device_metrics" {
+		t.Errorf("❌ Keys = %v, expected [device_metrics]", keys)
+	}
+
+	store.Clear()
+	if len(store.Keys()) != 0 {
+		t.Error("❌ Clear must remove all keys")
+	}
+	t.Log("✅ Delete and clear")
+}
@@ -31,6 +31,16 @@ func BuildUniqueID(deviceID, sensorKey string) string {
 	return fmt.Sprintf("%s_%s", deviceID, sensorKey)
 }
 
+// Home Assistant MQTT discovery components (entity platforms)
+const (
+	ComponentSensor       = "sensor"
+	ComponentBinarySensor = "binary_sensor"
+	ComponentNumber       = "number"
+	ComponentSelect       = "select"
+	ComponentSwitch       = "switch"
+	ComponentButton       = "button"
+)
+
 // BuildTopic constructs a complete MQTT topic for Home Assistant
 // Pattern: {prefix}/sensor/{device_id}/{device_id}_{sensor_key}/{type}
 // Example: homeassistant/sensor/energy_meter_mains/energy_meter_mains_voltage/config
@@ -38,8 +48,15 @@ func BuildUniqueID(deviceID, sensorKey string) string {
 // This is the single source of truth for topic construction, ensuring consistency
 // across all sensor types (regular, diagnostic, device diagnostic, etc.)
 func BuildTopic(deviceID, sensorKey, topicType string) string {
+	return BuildComponentTopic(ComponentSensor, deviceID, sensorKey, topicType)
+}
+
+// BuildComponentTopic constructs a topic for any Home Assistant component
+// Pattern: {prefix}/{component}/{device_id}/{device_id}_{sensor_key}/{type}
+// Example: homeassistant/binary_sensor/energy_meter_mains/energy_meter_mains_relay/config
+func BuildComponentTopic(component, deviceID, sensorKey, topicType string) string {
 	entityID := BuildUniqueID(deviceID, sensorKey)
-	return fmt.Sprintf("%s/sensor/%s/%s/%s", discoveryPrefix, deviceID, entityID, topicType)
+	return fmt.Sprintf("%s/%s/%s/%s/%s", discoveryPrefix, component, deviceID, entityID, topicType)
 }
 
 // ConstructHATopic builds Home Assistant MQTT state topic with configurable prefix
@@ -49,6 +66,18 @@ func ConstructHATopic(deviceID, sensorKey, deviceClass string) string {
 	return BuildTopic(deviceID, sensorKey, "state")
 }
 
+// ConstructComponentTopic builds the state topic of an entity of the given component
+// Pattern: {prefix}/{component}/{device_id}/{device_id}_{sensor_key}/state
+func ConstructComponentTopic(component, deviceID, sensorKey string) string {
+	return BuildComponentTopic(component, deviceID, sensorKey, "state")
+}
+
+// ConstructCommandTopic builds the command topic of a writable entity (number, select, switch, button)
+// Pattern: {prefix}/{component}/{device_id}/{device_id}_{sensor_key}/set
+func ConstructCommandTopic(component, deviceID, sensorKey string) string {
+	return BuildComponentTopic(component, deviceID, sensorKey, "set")
+}
+
 // BuildDiscoveryTopic constructs the discovery config topic for a sensor
 // Pattern: {prefix}/sensor/{device_id}/{device_id}_{sensor_key}/config
 func BuildDiscoveryTopic(deviceID, sensorKey string) string {
@@ -128,3 +157,17 @@ func BuildDiagnosticDataTopic(deviceID string) string {
 	clientID = strings.TrimPrefix(clientID, "mqtt-")
 	return fmt.Sprintf("%s/diagnostic", clientID)
 }
+
+// BuildWriteAuditTopic constructs the topic receiving the audit log of write commands
+// Pattern: {client_id}/write_audit (e.g., modbus-bridge/write_audit)
+func BuildWriteAuditTopic(deviceID string) string {
+	clientID := strings.TrimPrefix(strings.ReplaceAll(deviceID, "_", "-"), "mqtt-")
+	return fmt.Sprintf("%s/write_audit", clientID)
+}
+
+// BuildCounterResetTopic constructs the topic acknowledging meter resets (payload: register key)
+// Pattern: {client_id}/counter_reset (e.g., modbus-bridge/counter_reset)
+func BuildCounterResetTopic(deviceID string) string {
+	clientID := strings.TrimPrefix(strings.ReplaceAll(deviceID, "_", "-"), "mqtt-")
+	return fmt.Sprintf("%s/counter_reset", clientID)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/logger"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/mqtt"
+	"mqtt-modbus-bridge/pkg/state"
+	"mqtt-modbus-bridge/pkg/topics"
+	"strings"
+	"time"
+)
+
+// Keys of the state store (see application.state_file)
+const (
+	stateKeyEnergy        = "energy"         // Last energy readings (map[string]mqtt.EnergyReading)
+	stateKeyDeviceMetrics = "device_metrics" // Device diagnostics counters (map[string]mqtt.DeviceMetrics)
+	stateKeyLastPublish   = "last_publish"   // Last publish time per sensor for the forced republish
+	stateKeyCounters      = "counters"       // Offsets of the total_increasing registers (map[string]modbus.CounterState)
+)
+
+// openStateStore opens the configured state file and restores the saved state
+// A state file that cannot be read is logged and replaced at the next save: losing
+// counters must not keep the bridge from starting.
+func (app *Application) openStateStore() {
+	path := app.config.Application.StateFile
+	if path == "" {
+		return
+	}
+
+	store, err := state.Open(path)
+	if err != nil {
+		logger.LogError("⚠️ Error opening state store, starting with empty state: %v", err)
+		store = state.New(path)
+	}
+	app.stateStore = store
+
+	if savedAt := store.SavedAt(); !savedAt.IsZero() {
+		logger.LogInfo("💾 Restoring state from %s (saved %s)", path, savedAt.Format(time.RFC3339))
+	} else {
+		logger.LogInfo("💾 State store at %s is empty", path)
+	}
+	app.restoreState()
+}
+
+// restoreState loads the energy readings, device metrics and publish times from the store
+func (app *Application) restoreState() {
+	var energy map[string]mqtt.EnergyReading
+	if found, err := app.stateStore.Get(stateKeyEnergy, &energy); err != nil {
+		logger.LogWarn("⚠️ %v", err)
+	} else if found {
+		app.publisher.RestoreEnergy(energy)
+		logger.LogDebug("💾 Restored %d energy readings", len(energy))
+	}
+
+	if app.diagnosticManager != nil {
+		var metrics map[string]mqtt.DeviceMetrics
+		if found, err := app.stateStore.Get(stateKeyDeviceMetrics, &metrics); err != nil {
+			logger.LogWarn("⚠️ %v", err)
+		} else if found {
+			app.diagnosticManager.Restore(metrics)
+			logger.LogDebug("💾 Restored metrics of %d devices", len(metrics))
+		}
+	}
+
+	var counters map[string]modbus.CounterState
+	if found, err := app.stateStore.Get(stateKeyCounters, &counters); err != nil {
+		logger.LogWarn("⚠️ %v", err)
+	} else if found {
+		for _, bus := range app.buses {
+			bus.executor.RestoreCounters(counters)
+			for _, key := range bus.executor.PendingCounterResets() {
+				logger.LogWarn("⚠️ Meter reset of %s still waits for acknowledgement on %s",
+					key, topics.BuildCounterResetTopic(config.BridgeDeviceID))
+			}
+		}
+		logger.LogDebug("💾 Restored %d meter counters", len(counters))
+	}
+
+	var lastPublish map[string]time.Time
+	if found, err := app.stateStore.Get(stateKeyLastPublish, &lastPublish); err != nil {
+		logger.LogWarn("⚠️ %v", err)
+	} else if found {
+		app.mu.Lock()
+		for sensorName, publishedAt := range lastPublish {
+			app.lastPublishTime[sensorName] = publishedAt
+		}
+		app.mu.Unlock()
+		logger.LogDebug("💾 Restored publish times of %d sensors", len(lastPublish))
+	}
+}
+
+// saveState writes the current state of all components to the store file
+func (app *Application) saveState() {
+	if app.stateStore == nil {
+		return
+	}
+
+	if err := app.stateStore.Set(stateKeyEnergy, app.publisher.EnergySnapshot()); err != nil {
+		logger.LogWarn("⚠️ %v", err)
+	}
+	if app.diagnosticManager != nil {
+		if err := app.stateStore.Set(stateKeyDeviceMetrics, app.diagnosticManager.Snapshot()); err != nil {
+			logger.LogWarn("⚠️ %v", err)
+		}
+	}
+
+	counters := make(map[string]modbus.CounterState)
+	for _, bus := range app.buses {
+		for key, counter := range bus.executor.CounterSnapshot() {
+			counters[key] = counter
+		}
+	}
+	if err := app.stateStore.Set(stateKeyCounters, counters); err != nil {
+		logger.LogWarn("⚠️ %v", err)
+	}
+
+	app.mu.Lock()
+	lastPublish := make(map[string]time.Time, len(app.lastPublishTime))
+	for sensorName, publishedAt := range app.lastPublishTime {
+		lastPublish[sensorName] = publishedAt
+	}
+	app.mu.Unlock()
+	if err := app.stateStore.Set(stateKeyLastPublish, lastPublish); err != nil {
+		logger.LogWarn("⚠️ %v", err)
+	}
+
+	if err := app.stateStore.Save(); err != nil {
+		logger.LogError("❌ Error saving state: %v", err)
+		return
+	}
+	logger.LogTrace("💾 State saved to %s", app.stateStore.Path())
+}
+
+// stateSaveLoop periodically saves the state so a crash loses at most one interval
+func (app *Application) stateSaveLoop(ctx context.Context) {
+	interval := time.Duration(app.config.Application.StateSaveInterval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.LogInfo("💾 State save loop started with interval: %v", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.LogDebug("⏹️ State save loop stopped")
+			return
+		case <-ticker.C:
+			app.saveState()
+		}
+	}
+}
+
+// subscribeCounterResets listens for acknowledgements of meter resets and replacements
+// The payload is the register key (e.g. energy_meter_mains_energy_imported); the series
+// continues from its last value and the new offset is saved at once.
+func (app *Application) subscribeCounterResets() {
+	topic := topics.BuildCounterResetTopic(config.BridgeDeviceID)
+	err := app.publisher.SubscribeCommand(topic, func(payload string) {
+		app.acknowledgeCounterReset(strings.TrimSpace(payload))
+	})
+	if err != nil {
+		logger.LogError("❌ Cannot subscribe to counter reset acknowledgements: %v", err)
+	}
+}
+
+// acknowledgeCounterReset accepts the pending meter reset of a register on whichever bus has it
+func (app *Application) acknowledgeCounterReset(key string) {
+	for _, bus := range app.buses {
+		if _, exists := bus.executor.RegisterConfig(key); !exists {
+			continue
+		}
+		offset, err := bus.executor.AcknowledgeCounterReset(key)
+		if err != nil {
+			logger.LogWarn("⚠️ Counter reset acknowledgement ignored: %v", err)
+			return
+		}
+		logger.LogInfo("🔁 Meter reset of %s acknowledged, continuing with offset %.3f", key, offset)
+		app.saveState()
+		return
+	}
+	logger.LogWarn("⚠️ Counter reset acknowledgement for unknown register '%s'", key)
+}
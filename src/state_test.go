@@ -0,0 +1,53 @@
+package main
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/mqtt"
+	"mqtt-modbus-bridge/pkg/state"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newStateTestApplication creates an application with only the parts the state store uses
+func newStateTestApplication(t *testing.T, path string) *Application {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Application.StateFile = path
+	store, err := state.Open(path)
+	if err != nil {
+		t.Fatalf("❌ Opening state store failed: %v", err)
+	}
+	return &Application{
+		config:          cfg,
+		publisher:       mqtt.NewPublisher(&cfg.MQTT, &cfg.HomeAssistant),
+		lastPublishTime: make(map[string]time.Time),
+		publishFilter:   mqtt.NewPublishFilter(),
+		stateStore:      store,
+	}
+}
+
+// TestLastPublishSurvivesRestart verifies publish times of scheduled group publishes are saved and restored
+func TestLastPublishSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.json")
+	app := newStateTestApplication(t, path)
+
+	// Scheduled group publishes record their results like the full poll
+	result := &modbus.CommandResult{Name: "Imported Energy", Key: "meter_energy_imported", Value: 1500.25}
+	before := time.Now()
+	app.markPublished("meter_energy_imported", result)
+	published := app.lastPublishTime["meter_energy_imported"]
+	if published.Before(before) {
+		t.Fatalf("❌ Publish time not recorded: %v", published)
+	}
+	app.saveState()
+
+	restarted := newStateTestApplication(t, path)
+	restarted.restoreState()
+	restored, exists := restarted.lastPublishTime["meter_energy_imported"]
+	if !exists || !restored.Equal(published) {
+		t.Errorf("❌ Restored publish time %v (exists=%v), expected %v", restored, exists, published)
+	}
+	t.Log("✅ last_publish round-trips after a scheduled publish")
+}
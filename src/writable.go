@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"mqtt-modbus-bridge/pkg/config"
+	"mqtt-modbus-bridge/pkg/gateway"
+	"mqtt-modbus-bridge/pkg/logger"
+	"mqtt-modbus-bridge/pkg/modbus"
+	"mqtt-modbus-bridge/pkg/topics"
+	"sort"
+	"time"
+)
+
+// newRegisterWriters creates a writer for every writable register of the enabled devices
+// Writers are sorted by key so subscriptions and initial reads happen in a stable order
+func newRegisterWriters(devices map[string]config.Device, gw gateway.Gateway) []*modbus.RegisterWriter {
+	var writers []*modbus.RegisterWriter
+	for deviceKey, device := range devices {
+		if !device.IsEnabled() {
+			continue
+		}
+		haDeviceID := device.GetHADeviceID(deviceKey)
+		for _, register := range device.Writable {
+			key := deviceKey + "_" + register.Key
+			writers = append(writers, modbus.NewRegisterWriter(key, haDeviceID, register, device.GetSlaveID(), gw))
+		}
+	}
+	sort.Slice(writers, func(i, j int) bool { return writers[i].GetKey() < writers[j].GetKey() })
+	return writers
+}
+
+// subscribeWriteCommands subscribes to the command topic of every writable register
+func (app *Application) subscribeWriteCommands(ctx context.Context) {
+	for _, bus := range app.buses {
+		for _, writer := range bus.writers {
+			register := writer.GetRegister()
+			topic := topics.ConstructCommandTopic(register.Component, writer.GetDeviceID(), register.Key)
+
+			err := app.publisher.SubscribeCommand(topic, func(payload string) {
+				// Writes wait for the bus lock, so never run them on the MQTT client goroutine
+				go app.handleWriteCommand(ctx, bus, writer, payload)
+			})
+			if err != nil {
+				logger.LogError("❌ Cannot subscribe to commands for %s: %v", writer.GetKey(), err)
+			}
+		}
+	}
+}
+
+// handleWriteCommand checks a Home Assistant command against the bus write policy,
+// writes it and publishes the value read back
+func (app *Application) handleWriteCommand(ctx context.Context, bus *gatewayBus, writer *modbus.RegisterWriter, payload string) {
+	entry := bus.writePolicy.Authorize(writer, payload)
+	app.auditWrite(ctx, entry)
+	if entry.Result != modbus.WriteAccepted {
+		return
+	}
+
+	actual, err := writer.WriteAndConfirm(ctx, *entry.RawValue)
+	if err != nil {
+		entry.Result = modbus.WriteFailed
+		entry.Reason = err.Error()
+		app.auditWrite(ctx, entry)
+		// Publish the value the device actually holds so HA does not show the rejected one
+		if actual, readErr := writer.Read(ctx); readErr == nil {
+			app.publishWritableState(ctx, writer, actual)
+		}
+		return
+	}
+
+	entry.Result = modbus.WriteDone
+	entry.Reason = ""
+	app.auditWrite(ctx, entry)
+	app.publishWritableState(ctx, writer, actual)
+}
+
+// auditWrite logs a write audit entry and publishes it to the write audit topic
+func (app *Application) auditWrite(ctx context.Context, entry *modbus.WriteAuditEntry) {
+	entry.Timestamp = time.Now()
+	switch entry.Result {
+	case modbus.WriteRejected:
+		logger.LogWarn("🚫 Write %s", entry)
+	case modbus.WriteFailed:
+		logger.LogError("❌ Write %s", entry)
+	default:
+		logger.LogInfo("✏️ Write %s", entry)
+	}
+
+	if err := app.publisher.PublishWriteAudit(ctx, entry); err != nil {
+		logger.LogError("⚠️ Error publishing write audit for %s: %v", entry.Key, err)
+	}
+}
+
+// publishWritableStates reads every writable register of a bus and publishes its current state
+func (app *Application) publishWritableStates(ctx context.Context, bus *gatewayBus) {
+	for _, writer := range bus.writers {
+		if !writer.HasState() {
+			continue
+		}
+		raw, err := writer.Read(ctx)
+		if err != nil {
+			logger.LogWarn("⚠️ Cannot read initial state of %s: %v", writer.GetKey(), err)
+			continue
+		}
+		app.publishWritableState(ctx, writer, raw)
+	}
+}
+
+// publishWritableState publishes the state of a writable register from its raw value
+func (app *Application) publishWritableState(ctx context.Context, writer *modbus.RegisterWriter, raw float64) {
+	if !writer.HasState() {
+		return
+	}
+	state, err := writer.FormatState(raw)
+	if err != nil {
+		logger.LogWarn("⚠️ Cannot publish state of %s: %v", writer.GetKey(), err)
+		return
+	}
+	if err := app.publisher.PublishWritableState(ctx, writer.GetDeviceID(), writer.GetRegister(), state); err != nil {
+		logger.LogError("⚠️ Error publishing state for %s: %v", writer.GetKey(), err)
+	}
+}
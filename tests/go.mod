@@ -4,12 +4,14 @@ go 1.24.3
 
 replace mqtt-modbus-bridge => ../src
 
-require mqtt-modbus-bridge v0.0.0-00010101000000-000000000000
+require (
+	gopkg.in/yaml.v3 v3.0.1
+	mqtt-modbus-bridge v0.0.0-00010101000000-000000000000
+)
 
 require (
 	github.com/eclipse/paho.mqtt.golang v1.4.3 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	golang.org/x/net v0.8.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"fmt"
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+)
+
+// catalogYAML configures one device per built-in profile
+const catalogYAML = `
+version: "2.1"
+mqtt:
+  broker: "localhost"
+  port: 1883
+  gateway:
+    mac: "TEST123456"
+    cmd_topic: "test/cmd"
+    data_topic: "test/data"
+modbus:
+  poll_interval: 1000
+devices:
+%DEVICES%
+`
+
+func TestCatalog_ProfilesValidate(t *testing.T) {
+	entries, err := config.Catalog()
+	if err != nil {
+		t.Fatalf("❌ Failed to load catalog: %v", err)
+	}
+	for _, model := range []string{"DDSU666", "DDSU666-H", "DTSU666", "DTSU666-H"} {
+		if _, found, _ := config.FindCatalogProfile(model); !found {
+			t.Errorf("❌ Catalog has no profile for %s", model)
+		}
+	}
+
+	var devices strings.Builder
+	for i, entry := range entries {
+		if entry.Profile.Manufacturer == "" || entry.Profile.Model == "" || len(entry.Profile.Modbus.RegisterGroups) == 0 {
+			t.Errorf("❌ Profile %s is incomplete: %+v", entry.Name, entry.Profile)
+		}
+		fmt.Fprintf(&devices, "  meter_%d:\n    profile: %s\n    metadata:\n      name: \"Meter %d\"\n      enabled: true\n    rtu:\n      slave_id: %d\n",
+			i, entry.Name, i, i+1)
+	}
+
+	cfg, err := config.LoadConfigFromString(strings.Replace(catalogYAML, "%DEVICES%", devices.String(), 1))
+	if err != nil {
+		t.Fatalf("❌ Catalog profiles do not validate: %v", err)
+	}
+	for i, entry := range entries {
+		device := cfg.Devices[fmt.Sprintf("meter_%d", i)]
+		if device.Metadata.Model != entry.Profile.Model {
+			t.Errorf("❌ %s: metadata.model not taken from profile: %q", entry.Name, device.Metadata.Model)
+		}
+	}
+	t.Logf("✅ %d catalog profiles validate", len(entries))
+}
+
+func TestCatalog_SelectByModel(t *testing.T) {
+	devices := `  mains:
+    profile: DTSU666-H
+    metadata:
+      name: "Mains"
+      enabled: true
+    rtu:
+      slave_id: 1
+    overrides:
+      disable_groups: [power_factor]
+`
+	cfg, err := config.LoadConfigFromString(strings.Replace(catalogYAML, "%DEVICES%", devices, 1))
+	if err != nil {
+		t.Fatalf("❌ Failed to load config: %v", err)
+	}
+
+	instant := cfg.Devices["mains"].Modbus.RegisterGroups["instant"]
+	if instant.StartAddress != 0x2000 || instant.SlaveID != 1 {
+		t.Errorf("❌ Unexpected instant group: %+v", instant)
+	}
+	if _, exists := cfg.Devices["mains"].Modbus.RegisterGroups["power_factor"]; exists {
+		t.Error("❌ Override not applied to catalog profile")
+	}
+	if _, exists := cfg.Registers["mains_voltage_l1"]; !exists {
+		t.Error("❌ Catalog registers missing from converted registers")
+	}
+
+	data, err := config.CatalogProfileYAML("dtsu666-h")
+	if err != nil || !strings.Contains(string(data), "model: \"DTSU666-H\"") {
+		t.Errorf("❌ Unexpected catalog file (err=%v)", err)
+	}
+	if _, err := config.CatalogProfileYAML("DTSU999"); err == nil {
+		t.Error("❌ Expected error for unknown profile")
+	}
+}
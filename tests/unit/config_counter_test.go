@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCounterConfig_IsRollover(t *testing.T) {
+	counter := &config.CounterConfig{Rollover: 100000}
+	tests := []struct {
+		last, current float64
+		rollover      bool
+	}{
+		{99995, 3, true},      // Advanced 8 across the wrap
+		{95000, 2000, true},   // Advanced 7000 (< 10% of the rollover)
+		{50000, 10, false},    // Reset or replacement
+		{99995, 20000, false}, // Too far across the wrap
+		{100, 200, false},     // No drop
+	}
+	for _, tt := range tests {
+		if got := counter.IsRollover(tt.last, tt.current); got != tt.rollover {
+			t.Errorf("❌ %.0f → %.0f: rollover=%v, expected %v", tt.last, tt.current, got, tt.rollover)
+		}
+	}
+
+	var none *config.CounterConfig
+	if none.IsRollover(99995, 3) {
+		t.Error("❌ Registers without counter config never roll over")
+	}
+	t.Log("✅ Rollover detection")
+}
+
+func TestCounterConfig_RegisterValidation(t *testing.T) {
+	var group config.RegisterGroup
+	if err := yaml.Unmarshal([]byte(`
+name: Energy
+slave_id: 1
+function_code: 3
+start_address: 0x4000
+register_count: 2
+poll_interval: 60000
+registers:
+  - key: energy_imported
+    name: Imported Energy
+    offset: 0
+    unit: kWh
+    device_class: energy
+    state_class: total_increasing
+    counter:
+      rollover: 99999.99
+      auto_acknowledge: true
+`), &group); err != nil {
+		t.Fatalf("❌ YAML parse failed: %v", err)
+	}
+	counter := group.Registers[0].Counter
+	if counter == nil || counter.Rollover != 99999.99 || !counter.AutoAcknowledge {
+		t.Fatalf("❌ Counter = %+v", counter)
+	}
+	if err := group.Validate(); err != nil {
+		t.Errorf("❌ Valid counter rejected: %v", err)
+	}
+
+	group.Registers[0].StateClass = "measurement"
+	if err := group.Validate(); err == nil || !strings.Contains(err.Error(), "total_increasing") {
+		t.Errorf("❌ Counter on a measurement must fail, got %v", err)
+	}
+
+	group.Registers[0].StateClass = config.StateClassTotalIncreasing
+	group.Registers[0].Counter = &config.CounterConfig{Rollover: -1}
+	if err := group.Validate(); err == nil || !strings.Contains(err.Error(), "rollover") {
+		t.Errorf("❌ Negative rollover must fail, got %v", err)
+	}
+
+	bits := newTestGroup(config.FunctionReadCoils, 8)
+	bits.Registers[0].Counter = &config.CounterConfig{}
+	if err := bits.Validate(); err == nil || !strings.Contains(err.Error(), "0x03/0x04") {
+		t.Errorf("❌ Counter in a coil group must fail, got %v", err)
+	}
+	t.Log("✅ Counters limited to total_increasing word registers")
+}
@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+)
+
+func TestValueFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter config.ValueFilter
+		errMsg string
+	}{
+		{"moving average", config.ValueFilter{Type: config.FilterMovingAverage, Samples: 5}, ""},
+		{"median with spikes", config.ValueFilter{Type: config.FilterMedian, Samples: 3, MaxRate: 50}, ""},
+		{"ema", config.ValueFilter{Type: config.FilterEMA, Alpha: 0.2}, ""},
+		{"spike rejection only", config.ValueFilter{MaxRate: 100}, ""},
+		{"no samples", config.ValueFilter{Type: config.FilterMovingAverage}, "samples between 2"},
+		{"too many samples", config.ValueFilter{Type: config.FilterMedian, Samples: 500}, "samples between 2"},
+		{"ema without alpha", config.ValueFilter{Type: config.FilterEMA}, "alpha"},
+		{"ema alpha above 1", config.ValueFilter{Type: config.FilterEMA, Alpha: 1.5}, "alpha"},
+		{"empty", config.ValueFilter{}, "needs a type"},
+		{"unknown type", config.ValueFilter{Type: "kalman"}, "unknown filter type"},
+		{"negative rate", config.ValueFilter{Type: config.FilterEMA, Alpha: 0.5, MaxRate: -1}, "max_rate"},
+	}
+	for _, tt := range tests {
+		err := tt.filter.Validate()
+		if tt.errMsg == "" && err != nil {
+			t.Errorf("❌ %s: unexpected error %v", tt.name, err)
+		}
+		if tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)) {
+			t.Errorf("❌ %s: expected error containing '%s', got %v", tt.name, tt.errMsg, err)
+		}
+	}
+
+	if spikes := (&config.ValueFilter{MaxRate: 10}).GetMaxSpikes(); spikes != config.DefaultMaxSpikes {
+		t.Errorf("❌ Default max_spikes = %d, expected %d", spikes, config.DefaultMaxSpikes)
+	}
+	t.Log("✅ Filter validation")
+}
+
+func TestValueFilter_RegisterValidation(t *testing.T) {
+	group := newTestGroup(config.FunctionReadHoldingRegisters, 2)
+	group.Registers[0].Filter = &config.ValueFilter{Type: config.FilterMedian, Samples: 5}
+	if err := group.Validate(); err != nil {
+		t.Errorf("❌ Valid filter rejected: %v", err)
+	}
+
+	group.Registers[0].DataType = config.DataTypeUint16
+	group.Registers[0].Enum = map[int64]string{0: "off", 1: "on"}
+	group.Registers[0].DeviceClass = config.DeviceClassEnum
+	if err := group.Validate(); err == nil || !strings.Contains(err.Error(), "filter is not supported") {
+		t.Errorf("❌ Filter on an enum register must fail, got %v", err)
+	}
+
+	bits := newTestGroup(config.FunctionReadCoils, 8)
+	bits.Registers[0].Filter = &config.ValueFilter{MaxRate: 1}
+	if err := bits.Validate(); err == nil || !strings.Contains(err.Error(), "0x03/0x04") {
+		t.Errorf("❌ Filter in a coil group must fail, got %v", err)
+	}
+	t.Log("✅ Filters limited to numeric word registers")
+}
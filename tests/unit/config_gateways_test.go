@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+)
+
+// multiGatewayYAML describes three DR164s, each on its own RS-485 bus
+const multiGatewayYAML = `
+version: "2.1"
+
+mqtt:
+  broker: "localhost"
+  port: 1883
+  client_id: "bridge"
+
+gateways:
+  bus_a:
+    mac: "AAAAAAAAAAAA"
+    cmd_topic: "AAAAAAAAAAAA/cmd"
+    data_topic: "AAAAAAAAAAAA/data"
+  bus_b:
+    mac: "BBBBBBBBBBBB"
+    cmd_topic: "BBBBBBBBBBBB/cmd"
+    data_topic: "BBBBBBBBBBBB/data"
+  bus_c:
+    type: "modbus_tcp"
+    modbus_tcp:
+      host: "192.168.1.50"
+
+homeassistant:
+  discovery_prefix: "homeassistant"
+
+modbus:
+  poll_interval: 1000
+
+devices:
+  meter_a:
+    metadata:
+      name: "Meter A"
+      enabled: true
+    rtu:
+      slave_id: 1
+      gateway: "bus_a"
+    modbus:
+      register_groups:
+        instant:
+          function_code: 3
+          start_address: 0x2000
+          register_count: 2
+          enabled: true
+          poll_interval: 1000
+          registers:
+            - key: "voltage"
+              name: "Voltage"
+              offset: 0
+              unit: "V"
+  meter_b:
+    metadata:
+      name: "Meter B"
+      enabled: true
+    rtu:
+      slave_id: 1
+      gateway: "%GATEWAY%"
+    modbus:
+      register_groups:
+        instant:
+          function_code: 3
+          start_address: 0x2000
+          register_count: 2
+          enabled: true
+          poll_interval: 1000
+          registers:
+            - key: "voltage"
+              name: "Voltage"
+              offset: 0
+              unit: "V"
+`
+
+func TestConfig_MultipleGateways(t *testing.T) {
+	cfg, err := config.LoadConfigFromString(strings.Replace(multiGatewayYAML, "%GATEWAY%", "bus_b", 1))
+	if err != nil {
+		t.Fatalf("Failed to load multi-gateway config: %v", err)
+	}
+
+	gateways := cfg.GetGateways()
+	if len(gateways) != 3 {
+		t.Fatalf("Expected 3 gateways, got %d", len(gateways))
+	}
+	if _, exists := gateways[config.DefaultGatewayName]; exists {
+		t.Error("Unconfigured mqtt.gateway must not be exposed as the default gateway")
+	}
+	if gateways["bus_c"].ModbusTCP.Port != 502 {
+		t.Errorf("Expected defaults applied to named gateway, got port %d", gateways["bus_c"].ModbusTCP.Port)
+	}
+
+	busA := config.FilterDevicesByGateway(cfg.Devices, "bus_a")
+	if _, exists := busA["meter_a"]; !exists || len(busA) != 1 {
+		t.Errorf("Expected only meter_a on bus_a, got %v", busA)
+	}
+}
+
+func TestConfig_SameSlaveIDOnDifferentGateways(t *testing.T) {
+	// Both meters use slave_id 1 - allowed because they are on different buses
+	if _, err := config.LoadConfigFromString(strings.Replace(multiGatewayYAML, "%GATEWAY%", "bus_b", 1)); err != nil {
+		t.Fatalf("Same slave_id on different gateways should be valid: %v", err)
+	}
+
+	// Same slave_id on the same bus is still a conflict
+	_, err := config.LoadConfigFromString(strings.Replace(multiGatewayYAML, "%GATEWAY%", "bus_a", 1))
+	if err == nil || !strings.Contains(err.Error(), "duplicate rtu.slave_id 1 on gateway 'bus_a'") {
+		t.Errorf("Expected duplicate slave_id error on bus_a, got: %v", err)
+	}
+}
+
+func TestConfig_UnknownGatewayReference(t *testing.T) {
+	_, err := config.LoadConfigFromString(strings.Replace(multiGatewayYAML, "%GATEWAY%", "bus_x", 1))
+	if err == nil || !strings.Contains(err.Error(), "rtu.gateway 'bus_x' is not defined") {
+		t.Errorf("Expected unknown gateway error, got: %v", err)
+	}
+}
+
+func TestConfig_InvalidNamedGateway(t *testing.T) {
+	yamlContent := strings.Replace(multiGatewayYAML, `      host: "192.168.1.50"`, `      host: ""`, 1)
+	_, err := config.LoadConfigFromString(strings.Replace(yamlContent, "%GATEWAY%", "bus_b", 1))
+	if err == nil || !strings.Contains(err.Error(), "gateways.bus_c.modbus_tcp.host") {
+		t.Errorf("Expected error with gateways.bus_c path, got: %v", err)
+	}
+}
+
+func TestConfig_LegacyGatewayIsDefault(t *testing.T) {
+	cfg := &config.Config{
+		MQTT: config.MQTTConfig{Gateway: config.GatewayConfig{MAC: "AABBCCDDEEFF"}},
+	}
+	gateways := cfg.GetGateways()
+	if gw, exists := gateways[config.DefaultGatewayName]; !exists || gw.MAC != "AABBCCDDEEFF" {
+		t.Errorf("Expected mqtt.gateway as '%s', got %v", config.DefaultGatewayName, gateways)
+	}
+
+	device := config.Device{}
+	if device.GetGateway() != config.DefaultGatewayName {
+		t.Errorf("Expected devices without rtu.gateway on '%s', got '%s'", config.DefaultGatewayName, device.GetGateway())
+	}
+}
+
+func TestConfig_MaxRegistersPerRequest(t *testing.T) {
+	yamlContent := strings.Replace(multiGatewayYAML, `      host: "192.168.1.50"`, `      host: "192.168.1.50"
+    max_registers_per_request: 60`, 1)
+	cfg, err := config.LoadConfigFromString(strings.Replace(yamlContent, "%GATEWAY%", "bus_b", 1))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	busA, busC := cfg.Gateways["bus_a"], cfg.Gateways["bus_c"]
+	if limit := busC.GetMaxRegistersPerRequest(); limit != 60 {
+		t.Errorf("Expected bus_c limit 60, got %d", limit)
+	}
+	if limit := busA.GetMaxRegistersPerRequest(); limit != config.MaxReadRegisters {
+		t.Errorf("Expected default limit %d, got %d", config.MaxReadRegisters, limit)
+	}
+
+	yamlContent = strings.Replace(yamlContent, "max_registers_per_request: 60", "max_registers_per_request: 126", 1)
+	_, err = config.LoadConfigFromString(strings.Replace(yamlContent, "%GATEWAY%", "bus_b", 1))
+	if err == nil || !strings.Contains(err.Error(), "gateways.bus_c.max_registers_per_request must be 0 (default) or between 1 and 125") {
+		t.Errorf("Expected max_registers_per_request error, got: %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+)
+
+// newTestGroup returns a valid group with one register at offset 0
+func newTestGroup(functionCode uint8, count uint16) config.RegisterGroup {
+	return config.RegisterGroup{
+		Name:          "test",
+		SlaveID:       1,
+		FunctionCode:  functionCode,
+		StartAddress:  0x0000,
+		RegisterCount: count,
+		PollInterval:  1000,
+		Registers:     []config.GroupRegister{{Key: "value", Name: "Value", Offset: 0}},
+	}
+}
+
+func TestRegisterGroup_SupportedFunctionCodes(t *testing.T) {
+	for _, fc := range []uint8{
+		config.FunctionReadCoils,
+		config.FunctionReadDiscreteInputs,
+		config.FunctionReadHoldingRegisters,
+		config.FunctionReadInputRegisters,
+	} {
+		group := newTestGroup(fc, 2)
+		if err := group.Validate(); err != nil {
+			t.Errorf("Function code 0x%02X should be valid: %v", fc, err)
+		}
+	}
+
+	group := newTestGroup(0x06, 2)
+	err := group.Validate()
+	if err == nil || !strings.Contains(err.Error(), "function_code 0x06 is not supported") {
+		t.Errorf("Expected unsupported function code error, got: %v", err)
+	}
+}
+
+func TestRegisterGroup_BitOffsets(t *testing.T) {
+	group := newTestGroup(config.FunctionReadCoils, 10)
+	if !group.IsBitGroup() {
+		t.Fatal("Coil group should be a bit group")
+	}
+
+	// Offset is a bit index: 9 is the last coil of a 10-coil group
+	group.Registers[0].Offset = 9
+	if err := group.Validate(); err != nil {
+		t.Errorf("Bit offset 9 should be valid for 10 coils: %v", err)
+	}
+
+	group.Registers[0].Offset = 10
+	err := group.Validate()
+	if err == nil || !strings.Contains(err.Error(), "bit offset 10 exceeds group range") {
+		t.Errorf("Expected bit offset range error, got: %v", err)
+	}
+
+	group = newTestGroup(config.FunctionReadDiscreteInputs, 2001)
+	if err := group.Validate(); err == nil {
+		t.Error("Expected error for more than 2000 discrete inputs")
+	}
+}
+
+func TestRegisterGroup_DataTypeWidths(t *testing.T) {
+	widths := map[string]int{
+		config.DataTypeInt16:   2,
+		config.DataTypeUint16:  2,
+		config.DataTypeInt32:   4,
+		config.DataTypeUint32:  4,
+		config.DataTypeFloat32: 4,
+		config.DataTypeInt64:   8,
+		config.DataTypeFloat64: 8,
+	}
+
+	// 4 registers = 8 bytes: each type fits exactly at offset 8 - width, not one register later
+	for dataType, width := range widths {
+		for _, byteOrder := range []string{config.ByteOrderABCD, config.ByteOrderCDAB, config.ByteOrderBADC, config.ByteOrderDCBA} {
+			group := newTestGroup(config.FunctionReadHoldingRegisters, 4)
+			group.Registers[0].DataType = dataType
+			group.Registers[0].ByteOrder = byteOrder
+
+			group.Registers[0].Offset = 8 - width
+			if err := group.Validate(); err != nil {
+				t.Errorf("%s/%s at offset %d should be valid: %v", dataType, byteOrder, 8-width, err)
+			}
+
+			group.Registers[0].Offset = 10 - width
+			if err := group.Validate(); err == nil || !strings.Contains(err.Error(), "exceeds group range") {
+				t.Errorf("%s/%s at offset %d: expected range error, got: %v", dataType, byteOrder, 10-width, err)
+			}
+		}
+	}
+
+	// Without data_type the register is a float32, as before
+	group := newTestGroup(config.FunctionReadHoldingRegisters, 2)
+	if group.Registers[0].GetDataType() != config.DataTypeFloat32 || group.Registers[0].GetByteOrder() != config.ByteOrderABCD {
+		t.Errorf("Expected float32/ABCD defaults, got %s/%s", group.Registers[0].GetDataType(), group.Registers[0].GetByteOrder())
+	}
+}
+
+func TestRegisterGroup_InvalidDataType(t *testing.T) {
+	group := newTestGroup(config.FunctionReadHoldingRegisters, 4)
+	group.Registers[0].DataType = "int8"
+	if err := group.Validate(); err == nil || !strings.Contains(err.Error(), "unsupported data_type 'int8'") {
+		t.Errorf("Expected data_type error, got: %v", err)
+	}
+
+	group.Registers[0].DataType = config.DataTypeInt32
+	group.Registers[0].ByteOrder = "little"
+	if err := group.Validate(); err == nil || !strings.Contains(err.Error(), "unsupported byte_order 'little'") {
+		t.Errorf("Expected byte_order error, got: %v", err)
+	}
+}
+
+func TestRegisterGroup_StatusRegisters(t *testing.T) {
+	group := newTestGroup(config.FunctionReadHoldingRegisters, 2)
+	group.Registers = []config.GroupRegister{
+		{Key: "status", Name: "Status", Offset: 0, DataType: config.DataTypeUint16, Bits: []config.BitField{
+			{Bit: 0, Key: "overvoltage", Name: "Overvoltage", DeviceClass: "problem"},
+			{Bit: 15, Key: "running", Name: "Running"},
+		}},
+		{Key: "mode", Name: "Mode", Offset: 2, DataType: config.DataTypeUint16, Enum: map[int64]string{2: "Export", 0: "Idle"}},
+	}
+	if err := group.Validate(); err != nil {
+		t.Fatalf("Status registers should be valid: %v", err)
+	}
+	if options := group.Registers[1].EnumOptions(); len(options) != 2 || options[0] != "Idle" {
+		t.Errorf("Enum options should be ordered by value, got %v", options)
+	}
+
+	tests := []struct {
+		modify   func(reg *config.GroupRegister)
+		expected string
+	}{
+		{func(reg *config.GroupRegister) { reg.Bits[1].Bit = 16 }, "bit 16 out of range for uint16"},
+		{func(reg *config.GroupRegister) { reg.Bits[1].Key = "overvoltage" }, "duplicate key 'overvoltage'"},
+		{func(reg *config.GroupRegister) { reg.DataType = "" }, "require an integer data_type"},
+		{func(reg *config.GroupRegister) { reg.Enum = map[int64]string{1: "On"} }, "cannot be combined"},
+		{func(reg *config.GroupRegister) {
+			reg.Bits = nil
+			reg.Enum = map[int64]string{1: "On"}
+			reg.Unit = "V"
+		}, "cannot have a unit"},
+		{func(reg *config.GroupRegister) {
+			reg.Bits = nil
+			reg.Enum = map[int64]string{1: "On", 2: "On"}
+		}, "duplicate enum label 'On'"},
+	}
+	for _, tt := range tests {
+		reg := config.GroupRegister{Key: "status", Name: "Status", DataType: config.DataTypeUint16, Bits: []config.BitField{
+			{Bit: 0, Key: "overvoltage"}, {Bit: 1, Key: "undervoltage"},
+		}}
+		tt.modify(&reg)
+		invalid := newTestGroup(config.FunctionReadHoldingRegisters, 2)
+		invalid.Registers = []config.GroupRegister{reg}
+		if err := invalid.Validate(); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got: %v", tt.expected, err)
+		}
+	}
+
+	coils := newTestGroup(config.FunctionReadCoils, 8)
+	coils.Registers[0].Enum = map[int64]string{1: "On"}
+	if err := coils.Validate(); err == nil || !strings.Contains(err.Error(), "only supported in 0x03/0x04 groups") {
+		t.Errorf("Expected bit group error, got: %v", err)
+	}
+}
+
+func TestDevice_BitKeyConflict(t *testing.T) {
+	group := newTestGroup(config.FunctionReadHoldingRegisters, 2)
+	group.Registers[0].DataType = config.DataTypeUint16
+	group.Registers[0].Bits = []config.BitField{{Bit: 0, Key: "alarm", Name: "Alarm"}}
+	device := config.Device{
+		Metadata: config.DeviceMetadata{Name: "Meter", Enabled: true},
+		RTU:      config.RTUConfig{SlaveID: 1},
+		Modbus:   config.ModbusDeviceConfig{RegisterGroups: map[string]config.RegisterGroup{"status": group}},
+	}
+	if err := device.Validate(); err != nil {
+		t.Fatalf("Device should be valid: %v", err)
+	}
+
+	device.Modbus.RegisterGroups["status"].Registers[0].Bits[0].Key = "value" // Same key as the register
+	if err := device.Validate(); err == nil || !strings.Contains(err.Error(), "duplicate register key 'value'") {
+		t.Errorf("Expected duplicate key error, got: %v", err)
+	}
+}
+
+func TestRegisterGroup_TextRegisters(t *testing.T) {
+	group := newTestGroup(config.FunctionReadHoldingRegisters, 6)
+	group.Registers = []config.GroupRegister{
+		{Key: "serial", Name: "Serial", Offset: 0, DataType: config.DataTypeString, Length: 8, DeviceInfo: config.DeviceInfoSerialNumber},
+		{Key: "firmware", Name: "Firmware", Offset: 8, DataType: config.DataTypeBCD, Length: 4, DeviceInfo: config.DeviceInfoSwVersion},
+	}
+	group.PollInterval = 3600000 // Device info groups may be polled hourly
+	if err := group.Validate(); err != nil {
+		t.Fatalf("Text registers should be valid: %v", err)
+	}
+
+	tests := []struct {
+		modify   func(reg *config.GroupRegister)
+		expected string
+	}{
+		{func(reg *config.GroupRegister) { reg.Length = 0 }, "requires a positive, even length"},
+		{func(reg *config.GroupRegister) { reg.Length = 3 }, "requires a positive, even length"},
+		{func(reg *config.GroupRegister) { reg.Length = 16 }, "exceeds group range"},
+		{func(reg *config.GroupRegister) { reg.DataType = config.DataTypeUint16 }, "length is only used by string and bcd"},
+		{func(reg *config.GroupRegister) { reg.DeviceInfo = "model" }, "unsupported device_info 'model'"},
+		{func(reg *config.GroupRegister) {
+			reg.DataType = config.DataTypeUint16
+			reg.Length = 0
+		}, "device_info requires a string or bcd data_type"},
+	}
+	for _, tt := range tests {
+		invalid := newTestGroup(config.FunctionReadHoldingRegisters, 6)
+		reg := config.GroupRegister{Key: "serial", Name: "Serial", DataType: config.DataTypeString, Length: 8,
+			DeviceInfo: config.DeviceInfoSerialNumber}
+		tt.modify(&reg)
+		invalid.Registers = []config.GroupRegister{reg}
+		if err := invalid.Validate(); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got: %v", tt.expected, err)
+		}
+	}
+
+	// Only groups made of device_info registers may be polled that slowly
+	group.Registers = append(group.Registers, config.GroupRegister{Key: "mode", Name: "Mode", Offset: 4, DataType: config.DataTypeUint16})
+	if err := group.Validate(); err == nil || !strings.Contains(err.Error(), "poll_interval too large") {
+		t.Errorf("Expected poll_interval error, got: %v", err)
+	}
+}
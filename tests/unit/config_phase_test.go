@@ -0,0 +1,64 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+)
+
+func TestParsePhaseKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		quantity string
+		phase    string
+	}{
+		{"voltage", "voltage", ""},
+		{"power_active", "power_active", ""},
+		{"current_l2", "current", "l2"},
+		{"power_factor_l3", "power_factor", "l3"},
+		{"voltage_l1_l2", "voltage", "l1_l2"},
+		{"voltage_L3_L1", "voltage", "l3_l1"},
+		{"voltage_l1_n", "voltage", "l1"},
+		{"current_n", "current", "n"},
+		{"l1", "l1", ""},
+	}
+	for _, tt := range tests {
+		phaseKey := config.ParsePhaseKey(tt.key)
+		if phaseKey.Quantity != tt.quantity || phaseKey.Phase() != tt.phase {
+			t.Errorf("❌ ParsePhaseKey(%s) = %s/%s, expected %s/%s", tt.key, phaseKey.Quantity, phaseKey.Phase(), tt.quantity, tt.phase)
+		}
+	}
+
+	_, phaseMax, _ := config.PhaseRange("voltage_l1", "voltage")
+	_, lineMax, _ := config.PhaseRange("voltage_l1_l2", "voltage")
+	if lineMax <= phaseMax || lineMax < 400*1.5 {
+		t.Errorf("❌ Line-to-line range %.0f V must exceed phase range %.0f V", lineMax, phaseMax)
+	}
+	if _, dcMax, _ := config.PhaseRange("pv_voltage", "voltage"); dcMax != config.MaxVoltage {
+		t.Errorf("❌ Voltages without phase suffix must keep the %.0f V bound, got %.0f V", config.MaxVoltage, dcMax)
+	}
+	if _, _, ok := config.PhaseRange("current_l1", "current"); ok {
+		t.Error("❌ Current has no plausibility range")
+	}
+	t.Logf("✅ Phase ranges: %.0f V phase, %.0f V line-to-line", phaseMax, lineMax)
+}
+
+func TestCatalog_ThreePhaseCalculatedValues(t *testing.T) {
+	devices := `  mains:
+    profile: dtsu666
+    metadata:
+      name: "Mains"
+      enabled: true
+    rtu:
+      slave_id: 1
+`
+	cfg, err := config.LoadConfigFromString(strings.Replace(catalogYAML, "%DEVICES%", devices, 1))
+	if err != nil {
+		t.Fatalf("❌ Failed to load config: %v", err)
+	}
+	for _, key := range []string{"mains_voltage_imbalance", "mains_current_imbalance", "mains_power_apparent"} {
+		if _, exists := cfg.Registers[key]; !exists {
+			t.Errorf("❌ Calculated value %s missing", key)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"math"
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRegister_CheckValue(t *testing.T) {
+	minValue, maxValue := 100.0, 300.0
+	register := config.Register{Name: "Voltage", Min: &minValue, Max: &maxValue}
+
+	tests := []struct {
+		value  float64
+		reason string
+	}{
+		{230, ""},
+		{100, ""},
+		{300, ""},
+		{99.9, "below min"},
+		{300.1, "above max"},
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "infinite"},
+	}
+	for _, tt := range tests {
+		err := register.CheckValue(tt.value)
+		if tt.reason == "" && err != nil {
+			t.Errorf("❌ %v must pass, got %v", tt.value, err)
+		}
+		if tt.reason != "" && (err == nil || !strings.Contains(err.Error(), tt.reason)) {
+			t.Errorf("❌ %v must fail with '%s', got %v", tt.value, tt.reason, err)
+		}
+	}
+
+	unbounded := config.Register{Name: "Power"}
+	if err := unbounded.CheckValue(math.Inf(-1)); err == nil {
+		t.Error("❌ -Inf must fail without min/max")
+	}
+	if err := unbounded.CheckValue(-1e9); err != nil {
+		t.Errorf("❌ Registers without min/max accept any finite value, got %v", err)
+	}
+	t.Log("✅ Values checked against min/max, NaN and Inf")
+}
+
+func TestRegisterGroup_RangeValidation(t *testing.T) {
+	group := newTestGroup(config.FunctionReadHoldingRegisters, 2)
+	group.Registers[0].Min = floatPtr(300)
+	group.Registers[0].Max = floatPtr(100)
+	err := group.Validate()
+	if err == nil || !strings.Contains(err.Error(), "greater than max") {
+		t.Errorf("❌ Expected empty range error, got %v", err)
+	}
+
+	group.Registers[0].Min = floatPtr(100)
+	group.Registers[0].Max = floatPtr(300)
+	if err := group.Validate(); err != nil {
+		t.Errorf("❌ Valid range rejected: %v", err)
+	}
+	t.Log("✅ Register min/max range validated")
+}
+
+func TestRegisterGroup_ZeroBound(t *testing.T) {
+	var group config.RegisterGroup
+	if err := yaml.Unmarshal([]byte(`
+name: Energy
+slave_id: 1
+function_code: 3
+start_address: 0x4000
+register_count: 4
+poll_interval: 60000
+registers:
+  - key: energy_imported
+    name: Imported Energy
+    offset: 0
+    min: 0
+  - key: power_exported
+    name: Exported Power
+    offset: 4
+    max: 0
+`), &group); err != nil {
+		t.Fatalf("❌ YAML parse failed: %v", err)
+	}
+
+	registers := config.ConvertGroupsToRegisters(map[string]config.RegisterGroup{"energy": group})
+	imported := registers["energy_imported"]
+	if imported.Min == nil || *imported.Min != 0 || imported.Max != nil {
+		t.Fatalf("❌ min: 0 must be kept as a bound, got min=%v max=%v", imported.Min, imported.Max)
+	}
+	if err := imported.CheckValue(-1); err == nil || !strings.Contains(err.Error(), "below min") {
+		t.Errorf("❌ min: 0 must reject -1, got %v", err)
+	}
+	if err := imported.CheckValue(0); err != nil {
+		t.Errorf("❌ min: 0 must accept 0, got %v", err)
+	}
+
+	exported := registers["power_exported"]
+	if err := exported.CheckValue(1); err == nil || !strings.Contains(err.Error(), "above max") {
+		t.Errorf("❌ max: 0 must reject 1, got %v", err)
+	}
+	t.Log("✅ A bound of 0 is enforced")
+}
@@ -0,0 +1,186 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// profileYAML defines one meter profile used by two devices
+const profileYAML = `
+version: "2.1"
+mqtt:
+  broker: "localhost"
+  port: 1883
+  gateway:
+    mac: "TEST123456"
+    cmd_topic: "test/cmd"
+    data_topic: "test/data"
+modbus:
+  poll_interval: 1000
+%PROFILES%
+devices:
+  meter_1:
+    profile: ddsu666
+    metadata:
+      name: "Meter 1"
+      enabled: true
+    rtu:
+      slave_id: 1
+  meter_2:
+    profile: ddsu666
+    metadata:
+      name: "Meter 2"
+      model: "DDSU666-H"
+      enabled: true
+    rtu:
+      slave_id: 2
+    overrides:
+      disable_groups: [energy]
+      disable_registers: [apparent_power]
+      groups:
+        instant:
+          poll_interval: 5000
+      registers:
+        voltage:
+          name: "Mains Voltage"
+          scale_factor: 0.1
+%EXTRA%
+`
+
+// ddsu666Profiles is the profiles section used by profileYAML
+const ddsu666Profiles = `profiles:
+  ddsu666:
+    manufacturer: "CHINT"
+    model: "DDSU666"
+    modbus:
+      register_groups:
+        instant:
+          function_code: 0x03
+          start_address: 0x2000
+          register_count: 4
+          enabled: true
+          poll_interval: 1000
+          registers:
+            - {key: voltage, name: Voltage, offset: 0, unit: V, device_class: voltage, state_class: measurement}
+            - {key: power, name: Power, offset: 4, unit: W, device_class: power, state_class: measurement}
+        energy:
+          function_code: 0x03
+          start_address: 0x4000
+          register_count: 2
+          enabled: true
+          poll_interval: 60000
+          registers:
+            - {key: energy, name: Energy, offset: 0, unit: kWh, device_class: energy, state_class: total_increasing}
+    calculated_values:
+      - {key: apparent_power, name: Apparent Power, unit: VA, formula: "power", device_class: apparent_power, state_class: measurement}
+`
+
+func profileConfig(profiles string, extra string) string {
+	yamlContent := strings.Replace(profileYAML, "%PROFILES%", profiles, 1)
+	return strings.Replace(yamlContent, "%EXTRA%", extra, 1)
+}
+
+func TestProfiles_ExpandWithOverrides(t *testing.T) {
+	cfg, err := config.LoadConfigFromString(profileConfig(ddsu666Profiles, ""))
+	if err != nil {
+		t.Fatalf("❌ Failed to load config: %v", err)
+	}
+
+	meter1, meter2 := cfg.Devices["meter_1"], cfg.Devices["meter_2"]
+	if len(meter1.Modbus.RegisterGroups) != 2 || len(meter1.CalculatedValues) != 1 {
+		t.Errorf("❌ meter_1 should get every profile group and calculated value: %+v", meter1.Modbus.RegisterGroups)
+	}
+	if meter1.Metadata.Manufacturer != "CHINT" || meter1.Metadata.Model != "DDSU666" {
+		t.Errorf("❌ meter_1 metadata defaults not applied: %+v", meter1.Metadata)
+	}
+	if meter2.Metadata.Model != "DDSU666-H" {
+		t.Errorf("❌ meter_2 metadata.model must win over the profile, got %s", meter2.Metadata.Model)
+	}
+
+	if _, exists := meter2.Modbus.RegisterGroups["energy"]; exists || len(meter2.CalculatedValues) != 0 {
+		t.Errorf("❌ meter_2 disabled group or calculated value still present")
+	}
+	instant := meter2.Modbus.RegisterGroups["instant"]
+	if instant.PollInterval != 5000 || instant.StartAddress != 0x2000 || instant.SlaveID != 2 {
+		t.Errorf("❌ meter_2 group override not merged: %+v", instant)
+	}
+	if voltage := instant.Registers[0]; voltage.Name != "Mains Voltage" || voltage.ScaleFactor != 0.1 || voltage.Unit != "V" {
+		t.Errorf("❌ meter_2 register override not merged: %+v", voltage)
+	}
+
+	// Overrides of one device do not leak into the profile or other devices
+	voltage := meter1.Modbus.RegisterGroups["instant"].Registers[0]
+	if voltage.Name != "Voltage" || voltage.ScaleFactor == 0.1 || meter1.Modbus.RegisterGroups["instant"].PollInterval != 1000 {
+		t.Errorf("❌ meter_2 overrides leaked into meter_1: %+v", voltage)
+	}
+	if _, exists := cfg.Registers["meter_2_power"]; !exists {
+		t.Error("❌ Expanded registers missing from converted registers")
+	}
+	t.Logf("✅ Profile expanded for %d devices", len(cfg.Devices))
+}
+
+func TestProfiles_ProfileFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "meters.yaml"), []byte(ddsu666Profiles), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(profileConfig(`profile_files: ["meters.yaml"]`, "")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("❌ Failed to load config with profile_files: %v", err)
+	}
+	if len(cfg.Devices["meter_1"].Modbus.RegisterGroups) != 2 {
+		t.Errorf("❌ Profile from file not applied")
+	}
+}
+
+func TestProfiles_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		errorMsg string
+	}{
+		{
+			"unknown profile",
+			strings.Replace(profileConfig(ddsu666Profiles, ""), "profile: ddsu666", "profile: dtsu999", 1),
+			"profile 'dtsu999' is not defined",
+		},
+		{
+			"unknown register override",
+			strings.Replace(profileConfig(ddsu666Profiles, ""), "disable_registers: [apparent_power]", "disable_registers: [current]", 1),
+			"overrides.disable_registers: no register 'current'",
+		},
+		{
+			"group defined twice",
+			profileConfig(ddsu666Profiles, `    modbus:
+      register_groups:
+        instant:
+          function_code: 0x03
+          start_address: 0x2100
+          register_count: 2
+          poll_interval: 1000
+          registers:
+            - {key: frequency, name: Frequency, offset: 0, unit: Hz}`),
+			"modbus.register_groups.instant is defined by the profile",
+		},
+		{
+			// Validation runs on the expanded device
+			"override breaks group",
+			strings.Replace(profileConfig(ddsu666Profiles, ""), "poll_interval: 5000", "register_count: 1", 1),
+			"exceeds group range",
+		},
+	}
+	for _, tt := range tests {
+		_, err := config.LoadConfigFromString(tt.yaml)
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("❌ %s: expected error containing %q, got %v", tt.name, tt.errorMsg, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPublishPolicy_YAML(t *testing.T) {
+	data := `
+key: "voltage"
+name: "Voltage"
+offset: 0
+unit: "V"
+deadband: 0.5
+deadband_percent: 1
+min_publish_interval: 5
+max_publish_interval: 300
+`
+	var reg config.GroupRegister
+	if err := yaml.Unmarshal([]byte(data), &reg); err != nil {
+		t.Fatalf("❌ Unmarshal failed: %v", err)
+	}
+	expected := config.PublishPolicy{Deadband: 0.5, DeadbandPercent: 1, MinPublishInterval: 5, MaxPublishInterval: 300}
+	if reg.PublishPolicy != expected {
+		t.Errorf("❌ Policy = %+v, expected %+v", reg.PublishPolicy, expected)
+	}
+
+	registers := config.ConvertGroupsToRegisters(map[string]config.RegisterGroup{
+		"instant": {Name: "Instant", Registers: []config.GroupRegister{reg}},
+	})
+	if registers["voltage"].Publish != expected {
+		t.Errorf("❌ Converted register policy = %+v, expected %+v", registers["voltage"].Publish, expected)
+	}
+	t.Log("✅ Publish policy parsed and carried into the register")
+}
+
+func TestPublishPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy config.PublishPolicy
+		errMsg string
+	}{
+		{"empty", config.PublishPolicy{}, ""},
+		{"full", config.PublishPolicy{Deadband: 1, DeadbandPercent: 5, MinPublishInterval: 5, MaxPublishInterval: 60}, ""},
+		{"negative deadband", config.PublishPolicy{Deadband: -1}, "must not be negative"},
+		{"percent over 100", config.PublishPolicy{DeadbandPercent: 150}, "between 0 and 100"},
+		{"negative interval", config.PublishPolicy{MaxPublishInterval: -5}, "must not be negative"},
+		{"min above max", config.PublishPolicy{MinPublishInterval: 60, MaxPublishInterval: 30}, "greater than max_publish_interval"},
+	}
+	for _, tt := range tests {
+		err := tt.policy.Validate()
+		if tt.errMsg == "" && err != nil {
+			t.Errorf("❌ %s: unexpected error %v", tt.name, err)
+		}
+		if tt.errMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errMsg)) {
+			t.Errorf("❌ %s: expected error containing '%s', got %v", tt.name, tt.errMsg, err)
+		}
+	}
+
+	group := newTestGroup(config.FunctionReadHoldingRegisters, 2)
+	group.Registers[0].Deadband = -1
+	if err := group.Validate(); err == nil || !strings.Contains(err.Error(), "register 'value'") {
+		t.Errorf("❌ Group validation must reject the register policy, got %v", err)
+	}
+	t.Log("✅ Publish policy validation")
+}
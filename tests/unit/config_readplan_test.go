@@ -0,0 +1,160 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+)
+
+// plannedRegister builds a float32 planned register at an absolute address
+func plannedRegister(key string, address uint16) config.PlannedRegister {
+	return config.PlannedRegister{
+		Address:       address,
+		GroupRegister: config.GroupRegister{Key: key, Name: key, Unit: "V", DeviceClass: "voltage", StateClass: "measurement"},
+	}
+}
+
+func TestPlanReads_MergesByGapAndBlockSize(t *testing.T) {
+	registers := []config.PlannedRegister{
+		plannedRegister("power", 0x2008),
+		plannedRegister("voltage", 0x2000),
+		plannedRegister("current", 0x2002),
+		plannedRegister("frequency", 0x2040),
+	}
+
+	// Contiguous only: voltage+current, power, frequency
+	groups, err := config.PlanReads(registers, 1000, config.ReadPlanConfig{})
+	if err != nil {
+		t.Fatalf("❌ Unexpected error: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("❌ Expected 3 reads without gaps, got %d", len(groups))
+	}
+	if groups[0].StartAddress != 0x2000 || groups[0].RegisterCount != 4 || groups[0].Registers[1].Offset != 4 {
+		t.Errorf("❌ Unexpected first read: %+v", groups[0])
+	}
+
+	// A gap of 4 unused registers merges power into the first block
+	groups, err = config.PlanReads(registers, 1000, config.ReadPlanConfig{MaxGap: 4})
+	if err != nil {
+		t.Fatalf("❌ Unexpected error: %v", err)
+	}
+	if len(groups) != 2 || groups[0].RegisterCount != 10 || groups[0].Registers[2].Offset != 16 {
+		t.Fatalf("❌ Expected 0x2000-0x2009 and 0x2040 reads, got %+v", groups)
+	}
+	if groups[0].PollInterval != 1000 || groups[0].FunctionCode != config.FunctionReadHoldingRegisters {
+		t.Errorf("❌ Defaults not applied: %+v", groups[0])
+	}
+
+	// The block size limit splits reads even when the gap allows merging
+	groups, err = config.PlanReads(registers, 1000, config.ReadPlanConfig{MaxGap: 100, MaxBlockSize: 8})
+	if err != nil {
+		t.Fatalf("❌ Unexpected error: %v", err)
+	}
+	for _, group := range groups {
+		if group.RegisterCount > 8 {
+			t.Errorf("❌ Read %s exceeds max_block_size: %d registers", group.Name, group.RegisterCount)
+		}
+	}
+	if len(groups) != 3 {
+		t.Errorf("❌ Expected 3 reads with max_block_size 8, got %d", len(groups))
+	}
+	t.Logf("✅ Planned %d reads", len(groups))
+}
+
+func TestPlanReads_SplitsByFunctionCodeAndInterval(t *testing.T) {
+	energy := plannedRegister("energy", 0x2002)
+	energy.PollInterval = 60000
+	input := plannedRegister("input", 0x2004)
+	input.FunctionCode = config.FunctionReadInputRegisters
+
+	groups, err := config.PlanReads([]config.PlannedRegister{plannedRegister("voltage", 0x2000), energy, input},
+		1000, config.ReadPlanConfig{MaxGap: 10})
+	if err != nil {
+		t.Fatalf("❌ Unexpected error: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("❌ Expected one read per function code and interval, got %d", len(groups))
+	}
+	if groups[0].PollInterval != 1000 || groups[1].PollInterval != 60000 || groups[2].FunctionCode != config.FunctionReadInputRegisters {
+		t.Errorf("❌ Unexpected read order: %+v", groups)
+	}
+}
+
+func TestPlanReads_Invalid(t *testing.T) {
+	coil := plannedRegister("coil", 0)
+	coil.FunctionCode = config.FunctionReadCoils
+	text := plannedRegister("serial", 0)
+	text.DataType, text.Length = config.DataTypeString, 20
+	last := plannedRegister("last", 0xFFFF)
+
+	tests := []struct {
+		name      string
+		registers []config.PlannedRegister
+		plan      config.ReadPlanConfig
+		errorMsg  string
+	}{
+		{"coil", []config.PlannedRegister{coil}, config.ReadPlanConfig{}, "cannot be planned"},
+		{"block too large", nil, config.ReadPlanConfig{MaxBlockSize: 126}, "max_block_size must be between 1 and 125"},
+		{"negative gap", nil, config.ReadPlanConfig{MaxGap: -1}, "max_gap must be non-negative"},
+		{"value larger than block", []config.PlannedRegister{text}, config.ReadPlanConfig{MaxBlockSize: 8}, "do not fit"},
+		{"address overflow", []config.PlannedRegister{last}, config.ReadPlanConfig{}, "exceeds the address space"},
+	}
+	for _, tt := range tests {
+		_, err := config.PlanReads(tt.registers, 1000, tt.plan)
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("❌ %s: expected error containing %q, got %v", tt.name, tt.errorMsg, err)
+		}
+	}
+}
+
+func TestLoadConfig_PlannedRegisters(t *testing.T) {
+	yamlContent := `
+version: "2.1"
+mqtt:
+  broker: "localhost"
+  port: 1883
+  gateway:
+    mac: "TEST123456"
+    cmd_topic: "test/cmd"
+    data_topic: "test/data"
+modbus:
+  poll_interval: 2000
+devices:
+  meter:
+    metadata:
+      name: "Meter"
+      enabled: true
+    rtu:
+      slave_id: 11
+    modbus:
+      read_plan:
+        max_gap: 6
+      registers:
+        - {address: 0x2000, key: voltage, name: Voltage, unit: V, device_class: voltage, state_class: measurement}
+        - {address: 0x2008, key: power, name: Power, unit: W, device_class: power, state_class: measurement}
+`
+	cfg, err := config.LoadConfigFromString(yamlContent)
+	if err != nil {
+		t.Fatalf("❌ Failed to load config: %v", err)
+	}
+
+	device := cfg.Devices["meter"]
+	if len(device.Modbus.PlannedGroups) != 1 || device.Modbus.PlannedGroups[0] != "read_plan_1" {
+		t.Fatalf("❌ Expected one planned read, got %v", device.Modbus.PlannedGroups)
+	}
+	group := device.Modbus.RegisterGroups["read_plan_1"]
+	if group.SlaveID != 11 || group.RegisterCount != 10 || group.PollInterval != 2000 {
+		t.Errorf("❌ Unexpected planned group: %+v", group)
+	}
+	if _, exists := cfg.Registers["meter_power"]; !exists {
+		t.Errorf("❌ Planned registers missing from converted registers")
+	}
+
+	// Planned register keys share the device namespace with register groups
+	duplicate := strings.Replace(yamlContent, "key: power", "key: voltage", 1)
+	if _, err := config.LoadConfigFromString(duplicate); err == nil || !strings.Contains(err.Error(), "duplicate register key 'voltage'") {
+		t.Errorf("❌ Expected duplicate key error, got %v", err)
+	}
+	t.Logf("✅ Planned read: %s", group.Name)
+}
@@ -0,0 +1,189 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestTransform_Offset(t *testing.T) {
+	step := config.Transform{Offset: floatPtr(-0.4)}
+	if got := step.Apply(230.4); got < 229.999 || got > 230.001 {
+		t.Errorf("❌ offset: got %f, expected 230", got)
+	}
+}
+
+func TestTransform_Invert(t *testing.T) {
+	step := config.Transform{Invert: true}
+	if got := step.Apply(-1500); got != 1500 {
+		t.Errorf("❌ invert: got %f, expected 1500", got)
+	}
+	if got := step.Apply(0); got != 0 {
+		t.Errorf("❌ invert: got %f, expected 0", got)
+	}
+}
+
+func TestTransform_Clamp(t *testing.T) {
+	step := config.Transform{Clamp: &config.ClampTransform{Min: floatPtr(0), Max: floatPtr(100)}}
+	for input, expected := range map[float64]float64{-3: 0, 42: 42, 140: 100} {
+		if got := step.Apply(input); got != expected {
+			t.Errorf("❌ clamp(%f): got %f, expected %f", input, got, expected)
+		}
+	}
+	minOnly := config.Transform{Clamp: &config.ClampTransform{Min: floatPtr(0)}}
+	if got := minOnly.Apply(1e9); got != 1e9 {
+		t.Errorf("❌ clamp without max must not limit the upper end, got %f", got)
+	}
+}
+
+func TestTransform_Round(t *testing.T) {
+	tests := []struct {
+		decimals int
+		input    float64
+		expected float64
+	}{
+		{2, 1.23456, 1.23},
+		{1, -0.25, -0.3},
+		{0, 229.5, 230},
+	}
+	for _, tt := range tests {
+		step := config.Transform{Round: intPtr(tt.decimals)}
+		if got := step.Apply(tt.input); got != tt.expected {
+			t.Errorf("❌ round %d (%f): got %f, expected %f", tt.decimals, tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestTransform_Convert(t *testing.T) {
+	tests := []struct {
+		from, to string
+		input    float64
+		expected float64
+	}{
+		{"W", "kW", 1500, 1.5},
+		{"Wh", "kWh", 2500, 2.5},
+		{"kWh", "Wh", 1.25, 1250},
+		{"kvar", "var", 0.5, 500},
+		{"mA", "A", 250, 0.25},
+		{"kWh", "MWh", 1500, 1.5},
+	}
+	for _, tt := range tests {
+		step := config.Transform{Convert: &config.UnitConversion{From: tt.from, To: tt.to}}
+		if err := step.Validate(); err != nil {
+			t.Errorf("❌ convert %s→%s: %v", tt.from, tt.to, err)
+			continue
+		}
+		if got := step.Apply(tt.input); got < tt.expected*0.999999 || got > tt.expected*1.000001 {
+			t.Errorf("❌ convert %s→%s (%f): got %f, expected %f", tt.from, tt.to, tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestTransforms_Order(t *testing.T) {
+	pipeline := config.Transforms{
+		{Invert: true},
+		{Clamp: &config.ClampTransform{Min: floatPtr(0)}},
+		{Convert: &config.UnitConversion{From: "W", To: "kW"}},
+		{Round: intPtr(1)},
+	}
+	// Export reading from a CT installed backwards: -(-2345) W → 2.345 kW → 2.3 kW
+	if got := pipeline.Apply(-2345); got != 2.3 {
+		t.Errorf("❌ pipeline: got %f, expected 2.3", got)
+	}
+	// Import is clamped to zero after the inversion
+	if got := pipeline.Apply(800); got != 0 {
+		t.Errorf("❌ pipeline: got %f, expected 0", got)
+	}
+}
+
+func TestTransforms_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     config.Transform
+		errorMsg string
+	}{
+		{"empty step", config.Transform{}, "exactly one"},
+		{"two operations", config.Transform{Invert: true, Round: intPtr(1)}, "exactly one"},
+		{"empty clamp", config.Transform{Clamp: &config.ClampTransform{}}, "clamp needs min, max or both"},
+		{"inverted clamp", config.Transform{Clamp: &config.ClampTransform{Min: floatPtr(5), Max: floatPtr(1)}}, "greater than max"},
+		{"negative round", config.Transform{Round: intPtr(-1)}, "round must be between"},
+		{"different units", config.Transform{Convert: &config.UnitConversion{From: "W", To: "kWh"}}, "cannot convert W to kWh"},
+		{"unknown prefix", config.Transform{Convert: &config.UnitConversion{From: "W", To: "xW"}}, "unsupported unit 'xW'"},
+	}
+	for _, tt := range tests {
+		err := config.Transforms{tt.step}.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+			t.Errorf("❌ %s: expected error containing %q, got %v", tt.name, tt.errorMsg, err)
+		}
+	}
+}
+
+func TestLoadConfig_Transforms(t *testing.T) {
+	yamlContent := `
+version: "2.1"
+mqtt:
+  broker: "localhost"
+  port: 1883
+  gateway:
+    mac: "TEST123456"
+    cmd_topic: "test/cmd"
+    data_topic: "test/data"
+modbus:
+  poll_interval: 1000
+devices:
+  meter:
+    metadata:
+      name: "Meter"
+      enabled: true
+    rtu:
+      slave_id: 1
+    modbus:
+      register_groups:
+        instant:
+          function_code: 0x03
+          start_address: 0x2000
+          register_count: 2
+          poll_interval: 1000
+          registers:
+            - key: power_active
+              name: Active Power
+              offset: 0
+              unit: kW
+              device_class: power
+              state_class: measurement
+              transforms:
+                - invert: true
+                - convert: {from: W, to: kW}
+                - round: 3
+    calculated_values:
+      - key: power_abs
+        name: Absolute Power
+        unit: W
+        formula: "abs(power_active)"
+        device_class: power
+        state_class: measurement
+        transforms:
+          - convert: {from: kW, to: W}
+`
+	cfg, err := config.LoadConfigFromString(yamlContent)
+	if err != nil {
+		t.Fatalf("❌ Failed to load config: %v", err)
+	}
+	if got := len(cfg.Registers["meter_power_active"].Transforms); got != 3 {
+		t.Errorf("❌ Register transforms not converted: %d steps", got)
+	}
+	if got := len(cfg.Registers["meter_power_abs"].Transforms); got != 1 {
+		t.Errorf("❌ Calculated value transforms not converted: %d steps", got)
+	}
+
+	// The last conversion must produce the published unit
+	invalid := strings.Replace(yamlContent, "unit: kW\n", "unit: W\n", 1)
+	if _, err := config.LoadConfigFromString(invalid); err == nil || !strings.Contains(err.Error(), "convert produces kW but unit is 'W'") {
+		t.Errorf("❌ Expected unit mismatch error, got %v", err)
+	}
+	t.Logf("✅ Transforms loaded for registers and calculated values")
+}
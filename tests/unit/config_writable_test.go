@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"mqtt-modbus-bridge/pkg/config"
+	"strings"
+	"testing"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestWritableRegister_Validate(t *testing.T) {
+	valid := []config.WritableRegister{
+		{Key: "ct_ratio", Name: "CT Ratio", Component: config.WritableNumber, Min: floatPtr(1), Max: floatPtr(500)},
+		{Key: "limit", Name: "Limit", Component: config.WritableNumber, ValueType: config.ValueTypeFloat32,
+			Min: floatPtr(0), Max: floatPtr(100), Step: 0.1},
+		{Key: "baud", Name: "Baud", Component: config.WritableSelect,
+			Options: []config.SelectOption{{Label: "9600", Value: 3}, {Label: "19200", Value: 4}}},
+		{Key: "relay", Name: "Relay", Component: config.WritableSwitch, RegisterType: config.RegisterTypeCoil},
+		{Key: "mode", Name: "Mode", Component: config.WritableSwitch, OnValue: floatPtr(2), OffValue: floatPtr(1)},
+		{Key: "reset", Name: "Reset", Component: config.WritableButton, PressValue: floatPtr(0x0A)},
+	}
+	for _, reg := range valid {
+		if err := reg.Validate(); err != nil {
+			t.Errorf("%s should be valid: %v", reg.Key, err)
+		}
+	}
+
+	invalid := []struct {
+		reg      config.WritableRegister
+		expected string
+	}{
+		{config.WritableRegister{Name: "No key", Component: config.WritableButton}, "key cannot be empty"},
+		{config.WritableRegister{Key: "x", Name: "X", Component: "light"}, "unsupported component 'light'"},
+		{config.WritableRegister{Key: "x", Name: "X", Component: config.WritableNumber, Max: floatPtr(1)}, "requires min and max"},
+		{config.WritableRegister{Key: "x", Name: "X", Component: config.WritableNumber, Min: floatPtr(5), Max: floatPtr(5)}, "must be less than max"},
+		{config.WritableRegister{Key: "x", Name: "X", Component: config.WritableSelect}, "at least one option"},
+		{config.WritableRegister{Key: "x", Name: "X", Component: config.WritableSelect,
+			Options: []config.SelectOption{{Label: "A", Value: 1}, {Label: "A", Value: 2}}}, "duplicate option label 'A'"},
+		{config.WritableRegister{Key: "x", Name: "X", Component: config.WritableSwitch, OnValue: floatPtr(0)}, "on_value and off_value must differ"},
+		{config.WritableRegister{Key: "x", Name: "X", Component: config.WritableNumber, RegisterType: config.RegisterTypeCoil,
+			Min: floatPtr(0), Max: floatPtr(1)}, "only supported for switch and button"},
+		{config.WritableRegister{Key: "x", Name: "X", Component: config.WritableButton, ValueType: "int8"}, "unsupported value_type 'int8'"},
+	}
+	for _, tt := range invalid {
+		err := tt.reg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got: %v", tt.expected, err)
+		}
+	}
+}
+
+func TestWritableRegister_Defaults(t *testing.T) {
+	reg := config.WritableRegister{Key: "limit", Name: "Limit", Component: config.WritableNumber}
+	if reg.GetRegisterType() != config.RegisterTypeHolding || reg.GetValueType() != config.ValueTypeUint16 {
+		t.Errorf("Expected holding uint16 defaults, got %s %s", reg.GetRegisterType(), reg.GetValueType())
+	}
+	if reg.GetScaleFactor() != 1 || reg.GetStep() != 1 || reg.RegisterCount() != 1 {
+		t.Errorf("Unexpected defaults: scale %g, step %g, count %d", reg.GetScaleFactor(), reg.GetStep(), reg.RegisterCount())
+	}
+
+	reg.ValueType = config.ValueTypeInt32
+	if reg.RegisterCount() != 2 {
+		t.Errorf("int32 should occupy 2 registers, got %d", reg.RegisterCount())
+	}
+}
+
+func TestDevice_WritableKeyConflict(t *testing.T) {
+	device := config.Device{
+		Metadata: config.DeviceMetadata{Name: "Meter", Enabled: true},
+		RTU:      config.RTUConfig{SlaveID: 1},
+		Modbus: config.ModbusDeviceConfig{RegisterGroups: map[string]config.RegisterGroup{
+			"instant": newTestGroup(config.FunctionReadHoldingRegisters, 2),
+		}},
+		Writable: []config.WritableRegister{
+			{Key: "reset", Name: "Reset", Component: config.WritableButton},
+		},
+	}
+	if err := device.Validate(); err != nil {
+		t.Fatalf("Device should be valid: %v", err)
+	}
+
+	device.Writable[0].Key = "value" // Same key as the group register
+	err := device.Validate()
+	if err == nil || !strings.Contains(err.Error(), "writable register key 'value' conflicts") {
+		t.Errorf("Expected key conflict error, got: %v", err)
+	}
+
+	device.Writable[0] = config.WritableRegister{Key: "limit", Name: "Limit", Component: config.WritableNumber}
+	err = device.Validate()
+	if err == nil || !strings.Contains(err.Error(), "writable_registers[0]") {
+		t.Errorf("Expected writable_registers path in error, got: %v", err)
+	}
+}
+
+func TestWritePolicy_Defaults(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ApplyWritePolicyDefaults()
+	if cfg.WritePolicy.MaxWritesPerMinute != 10 || cfg.WritePolicy.ConfirmTimeout != 30 {
+		t.Errorf("Unexpected write policy defaults: %+v", cfg.WritePolicy)
+	}
+
+	cfg.WritePolicy.MaxWritesPerMinute = 2
+	cfg.ApplyWritePolicyDefaults()
+	if cfg.WritePolicy.MaxWritesPerMinute != 2 {
+		t.Errorf("Configured max_writes_per_minute overridden: %d", cfg.WritePolicy.MaxWritesPerMinute)
+	}
+}
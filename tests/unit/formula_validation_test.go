@@ -61,6 +61,12 @@ func TestValidateFormula(t *testing.T) {
 			wantVariables: []string{"power_active_L1", "power_active_L2", "power_active_L3"},
 			wantError:     false,
 		},
+		{
+			name:          "Phase imbalance with min and max",
+			formula:       "(max(current_l1, current_l2, current_l3) - min(current_l1, current_l2, current_l3)) / max(current_l1 + current_l2 + current_l3, 0.3) * 300",
+			wantVariables: []string{"current_l1", "current_l2", "current_l3"},
+			wantError:     false,
+		},
 		// Error cases
 		{
 			name:          "Empty formula",
@@ -86,6 +92,12 @@ func TestValidateFormula(t *testing.T) {
 			wantError:     true,
 			errorContains: "unsupported function 'sin'",
 		},
+		{
+			name:          "Function name inside a key",
+			formula:       "current_max(current_l1)",
+			wantError:     true,
+			errorContains: "unsupported function 'current_max'",
+		},
 		{
 			name:          "Invalid operator",
 			formula:       "power_active & power_reactive",